@@ -18,15 +18,28 @@ import (
 	"github.com/ChipArtem/k6/metrics/engine"
 )
 
-func newHandler(cs *v1.ControlSurface, profilingEnabled bool) http.Handler {
+// newHandler returns the handler of the REST API, along with the handlers of
+// the outputs. It returns an error if the path of an output handler is
+// already registered, since http.ServeMux would panic.
+func newHandler(
+	cs *v1.ControlSurface, profilingEnabled bool, outputHandlers map[string]http.Handler,
+) (http.Handler, error) {
 	mux := http.NewServeMux()
 	mux.Handle("/v1/", v1.NewHandler(cs))
 	mux.Handle("/ping", handlePing(cs.RunState.Logger))
 	mux.Handle("/", handlePing(cs.RunState.Logger))
-
 	injectProfilerHandler(mux, profilingEnabled)
 
-	return mux
+	registered := map[string]bool{"/v1/": true, "/ping": true, "/": true, "/debug/pprof/": true}
+	for path, handler := range outputHandlers {
+		if registered[path] {
+			return nil, fmt.Errorf("the path %q of an output is already used by the REST API server", path)
+		}
+		registered[path] = true
+		mux.Handle(path, handler)
+	}
+
+	return mux, nil
 }
 
 func injectProfilerHandler(mux *http.ServeMux, profilingEnabled bool) {
@@ -44,7 +57,9 @@ func injectProfilerHandler(mux *http.ServeMux, profilingEnabled bool) {
 	mux.Handle("/debug/pprof/", handler)
 }
 
-// GetServer returns a http.Server instance that can serve k6's REST API.
+// GetServer returns a http.Server instance that can serve k6's REST API. The
+// outputHandlers are additional handlers, by path, exposed by the outputs,
+// and an error is returned if any of their paths is already in use.
 func GetServer(
 	runCtx context.Context,
 	addr string,
@@ -53,7 +68,8 @@ func GetServer(
 	samples chan metrics.SampleContainer,
 	me *engine.MetricsEngine,
 	es *execution.Scheduler,
-) *http.Server {
+	outputHandlers map[string]http.Handler,
+) (*http.Server, error) {
 	// TODO: reduce the control surface as much as possible? For example, if
 	// we refactor the Runner API, we won't need to send the Samples channel.
 	cs := &v1.ControlSurface{
@@ -64,8 +80,12 @@ func GetServer(
 		RunState:      runState,
 	}
 
-	mux := withLoggingHandler(runState.Logger, newHandler(cs, profilingEnabled))
-	return &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	handler, err := newHandler(cs, profilingEnabled, outputHandlers)
+	if err != nil {
+		return nil, err
+	}
+	mux := withLoggingHandler(runState.Logger, handler)
+	return &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}, nil
 }
 
 type wrappedResponseWriter struct {
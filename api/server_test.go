@@ -9,7 +9,10 @@ import (
 	"github.com/sirupsen/logrus"
 	logtest "github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	v1 "github.com/ChipArtem/k6/api/v1"
+	"github.com/ChipArtem/k6/lib"
 	"github.com/ChipArtem/k6/lib/testutils"
 )
 
@@ -63,3 +66,36 @@ func TestPing(t *testing.T) {
 	assert.Equal(t, http.StatusOK, res.StatusCode)
 	assert.Equal(t, []byte{'o', 'k'}, rw.Body.Bytes())
 }
+
+func TestOutputHandlers(t *testing.T) {
+	t.Parallel()
+	logger := logrus.New()
+	logger.SetOutput(testutils.NewTestOutput(t))
+	cs := &v1.ControlSurface{
+		RunState: &lib.TestRunState{TestPreInitState: &lib.TestPreInitState{Logger: logger}},
+	}
+	mux, err := newHandler(cs, false, map[string]http.Handler{"/metrics": http.HandlerFunc(testHTTPHandler)})
+	require.NoError(t, err)
+
+	rw := httptest.NewRecorder()
+	mux.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	res := rw.Result()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/plain; charset=utf-8", res.Header.Get("Content-Type"))
+	assert.Equal(t, "ok", rw.Body.String())
+}
+
+func TestOutputHandlersPathConflict(t *testing.T) {
+	t.Parallel()
+	logger := logrus.New()
+	logger.SetOutput(testutils.NewTestOutput(t))
+	cs := &v1.ControlSurface{
+		RunState: &lib.TestRunState{TestPreInitState: &lib.TestPreInitState{Logger: logger}},
+	}
+
+	for _, path := range []string{"/", "/ping", "/v1/", "/debug/pprof/"} {
+		_, err := newHandler(cs, true, map[string]http.Handler{path: http.HandlerFunc(testHTTPHandler)})
+		assert.EqualError(t, err, fmt.Sprintf("the path %q of an output is already used by the REST API server", path))
+	}
+}
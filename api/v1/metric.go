@@ -62,13 +62,33 @@ type Metric struct {
 	Sample map[string]float64 `json:"sample" yaml:"sample"`
 }
 
-// NewMetric constructs a new Metric
-func NewMetric(m *metrics.Metric, t time.Duration) Metric {
+// NewMetric constructs a new Metric. The sample of Trend metrics contains the
+// provided summary trend stats, or the default ones if none were provided.
+func NewMetric(m *metrics.Metric, t time.Duration, summaryTrendStats []string) Metric {
 	return Metric{
 		Name:     m.Name,
 		Type:     NullMetricType{m.Type, true},
 		Contains: NullValueType{m.Contains, true},
 		Tainted:  m.Tainted,
-		Sample:   m.Sink.Format(t),
+		Sample:   formatSample(m.Sink, t, summaryTrendStats),
 	}
 }
+
+func formatSample(sink metrics.Sink, t time.Duration, summaryTrendStats []string) map[string]float64 {
+	trendSink, ok := sink.(*metrics.TrendSink)
+	if !ok || len(summaryTrendStats) == 0 {
+		return sink.Format(t)
+	}
+
+	// The summary trend stats are validated with the rest of the options
+	trendResolvers, err := metrics.GetResolversForTrendColumns(summaryTrendStats)
+	if err != nil {
+		return sink.Format(t)
+	}
+
+	sample := make(map[string]float64, len(trendResolvers))
+	for stat, resolve := range trendResolvers {
+		sample[stat] = resolve(trendSink)
+	}
+	return sample
+}
@@ -21,17 +21,17 @@ type metricData struct {
 	Attributes Metric `json:"attributes"`
 }
 
-func newMetricEnvelope(m *metrics.Metric, t time.Duration) metricJSONAPI {
+func newMetricEnvelope(m *metrics.Metric, t time.Duration, summaryTrendStats []string) metricJSONAPI {
 	return metricJSONAPI{
-		Data: newMetricData(m, t),
+		Data: newMetricData(m, t, summaryTrendStats),
 	}
 }
 
-func newMetricsJSONAPI(list map[string]*metrics.Metric, t time.Duration) MetricsJSONAPI {
+func newMetricsJSONAPI(list map[string]*metrics.Metric, t time.Duration, summaryTrendStats []string) MetricsJSONAPI {
 	metrics := make([]metricData, 0, len(list))
 
 	for _, m := range list {
-		metrics = append(metrics, newMetricData(m, t))
+		metrics = append(metrics, newMetricData(m, t, summaryTrendStats))
 	}
 
 	return MetricsJSONAPI{
@@ -39,8 +39,8 @@ func newMetricsJSONAPI(list map[string]*metrics.Metric, t time.Duration) Metrics
 	}
 }
 
-func newMetricData(m *metrics.Metric, t time.Duration) metricData {
-	metric := NewMetric(m, t)
+func newMetricData(m *metrics.Metric, t time.Duration, summaryTrendStats []string) metricData {
+	metric := NewMetric(m, t, summaryTrendStats)
 
 	return metricData{
 		Type:       "metrics",
@@ -13,7 +13,7 @@ func handleGetMetrics(cs *ControlSurface, rw http.ResponseWriter, _ *http.Reques
 	}
 
 	cs.MetricsEngine.MetricsLock.Lock()
-	metrics := newMetricsJSONAPI(cs.MetricsEngine.ObservedMetrics, t)
+	metrics := newMetricsJSONAPI(cs.MetricsEngine.ObservedMetrics, t, cs.RunState.Options.SummaryTrendStats)
 	cs.MetricsEngine.MetricsLock.Unlock()
 
 	data, err := json.Marshal(metrics)
@@ -37,7 +37,7 @@ func handleGetMetric(cs *ControlSurface, rw http.ResponseWriter, _ *http.Request
 		apiError(rw, "Not Found", "No metric with that ID was found", http.StatusNotFound)
 		return
 	}
-	wrappedMetric := newMetricEnvelope(metric, t)
+	wrappedMetric := newMetricEnvelope(metric, t, cs.RunState.Options.SummaryTrendStats)
 	cs.MetricsEngine.MetricsLock.Unlock()
 
 	data, err := json.Marshal(wrappedMetric)
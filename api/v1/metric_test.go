@@ -95,7 +95,7 @@ func TestNewMetric(t *testing.T) {
 	old, err := metrics.NewRegistry().NewMetric("test_metric", metrics.Trend, metrics.Time)
 	require.NoError(t, err)
 	old.Tainted = null.BoolFrom(true)
-	m := NewMetric(old, 0)
+	m := NewMetric(old, 0, nil)
 	assert.Equal(t, "test_metric", m.Name)
 	assert.True(t, m.Type.Valid)
 	assert.Equal(t, metrics.Trend, m.Type.Type)
@@ -105,3 +105,24 @@ func TestNewMetric(t *testing.T) {
 	assert.Equal(t, metrics.Time, m.Contains.Type)
 	assert.NotEmpty(t, m.Sample)
 }
+
+func TestNewMetricWithSummaryTrendStats(t *testing.T) {
+	t.Parallel()
+
+	trend, err := metrics.NewRegistry().NewMetric("test_trend", metrics.Trend, metrics.Time)
+	require.NoError(t, err)
+	for _, v := range []float64{10, 20, 30, 40} {
+		trend.Sink.Add(metrics.Sample{Value: v})
+	}
+
+	m := NewMetric(trend, 0, []string{"count", "sum", "iqr", "med"})
+	assert.Equal(t, map[string]float64{
+		"count": 4,
+		"sum":   100,
+		"iqr":   15,
+		"med":   25,
+	}, m.Sample)
+
+	m = NewMetric(trend, 0, nil)
+	assert.Equal(t, trend.Sink.Format(0), m.Sample)
+}
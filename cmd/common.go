@@ -41,6 +41,14 @@ func getNullInt64(flags *pflag.FlagSet, key string) null.Int {
 	return null.NewInt(v, flags.Changed(key))
 }
 
+func getNullFloat64(flags *pflag.FlagSet, key string) null.Float {
+	v, err := flags.GetFloat64(key)
+	if err != nil {
+		panic(err)
+	}
+	return null.NewFloat(v, flags.Changed(key))
+}
+
 func getNullDuration(flags *pflag.FlagSet, key string) types.NullDuration {
 	// TODO: use types.ParseExtendedDuration? not sure we should support
 	// unitless durations (i.e. milliseconds) here...
@@ -60,7 +60,8 @@ func getCmdInspect(gs *state.GlobalState) *cobra.Command {
 }
 
 // If --execution-requirements is enabled, this will consolidate the config,
-// derive the value of `scenarios` and calculate the max test duration and VUs.
+// derive the value of `scenarios` and calculate the max test duration and VUs,
+// as well as the planned execution of every scenario.
 func inspectOutputWithExecRequirements(
 	gs *state.GlobalState, cmd *cobra.Command, test *loadedTest,
 ) (interface{}, error) {
@@ -83,11 +84,52 @@ func inspectOutputWithExecRequirements(
 
 	return struct {
 		lib.Options
-		TotalDuration types.NullDuration `json:"totalDuration"`
-		MaxVUs        uint64             `json:"maxVUs"`
+		TotalDuration types.NullDuration      `json:"totalDuration"`
+		MaxVUs        uint64                  `json:"maxVUs"`
+		ScenarioGraph map[string]scenarioPlan `json:"scenarioGraph"`
 	}{
 		configuredTest.derivedConfig.Options,
 		types.NewNullDuration(duration, true),
 		lib.GetMaxPossibleVUs(executionPlan),
+		getScenarioGraph(configuredTest.derivedConfig.Scenarios, et),
 	}, nil
 }
+
+// scenarioPlan is the planned execution of a scenario, with its dependencies
+// on the other scenarios, i.e. a node of the graph of the scenarios.
+type scenarioPlan struct {
+	StartAfter string             `json:"startAfter,omitempty"`
+	OnlyIf     []string           `json:"onlyIf,omitempty"`
+	Dependents []string           `json:"dependents,omitempty"`
+	StartTime  types.NullDuration `json:"startTime"`
+	EndTime    types.NullDuration `json:"endTime"`
+	MaxVUs     uint64             `json:"maxVUs"`
+}
+
+// getScenarioGraph returns the planned execution of every scenario, including
+// the scenarios that start after it ends.
+func getScenarioGraph(scenarios lib.ScenarioConfigs, et *lib.ExecutionTuple) map[string]scenarioPlan {
+	startTimes := scenarios.GetPlannedStartTimes(et)
+	configs := scenarios.GetSortedConfigs()
+	graph := make(map[string]scenarioPlan, len(configs))
+	for _, config := range configs {
+		name := config.GetName()
+		steps := config.GetExecutionRequirements(et)
+		endOffset, _ := lib.GetEndOffset(steps)
+		graph[name] = scenarioPlan{
+			StartAfter: config.GetStartAfter(),
+			OnlyIf:     config.GetOnlyIf(),
+			StartTime:  types.NewNullDuration(startTimes[name], true),
+			EndTime:    types.NewNullDuration(startTimes[name]+endOffset, true),
+			MaxVUs:     lib.GetMaxPossibleVUs(steps),
+		}
+	}
+	// The configs are sorted, so the dependents are too
+	for _, config := range configs {
+		if dependency, ok := graph[config.GetStartAfter()]; ok {
+			dependency.Dependents = append(dependency.Dependents, config.GetName())
+			graph[config.GetStartAfter()] = dependency
+		}
+	}
+	return graph
+}
@@ -22,7 +22,9 @@ func getCmdLoginInfluxDB(gs *state.GlobalState) *cobra.Command {
 		Short: "Authenticate with InfluxDB",
 		Long: `Authenticate with InfluxDB.
 
-This will set the default server used when just "-o influxdb" is passed.`,
+This will set the default server used when just "-o influxdb" is passed.
+The organization, bucket and token of InfluxDB v2.x or v3.x are asked for
+when the v2 API is selected, e.g. with an http+v2:// or https+v2:// uri.`,
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			config, err := readDiskConfig(gs)
@@ -54,6 +56,27 @@ This will set the default server used when just "-o influxdb" is passed.`,
 						Label:   "Address",
 						Default: conf.Addr.String,
 					},
+				},
+			}
+			if conf.IsV2() {
+				form.Fields = append(form.Fields,
+					ui.StringField{
+						Key:     "Organization",
+						Label:   "Organization",
+						Default: conf.Organization.String,
+					},
+					ui.StringField{
+						Key:     "Bucket",
+						Label:   "Bucket",
+						Default: conf.Bucket.String,
+					},
+					ui.PasswordField{
+						Key:   "Token",
+						Label: "Token",
+					},
+				)
+			} else {
+				form.Fields = append(form.Fields,
 					ui.StringField{
 						Key:     "DB",
 						Label:   "Database",
@@ -68,7 +91,7 @@ This will set the default server used when just "-o influxdb" is passed.`,
 						Key:   "Password",
 						Label: "Password",
 					},
-				},
+				)
 			}
 			if !term.IsTerminal(int(syscall.Stdin)) { //nolint:unconvert
 				gs.Logger.Warn("Stdin is not a terminal, falling back to plain text input")
@@ -79,9 +102,15 @@ This will set the default server used when just "-o influxdb" is passed.`,
 			}
 
 			conf.Addr = null.StringFrom(vals["Addr"])
-			conf.DB = null.StringFrom(vals["DB"])
-			conf.Username = null.StringFrom(vals["Username"])
-			conf.Password = null.StringFrom(vals["Password"])
+			if conf.IsV2() {
+				conf.Organization = null.StringFrom(vals["Organization"])
+				conf.Bucket = null.StringFrom(vals["Bucket"])
+				conf.Token = null.StringFrom(vals["Token"])
+			} else {
+				conf.DB = null.StringFrom(vals["DB"])
+				conf.Username = null.StringFrom(vals["Username"])
+				conf.Password = null.StringFrom(vals["Password"])
+			}
 
 			client, err := influxdb.MakeClient(conf)
 			if err != nil {
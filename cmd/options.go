@@ -57,6 +57,18 @@ func optionFlagSet() *pflag.FlagSet {
 	)
 	flags.StringSlice("summary-trend-stats", nil, sumTrendStatsHelp)
 	flags.String("summary-time-unit", "", "define the time unit used to display the trend stats. Possible units are: 's', 'ms' and 'us'") //nolint:lll
+	flags.Float64("trend-relative-error", 0, "store trend metrics in histograms with the given relative error, "+
+		"e.g. 0.01, instead of keeping every value in memory")
+	flags.Int64("max-time-series", 0, "maximum number of unique metric time series, 0 means unlimited")
+	flags.Int64("max-time-series-per-metric", 0, "maximum number of unique time series for each metric, "+
+		"0 means unlimited")
+	flags.String("time-series-overflow", lib.TimeSeriesOverflowCollapse, "what to do with the samples of new "+
+		"time series above the budget. Possible values are: 'collapse' into an '__overflow__' series, "+
+		"'drop-tag' with the most unique values or 'abort' the test")
+	flags.String("summary-mode", lib.SummaryModeCompact, "define the mode of the end-of-test summary. Possible "+
+		"values are: 'compact' and 'full', which adds a breakdown per scenario and per group")
+	flags.Duration("summary-timeline-interval", 0, "aggregate the metric values in intervals of the given duration, "+
+		"e.g. '10s', and add them as a timeline to the end-of-test summary data")
 	// system-tags must have a default value, but we can't specify it here, otherwiese, it will always override others.
 	// set it to nil here, and add the default in applyDefault() instead.
 	systemTagsCliHelpText := fmt.Sprintf(
@@ -98,6 +110,12 @@ func getOptions(flags *pflag.FlagSet) (lib.Options, error) {
 		MinIterationDuration:    getNullDuration(flags, "min-iteration-duration"),
 		Throw:                   getNullBool(flags, "throw"),
 		DiscardResponseBodies:   getNullBool(flags, "discard-response-bodies"),
+		TrendRelativeError:      getNullFloat64(flags, "trend-relative-error"),
+		SummaryTimelineInterval: getNullDuration(flags, "summary-timeline-interval"),
+		SummaryMode:             getNullString(flags, "summary-mode"),
+		MaxTimeSeries:           getNullInt64(flags, "max-time-series"),
+		MaxTimeSeriesPerMetric:  getNullInt64(flags, "max-time-series-per-metric"),
+		TimeSeriesOverflow:      getNullString(flags, "time-series-overflow"),
 		MetricSamplesBufferSize: null.NewInt(1000, false),
 	}
 
@@ -1,19 +1,26 @@
 package cmd
 
 import (
+	stdjson "encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"sort"
 	"strings"
 
 	"github.com/ChipArtem/k6/cmd/state"
 	"github.com/ChipArtem/k6/ext"
 	"github.com/ChipArtem/k6/lib"
+	"github.com/ChipArtem/k6/metrics"
 	"github.com/ChipArtem/k6/output"
 	"github.com/ChipArtem/k6/output/cloud"
 	"github.com/ChipArtem/k6/output/csv"
+	"github.com/ChipArtem/k6/output/html"
 	"github.com/ChipArtem/k6/output/influxdb"
 	"github.com/ChipArtem/k6/output/json"
+	"github.com/ChipArtem/k6/output/junit"
+	"github.com/ChipArtem/k6/output/opentelemetry"
+	"github.com/ChipArtem/k6/output/prometheus"
 	"github.com/ChipArtem/k6/output/statsd"
 
 	"github.com/grafana/xk6-output-prometheus-remote/pkg/remotewrite"
@@ -41,7 +48,11 @@ func getAllOutputConstructors() (map[string]output.Constructor, error) {
 			return nil, errors.New("the datadog output was deprecated in k6 v0.32.0 and removed in k6 v0.34.0, " +
 				"please use the statsd output with env. variable K6_STATSD_ENABLE_TAGS=true instead")
 		},
-		"csv": csv.New,
+		"csv":           csv.New,
+		"html":          html.New,
+		"junit":         junit.New,
+		"opentelemetry": opentelemetry.New,
+		"prometheus":    prometheus.New,
 		"experimental-prometheus-rw": func(params output.Params) (output.Output, error) {
 			return remotewrite.New(params)
 		},
@@ -74,13 +85,11 @@ func getPossibleIDList(constrs map[string]output.Constructor) string {
 	return strings.Join(res, ", ")
 }
 
+// createOutputs returns the outputs, along with the filters and aggregators of
+// their samples, which are nil for the outputs without one.
 func createOutputs(
 	gs *state.GlobalState, test *loadedAndConfiguredTest, executionPlan []lib.ExecutionStep,
-) ([]output.Output, error) {
-	outputConstructors, err := getAllOutputConstructors()
-	if err != nil {
-		return nil, err
-	}
+) ([]output.Output, []*output.Filter, []*output.Aggregator, error) {
 	baseParams := output.Params{
 		ScriptPath:     test.source.URL,
 		Logger:         gs.Logger,
@@ -91,51 +100,116 @@ func createOutputs(
 		ScriptOptions:  test.derivedConfig.Options,
 		RuntimeOptions: test.preInitState.RuntimeOptions,
 		ExecutionPlan:  executionPlan,
+		Registry:       test.preInitState.Registry,
 	}
-	result := make([]output.Output, 0, len(test.derivedConfig.Out))
+	return createOutputsFromArgs(
+		test.derivedConfig.Out, test.derivedConfig.Collectors, baseParams, test.preInitState.BuiltinMetrics,
+	)
+}
 
-	for _, outputFullArg := range test.derivedConfig.Out {
-		outputType, outputArg := parseOutputArgument(outputFullArg)
+// createOutputsFromArgs creates the outputs from their --out arguments, with
+// the given base params and the JSON configs of the outputs, by type. The
+// metrics of the aggregated statistics are registered in the registry of the
+// base params.
+func createOutputsFromArgs(
+	outArgs []string, jsonConfigs map[string]stdjson.RawMessage,
+	baseParams output.Params, builtinMetrics *metrics.BuiltinMetrics,
+) ([]output.Output, []*output.Filter, []*output.Aggregator, error) {
+	outputConstructors, err := getAllOutputConstructors()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	result := make([]output.Output, 0, len(outArgs))
+	filters := make([]*output.Filter, 0, len(outArgs))
+	aggregators := make([]*output.Aggregator, 0, len(outArgs))
+
+	for _, outputFullArg := range outArgs {
+		outputType, pipelineSpec, outputArg, err := parseOutputArgument(outputFullArg)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("invalid output argument '%s': %w", outputFullArg, err)
+		}
 		outputConstructor, ok := outputConstructors[outputType]
 		if !ok {
-			return nil, fmt.Errorf(
+			return nil, nil, nil, fmt.Errorf(
 				"invalid output type '%s', available types are: %s",
 				outputType, getPossibleIDList(outputConstructors),
 			)
 		}
 
+		filter, aggregator, err := output.ParsePipeline(pipelineSpec, baseParams.Registry)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("invalid options of the '%s' output: %w", outputType, err)
+		}
+
 		params := baseParams
 		params.OutputType = outputType
 		params.ConfigArgument = outputArg
-		params.JSONConfig = test.derivedConfig.Collectors[outputType]
+		params.JSONConfig = jsonConfigs[outputType]
 
 		out, err := outputConstructor(params)
 		if err != nil {
-			return nil, fmt.Errorf("could not create the '%s' output: %w", outputType, err)
+			return nil, nil, nil, fmt.Errorf("could not create the '%s' output: %w", outputType, err)
 		}
 
 		if thresholdOut, ok := out.(output.WithThresholds); ok {
-			thresholdOut.SetThresholds(test.derivedConfig.Thresholds)
+			thresholdOut.SetThresholds(baseParams.ScriptOptions.Thresholds)
 		}
 
 		if builtinMetricOut, ok := out.(output.WithBuiltinMetrics); ok {
-			builtinMetricOut.SetBuiltinMetrics(test.preInitState.BuiltinMetrics)
+			builtinMetricOut.SetBuiltinMetrics(builtinMetrics)
 		}
 
 		result = append(result, out)
+		filters = append(filters, filter)
+		aggregators = append(aggregators, aggregator)
 	}
 
-	return result, nil
+	return result, filters, aggregators, nil
+}
+
+// getOutputHTTPHandlers returns the handlers, by path, that the outputs want
+// to be served by the REST API server.
+func getOutputHTTPHandlers(outputs []output.Output) map[string]http.Handler {
+	result := make(map[string]http.Handler)
+	for _, out := range outputs {
+		handlerOut, ok := out.(output.WithHTTPHandler)
+		if !ok {
+			continue
+		}
+		if path, handler := handlerOut.HTTPHandler(); path != "" {
+			result[path] = handler
+		}
+	}
+	return result
 }
 
-func parseOutputArgument(s string) (t, arg string) {
-	parts := strings.SplitN(s, "=", 2)
-	switch len(parts) {
-	case 0:
-		return "", ""
-	case 1:
-		return parts[0], ""
+// parseOutputArgument splits a --out argument into the output type, the
+// specification of the filter and aggregation of its samples, which is between
+// square brackets after the type, and the argument of the output, e.g.:
+//
+//	influxdb[include=http_req_duration;dropTags=url;aggregate=10s]=http://localhost:8086/k6
+func parseOutputArgument(s string) (t, pipelineSpec, arg string, err error) {
+	bracket := strings.IndexByte(s, '[')
+	if eq := strings.IndexByte(s, '='); bracket < 0 || (eq >= 0 && eq < bracket) {
+		parts := strings.SplitN(s, "=", 2)
+		if len(parts) == 1 {
+			return parts[0], "", "", nil
+		}
+		return parts[0], "", parts[1], nil
+	}
+
+	t, rest := s[:bracket], s[bracket+1:]
+	end := strings.IndexByte(rest, ']')
+	if end < 0 {
+		return "", "", "", errors.New("the output options aren't closed with ']'")
+	}
+	pipelineSpec, rest = rest[:end], rest[end+1:]
+	switch {
+	case rest == "":
+	case rest[0] == '=':
+		arg = rest[1:]
 	default:
-		return parts[0], parts[1]
+		return "", "", "", fmt.Errorf("unexpected '%s' after the output options", rest)
 	}
+	return t, pipelineSpec, arg, nil
 }
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOutputArgument(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		arg, outputType, pipelineSpec, outputArg string
+	}{
+		{arg: "json", outputType: "json"},
+		{arg: "json=results.json", outputType: "json", outputArg: "results.json"},
+		{arg: "influxdb=http://[::1]:8086/k6", outputType: "influxdb", outputArg: "http://[::1]:8086/k6"},
+		{arg: "json[exclude=vus]", outputType: "json", pipelineSpec: "exclude=vus"},
+		{
+			arg:          "influxdb[include=http_req_duration{status:200};dropTags=url]=http://localhost:8086/k6",
+			outputType:   "influxdb",
+			pipelineSpec: "include=http_req_duration{status:200};dropTags=url",
+			outputArg:    "http://localhost:8086/k6",
+		},
+	}
+	for _, tc := range testCases {
+		outputType, pipelineSpec, outputArg, err := parseOutputArgument(tc.arg)
+		require.NoError(t, err, tc.arg)
+		assert.Equal(t, tc.outputType, outputType, tc.arg)
+		assert.Equal(t, tc.pipelineSpec, pipelineSpec, tc.arg)
+		assert.Equal(t, tc.outputArg, outputArg, tc.arg)
+	}
+
+	for _, arg := range []string{"json[exclude=vus", "json[exclude=vus]results.json"} {
+		_, _, _, err := parseOutputArgument(arg)
+		assert.Error(t, err, arg)
+	}
+}
@@ -0,0 +1,433 @@
+package cmd
+
+import (
+	"context"
+	stdjson "encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/ChipArtem/k6/cmd/state"
+	"github.com/ChipArtem/k6/errext"
+	"github.com/ChipArtem/k6/errext/exitcodes"
+	"github.com/ChipArtem/k6/js"
+	"github.com/ChipArtem/k6/lib"
+	"github.com/ChipArtem/k6/lib/fsext"
+	"github.com/ChipArtem/k6/loader"
+	"github.com/ChipArtem/k6/metrics"
+	"github.com/ChipArtem/k6/metrics/engine"
+	"github.com/ChipArtem/k6/output"
+	"github.com/ChipArtem/k6/output/json"
+	"github.com/ChipArtem/k6/output/rotation"
+)
+
+// replayBatchSize is the maximum number of samples that are sent to the
+// outputs at once.
+const replayBatchSize = 1000
+
+// cmdReplay handles the `k6 replay` sub-command
+type cmdReplay struct {
+	gs *state.GlobalState
+
+	out           []string
+	summary       bool
+	thresholds    bool
+	summaryExport string
+}
+
+// replayDuration keeps the time span of the replayed samples, which is used
+// as the duration of the test run by the thresholds and the summary. The
+// samples aren't necessarily in order, e.g. when several files are replayed,
+// so it keeps the earliest and the latest times.
+type replayDuration struct {
+	mu       sync.Mutex
+	min, max time.Time
+}
+
+func (d *replayDuration) observe(t time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.min.IsZero() || t.Before(d.min) {
+		d.min = t
+	}
+	if t.After(d.max) {
+		d.max = t
+	}
+}
+
+func (d *replayDuration) get() time.Duration {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.max.Sub(d.min)
+}
+
+//nolint:funlen
+func (c *cmdReplay) run(_ *cobra.Command, args []string) (err error) {
+	logger := c.gs.Logger
+	ctx, cancel := context.WithCancel(c.gs.Ctx)
+	defer cancel()
+
+	files, err := c.expandManifests(args)
+	if err != nil {
+		return err
+	}
+
+	registry := metrics.NewRegistry()
+	builtinMetrics := metrics.RegisterBuiltinMetrics(registry)
+	runtimeOptions := lib.RuntimeOptions{
+		NoThresholds:  null.BoolFrom(!c.thresholds),
+		NoSummary:     null.BoolFrom(!c.summary),
+		SummaryExport: null.NewString(c.summaryExport, c.summaryExport != ""),
+	}
+	options := applyDefault(Config{}).Options
+	if c.thresholds {
+		// the metrics have to be registered before the thresholds are validated
+		if options.Thresholds, err = c.readThresholds(files, registry); err != nil {
+			return err
+		}
+	}
+
+	var runner *js.Runner
+	if c.summary {
+		if runner, err = c.newSummaryRunner(registry, builtinMetrics, runtimeOptions, options); err != nil {
+			return err
+		}
+	}
+
+	outputs, outputFilters, outputAggregators, err := createOutputsFromArgs(c.out, nil, output.Params{
+		ScriptPath:     &url.URL{Scheme: "file", Path: filepath.ToSlash(files[0])},
+		Logger:         logger,
+		Environment:    c.gs.Env,
+		StdOut:         c.gs.Stdout,
+		StdErr:         c.gs.Stderr,
+		FS:             c.gs.FS,
+		ScriptOptions:  options,
+		RuntimeOptions: runtimeOptions,
+		Registry:       registry,
+	}, builtinMetrics)
+	if err != nil {
+		return err
+	}
+	if len(outputs) == 0 && !c.summary && !c.thresholds {
+		return errors.New("there is nothing to replay the results to, " +
+			"specify at least one output with --out, or --summary or --thresholds")
+	}
+
+	metricsEngine, err := engine.NewMetricsEngine(registry, logger)
+	if err != nil {
+		return err
+	}
+	var metricsIngester *engine.OutputIngester
+	if c.summary || c.thresholds {
+		if err = metricsEngine.InitSubMetricsAndThresholds(options, !c.thresholds); err != nil {
+			return err
+		}
+		metricsIngester = metricsEngine.CreateIngester()
+		outputs = append(outputs, metricsIngester)
+	}
+
+	duration := &replayDuration{}
+	if c.summary {
+		defer func() {
+			logger.Debug("Generating the end-of-test summary...")
+			summaryResult, hsErr := runner.HandleSummary(ctx, &lib.Summary{
+				Metrics:              metricsEngine.ObservedMetrics,
+				ThresholdExpressions: metricsEngine.ExpressionThresholds(),
+				Timeline:             metricsEngine.Timeline(),
+				Scenarios:            metricsEngine.Scenarios(),
+				RootGroup:            runner.GetDefaultGroup(),
+				TestRunDuration:      duration.get(),
+				NoColor:              c.gs.Flags.NoColor,
+				UIState: lib.UIState{
+					IsStdOutTTY: c.gs.Stdout.IsTTY,
+					IsStdErrTTY: c.gs.Stderr.IsTTY,
+				},
+			})
+			if hsErr == nil {
+				hsErr = handleSummaryResult(c.gs.FS, c.gs.Stdout, c.gs.Stderr, summaryResult)
+			}
+			if hsErr != nil {
+				logger.WithError(hsErr).Error("failed to handle the end-of-test summary")
+			}
+		}()
+	}
+
+	outputManager := output.NewManager(outputs, logger, func(err error) {
+		if err != nil {
+			logger.WithError(err).Error("Received error to stop from output")
+		}
+		cancel()
+	})
+	outputManager.SetFilters(outputFilters)
+	outputManager.SetAggregators(outputAggregators)
+	samples := make(chan metrics.SampleContainer, 10)
+	waitOutputsFlushed, stopOutputs, err := outputManager.Start(samples)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		logger.Debug("Stopping outputs...")
+		stopOutputs(err)
+	}()
+
+	if c.thresholds {
+		// the aborts are ignored, since all of the results are replayed anyway
+		finalizeThresholds := metricsEngine.StartThresholdCalculations(metricsIngester, func(error) {}, duration.get)
+		if finalizeThresholds != nil {
+			defer func() {
+				logger.Debug("Finalizing thresholds...")
+				breachedThresholds := finalizeThresholds()
+				if len(breachedThresholds) == 0 || err != nil {
+					return
+				}
+				err = errext.WithAbortReasonIfNone(
+					errext.WithExitCodeIfNone(
+						fmt.Errorf("thresholds on metrics '%s' have been crossed", strings.Join(breachedThresholds, ", ")),
+						exitcodes.ThresholdsHaveFailed,
+					), errext.AbortedByThresholdsAfterTestEnd)
+			}()
+		}
+	}
+
+	defer func() {
+		logger.Debug("Waiting for metrics processing to finish...")
+		close(samples)
+		waitOutputsFlushed()
+	}()
+
+	// This has to happen before the samples channel is closed above.
+	stopOutputMetrics := outputManager.StartMetricSamplesEmission(samples)
+	defer stopOutputMetrics()
+
+	var rootGroup *lib.Group
+	if runner != nil {
+		rootGroup = runner.GetDefaultGroup()
+	}
+	for _, file := range files {
+		logger.Debugf("Replaying '%s'...", file)
+		err = c.replayFile(ctx, file, registry, func(batch metrics.Samples) {
+			for _, sample := range batch {
+				duration.observe(sample.Time)
+				if rootGroup != nil && sample.Metric == builtinMetrics.Checks {
+					if cErr := rootGroup.AddCheckSample(sample); cErr != nil {
+						logger.WithError(cErr).Warn("Couldn't add the check result to the summary")
+					}
+				}
+			}
+			samples <- batch
+		})
+		if err != nil {
+			return fmt.Errorf("couldn't replay '%s': %w", file, err)
+		}
+	}
+	return nil
+}
+
+// expandManifests resolves the paths of the files, replacing the manifests
+// of the rotated json output files with the files they list, in order.
+func (c *cmdReplay) expandManifests(args []string) ([]string, error) {
+	pwd, err := c.gs.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0, len(args))
+	for _, arg := range args {
+		if !filepath.IsAbs(arg) {
+			arg = filepath.Join(pwd, arg)
+		}
+		if !strings.HasSuffix(arg, ".manifest.json") {
+			files = append(files, arg)
+			continue
+		}
+		data, err := fsext.ReadFile(c.gs.FS, arg)
+		if err != nil {
+			return nil, err
+		}
+		var manifest rotation.Manifest
+		if err := stdjson.Unmarshal(data, &manifest); err != nil {
+			return nil, fmt.Errorf("couldn't parse the manifest '%s': %w", arg, err)
+		}
+		if manifest.Deleted > 0 {
+			c.gs.Logger.Warnf("%d of the files listed in '%s' were deleted by the rotation, "+
+				"their results can't be replayed", manifest.Deleted, arg)
+		}
+		for _, entry := range manifest.Files {
+			files = append(files, filepath.Join(filepath.Dir(arg), entry.Name))
+		}
+	}
+	return files, nil
+}
+
+// readThresholds reads all of the files, registering their metrics, and
+// returns the parsed thresholds that were recorded for them.
+func (c *cmdReplay) readThresholds(files []string, registry *metrics.Registry) (map[string]metrics.Thresholds, error) {
+	thresholds := make(map[string]metrics.Thresholds)
+	for _, file := range files {
+		err := c.readFile(file, registry, func(r *json.Reader) error {
+			for {
+				if _, err := r.Read(); err != nil {
+					if errors.Is(err, io.EOF) {
+						break
+					}
+					return err
+				}
+			}
+			for name, ts := range r.Thresholds() {
+				thresholds[name] = ts
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("couldn't read the thresholds of '%s': %w", file, err)
+		}
+	}
+
+	for name, ts := range thresholds {
+		if err := ts.Parse(); err != nil {
+			return nil, errext.WithExitCodeIfNone(err, exitcodes.InvalidConfig)
+		}
+		if err := ts.Validate(name, registry); err != nil {
+			return nil, errext.WithExitCodeIfNone(err, exitcodes.InvalidConfig)
+		}
+		if err := ts.ResolveBaseline(name, nil); err != nil {
+			return nil, err
+		}
+		thresholds[name] = ts
+	}
+	return thresholds, nil
+}
+
+// replayFile sends the samples of the file to the outputs, in batches.
+func (c *cmdReplay) replayFile(
+	ctx context.Context, file string, registry *metrics.Registry, send func(metrics.Samples),
+) error {
+	return c.readFile(file, registry, func(r *json.Reader) error {
+		batch := make(metrics.Samples, 0, replayBatchSize)
+		for {
+			sample, err := r.Read()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			batch = append(batch, sample)
+			if len(batch) == replayBatchSize {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				send(batch)
+				batch = make(metrics.Samples, 0, replayBatchSize)
+			}
+		}
+		if len(batch) > 0 {
+			send(batch)
+		}
+		return nil
+	})
+}
+
+// readFile opens the file, which is gunzipped if its name ends with .gz, and
+// calls fn with a reader of its samples.
+func (c *cmdReplay) readFile(file string, registry *metrics.Registry, fn func(*json.Reader) error) error {
+	f, err := c.gs.FS.Open(file)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	var r io.Reader = f
+	if strings.HasSuffix(file, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = gz.Close() }()
+		r = gz
+	}
+	return fn(json.NewReader(r, registry))
+}
+
+// newSummaryRunner returns a runner of an empty script, which generates the
+// end-of-test summary the same way as `k6 run` does.
+func (c *cmdReplay) newSummaryRunner(
+	registry *metrics.Registry, builtinMetrics *metrics.BuiltinMetrics,
+	runtimeOptions lib.RuntimeOptions, options lib.Options,
+) (*js.Runner, error) {
+	preInitState := &lib.TestPreInitState{
+		Logger:         c.gs.Logger,
+		RuntimeOptions: runtimeOptions,
+		Registry:       registry,
+		BuiltinMetrics: builtinMetrics,
+		Events:         c.gs.Events,
+		LookupEnv: func(key string) (string, bool) {
+			val, ok := c.gs.Env[key]
+			return val, ok
+		},
+	}
+	src := &loader.SourceData{
+		URL:  &url.URL{Scheme: "file", Path: "/replay.js"},
+		Data: []byte("export default function () {}"),
+	}
+	runner, err := js.New(preInitState, src, loader.CreateFilesystems(c.gs.FS))
+	if err != nil {
+		return nil, err
+	}
+	if err := runner.SetOptions(options); err != nil {
+		return nil, err
+	}
+	return runner, nil
+}
+
+func (c *cmdReplay) flagSet() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("", pflag.ContinueOnError)
+	flags.SortFlags = false
+	flags.StringArrayVarP(&c.out, "out", "o", c.out, "`uri` for an external metrics database")
+	flags.BoolVar(&c.summary, "summary", false, "recompute and show the end-of-test summary")
+	flags.BoolVar(&c.thresholds, "thresholds", false,
+		"recompute the recorded thresholds and exit with an error if they are crossed")
+	flags.StringVar(&c.summaryExport, "summary-export", "",
+		"output the end-of-test summary report to JSON file, requires --summary")
+	return flags
+}
+
+func getCmdReplay(gs *state.GlobalState) *cobra.Command {
+	c := &cmdReplay{gs: gs}
+
+	exampleText := getExampleText(gs, `
+  # Send the results of a test run, recorded with --out json, to InfluxDB.
+  {{.}} replay -o influxdb=http://localhost:8086/k6 results.json.gz
+
+  # Recompute the thresholds and the summary of the rotated files of a test run.
+  {{.}} replay --thresholds --summary results.json.manifest.json`[1:])
+
+	replayCmd := &cobra.Command{
+		Use:   "replay [flags] results.json[.gz]...",
+		Short: "Replay the recorded results of a test run to outputs",
+		Long: `Replay the recorded results of a test run to outputs.
+
+The results written by the json output are read and sent to the outputs with
+their original timestamps. The thresholds of the metrics and the end-of-test
+summary can be recomputed as well, although the thresholds of the submetrics
+aren't recorded by the json output. The manifest of rotated json output files
+can be given instead of the files themselves.`,
+		Example: exampleText,
+		Args:    cobra.MinimumNArgs(1),
+		RunE:    c.run,
+	}
+
+	replayCmd.Flags().SortFlags = false
+	replayCmd.Flags().AddFlagSet(c.flagSet())
+
+	return replayCmd
+}
@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplayDuration(t *testing.T) {
+	t.Parallel()
+
+	d := &replayDuration{}
+	assert.Equal(t, time.Duration(0), d.get())
+
+	start := time.Unix(1700000000, 0)
+	for _, offset := range []time.Duration{5 * time.Second, 2 * time.Second, 9 * time.Second, 7 * time.Second} {
+		d.observe(start.Add(offset))
+	}
+	assert.Equal(t, 7*time.Second, d.get(), "the span between the earliest and the latest samples")
+}
@@ -160,7 +160,7 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 
 	// Create all outputs.
 	executionPlan := execScheduler.GetExecutionPlan()
-	outputs, err := createOutputs(c.gs, test, executionPlan)
+	outputs, outputFilters, outputAggregators, err := createOutputs(c.gs, test, executionPlan)
 	if err != nil {
 		return err
 	}
@@ -170,10 +170,18 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 		return err
 	}
 
+	// The time series budget protects all of the outputs, so it's enforced
+	// even when the metrics aren't processed for the summary or thresholds.
+	if budget := engine.NewTimeSeriesBudget(conf.Options, testRunState.Registry, logger); budget != nil {
+		outputs = append(outputs, budget)
+	}
+
 	// We'll need to pipe metrics to the MetricsEngine and process them if any
-	// of these are enabled: thresholds, end-of-test summary
+	// of these are enabled: thresholds, end-of-test summary, derived metrics,
+	// since the derived metrics are computed from the engine's sinks.
 	shouldProcessMetrics := (!testRunState.RuntimeOptions.NoSummary.Bool ||
-		!testRunState.RuntimeOptions.NoThresholds.Bool)
+		!testRunState.RuntimeOptions.NoThresholds.Bool ||
+		len(testRunState.Registry.Derived()) > 0)
 	var metricsIngester *engine.OutputIngester
 	if shouldProcessMetrics {
 		err = metricsEngine.InitSubMetricsAndThresholds(conf.Options, testRunState.RuntimeOptions.NoThresholds.Bool)
@@ -181,20 +189,27 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 			return err
 		}
 		// We'll need to pipe metrics to the MetricsEngine if either the
-		// thresholds or the end-of-test summary are enabled.
+		// thresholds, the end-of-test summary or the derived metrics are enabled.
 		metricsIngester = metricsEngine.CreateIngester()
 		outputs = append(outputs, metricsIngester)
 	}
 
 	executionState := execScheduler.GetState()
+	if metricsIngester != nil {
+		// The executors that adapt the load to the metrics need their values.
+		executionState.LiveMetrics = metricsEngine
+	}
 	if !testRunState.RuntimeOptions.NoSummary.Bool {
 		defer func() {
 			logger.Debug("Generating the end-of-test summary...")
 			summaryResult, hsErr := test.initRunner.HandleSummary(globalCtx, &lib.Summary{
-				Metrics:         metricsEngine.ObservedMetrics,
-				RootGroup:       testRunState.Runner.GetDefaultGroup(),
-				TestRunDuration: executionState.GetCurrentTestRunDuration(),
-				NoColor:         c.gs.Flags.NoColor,
+				Metrics:              metricsEngine.ObservedMetrics,
+				ThresholdExpressions: metricsEngine.ExpressionThresholds(),
+				Timeline:             metricsEngine.Timeline(),
+				Scenarios:            metricsEngine.Scenarios(),
+				RootGroup:            testRunState.Runner.GetDefaultGroup(),
+				TestRunDuration:      executionState.GetCurrentTestRunDuration(),
+				NoColor:              c.gs.Flags.NoColor,
 				UIState: lib.UIState{
 					IsStdOutTTY: c.gs.Stdout.IsTTY,
 					IsStdErrTTY: c.gs.Stderr.IsTTY,
@@ -222,6 +237,8 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 		// TODO: attach run status and exit code?
 		runAbort(err)
 	})
+	outputManager.SetFilters(outputFilters)
+	outputManager.SetAggregators(outputAggregators)
 	samples := make(chan metrics.SampleContainer, test.derivedConfig.MetricSamplesBufferSize.Int64)
 	waitOutputsFlushed, stopOutputs, err := outputManager.Start(samples)
 	if err != nil {
@@ -289,6 +306,19 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 		logger.Debug("Metrics and traces processing finished!")
 	}()
 
+	// This has to happen before the samples channel is closed above.
+	stopOutputMetrics := outputManager.StartMetricSamplesEmission(samples)
+	defer stopOutputMetrics()
+
+	if metricsIngester != nil {
+		// This has to happen before the samples channel is closed above.
+		stopDerivedMetrics := metricsEngine.StartDerivedMetricsCalculations(
+			samples, executionState.GetCurrentTestRunDuration,
+		)
+		defer stopDerivedMetrics()
+	}
+
+	outputHandlers := getOutputHTTPHandlers(outputs)
 	// Spin up the REST API server, if not disabled.
 	if c.gs.Flags.Address != "" { //nolint:nestif
 		initBar.Modify(pb.WithConstProgress(0, "Init API server"))
@@ -296,21 +326,26 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 		// We cannot use backgroundProcesses here, since we need the REST API to
 		// be down before we can close the samples channel above and finish the
 		// processing the metrics pipeline.
-		apiWG := &sync.WaitGroup{}
-		apiWG.Add(2)
-		defer apiWG.Wait()
-
-		srvCtx, srvCancel := context.WithCancel(globalCtx)
-		defer srvCancel()
-
-		srv := api.GetServer(
+		srv, err := api.GetServer(
 			runCtx,
 			c.gs.Flags.Address, c.gs.Flags.ProfilingEnabled,
 			testRunState,
 			samples,
 			metricsEngine,
 			execScheduler,
+			outputHandlers,
 		)
+		if err != nil {
+			return err
+		}
+
+		apiWG := &sync.WaitGroup{}
+		apiWG.Add(2)
+		defer apiWG.Wait()
+
+		srvCtx, srvCancel := context.WithCancel(globalCtx)
+		defer srvCancel()
+
 		go func() {
 			defer apiWG.Done()
 			logger.Debugf("Starting the REST API server on %s", c.gs.Flags.Address)
@@ -336,6 +371,8 @@ func (c *cmdRun) run(cmd *cobra.Command, args []string) (err error) {
 				logger.WithError(aerr).Debug("REST API server did not shut down correctly")
 			}
 		}()
+	} else if len(outputHandlers) > 0 {
+		logger.Warn("The REST API server is disabled, the outputs that use it won't be able to serve their data")
 	}
 
 	printExecutionDescription(
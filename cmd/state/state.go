@@ -12,9 +12,9 @@ import (
 	"github.com/mattn/go-isatty"
 	"github.com/sirupsen/logrus"
 
-
-	"github.com/ChipArtem/k6/lib/fsext"	"github.com/ChipArtem/k6/ui/console"
 	"github.com/ChipArtem/k6/event"
+	"github.com/ChipArtem/k6/lib/fsext"
+	"github.com/ChipArtem/k6/ui/console"
 )
 
 const defaultConfigFileName = "config.json"
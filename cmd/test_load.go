@@ -203,6 +203,12 @@ func (lt *loadedTest) consolidateDeriveAndValidateConfig(
 	// If parsing the threshold expressions failed, consider it as an
 	// invalid configuration error.
 	if !lt.preInitState.RuntimeOptions.NoThresholds.Bool {
+		var baseline metrics.Baseline
+		baseline, err = lt.loadBaseline(gs)
+		if err != nil {
+			return nil, errext.WithExitCodeIfNone(err, exitcodes.InvalidConfig)
+		}
+
 		for metricName, thresholdsDefinition := range consolidatedConfig.Options.Thresholds {
 			err = thresholdsDefinition.Parse()
 			if err != nil {
@@ -213,6 +219,21 @@ func (lt *loadedTest) consolidateDeriveAndValidateConfig(
 			if err != nil {
 				return nil, errext.WithExitCodeIfNone(err, exitcodes.InvalidConfig)
 			}
+
+			err = thresholdsDefinition.ResolveBaseline(metricName, baseline)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		err = consolidatedConfig.Options.ThresholdExpressions.Parse()
+		if err != nil {
+			return nil, errext.WithExitCodeIfNone(err, exitcodes.InvalidConfig)
+		}
+
+		err = consolidatedConfig.Options.ThresholdExpressions.Validate(lt.preInitState.Registry)
+		if err != nil {
+			return nil, errext.WithExitCodeIfNone(err, exitcodes.InvalidConfig)
 		}
 	}
 
@@ -228,6 +249,20 @@ func (lt *loadedTest) consolidateDeriveAndValidateConfig(
 	}, nil
 }
 
+// loadBaseline reads the baseline summary specified with --baseline, if any.
+func (lt *loadedTest) loadBaseline(gs *state.GlobalState) (metrics.Baseline, error) {
+	baselinePath := lt.preInitState.RuntimeOptions.Baseline
+	if !baselinePath.Valid || baselinePath.String == "" {
+		return nil, nil //nolint:nilnil
+	}
+
+	data, err := fsext.ReadFile(gs.FS, baselinePath.String)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't load the baseline summary from %q: %w", baselinePath.String, err)
+	}
+	return metrics.ParseBaseline(data)
+}
+
 // loadedAndConfiguredTest contains the whole loadedTest, as well as the
 // consolidated test config and the full test run state.
 type loadedAndConfiguredTest struct {
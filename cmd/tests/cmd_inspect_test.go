@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ChipArtem/k6/cmd"
+	"github.com/ChipArtem/k6/lib/fsext"
+)
+
+func TestInspectExecutionRequirementsScenarioGraph(t *testing.T) {
+	t.Parallel()
+	script := `
+		export const options = {
+			thresholds: {
+				'http_req_failed{scenario:warmup}': ['rate<0.01'],
+			},
+			scenarios: {
+				warmup: {
+					executor: 'constant-vus',
+					vus: 5,
+					duration: '10s',
+					gracefulStop: '5s',
+				},
+				main: {
+					executor: 'ramping-vus',
+					startAfter: 'warmup',
+					onlyIf: ['http_req_failed{scenario:warmup}'],
+					stages: [{ duration: '30s', target: 20 }],
+					gracefulRampDown: '0s',
+					gracefulStop: '0s',
+				},
+				cooldown: {
+					executor: 'constant-vus',
+					startAfter: 'main',
+					startTime: '5s',
+					vus: 1,
+					duration: '10s',
+					gracefulStop: '0s',
+				},
+			},
+		};
+
+		export default function () {}
+	`
+
+	ts := NewGlobalTestState(t)
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "test.js"), []byte(script), 0o644))
+	ts.CmdArgs = []string{"k6", "inspect", "--execution-requirements", "test.js"}
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	var result struct {
+		TotalDuration string                     `json:"totalDuration"`
+		MaxVUs        uint64                     `json:"maxVUs"`
+		ScenarioGraph map[string]json.RawMessage `json:"scenarioGraph"`
+	}
+	require.NoError(t, json.Unmarshal(ts.Stdout.Bytes(), &result))
+	assert.Equal(t, "1m0s", result.TotalDuration)
+	assert.Equal(t, uint64(20), result.MaxVUs)
+	assert.JSONEq(t,
+		`{"dependents":["main"],"startTime":"0s","endTime":"15s","maxVUs":5}`,
+		string(result.ScenarioGraph["warmup"]))
+	assert.JSONEq(t,
+		`{"startAfter":"warmup","onlyIf":["http_req_failed{scenario:warmup}"],"dependents":["cooldown"],`+
+			`"startTime":"15s","endTime":"45s","maxVUs":20}`,
+		string(result.ScenarioGraph["main"]))
+	assert.JSONEq(t,
+		`{"startAfter":"main","startTime":"50s","endTime":"1m0s","maxVUs":1}`,
+		string(result.ScenarioGraph["cooldown"]))
+}
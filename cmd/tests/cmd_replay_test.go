@@ -0,0 +1,63 @@
+package tests
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ChipArtem/k6/cmd"
+	"github.com/ChipArtem/k6/errext/exitcodes"
+	"github.com/ChipArtem/k6/lib/fsext"
+	"github.com/ChipArtem/k6/lib/testutils"
+)
+
+func TestReplay(t *testing.T) {
+	t.Parallel()
+
+	results := strings.Join([]string{
+		`{"type":"Metric","data":{"name":"iterations","type":"counter","contains":"default","thresholds":["count==3"],"submetrics":null},"metric":"iterations"}`,
+		`{"type":"Point","data":{"time":"2024-01-02T15:04:05Z","value":1,"tags":{"scenario":"default"}},"metric":"iterations"}`,
+		`{"type":"Point","data":{"time":"2024-01-02T15:04:06Z","value":1,"tags":{"scenario":"default"}},"metric":"iterations"}`,
+		`{"type":"Metric","data":{"name":"checks","type":"rate","contains":"default","thresholds":[],"submetrics":null},"metric":"checks"}`,
+		`{"type":"Point","data":{"time":"2024-01-02T15:04:07Z","value":1,"tags":{"check":"is ok","group":"::g1"}},"metric":"checks"}`,
+	}, "\n")
+
+	ts := NewGlobalTestState(t)
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "results.json"), []byte(results), 0o644))
+	ts.CmdArgs = []string{"k6", "replay", "--summary", "--thresholds", "--out", "csv=results.csv", "results.json"}
+	ts.ExpectedExitCode = int(exitcodes.ThresholdsHaveFailed)
+
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	assert.True(t, testutils.LogContains(
+		ts.LoggerHook.Drain(), logrus.ErrorLevel, "thresholds on metrics 'iterations' have been crossed",
+	))
+	stdout := ts.Stdout.String()
+	t.Log(stdout)
+	assert.Contains(t, stdout, "█ g1")
+	assert.Contains(t, stdout, "✓ is ok")
+	assert.Contains(t, stdout, "✗ iterations...: 2       1/s")
+
+	data, err := fsext.ReadFile(ts.FS, "results.csv")
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 4)
+	assert.True(t, strings.HasPrefix(lines[1], "iterations,1704207845,1.000000,"), lines[1])
+}
+
+func TestReplayWithoutOutputs(t *testing.T) {
+	t.Parallel()
+
+	ts := NewGlobalTestState(t)
+	require.NoError(t, fsext.WriteFile(ts.FS, filepath.Join(ts.Cwd, "results.json"), nil, 0o644))
+	ts.CmdArgs = []string{"k6", "replay", "results.json"}
+	ts.ExpectedExitCode = -1
+
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	assert.True(t, testutils.LogContains(ts.LoggerHook.Drain(), logrus.ErrorLevel, "there is nothing to replay"))
+}
@@ -440,7 +440,7 @@ func TestExecutionTestOptionsDefaultValues(t *testing.T) {
 	loglines := ts.LoggerHook.Drain()
 	require.Len(t, loglines, 1)
 
-	expected := `{"paused":null,"executionSegment":null,"executionSegmentSequence":null,"noSetup":null,"setupTimeout":null,"noTeardown":null,"teardownTimeout":null,"rps":null,"dns":{"ttl":null,"select":null,"policy":null},"maxRedirects":null,"userAgent":null,"batch":null,"batchPerHost":null,"httpDebug":null,"insecureSkipTLSVerify":null,"tlsCipherSuites":null,"tlsVersion":null,"tlsAuth":null,"throw":null,"thresholds":null,"blacklistIPs":null,"blockHostnames":null,"hosts":null,"noConnectionReuse":null,"noVUConnectionReuse":null,"minIterationDuration":null,"ext":null,"summaryTrendStats":["avg", "min", "med", "max", "p(90)", "p(95)"],"summaryTimeUnit":null,"systemTags":["check","error","error_code","expected_response","group","method","name","proto","scenario","service","status","subproto","tls_version","url"],"tags":null,"metricSamplesBufferSize":null,"noCookiesReset":null,"discardResponseBodies":null,"consoleOutput":null,"scenarios":{"default":{"vus":null,"iterations":1,"executor":"shared-iterations","maxDuration":null,"startTime":null,"env":null,"tags":null,"gracefulStop":null,"exec":null}},"localIPs":null}`
+	expected := `{"paused":null,"executionSegment":null,"executionSegmentSequence":null,"noSetup":null,"setupTimeout":null,"noTeardown":null,"teardownTimeout":null,"rps":null,"dns":{"ttl":null,"select":null,"policy":null},"maxRedirects":null,"userAgent":null,"batch":null,"batchPerHost":null,"httpDebug":null,"insecureSkipTLSVerify":null,"tlsCipherSuites":null,"tlsVersion":null,"tlsAuth":null,"throw":null,"thresholds":null,"thresholdExpressions":null,"blacklistIPs":null,"blockHostnames":null,"hosts":null,"noConnectionReuse":null,"noVUConnectionReuse":null,"minIterationDuration":null,"ext":null,"summaryTrendStats":["avg", "min", "med", "max", "p(90)", "p(95)"],"summaryTimeUnit":null,"systemTags":["check","error","error_code","expected_response","group","method","name","proto","scenario","service","status","subproto","tls_version","url"],"tags":null,"metricSamplesBufferSize":null,"trendRelativeError":null,"summaryTimelineInterval":null,"summaryMode":null,"maxTimeSeries":null,"maxTimeSeriesPerMetric":null,"timeSeriesOverflow":null,"noCookiesReset":null,"discardResponseBodies":null,"consoleOutput":null,"scenarios":{"default":{"vus":null,"iterations":1,"executor":"shared-iterations","maxDuration":null,"startTime":null,"startAfter":null,"onlyIf":null,"env":null,"tags":null,"gracefulStop":null,"exec":null}},"localIPs":null}`
 	assert.JSONEq(t, expected, loglines[0].Message)
 }
 
@@ -1587,6 +1587,104 @@ func TestMetricNameError(t *testing.T) {
 	require.Contains(t, filteredEntries[0].Message, "'test counter'")
 }
 
+func TestRunTimeSeriesBudgetWithoutSummaryAndThresholds(t *testing.T) {
+	t.Parallel()
+	script := `
+		import { Counter } from 'k6/metrics';
+		import exec from 'k6/execution';
+
+		export let options = {
+			iterations: 5,
+			maxTimeSeriesPerMetric: 2,
+		};
+
+		var c = new Counter('test_counter');
+
+		export default function () { c.add(1, { id: String(exec.scenario.iterationInTest) }); };
+	`
+
+	ts := getSingleFileTestState(t, script, []string{
+		"--no-summary", "--no-thresholds", "--out", "json=results.json",
+	}, 0)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	jsonResults, err := fsext.ReadFile(ts.FS, "results.json")
+	require.NoError(t, err)
+	assert.Equal(t, float64(5), sum(getSampleValues(t, jsonResults, "test_counter", nil)))
+	assert.Len(t, getSampleValues(t, jsonResults, "test_counter", map[string]string{"__overflow__": "true"}), 3)
+
+	assert.True(t, testutils.LogContains(ts.LoggerHook.Drain(), logrus.WarnLevel,
+		"The metric 'test_counter' exceeded the time series budget with 2 series, 3 samples"))
+}
+
+func TestRunDerivedMetricsWithoutSummaryAndThresholds(t *testing.T) {
+	t.Parallel()
+	script := `
+		import { Counter, Derived } from 'k6/metrics';
+		import { sleep } from 'k6';
+
+		export let options = {
+			iterations: 2,
+		};
+
+		var errors = new Counter('test_errors');
+		new Derived('test_errors_per_iteration', 'test_errors.count / iterations.count');
+
+		export default function () {
+			errors.add(1);
+			sleep(0.7);
+		};
+	`
+
+	ts := getSingleFileTestState(t, script, []string{
+		"--no-summary", "--no-thresholds", "--out", "json=results.json",
+	}, 0)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	jsonResults, err := fsext.ReadFile(ts.FS, "results.json")
+	require.NoError(t, err)
+	assert.NotEmpty(t, getSampleValues(t, jsonResults, "test_errors_per_iteration", nil))
+}
+
+func TestRunInfluxDBSpoolMetrics(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/write" {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	script := `
+		import { sleep } from 'k6';
+
+		export let options = {
+			iterations: 1,
+		};
+
+		export default function () {
+			sleep(1.5);
+		};
+	`
+
+	ts := getSingleFileTestState(t, script, []string{
+		"--out", "influxdb=" + srv.URL + "?pushInterval=100ms&spoolDir=spool",
+		"--out", "json=results.json",
+	}, 0)
+	cmd.ExecuteWithGlobalState(ts.GlobalState)
+
+	// the spool's metrics reach the other outputs and the summary
+	jsonResults, err := fsext.ReadFile(ts.FS, "results.json")
+	require.NoError(t, err)
+	records := getSampleValues(t, jsonResults, "output_spool_records", nil)
+	require.NotEmpty(t, records)
+	assert.Positive(t, records[len(records)-1])
+	assert.Contains(t, ts.Stdout.String(), "output_spool_records")
+}
+
 func TestRunTags(t *testing.T) {
 	t.Parallel()
 
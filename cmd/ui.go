@@ -113,14 +113,14 @@ func printExecutionDescription(
 	default:
 		for _, out := range outputs {
 			desc := out.Description()
-			if desc == engine.IngesterDescription {
-				if len(outputs) != 1 {
-					continue
-				}
-				desc = "-"
+			if desc == engine.IngesterDescription || desc == engine.TimeSeriesBudgetDescription {
+				continue
 			}
 			outputDescriptions = append(outputDescriptions, desc)
 		}
+		if len(outputDescriptions) == 0 && len(outputs) != 0 {
+			outputDescriptions = []string{"-"}
+		}
 	}
 
 	fmt.Fprintf(buf, "     output: %s\n", valueColor.Sprint(strings.Join(outputDescriptions, ", ")))
@@ -53,4 +53,8 @@ const (
 
 	// GoPanic indicates the script was aborted by a panic in the Go runtime.
 	GoPanic ExitCode = 109
+
+	// TimeSeriesLimitExceeded indicates the test generated more unique metric
+	// time series than its configured budget allows, with the abort policy.
+	TimeSeriesLimitExceeded ExitCode = 110
 )
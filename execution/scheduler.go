@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"runtime"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -21,8 +22,8 @@ import (
 // executors for the different scenarios at the appropriate times.
 type Scheduler struct {
 	initProgress    *pb.ProgressBar
-	executorConfigs []lib.ExecutorConfig // sorted by (startTime, ID)
-	executors       []lib.Executor       // sorted by (startTime, ID), excludes executors with no work
+	executorConfigs []lib.ExecutorConfig // sorted by (planned start time, ID)
+	executors       []lib.Executor       // sorted by (planned start time, ID), excludes executors with no work
 	executionPlan   []lib.ExecutionStep
 	maxDuration     time.Duration // cached value derived from the execution plan
 	maxPossibleVUs  uint64        // cached value derived from the execution plan
@@ -50,6 +51,12 @@ func NewScheduler(trs *lib.TestRunState) (*Scheduler, error) {
 	executors := make([]lib.Executor, 0, len(executorConfigs))
 	// Only take executors which have work.
 	for _, sc := range executorConfigs {
+		if len(sc.GetOnlyIf()) > 0 && trs.RuntimeOptions.NoThresholds.Bool {
+			trs.Logger.Warnf(
+				"The onlyIf conditions of scenario '%s' will be ignored, since the thresholds are disabled",
+				sc.GetName(),
+			)
+		}
 		if !sc.HasWork(et) {
 			trs.Logger.Warnf(
 				"Executor '%s' is disabled for segment %s due to lack of work!",
@@ -94,13 +101,13 @@ func (e *Scheduler) GetState() *lib.ExecutionState {
 }
 
 // GetExecutors returns the slice of configured executor instances which
-// have work, sorted by their (startTime, name) in an ascending order.
+// have work, sorted by their (planned start time, name) in an ascending order.
 func (e *Scheduler) GetExecutors() []lib.Executor {
 	return e.executors
 }
 
 // GetExecutorConfigs returns the slice of all executor configs, sorted by
-// their (startTime, name) in an ascending order.
+// their (planned start time, name) in an ascending order.
 func (e *Scheduler) GetExecutorConfigs() []lib.ExecutorConfig {
 	return e.executorConfigs
 }
@@ -321,41 +328,108 @@ func (e *Scheduler) initVUsAndExecutors(ctx context.Context, samplesOut chan<- m
 	return nil
 }
 
+// waitForStart waits until the executor should start: until the end of the
+// scenario it should start after, if there is one, and then its startTime. It
+// returns false if the test run ended in the meantime.
+func (e *Scheduler) waitForStart(
+	runCtx context.Context, executorConfig lib.ExecutorConfig, ended map[string]chan struct{},
+	executorProgress *pb.ProgressBar, executorLogger logrus.FieldLogger,
+) bool {
+	if startAfter := executorConfig.GetStartAfter(); startAfter != "" {
+		executorProgress.Modify(
+			pb.WithStatus(pb.Waiting),
+			pb.WithConstProgress(0, "waiting for "+startAfter),
+		)
+
+		executorLogger.Debugf("Waiting for the end of scenario %s...", startAfter)
+		select {
+		case <-runCtx.Done():
+			return false
+		case <-ended[startAfter]:
+			// continue
+		}
+	}
+
+	executorStartTime := executorConfig.GetStartTime()
+	if executorStartTime <= 0 {
+		return true
+	}
+	startTime := time.Now()
+	executorProgress.Modify(
+		pb.WithStatus(pb.Waiting),
+		pb.WithProgress(func() (float64, []string) {
+			remWait := (executorStartTime - time.Since(startTime))
+			return 0, []string{"waiting", pb.GetFixedLengthDuration(remWait, executorStartTime)}
+		}),
+	)
+
+	executorLogger.Debugf("Waiting for executor start time...")
+	select {
+	case <-runCtx.Done():
+		return false
+	case <-time.After(executorStartTime):
+		return true
+	}
+}
+
+// getCrossedThresholds returns the onlyIf conditions of the executor whose
+// thresholds are crossed, i.e. it should be skipped if there are any.
+func (e *Scheduler) getCrossedThresholds(executorConfig lib.ExecutorConfig) ([]string, error) {
+	onlyIf := executorConfig.GetOnlyIf()
+	if len(onlyIf) == 0 || e.state.Test.RuntimeOptions.NoThresholds.Bool {
+		return nil, nil
+	}
+	if e.state.LiveMetrics == nil {
+		return nil, fmt.Errorf("the onlyIf conditions of scenario %s require the metrics to be processed",
+			executorConfig.GetName())
+	}
+	return e.state.LiveMetrics.CheckThresholds(onlyIf, e.state.GetCurrentTestRunDuration())
+}
+
 // runExecutor gets called by the public Run() method once per configured
 // executor, each time in a new goroutine. It is responsible for waiting out the
-// configured startTime for the specific executor and then running its Run()
-// method.
+// end of the scenario the executor should start after and its configured
+// startTime, checking its onlyIf conditions and then running its Run() method.
+// The executor's channel in ended is closed when it's done, so the scenarios
+// that start after it can start, even if it was skipped.
+//
+// The scenarios that start after another one can start earlier than planned,
+// when the other one ends before the end of its gracefulStop. Their VUs are
+// reserved from the earliest time they could start to the latest one, see
+// getShiftedExecutionSteps, so there are enough VUs for them either way.
 func (e *Scheduler) runExecutor(
 	runCtx context.Context, runResults chan<- error, engineOut chan<- metrics.SampleContainer, executor lib.Executor,
+	ended map[string]chan struct{},
 ) {
 	executorConfig := executor.GetConfig()
-	executorStartTime := executorConfig.GetStartTime()
+	defer close(ended[executorConfig.GetName()])
 	executorLogger := e.state.Test.Logger.WithFields(logrus.Fields{
 		"executor":  executorConfig.GetName(),
 		"type":      executorConfig.GetType(),
-		"startTime": executorStartTime,
+		"startTime": executorConfig.GetStartTime(),
 	})
 	executorProgress := executor.GetProgress()
 
-	// Check if we have to wait before starting the actual executor execution
-	if executorStartTime > 0 {
-		startTime := time.Now()
+	if !e.waitForStart(runCtx, executorConfig, ended, executorProgress, executorLogger) {
+		runResults <- nil // no error since executor hasn't started yet
+		return
+	}
+
+	crossed, err := e.getCrossedThresholds(executorConfig)
+	if err != nil {
+		executorLogger.WithField("error", err).Errorf("Executor error")
+		runResults <- err
+		return
+	}
+	if len(crossed) > 0 {
+		executorLogger.Infof("Skipping scenario %s, since the thresholds on %s were crossed",
+			executorConfig.GetName(), strings.Join(crossed, ", "))
 		executorProgress.Modify(
-			pb.WithStatus(pb.Waiting),
-			pb.WithProgress(func() (float64, []string) {
-				remWait := (executorStartTime - time.Since(startTime))
-				return 0, []string{"waiting", pb.GetFixedLengthDuration(remWait, executorStartTime)}
-			}),
+			pb.WithStatus(pb.Done),
+			pb.WithConstProgress(0, "skipped"),
 		)
-
-		executorLogger.Debugf("Waiting for executor start time...")
-		select {
-		case <-runCtx.Done():
-			runResults <- nil // no error since executor hasn't started yet
-			return
-		case <-time.After(executorStartTime):
-			// continue
-		}
+		runResults <- nil
+		return
 	}
 
 	executorProgress.Modify(
@@ -363,7 +437,7 @@ func (e *Scheduler) runExecutor(
 		pb.WithConstProgress(0, "started"),
 	)
 	executorLogger.Debugf("Starting executor")
-	err := executor.Run(runCtx, engineOut) // executor should handle context cancel itself
+	err = executor.Run(runCtx, engineOut) // executor should handle context cancel itself
 	if err == nil {
 		executorLogger.Debugf("Executor finished successfully")
 	} else {
@@ -372,6 +446,18 @@ func (e *Scheduler) runExecutor(
 	runResults <- err
 }
 
+// waitForStartWithoutWork gets called by the public Run() method for the
+// executors that don't have any work for the execution segment. They end as
+// soon as they should start, so the scenarios that start after them start at
+// the same time in every k6 instance.
+func (e *Scheduler) waitForStartWithoutWork(
+	runCtx context.Context, executorConfig lib.ExecutorConfig, ended map[string]chan struct{},
+) {
+	defer close(ended[executorConfig.GetName()])
+	executorLogger := e.state.Test.Logger.WithField("executor", executorConfig.GetName())
+	e.waitForStart(runCtx, executorConfig, ended, pb.New(), executorLogger)
+}
+
 // Init concurrently initializes all of the planned VUs and then sequentially
 // initializes all of the configured executors. It also starts the measurement
 // and emission of the `vus` and `vus_max` metrics.
@@ -456,14 +542,26 @@ func (e *Scheduler) Run(globalCtx, runCtx context.Context, samplesOut chan<- met
 	}
 	e.initProgress.Modify(pb.WithHijack(e.getRunStats))
 
-	// Start all executors at their particular startTime in a separate goroutine...
+	// Start all executors in a separate goroutine, each of them waits for its
+	// particular start...
 	logger.Debug("Start all executors...")
 	e.state.SetExecutionStatus(lib.ExecutionStatusRunning)
 
 	executorsRunCtx, executorsRunCancel := context.WithCancel(withExecStateCtx)
 	defer executorsRunCancel()
+	ended := make(map[string]chan struct{}, len(e.executorConfigs))
+	for _, config := range e.executorConfigs {
+		ended[config.GetName()] = make(chan struct{})
+	}
+	hasWork := make(map[string]bool, len(e.executors))
 	for _, exec := range e.executors {
-		go e.runExecutor(executorsRunCtx, runResults, samplesOut, exec)
+		hasWork[exec.GetConfig().GetName()] = true
+		go e.runExecutor(executorsRunCtx, runResults, samplesOut, exec, ended)
+	}
+	for _, config := range e.executorConfigs {
+		if !hasWork[config.GetName()] {
+			go e.waitForStartWithoutWork(executorsRunCtx, config, ended)
+		}
 	}
 
 	// Wait for all executors to finish
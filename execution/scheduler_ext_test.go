@@ -8,6 +8,7 @@ import (
 	"net"
 	"net/url"
 	"reflect"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -1001,6 +1002,132 @@ func TestSchedulerEndIterations(t *testing.T) {
 	}
 }
 
+// crossedThresholds is a lib.LiveMetrics whose thresholds are always crossed.
+type crossedThresholds struct{}
+
+func (crossedThresholds) WatchMetric(string, map[string]string) (func() metrics.Sink, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (crossedThresholds) CheckThresholds(names []string, _ time.Duration) ([]string, error) {
+	return names, nil
+}
+
+func TestSchedulerScenarioDependencies(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu     sync.Mutex
+		events []string
+	)
+	runner := &minirunner.MiniRunner{
+		Fn: func(ctx context.Context, _ *lib.State, out chan<- metrics.SampleContainer) error {
+			name := lib.GetScenarioState(ctx).Name
+			mu.Lock()
+			events = append(events, "start "+name)
+			mu.Unlock()
+			time.Sleep(100 * time.Millisecond)
+			mu.Lock()
+			events = append(events, "end "+name)
+			mu.Unlock()
+			return nil
+		},
+	}
+
+	newConfig := func(name string, vus int64, startAfter string) executor.PerVUIterationsConfig {
+		config := executor.NewPerVUIterationsConfig(name)
+		config.VUs = null.IntFrom(vus)
+		config.Iterations = null.IntFrom(1)
+		config.MaxDuration = types.NullDurationFrom(10 * time.Second)
+		config.GracefulStop = types.NullDurationFrom(10 * time.Second)
+		if startAfter != "" {
+			config.StartAfter = null.StringFrom(startAfter)
+		}
+		return config
+	}
+	warmup := newConfig("warmup", 1, "")
+	main := newConfig("main", 2, "warmup")
+	skipped := newConfig("skipped", 1, "warmup")
+	skipped.OnlyIf = []string{"checks"}
+	cooldown := newConfig("cooldown", 1, "main")
+	cooldown.StartTime = types.NullDurationFrom(100 * time.Millisecond)
+
+	thresholds := metrics.NewThresholds([]string{"rate>0.9"})
+	require.NoError(t, thresholds.Parse())
+	logger, hook := logtest.NewNullLogger()
+	ctx, cancel, execScheduler, samples := newTestScheduler(t, runner, logger, lib.Options{
+		Thresholds: map[string]metrics.Thresholds{"checks": thresholds},
+		Scenarios: lib.ScenarioConfigs{
+			"warmup": warmup, "main": main, "skipped": skipped, "cooldown": cooldown,
+		},
+	})
+	defer cancel()
+	execScheduler.GetState().LiveMetrics = crossedThresholds{}
+
+	// The plan counts with the whole durations of the scenarios they start after
+	endTime, isFinal := lib.GetEndOffset(execScheduler.GetExecutionPlan())
+	assert.Equal(t, 60100*time.Millisecond, endTime)
+	assert.True(t, isFinal)
+	// The dependent scenarios could all start right away
+	assert.Equal(t, uint64(5), lib.GetMaxPlannedVUs(execScheduler.GetExecutionPlan()))
+
+	startTime := time.Now()
+	require.NoError(t, execScheduler.Run(ctx, ctx, samples))
+	assert.Less(t, time.Since(startTime), 2*time.Second)
+
+	require.Len(t, events, 8)
+	assert.Equal(t, []string{"start warmup", "end warmup", "start main", "start main"}, events[:4])
+	assert.ElementsMatch(t, []string{"end main", "end main"}, events[4:6])
+	assert.Equal(t, []string{"start cooldown", "end cooldown"}, events[6:])
+
+	require.Len(t, hook.Entries, 1)
+	assert.Equal(t, "Skipping scenario skipped, since the thresholds on checks were crossed", hook.Entries[0].Message)
+}
+
+func TestSchedulerScenarioDependencyEndsEarly(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu     sync.Mutex
+		events []string
+	)
+	runner := &minirunner.MiniRunner{
+		Fn: func(ctx context.Context, _ *lib.State, out chan<- metrics.SampleContainer) error {
+			mu.Lock()
+			events = append(events, lib.GetScenarioState(ctx).Name)
+			mu.Unlock()
+			time.Sleep(200 * time.Millisecond)
+			return nil
+		},
+	}
+
+	// first ends long before its maxDuration, so second runs at the same
+	// time as fixed, which has a fixed startTime
+	first := executor.NewSharedIterationsConfig("first")
+	first.VUs = null.IntFrom(1)
+	first.Iterations = null.IntFrom(1)
+	first.MaxDuration = types.NullDurationFrom(10 * time.Second)
+	second := executor.NewPerVUIterationsConfig("second")
+	second.VUs = null.IntFrom(2)
+	second.Iterations = null.IntFrom(1)
+	second.StartAfter = null.StringFrom("first")
+	fixed := executor.NewPerVUIterationsConfig("fixed")
+	fixed.VUs = null.IntFrom(2)
+	fixed.Iterations = null.IntFrom(1)
+	fixed.StartTime = types.NullDurationFrom(300 * time.Millisecond)
+
+	ctx, cancel, execScheduler, samples := newTestScheduler(t, runner, nil, lib.Options{
+		Scenarios: lib.ScenarioConfigs{"first": first, "second": second, "fixed": fixed},
+	})
+	defer cancel()
+	assert.Equal(t, uint64(5), lib.GetMaxPlannedVUs(execScheduler.GetExecutionPlan()))
+
+	startTime := time.Now()
+	require.NoError(t, execScheduler.Run(ctx, ctx, samples))
+	assert.Less(t, time.Since(startTime), 2*time.Second)
+	assert.ElementsMatch(t, []string{"first", "second", "second", "fixed", "fixed"}, events)
+}
+
 func TestSchedulerIsRunning(t *testing.T) {
 	t.Parallel()
 	runner := &minirunner.MiniRunner{
@@ -53,6 +53,21 @@ func readFile(fileSystem fsext.Fs, filename string) (data []byte, err error) {
 	return fsext.ReadFile(fileSystem, filename)
 }
 
+// readScenarioFile reads a file needed by a scenario, e.g. the trace of the
+// trace-driven executor, whose path is relative to the script. Unlike open(),
+// it can read files after the init context, but they are still cached, so they
+// are included in the archives.
+func (b *Bundle) readScenarioFile(filename string) ([]byte, error) {
+	if filename == "" {
+		return nil, errors.New("the filename is empty")
+	}
+	fileSystem := b.filesystems["file"]
+	if cachedFS, ok := fileSystem.(*fsext.CacheOnReadFs); ok {
+		fileSystem = cachedFS.Fs
+	}
+	return fsext.ReadFile(fileSystem, fsext.Abs(b.pwd.Path, filename))
+}
+
 // allowOnlyOpenedFiles enables seen only files
 func allowOnlyOpenedFiles(fs fsext.Fs) {
 	alreadyOpenedFS, ok := fs.(fsext.OnlyCachedEnabler)
@@ -121,6 +121,14 @@ func (mi *ModuleInstance) newScenarioInfo() (*goja.Object, error) {
 
 			return vuState.GetScenarioGlobalVUIter()
 		},
+		"iterationData": func() interface{} {
+			ss := getScenarioState()
+			if ss.GetIterationData == nil || vuState.GetScenarioGlobalVUIter == nil {
+				return nil
+			}
+
+			return iterationDataAsValue(rt, ss.GetIterationData(vuState.GetScenarioGlobalVUIter()))
+		},
 	}
 
 	return newInfoObj(rt, si)
@@ -262,6 +270,26 @@ func newInfoObj(rt *goja.Runtime, props map[string]func() interface{}) (*goja.Ob
 	return o, nil
 }
 
+// iterationDataAsValue returns a new copy of the iteration data, created in the
+// VU's runtime. The data is shared between all of the VUs, so it can't be
+// wrapped by reference, since the scripts could modify it concurrently.
+func iterationDataAsValue(rt *goja.Runtime, data interface{}) goja.Value {
+	if data == nil {
+		return goja.Null()
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		common.Throw(rt, fmt.Errorf("failed to encode the iteration data as json: %w", err))
+	}
+
+	jsonParse, _ := goja.AssertFunction(rt.GlobalObject().Get("JSON").ToObject(rt).Get("parse"))
+	parsed, err := jsonParse(goja.Undefined(), rt.ToValue(string(b)))
+	if err != nil {
+		common.Throw(rt, err)
+	}
+	return parsed
+}
+
 // optionsAsObject maps the lib.Options struct that contains the consolidated
 // and derived options configuration in a goja.Object.
 //
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"sync"
 	"testing"
 	"time"
 
@@ -223,7 +224,7 @@ func TestAbortTest(t *testing.T) { //nolint:tparallel
 func TestOptionsTestFull(t *testing.T) {
 	t.Parallel()
 
-	expected := `{"paused":true,"scenarios":{"const-vus":{"executor":"constant-vus","options":{"browser":{"someOption":true}},"startTime":"10s","gracefulStop":"30s","env":{"FOO":"bar"},"exec":"default","tags":{"tagkey":"tagvalue"},"vus":50,"duration":"10m0s"}},"executionSegment":"0:1/4","executionSegmentSequence":"0,1/4,1/2,1","noSetup":true,"setupTimeout":"1m0s","noTeardown":true,"teardownTimeout":"5m0s","rps":100,"dns":{"ttl":"1m","select":"roundRobin","policy":"any"},"maxRedirects":3,"userAgent":"k6-user-agent","batch":15,"batchPerHost":5,"httpDebug":"full","insecureSkipTLSVerify":true,"tlsCipherSuites":["TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"],"tlsVersion":{"min":"tls1.2","max":"tls1.3"},"tlsAuth":[{"domains":["example.com"],"cert":"mycert.pem","key":"mycert-key.pem","password":"mypwd"}],"throw":true,"thresholds":{"http_req_duration":[{"threshold":"rate>0.01","abortOnFail":true,"delayAbortEval":"10s"}]},"blacklistIPs":["192.0.2.0/24"],"blockHostnames":["test.k6.io","*.example.com"],"hosts":{"test.k6.io":"1.2.3.4:8443"},"noConnectionReuse":true,"noVUConnectionReuse":true,"minIterationDuration":"10s","ext":{"ext-one":{"rawkey":"rawvalue"}},"summaryTrendStats":["avg","min","max"],"summaryTimeUnit":"ms","systemTags":["iter","vu"],"tags":null,"metricSamplesBufferSize":8,"noCookiesReset":true,"discardResponseBodies":true,"consoleOutput":"loadtest.log","tags":{"runtag-key":"runtag-value"},"localIPs":"192.168.20.12-192.168.20.15,192.168.10.0/27"}`
+	expected := `{"paused":true,"scenarios":{"const-vus":{"executor":"constant-vus","options":{"browser":{"someOption":true}},"startTime":"10s","startAfter":null,"onlyIf":null,"gracefulStop":"30s","env":{"FOO":"bar"},"exec":"default","tags":{"tagkey":"tagvalue"},"vus":50,"duration":"10m0s"}},"executionSegment":"0:1/4","executionSegmentSequence":"0,1/4,1/2,1","noSetup":true,"setupTimeout":"1m0s","noTeardown":true,"teardownTimeout":"5m0s","rps":100,"dns":{"ttl":"1m","select":"roundRobin","policy":"any"},"maxRedirects":3,"userAgent":"k6-user-agent","batch":15,"batchPerHost":5,"httpDebug":"full","insecureSkipTLSVerify":true,"tlsCipherSuites":["TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"],"tlsVersion":{"min":"tls1.2","max":"tls1.3"},"tlsAuth":[{"domains":["example.com"],"cert":"mycert.pem","key":"mycert-key.pem","password":"mypwd"}],"throw":true,"thresholds":{"http_req_duration":[{"threshold":"rate>0.01","abortOnFail":true,"delayAbortEval":"10s"}]},"thresholdExpressions":["errors.count / iterations.count < 0.01"],"blacklistIPs":["192.0.2.0/24"],"blockHostnames":["test.k6.io","*.example.com"],"hosts":{"test.k6.io":"1.2.3.4:8443"},"noConnectionReuse":true,"noVUConnectionReuse":true,"minIterationDuration":"10s","ext":{"ext-one":{"rawkey":"rawvalue"}},"summaryTrendStats":["avg","min","max"],"summaryTimeUnit":"ms","systemTags":["iter","vu"],"tags":null,"metricSamplesBufferSize":8,"trendRelativeError":0.01,"summaryTimelineInterval":"10s","summaryMode":"full","maxTimeSeries":10000,"maxTimeSeriesPerMetric":1000,"timeSeriesOverflow":"drop-tag","noCookiesReset":true,"discardResponseBodies":true,"consoleOutput":"loadtest.log","tags":{"runtag-key":"runtag-value"},"localIPs":"192.168.20.12-192.168.20.15,192.168.10.0/27"}`
 
 	var (
 		rt    = goja.New()
@@ -325,6 +326,9 @@ func TestOptionsTestFull(t *testing.T) {
 						},
 					},
 				},
+				ThresholdExpressions: metrics.ExpressionThresholds{
+					{Source: "errors.count / iterations.count < 0.01"},
+				},
 				BlockedHostnames: func() types.NullHostnameTrie {
 					bh, err := types.NewNullHostnameTrie([]string{"test.k6.io", "*.example.com"})
 					require.NoError(t, err)
@@ -351,6 +355,12 @@ func TestOptionsTestFull(t *testing.T) {
 				}(),
 				RunTags:                 map[string]string{"runtag-key": "runtag-value"},
 				MetricSamplesBufferSize: null.IntFrom(8),
+				TrendRelativeError:      null.FloatFrom(0.01),
+				SummaryTimelineInterval: types.NullDurationFrom(10 * time.Second),
+				SummaryMode:             null.StringFrom("full"),
+				MaxTimeSeries:           null.IntFrom(10000),
+				MaxTimeSeriesPerMetric:  null.IntFrom(1000),
+				TimeSeriesOverflow:      null.StringFrom("drop-tag"),
 				ConsoleOutput:           null.StringFrom("loadtest.log"),
 				LocalIPs: func() types.NullIPPool {
 					npool := types.NullIPPool{}
@@ -434,7 +444,9 @@ func TestScenarioNoAvailableInInitContext(t *testing.T) {
 	require.True(t, ok)
 	require.NoError(t, rt.Set("exec", m.Exports().Default))
 
-	scenarioExportedProps := []string{"name", "executor", "startTime", "progress", "iterationInInstance", "iterationInTest"}
+	scenarioExportedProps := []string{
+		"name", "executor", "startTime", "progress", "iterationInInstance", "iterationInTest", "iterationData",
+	}
 
 	for _, code := range scenarioExportedProps {
 		prop := fmt.Sprintf("exec.scenario.%s", code)
@@ -444,6 +456,94 @@ func TestScenarioNoAvailableInInitContext(t *testing.T) {
 	}
 }
 
+func TestScenarioIterationData(t *testing.T) {
+	t.Parallel()
+
+	for name, getIterationData := range map[string]func(uint64) interface{}{
+		"without data": nil,
+		"with data": func(iter uint64) interface{} {
+			return map[string]interface{}{"path": fmt.Sprintf("/%d", iter)}
+		},
+	} {
+		getIterationData := getIterationData
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			rt := goja.New()
+			ctx := lib.WithScenarioState(context.Background(), &lib.ScenarioState{
+				Name:             "trace",
+				GetIterationData: getIterationData,
+			})
+			m, ok := New().NewModuleInstance(
+				&modulestest.VU{
+					RuntimeField: rt,
+					CtxField:     ctx,
+					StateField: &lib.State{
+						GetScenarioGlobalVUIter: func() uint64 { return 7 },
+					},
+				},
+			).(*ModuleInstance)
+			require.True(t, ok)
+			require.NoError(t, rt.Set("exec", m.Exports().Default))
+
+			res, err := rt.RunString("JSON.stringify(exec.scenario.iterationData)")
+			require.NoError(t, err)
+			if getIterationData == nil {
+				assert.Equal(t, "null", res.String())
+			} else {
+				assert.Equal(t, `{"path":"/7"}`, res.String())
+			}
+		})
+	}
+}
+
+func TestScenarioIterationDataWrites(t *testing.T) {
+	t.Parallel()
+
+	// The same data is shared between all of the VUs
+	data := map[string]interface{}{"path": "/", "headers": map[string]interface{}{"accept": "*/*"}}
+	ctx := lib.WithScenarioState(context.Background(), &lib.ScenarioState{
+		Name:             "trace",
+		GetIterationData: func(uint64) interface{} { return data },
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		rt := goja.New()
+		m, ok := New().NewModuleInstance(
+			&modulestest.VU{
+				RuntimeField: rt,
+				CtxField:     ctx,
+				StateField: &lib.State{
+					GetScenarioGlobalVUIter: func() uint64 { return 0 },
+				},
+			},
+		).(*ModuleInstance)
+		require.True(t, ok)
+		require.NoError(t, rt.Set("exec", m.Exports().Default))
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := rt.RunString(`
+				var results = [];
+				for (var i = 0; i < 100; i++) {
+					var data = exec.scenario.iterationData;
+					data.path += i;
+					data.headers.accept = "text/html";
+					results.push(data.path);
+				}
+				exec.scenario.iterationData.path + " " + results[99];
+			`)
+			assert.NoError(t, err)
+			assert.Equal(t, "/ /99", res.String())
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, map[string]interface{}{"path": "/", "headers": map[string]interface{}{"accept": "*/*"}}, data)
+}
+
 func TestVUDefaultDetails(t *testing.T) {
 	t.Parallel()
 
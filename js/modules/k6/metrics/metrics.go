@@ -38,15 +38,61 @@ func (mi *ModuleInstance) newMetric(call goja.ConstructorCall, t metrics.MetricT
 		if err != nil {
 			return nil, err
 		}
-		metric := &Metric{metric: m, vu: mi.vu}
-		o := rt.NewObject()
-		err = o.DefineDataProperty("name", rt.ToValue(name), goja.FLAG_FALSE, goja.FLAG_FALSE, goja.FLAG_TRUE)
+		return mi.newMetricObject(m)
+	}))
+	v, err := c(call.This, call.Arguments...)
+	if err != nil {
+		return nil, err
+	}
+
+	return v.ToObject(rt), nil
+}
+
+func (mi *ModuleInstance) newHistogram(call goja.ConstructorCall) (*goja.Object, error) {
+	initEnv := mi.vu.InitEnv()
+	if initEnv == nil {
+		return nil, errors.New("metrics must be declared in the init context")
+	}
+	rt := mi.vu.Runtime()
+	c, _ := goja.AssertFunction(rt.ToValue(func(name string, buckets []float64, isTime ...bool) (*goja.Object, error) {
+		valueType := metrics.Default
+		if len(isTime) > 0 && isTime[0] {
+			valueType = metrics.Time
+		}
+		m, err := initEnv.Registry.NewHistogram(name, buckets, valueType)
 		if err != nil {
 			return nil, err
 		}
-		if err = o.Set("add", rt.ToValue(metric.add)); err != nil {
+		return mi.newMetricObject(m)
+	}))
+	v, err := c(call.This, call.Arguments...)
+	if err != nil {
+		return nil, err
+	}
+
+	return v.ToObject(rt), nil
+}
+
+func (mi *ModuleInstance) newDerived(call goja.ConstructorCall) (*goja.Object, error) {
+	initEnv := mi.vu.InitEnv()
+	if initEnv == nil {
+		return nil, errors.New("metrics must be declared in the init context")
+	}
+	rt := mi.vu.Runtime()
+	c, _ := goja.AssertFunction(rt.ToValue(func(name string, expression string) (*goja.Object, error) {
+		m, err := initEnv.Registry.NewDerived(name, expression)
+		if err != nil {
 			return nil, err
 		}
+		// Derived metrics don't have an add() method, their values are
+		// computed by k6 from the values of the referenced metrics.
+		o := rt.NewObject()
+		for k, v := range map[string]string{"name": m.Name, "expression": m.Expression} {
+			err = o.DefineDataProperty(k, rt.ToValue(v), goja.FLAG_FALSE, goja.FLAG_FALSE, goja.FLAG_TRUE)
+			if err != nil {
+				return nil, err
+			}
+		}
 		return o, nil
 	}))
 	v, err := c(call.This, call.Arguments...)
@@ -57,6 +103,20 @@ func (mi *ModuleInstance) newMetric(call goja.ConstructorCall, t metrics.MetricT
 	return v.ToObject(rt), nil
 }
 
+func (mi *ModuleInstance) newMetricObject(m *metrics.Metric) (*goja.Object, error) {
+	rt := mi.vu.Runtime()
+	metric := &Metric{metric: m, vu: mi.vu}
+	o := rt.NewObject()
+	err := o.DefineDataProperty("name", rt.ToValue(m.Name), goja.FLAG_FALSE, goja.FLAG_FALSE, goja.FLAG_TRUE)
+	if err != nil {
+		return nil, err
+	}
+	if err = o.Set("add", rt.ToValue(metric.add)); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
 const warnMessageValueMaxSize = 100
 
 func limitValue(v string) string {
@@ -154,10 +214,12 @@ func New() *RootModule {
 func (mi *ModuleInstance) Exports() modules.Exports {
 	return modules.Exports{
 		Named: map[string]interface{}{
-			"Counter": mi.XCounter,
-			"Gauge":   mi.XGauge,
-			"Trend":   mi.XTrend,
-			"Rate":    mi.XRate,
+			"Counter":   mi.XCounter,
+			"Gauge":     mi.XGauge,
+			"Trend":     mi.XTrend,
+			"Rate":      mi.XRate,
+			"Histogram": mi.XHistogram,
+			"Derived":   mi.XDerived,
 		},
 	}
 }
@@ -197,3 +259,21 @@ func (mi *ModuleInstance) XRate(call goja.ConstructorCall, rt *goja.Runtime) *go
 	}
 	return v
 }
+
+// XHistogram is a histogram constructor
+func (mi *ModuleInstance) XHistogram(call goja.ConstructorCall, rt *goja.Runtime) *goja.Object {
+	v, err := mi.newHistogram(call)
+	if err != nil {
+		common.Throw(rt, err)
+	}
+	return v
+}
+
+// XDerived is a derived metric constructor
+func (mi *ModuleInstance) XDerived(call goja.ConstructorCall, rt *goja.Runtime) *goja.Object {
+	v, err := mi.newDerived(call)
+	if err != nil {
+		common.Throw(rt, err)
+	}
+	return v
+}
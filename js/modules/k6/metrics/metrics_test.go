@@ -235,3 +235,87 @@ func TestMetricDuplicates(t *testing.T) {
 
 	require.True(t, v.ToBoolean())
 }
+
+func TestHistogram(t *testing.T) {
+	t.Parallel()
+	rt := goja.New()
+	rt.SetFieldNameMapper(common.FieldNameMapper{})
+
+	registry := metrics.NewRegistry()
+	mii := &modulestest.VU{
+		RuntimeField: rt,
+		InitEnvField: &common.InitEnvironment{TestPreInitState: &lib.TestPreInitState{Registry: registry}},
+		CtxField:     context.Background(),
+	}
+	m, ok := New().NewModuleInstance(mii).(*ModuleInstance)
+	require.True(t, ok)
+	require.NoError(t, rt.Set("metrics", m.Exports().Named))
+
+	v, err := rt.RunString(`
+		var h = new metrics.Histogram("my_histogram", [100, 200, 300], true)
+		h.name
+	`)
+	require.NoError(t, err)
+	assert.Equal(t, "my_histogram", v.String())
+
+	metric := registry.Get("my_histogram")
+	require.NotNil(t, metric)
+	assert.Equal(t, metrics.Histogram, metric.Type)
+	assert.Equal(t, metrics.Time, metric.Contains)
+	assert.Equal(t, []float64{100, 200, 300}, metric.Buckets)
+
+	_, err = rt.RunString(`new metrics.Histogram("my_histogram", [100, 200, 300], true)`)
+	require.NoError(t, err)
+
+	for _, js := range []string{
+		`new metrics.Histogram("my_histogram", [100, 200])`,
+		`new metrics.Histogram("other_histogram", [])`,
+		`new metrics.Histogram("other_histogram", [300, 200])`,
+		`new metrics.Histogram("other_histogram", "foo")`,
+		`new metrics.Trend("my_histogram")`,
+	} {
+		_, err = rt.RunString(js)
+		assert.Error(t, err, js)
+	}
+}
+
+func TestDerived(t *testing.T) {
+	t.Parallel()
+	rt := goja.New()
+	rt.SetFieldNameMapper(common.FieldNameMapper{})
+
+	registry := metrics.NewRegistry()
+	mii := &modulestest.VU{
+		RuntimeField: rt,
+		InitEnvField: &common.InitEnvironment{TestPreInitState: &lib.TestPreInitState{Registry: registry}},
+		CtxField:     context.Background(),
+	}
+	m, ok := New().NewModuleInstance(mii).(*ModuleInstance)
+	require.True(t, ok)
+	require.NoError(t, rt.Set("metrics", m.Exports().Named))
+
+	v, err := rt.RunString(`
+		var d = new metrics.Derived("error_ratio", "errors.count / http_reqs.count")
+		d.name + " = " + d.expression + " " + (d.add === undefined)
+	`)
+	require.NoError(t, err)
+	assert.Equal(t, "error_ratio = errors.count / http_reqs.count true", v.String())
+
+	metric := registry.Get("error_ratio")
+	require.NotNil(t, metric)
+	assert.Equal(t, metrics.Gauge, metric.Type)
+	assert.True(t, metric.IsDerived())
+
+	_, err = rt.RunString(`new metrics.Derived("error_ratio", "errors.count / http_reqs.count")`)
+	require.NoError(t, err)
+
+	for _, js := range []string{
+		`new metrics.Derived("error_ratio", "errors.count")`,
+		`new metrics.Derived("other_ratio", "errors.count /")`,
+		`new metrics.Derived("other_ratio")`,
+		`new metrics.Gauge("error_ratio")`,
+	} {
+		_, err = rt.RunString(js)
+		assert.Error(t, err, js)
+	}
+}
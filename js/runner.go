@@ -463,6 +463,14 @@ func (r *Runner) SetOptions(opts lib.Options) error {
 
 	// TODO: validate that all exec values are either nil or valid exported methods (or HTTP requests in the future)
 
+	for _, sc := range opts.Scenarios.GetSortedConfigs() {
+		if flc, ok := sc.(lib.FileLoaderExecutorConfig); ok {
+			if err := flc.LoadFiles(r.Bundle.readScenarioFile); err != nil {
+				return fmt.Errorf("couldn't load the files of the scenario '%s': %w", sc.GetName(), err)
+			}
+		}
+	}
+
 	if opts.ConsoleOutput.Valid {
 		// TODO: fix logger hack, see https://github.com/grafana/k6/issues/2958
 		// and https://github.com/grafana/k6/issues/2968
@@ -1967,6 +1967,56 @@ func TestInitContextForbidden(t *testing.T) {
 	}
 }
 
+func TestRunnerScenarioFiles(t *testing.T) {
+	t.Parallel()
+
+	baseFS := fsext.NewMemMapFs()
+	fileSystem := fsext.NewCacheOnReadFs(baseFS, fsext.NewMemMapFs(), 0)
+	data := `
+			exports.options = {
+				scenarios: {
+					replay: { executor: "trace-driven", file: "./trace.csv", preAllocatedVUs: 1 },
+				},
+			};
+			exports.default = function() {}
+		`
+	require.NoError(t, fsext.WriteFile(baseFS, "/data/trace.csv", []byte("timestamp\n10\n12\n"), fs.ModePerm))
+	require.NoError(t, fsext.WriteFile(baseFS, "/data/script.js", []byte(data), fs.ModePerm))
+	_, err := fsext.ReadFile(fileSystem, "/data/script.js") // like the loader does
+	require.NoError(t, err)
+	r1, err := getSimpleRunner(t, "/data/script.js", data, fileSystem)
+	require.NoError(t, err)
+
+	// The trace is read after the init context, but it's still in the archive.
+	buf := bytes.NewBuffer(nil)
+	require.NoError(t, r1.MakeArchive().Write(buf))
+	arc, err := lib.ReadArchive(buf)
+	require.NoError(t, err)
+	archived, err := fsext.ReadFile(arc.Filesystems["file"], "/data/trace.csv")
+	require.NoError(t, err)
+	assert.Equal(t, "timestamp\n10\n12\n", string(archived))
+
+	registry := metrics.NewRegistry()
+	r2, err := NewFromArchive(
+		&lib.TestPreInitState{
+			Logger:         testutils.NewLogger(t),
+			BuiltinMetrics: metrics.RegisterBuiltinMetrics(registry),
+			Registry:       registry,
+		}, arc)
+	require.NoError(t, err)
+
+	et, err := lib.NewExecutionTuple(nil, nil)
+	require.NoError(t, err)
+	for _, r := range []*Runner{r1, r2} {
+		assert.Equal(t, "2 iterations from ./trace.csv over 2s (maxVUs: 1, gracefulStop: 30s)",
+			r.GetOptions().Scenarios["replay"].GetDescription(et))
+	}
+
+	require.NoError(t, baseFS.Remove("/data/trace.csv"))
+	_, err = getSimpleRunner(t, "/data/script.js", data, fsext.NewCacheOnReadFs(baseFS, fsext.NewMemMapFs(), 0))
+	require.ErrorContains(t, err, "couldn't load the files of the scenario 'replay'")
+}
+
 func TestArchiveRunningIntegrity(t *testing.T) {
 	t.Parallel()
 
@@ -46,6 +46,8 @@ func metricValueGetter(summaryTrendStats []string) func(metrics.Sink, time.Durat
 			result = sink.Format(t)
 			result["passes"] = float64(sink.Trues)
 			result["fails"] = float64(sink.Total - sink.Trues)
+		case *metrics.HistogramSink:
+			result = sink.Format(t)
 		case *metrics.TrendSink:
 			result = make(map[string]float64, len(summaryTrendStats))
 			for _, col := range summaryTrendStats {
@@ -85,18 +87,34 @@ func summarizeMetricsToObject(data *lib.Summary, options lib.Options, setupData
 		}
 
 		if len(m.Thresholds.Thresholds) > 0 {
-			thresholds := make(map[string]interface{})
-			for _, threshold := range m.Thresholds.Thresholds {
-				thresholds[threshold.Source] = map[string]interface{}{
-					"ok": !threshold.LastFailed,
-				}
-			}
-			metricData["thresholds"] = thresholds
+			metricData["thresholds"] = exportThresholds(m.Thresholds)
 		}
 		metricsData[name] = metricData
 	}
 	m["metrics"] = metricsData
 
+	if len(data.ThresholdExpressions) > 0 {
+		expressionsData := make(map[string]interface{}, len(data.ThresholdExpressions))
+		for _, et := range data.ThresholdExpressions {
+			expressionData := map[string]interface{}{
+				"ok": !et.LastFailed,
+			}
+			if et.LastValue.Valid {
+				expressionData["value"] = et.LastValue.Float64
+			}
+			expressionsData[et.Source] = expressionData
+		}
+		m["threshold_expressions"] = expressionsData
+	}
+
+	if data.Timeline != nil {
+		m["timeline"] = exportTimeline(data.Timeline, getMetricValues)
+	}
+
+	if data.Scenarios != nil {
+		m["scenarios"] = exportScenarios(data.Scenarios, data.TestRunDuration, getMetricValues)
+	}
+
 	var setupDataI interface{}
 	if setupData != nil {
 		if err := json.Unmarshal(setupData, &setupDataI); err != nil {
@@ -112,6 +130,28 @@ func summarizeMetricsToObject(data *lib.Summary, options lib.Options, setupData
 	return m
 }
 
+func exportThresholds(ths metrics.Thresholds) map[string]interface{} {
+	thresholds := make(map[string]interface{}, len(ths.Thresholds))
+	for _, threshold := range ths.Thresholds {
+		thresholdData := map[string]interface{}{
+			"ok": !threshold.LastFailed,
+		}
+		if window := threshold.Window(); window > 0 {
+			thresholdData["window"] = float64(window) / float64(time.Millisecond)
+			thresholdData["windowBreaches"] = threshold.WindowBreaches
+		}
+		if threshold.Baseline.Valid {
+			thresholdData["baseline"] = threshold.Baseline.Float64
+			if threshold.LastValue.Valid {
+				thresholdData["value"] = threshold.LastValue.Float64
+				thresholdData["delta"] = threshold.LastValue.Float64 - threshold.Baseline.Float64
+			}
+		}
+		thresholds[threshold.Source] = thresholdData
+	}
+	return thresholds
+}
+
 func exportGroup(group *lib.Group) map[string]interface{} {
 	subGroups := make([]map[string]interface{}, len(group.OrderedGroups))
 	for i, subGroup := range group.OrderedGroups {
@@ -138,6 +178,104 @@ func exportGroup(group *lib.Group) map[string]interface{} {
 	}
 }
 
+// exportTimeline returns the values of the metrics for each interval of the
+// timeline, with the interval size in milliseconds and the start time of each
+// interval as a Unix timestamp in milliseconds.
+func exportTimeline(
+	timeline *lib.SummaryTimeline, getMetricValues func(metrics.Sink, time.Duration) map[string]float64,
+) map[string]interface{} {
+	metricsData := make(map[string]interface{}, len(timeline.Metrics))
+	for name, buckets := range timeline.Metrics {
+		points := make([]map[string]interface{}, len(buckets))
+		for i, b := range buckets {
+			points[i] = map[string]interface{}{
+				"time":   float64(b.Time.UnixNano()) / float64(time.Millisecond),
+				"values": getMetricValues(b.Sink, timeline.Interval),
+			}
+		}
+		metricsData[name] = points
+	}
+
+	return map[string]interface{}{
+		"interval": float64(timeline.Interval) / float64(time.Millisecond),
+		"metrics":  metricsData,
+	}
+}
+
+// exportScenarios returns the values of the metrics and the results of the
+// checks of each scenario and of the groups in it. The thresholds of the
+// submetrics of a single scenario, e.g. `http_req_duration{scenario:login}`,
+// are attached to the values of the metric for that scenario.
+func exportScenarios(
+	scenarios map[string]*lib.SummaryScenario, t time.Duration,
+	getMetricValues func(metrics.Sink, time.Duration) map[string]float64,
+) map[string]interface{} {
+	exportSinks := func(sinks map[*metrics.Metric]metrics.Sink, scenario string) map[string]interface{} {
+		metricsData := make(map[string]interface{}, len(sinks))
+		for m, sink := range sinks {
+			metricData := map[string]interface{}{
+				"type":     m.Type.String(),
+				"contains": m.Contains.String(),
+				"values":   getMetricValues(sink, t),
+			}
+			if scenario != "" {
+				if sm := scenarioSubmetric(m, scenario); sm != nil && len(sm.Metric.Thresholds.Thresholds) > 0 {
+					metricData["thresholds"] = exportThresholds(sm.Metric.Thresholds)
+				}
+			}
+			metricsData[m.Name] = metricData
+		}
+		return metricsData
+	}
+
+	var exportSummaryGroup func(group *lib.SummaryGroup) map[string]interface{}
+	exportSummaryGroup = func(group *lib.SummaryGroup) map[string]interface{} {
+		subGroups := make([]map[string]interface{}, len(group.Groups))
+		for i, subGroup := range group.Groups {
+			subGroups[i] = exportSummaryGroup(subGroup)
+		}
+
+		checks := make([]map[string]interface{}, len(group.Checks))
+		for i, check := range group.Checks {
+			checks[i] = map[string]interface{}{
+				"name":   check.Name,
+				"passes": check.Passes,
+				"fails":  check.Fails,
+			}
+		}
+
+		return map[string]interface{}{
+			"name":    group.Name,
+			"path":    group.Path,
+			"groups":  subGroups,
+			"checks":  checks,
+			"metrics": exportSinks(group.Metrics, ""),
+		}
+	}
+
+	result := make(map[string]interface{}, len(scenarios))
+	for name, scenario := range scenarios {
+		result[name] = map[string]interface{}{
+			"name":       scenario.Name,
+			"metrics":    exportSinks(scenario.Metrics, scenario.Name),
+			"root_group": exportSummaryGroup(scenario.RootGroup),
+		}
+	}
+	return result
+}
+
+// scenarioSubmetric returns the submetric of the metric that only has the
+// scenario tag with the given value, or nil if there isn't one.
+func scenarioSubmetric(m *metrics.Metric, scenario string) *metrics.Submetric {
+	for _, sm := range m.Submetrics {
+		tags := sm.Tags.Map()
+		if len(tags) == 1 && tags[metrics.TagScenario.String()] == scenario {
+			return sm
+		}
+	}
+	return nil
+}
+
 func getSummaryResult(rawResult goja.Value) (map[string]io.Reader, error) {
 	if goja.IsNull(rawResult) || goja.IsUndefined(rawResult) {
 		return nil, nil //nolint:nilnil // this is actually valid result in this case
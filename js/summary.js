@@ -236,11 +236,40 @@ function nonTrendMetricValueForSum(metric, timeUnit) {
         succMark + ' ' + metric.values.passes,
         failMark + ' ' + metric.values.fails,
       ]
+    case 'histogram':
+      return [
+        metric.values.count.toString(),
+        'avg=' + humanizeValue(metric.values.avg, metric, timeUnit),
+        'max=' + humanizeValue(metric.values.max, metric, timeUnit),
+      ]
     default:
       return ['[no data]']
   }
 }
 
+function summarizeHistogramBuckets(metric, timeUnit) {
+  var buckets = []
+  forEach(metric.values, function (key, value) {
+    if (key.indexOf('le(') === 0) {
+      buckets.push({ bound: parseFloat(key.substring(3, key.length - 1)), ratio: value })
+    }
+  })
+  buckets.sort(function (a, b) {
+    return a.bound - b.bound
+  })
+  return buckets
+    .map(function (bucket) {
+      return (
+        '≤' +
+        humanizeValue(bucket.bound, metric, timeUnit) +
+        ': ' +
+        toFixedNoTrailingZeros(bucket.ratio * 100, 2) +
+        '%'
+      )
+    })
+    .join(' ')
+}
+
 function summarizeMetrics(options, data, decorate) {
   var indent = options.indent + '  '
   var result = []
@@ -377,11 +406,129 @@ function summarizeMetrics(options, data, decorate) {
       )
 
     result.push(indent + fmtIndent + markColor(mark) + ' ' + fmtName + ' ' + getData(name))
+
+    if (metric.type == 'histogram' && metric.values.count > 0) {
+      result.push(
+        indent +
+        fmtIndent +
+        '  ' +
+        decorate(detailsPrefix + ' ' + summarizeHistogramBuckets(metric, options.summaryTimeUnit), palette.faint)
+      )
+    }
+
+    forEach(metric.thresholds, function (source, threshold) {
+      if (threshold.baseline === undefined || threshold.value === undefined) {
+        return
+      }
+      var line = summarizeBaselineThreshold(source, threshold, metric, options.summaryTimeUnit)
+      result.push(
+        indent +
+        fmtIndent +
+        '  ' +
+        decorate(detailsPrefix + ' ' + line, threshold.ok ? palette.green : palette.red)
+      )
+    })
   }
 
   return result
 }
 
+function summarizeBaselineThreshold(source, threshold, metric, timeUnit) {
+  var delta = 'n/a'
+  if (threshold.baseline !== 0) {
+    var pct = (100 * threshold.delta) / Math.abs(threshold.baseline)
+    delta = (pct >= 0 ? '+' : '') + pct.toFixed(2) + '%'
+  }
+  return (
+    source +
+    ': ' +
+    humanizeValue(threshold.value, metric, timeUnit) +
+    ' vs baseline ' +
+    humanizeValue(threshold.baseline, metric, timeUnit) +
+    ' (' +
+    delta +
+    ')'
+  )
+}
+
+function summarizeThresholdExpressions(options, data, decorate) {
+  var result = []
+  if (!data.threshold_expressions) {
+    return result
+  }
+
+  Object.keys(data.threshold_expressions)
+    .sort()
+    .forEach(function (source) {
+      var expression = data.threshold_expressions[source]
+      var line = (expression.ok ? succMark : failMark) + ' ' + source
+      var value = ''
+      if (expression.value !== undefined) {
+        value = ' ' + decorate('(' + toFixedNoTrailingZeros(expression.value, 4) + ')', palette.cyan, palette.faint)
+      }
+      result.push(
+        options.indent + '  ' + decorate(line, expression.ok ? palette.green : palette.red) + value
+      )
+    })
+  if (result.length > 0) {
+    result.unshift('')
+  }
+
+  return result
+}
+
+// summarizeScenarioGroup is like summarizeGroup, but it also shows the values
+// of the metrics in each group, with the same indentation as its checks.
+function summarizeScenarioGroup(options, indent, group, decorate) {
+  var result = []
+  if (group.name != '') {
+    result.push(indent + groupPrefix + ' ' + group.name + '\n')
+    indent = indent + '  '
+  }
+
+  for (var i = 0; i < group.checks.length; i++) {
+    result.push(summarizeCheck(indent, group.checks[i], decorate))
+  }
+  if (group.checks.length > 0) {
+    result.push('')
+  }
+  // The metrics of the root group are mostly the same as the ones of the
+  // whole scenario, which are shown separately
+  if (group.name != '' && Object.keys(group.metrics).length > 0) {
+    var metricsOpts = Object.assign({}, options, { indent: indent.substring(2) })
+    Array.prototype.push.apply(result, summarizeMetrics(metricsOpts, group, decorate))
+    result.push('')
+  }
+  for (var i = 0; i < group.groups.length; i++) {
+    Array.prototype.push.apply(result, summarizeScenarioGroup(options, indent, group.groups[i], decorate))
+  }
+
+  return result
+}
+
+function summarizeScenarios(options, data, decorate) {
+  var result = []
+  if (!data.scenarios) {
+    return result
+  }
+
+  var metricsOpts = Object.assign({}, options, { indent: options.indent + '  ' })
+  Object.keys(data.scenarios)
+    .sort()
+    .forEach(function (name) {
+      var scenario = data.scenarios[name]
+      result.push('')
+      result.push(options.indent + '  ' + decorate(groupPrefix + ' scenario ' + name, palette.bold) + '\n')
+      Array.prototype.push.apply(
+        result,
+        summarizeScenarioGroup(options, options.indent + '    ', scenario.root_group, decorate)
+      )
+      Array.prototype.push.apply(result, summarizeMetrics(metricsOpts, scenario, decorate))
+    })
+
+  return result
+}
+
 function generateTextSummary(data, options) {
   var mergedOpts = Object.assign({}, defaultOptions, data.options, options)
   var lines = []
@@ -407,6 +554,10 @@ function generateTextSummary(data, options) {
 
   Array.prototype.push.apply(lines, summarizeMetrics(mergedOpts, data, decorate))
 
+  Array.prototype.push.apply(lines, summarizeThresholdExpressions(mergedOpts, data, decorate))
+
+  Array.prototype.push.apply(lines, summarizeScenarios(mergedOpts, data, decorate))
+
   return lines.join('\n')
 }
 
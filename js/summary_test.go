@@ -133,6 +133,203 @@ func TestTextSummaryWithSubMetrics(t *testing.T) {
 	assert.Equal(t, "\n"+expected+"\n", string(summaryOut))
 }
 
+func TestTextSummaryWithBaselineThresholds(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	trend, err := registry.NewMetric("my_trend", metrics.Trend, metrics.Time)
+	require.NoError(t, err)
+	trend.Sink.Add(metrics.Sample{Value: 110})
+	trend.Tainted = null.BoolFrom(true)
+	trend.Thresholds = metrics.Thresholds{Thresholds: []*metrics.Threshold{
+		{Source: "max<100", LastFailed: true},
+		{
+			Source: "max<baseline*1.05", LastFailed: true,
+			Baseline: null.FloatFrom(100), LastValue: null.FloatFrom(110),
+		},
+	}}
+
+	summary := &lib.Summary{
+		Metrics:         map[string]*metrics.Metric{trend.Name: trend},
+		RootGroup:       &lib.Group{},
+		TestRunDuration: time.Second,
+	}
+
+	runner, err := getSimpleRunner(
+		t,
+		"/script.js",
+		`
+		exports.options = {summaryTrendStats: ["max"]};
+		exports.default = function() {/* we don't run this, metrics are mocked */};
+		`,
+		lib.RuntimeOptions{CompatibilityMode: null.NewString("base", true)},
+	)
+	require.NoError(t, err)
+
+	result, err := runner.HandleSummary(context.Background(), summary)
+	require.NoError(t, err)
+
+	summaryOut, err := io.ReadAll(result["stdout"])
+	require.NoError(t, err)
+
+	expected := "   ✗ my_trend...: max=110ms\n" +
+		"     ↳ max<baseline*1.05: 110ms vs baseline 100ms (+10.00%)\n"
+	assert.Equal(t, "\n"+expected+"\n", string(summaryOut))
+}
+
+func TestTextSummaryWithHistogram(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	histogram, err := registry.NewHistogram("my_histogram", []float64{100, 300}, metrics.Time)
+	require.NoError(t, err)
+	for _, v := range []float64{50, 150, 250, 1000} {
+		histogram.Sink.Add(metrics.Sample{Value: v})
+	}
+
+	summary := &lib.Summary{
+		Metrics:         map[string]*metrics.Metric{histogram.Name: histogram},
+		RootGroup:       &lib.Group{},
+		TestRunDuration: time.Second,
+	}
+
+	runner, err := getSimpleRunner(
+		t,
+		"/script.js",
+		`
+		exports.default = function() {/* we don't run this, metrics are mocked */};
+		`,
+		lib.RuntimeOptions{CompatibilityMode: null.NewString("base", true)},
+	)
+	require.NoError(t, err)
+
+	result, err := runner.HandleSummary(context.Background(), summary)
+	require.NoError(t, err)
+
+	summaryOut, err := io.ReadAll(result["stdout"])
+	require.NoError(t, err)
+
+	expected := "     my_histogram...: 4 avg=362.5ms max=1s\n" +
+		"     ↳ ≤100ms: 25% ≤300ms: 75%\n"
+	assert.Equal(t, "\n"+expected+"\n", string(summaryOut))
+}
+
+func TestSummaryWithThresholdExpressions(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	counter, err := registry.NewMetric("errors", metrics.Counter)
+	require.NoError(t, err)
+	counter.Sink.Add(metrics.Sample{Value: 2})
+
+	summary := &lib.Summary{
+		Metrics: map[string]*metrics.Metric{counter.Name: counter},
+		ThresholdExpressions: metrics.ExpressionThresholds{
+			{Source: "errors.count / iterations.count < 0.01", LastFailed: true, LastValue: null.FloatFrom(0.02)},
+			{Source: "errors.count < 5"},
+		},
+		RootGroup:       &lib.Group{},
+		TestRunDuration: time.Second,
+	}
+
+	runner, err := getSimpleRunner(
+		t,
+		"/script.js",
+		`
+		exports.options = {summaryTrendStats: ["max"]};
+		exports.default = function() {/* we don't run this, metrics are mocked */};
+		`,
+		lib.RuntimeOptions{CompatibilityMode: null.NewString("base", true)},
+	)
+	require.NoError(t, err)
+
+	result, err := runner.HandleSummary(context.Background(), summary)
+	require.NoError(t, err)
+
+	summaryOut, err := io.ReadAll(result["stdout"])
+	require.NoError(t, err)
+	expected := "     errors...: 2 2/s\n" +
+		"\n" +
+		"   ✗ errors.count / iterations.count < 0.01 (0.02)\n" +
+		"   ✓ errors.count < 5\n"
+	assert.Equal(t, "\n"+expected+"\n", string(summaryOut))
+
+	data := summarizeMetricsToObject(summary, lib.Options{SummaryTrendStats: []string{"max"}}, nil)
+	assert.Equal(t, map[string]interface{}{
+		"errors.count / iterations.count < 0.01": map[string]interface{}{"ok": false, "value": 0.02},
+		"errors.count < 5":                       map[string]interface{}{"ok": true},
+	}, data["threshold_expressions"])
+}
+
+func TestSummaryTimeline(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	counter, err := registry.NewMetric("http_reqs", metrics.Counter)
+	require.NoError(t, err)
+	trend, err := registry.NewMetric("http_req_duration", metrics.Trend, metrics.Time)
+	require.NoError(t, err)
+
+	start := time.UnixMilli(1672531200000)
+	newSink := func(m *metrics.Metric, values ...float64) metrics.Sink {
+		sink := metrics.NewSink(m.Type)
+		for _, v := range values {
+			sink.Add(metrics.Sample{Value: v})
+		}
+		return sink
+	}
+
+	summary := &lib.Summary{
+		Metrics:         map[string]*metrics.Metric{},
+		RootGroup:       &lib.Group{},
+		TestRunDuration: 20 * time.Second,
+		Timeline: &lib.SummaryTimeline{
+			Interval: 10 * time.Second,
+			Metrics: map[string][]lib.SummaryTimelineBucket{
+				counter.Name: {
+					{Time: start, Sink: newSink(counter, 1, 1, 1, 1, 1)},
+					{Time: start.Add(10 * time.Second), Sink: newSink(counter, 10, 10)},
+				},
+				trend.Name: {
+					{Time: start.Add(10 * time.Second), Sink: newSink(trend, 100, 300)},
+				},
+			},
+		},
+	}
+
+	runner, err := getSimpleRunner(
+		t, "/script.js",
+		`
+		exports.options = {summaryTrendStats: ["avg", "max"]};
+		exports.default = function() { /* we don't run this, metrics are mocked */ };
+		exports.handleSummary = function(data) {
+			return {'timeline.json': JSON.stringify(data.timeline)};
+		};
+		`,
+		lib.RuntimeOptions{CompatibilityMode: null.NewString("base", true)},
+	)
+	require.NoError(t, err)
+
+	result, err := runner.HandleSummary(context.Background(), summary)
+	require.NoError(t, err)
+
+	require.NotNil(t, result["timeline.json"])
+	timeline, err := io.ReadAll(result["timeline.json"])
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"interval": 10000,
+		"metrics": {
+			"http_reqs": [
+				{"time": 1672531200000, "values": {"count": 5, "rate": 0.5}},
+				{"time": 1672531210000, "values": {"count": 20, "rate": 2}}
+			],
+			"http_req_duration": [
+				{"time": 1672531210000, "values": {"avg": 200, "max": 300}}
+			]
+		}
+	}`, string(timeline))
+}
+
 func createTestMetrics(t *testing.T) (map[string]*metrics.Metric, *lib.Group) {
 	registry := metrics.NewRegistry()
 	testMetrics := make(map[string]*metrics.Metric)
@@ -701,3 +898,102 @@ func TestExceptionInHandleSummaryFallsBackToTextSummary(t *testing.T) {
 	assert.Contains(t, errMsg, "\"Error: intentional error\\n\\tat file:///script.js:5:11(3)\\n")
 	assert.Equal(t, logErrors[0].Data, logrus.Fields{"hint": "script exception"})
 }
+
+func TestTextSummaryFullMode(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	reqs, err := registry.NewMetric("http_reqs", metrics.Counter)
+	require.NoError(t, err)
+	duration, err := registry.NewMetric("http_req_duration", metrics.Trend, metrics.Time)
+	require.NoError(t, err)
+	sm, err := duration.AddSubmetric("scenario:checkout")
+	require.NoError(t, err)
+	sm.Metric.Thresholds = metrics.NewThresholds([]string{"max<100"})
+	sm.Metric.Thresholds.Thresholds[0].LastFailed = true
+
+	newSink := func(m *metrics.Metric, values ...float64) metrics.Sink {
+		sink := metrics.NewSink(m.Type)
+		for _, v := range values {
+			sink.Add(metrics.Sample{Value: v})
+		}
+		return sink
+	}
+	login := &lib.SummaryGroup{
+		Name:    "login",
+		Path:    "::login",
+		Metrics: map[*metrics.Metric]metrics.Sink{duration: newSink(duration, 200)},
+		Checks:  []*lib.SummaryCheck{{Name: "status is 200", Passes: 1, Fails: 1}},
+	}
+	summary := &lib.Summary{
+		Metrics:         map[string]*metrics.Metric{},
+		RootGroup:       &lib.Group{},
+		TestRunDuration: time.Second,
+		Scenarios: map[string]*lib.SummaryScenario{
+			"checkout": {
+				Name: "checkout",
+				Metrics: map[*metrics.Metric]metrics.Sink{
+					reqs:     newSink(reqs, 1, 1),
+					duration: newSink(duration, 50, 200),
+				},
+				RootGroup: &lib.SummaryGroup{
+					Metrics: map[*metrics.Metric]metrics.Sink{duration: newSink(duration, 50)},
+					Checks:  []*lib.SummaryCheck{{Name: "has items", Passes: 1}},
+					Groups:  []*lib.SummaryGroup{login},
+				},
+			},
+		},
+	}
+
+	runner, err := getSimpleRunner(
+		t, "/script.js",
+		`
+		exports.options = {summaryTrendStats: ["avg", "max"]};
+		exports.default = function() {/* we don't run this, metrics are mocked */};
+		`,
+		lib.RuntimeOptions{CompatibilityMode: null.NewString("base", true)},
+	)
+	require.NoError(t, err)
+
+	result, err := runner.HandleSummary(context.Background(), summary)
+	require.NoError(t, err)
+
+	summaryOut, err := io.ReadAll(result["stdout"])
+	require.NoError(t, err)
+	expected := "\n" +
+		"   █ scenario checkout\n\n" +
+		"     ✓ has items\n\n" +
+		"     █ login\n\n" +
+		"       ✗ status is 200\n" +
+		"        ↳  50% — ✓ 1 / ✗ 1\n\n" +
+		"         http_req_duration...: avg=200ms max=200ms\n\n" +
+		"     ✗ http_req_duration...: avg=125ms max=200ms\n" +
+		"       http_reqs...........: 2 2/s\n"
+	assert.Equal(t, "\n"+expected+"\n", string(summaryOut))
+
+	data := summarizeMetricsToObject(summary, lib.Options{SummaryTrendStats: []string{"max"}}, nil)
+	scenarios, err := json.Marshal(data["scenarios"])
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"checkout": {
+			"name": "checkout",
+			"metrics": {
+				"http_reqs": {"type": "counter", "contains": "default", "values": {"count": 2, "rate": 2}},
+				"http_req_duration": {
+					"type": "trend", "contains": "time", "values": {"max": 200},
+					"thresholds": {"max<100": {"ok": false}}
+				}
+			},
+			"root_group": {
+				"name": "", "path": "",
+				"checks": [{"name": "has items", "passes": 1, "fails": 0}],
+				"metrics": {"http_req_duration": {"type": "trend", "contains": "time", "values": {"max": 50}}},
+				"groups": [{
+					"name": "login", "path": "::login", "groups": [],
+					"checks": [{"name": "status is 200", "passes": 1, "fails": 1}],
+					"metrics": {"http_req_duration": {"type": "trend", "contains": "time", "values": {"max": 200}}}
+				}]
+			}
+		}
+	}`, string(scenarios))
+}
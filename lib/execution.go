@@ -9,6 +9,8 @@ import (
 	"time"
 
 	"github.com/sirupsen/logrus"
+
+	"github.com/ChipArtem/k6/metrics"
 )
 
 // MaxTimeToWaitForPlannedVU specifies the maximum allowable time for an executor
@@ -49,6 +51,20 @@ const (
 	ExecutionStatusInterrupted
 )
 
+// LiveMetrics gives access to the values of the metrics while the test is
+// running, so the executors can adapt the load to them and the scenarios can
+// be started depending on the thresholds.
+type LiveMetrics interface {
+	// WatchMetric starts collecting the values of the samples of the metric
+	// that have all of the given tags. It returns a function that returns
+	// a sink with the values collected since its previous call.
+	WatchMetric(name string, tags map[string]string) (func() metrics.Sink, error)
+	// CheckThresholds evaluates the thresholds of the given metrics and
+	// sub-metrics, like "http_req_failed{scenario:warmup}", and returns the
+	// names of the ones whose thresholds are crossed.
+	CheckThresholds(names []string, testRunDuration time.Duration) (crossed []string, err error)
+}
+
 // ExecutionState contains a few different things:
 //   - Some convenience items, that are needed by all executors, like the
 //     execution segment and the unique VU ID generator. By keeping those here,
@@ -80,6 +96,11 @@ type ExecutionState struct {
 
 	ExecutionTuple *ExecutionTuple // TODO Rename, possibly move
 
+	// LiveMetrics gives the executors and the scheduler access to the values
+	// of the metrics during the test run. It's nil if the metrics aren't processed by k6,
+	// e.g. when both the summary and the thresholds are disabled.
+	LiveMetrics LiveMetrics
+
 	// vus is the shared channel buffer that contains all of the VUs that have
 	// been initialized and aren't currently being used by a executor.
 	//
@@ -0,0 +1,606 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/ChipArtem/k6/lib"
+	"github.com/ChipArtem/k6/lib/types"
+	"github.com/ChipArtem/k6/metrics"
+	"github.com/ChipArtem/k6/ui/pb"
+)
+
+const adaptiveArrivalRateType = "adaptive-arrival-rate"
+
+// maxSustainableRateMetric is the name of the metric with the maximum rate,
+// in iterations per second, that the adaptive arrival-rate executors found to
+// be sustainable.
+const maxSustainableRateMetric = "max_sustainable_rate"
+
+func init() {
+	lib.RegisterExecutorConfigType(
+		adaptiveArrivalRateType,
+		func(name string, rawJSON []byte) (lib.ExecutorConfig, error) {
+			config := NewAdaptiveArrivalRateConfig(name)
+			err := lib.StrictJSONUnmarshal(rawJSON, &config)
+			return config, err
+		},
+	)
+}
+
+// AdaptiveArrivalRateConfig stores the config for the adaptive arrival-rate
+// executor, which searches for the maximum arrival rate that the system under
+// test can sustain without violating its service level objective (SLO). The
+// SLO is checked with the metrics of the local instance only, so the executor
+// can't be used with execution segments.
+type AdaptiveArrivalRateConfig struct {
+	BaseConfig
+	StartRate     null.Int           `json:"startRate"`
+	RateIncrement null.Int           `json:"rateIncrement"`
+	MaxRate       null.Int           `json:"maxRate"`
+	Precision     null.Int           `json:"precision"`
+	TimeUnit      types.NullDuration `json:"timeUnit"`
+	StepDuration  types.NullDuration `json:"stepDuration"`
+	MaxDuration   types.NullDuration `json:"maxDuration"`
+
+	// The SLO, which is violated during a step if any of its targets is
+	// exceeded, or if any iterations were dropped.
+	LatencyMetric     null.String        `json:"latencyMetric"`
+	LatencyPercentile null.Float         `json:"latencyPercentile"`
+	LatencyTarget     types.NullDuration `json:"latencyTarget"`
+	ErrorRateMetric   null.String        `json:"errorRateMetric"`
+	ErrorRateTarget   null.Float         `json:"errorRateTarget"`
+
+	// Initialize `PreAllocatedVUs` number of VUs, and if more than that are needed,
+	// they will be dynamically allocated, until `MaxVUs` is reached, which is an
+	// absolutely hard limit on the number of VUs the executor will use
+	PreAllocatedVUs null.Int `json:"preAllocatedVUs"`
+	MaxVUs          null.Int `json:"maxVUs"`
+}
+
+// NewAdaptiveArrivalRateConfig returns an AdaptiveArrivalRateConfig with
+// default values
+func NewAdaptiveArrivalRateConfig(name string) *AdaptiveArrivalRateConfig {
+	return &AdaptiveArrivalRateConfig{
+		BaseConfig:        NewBaseConfig(name, adaptiveArrivalRateType),
+		TimeUnit:          types.NewNullDuration(1*time.Second, false),
+		StepDuration:      types.NewNullDuration(30*time.Second, false),
+		Precision:         null.NewInt(1, false),
+		LatencyMetric:     null.NewString(metrics.HTTPReqDurationName, false),
+		LatencyPercentile: null.NewFloat(95, false),
+		ErrorRateMetric:   null.NewString(metrics.HTTPReqFailedName, false),
+	}
+}
+
+// Make sure we implement the lib.ExecutorConfig interface
+var _ lib.ExecutorConfig = &AdaptiveArrivalRateConfig{}
+
+// GetPreAllocatedVUs is just a helper method that returns the scaled pre-allocated VUs.
+func (aarc AdaptiveArrivalRateConfig) GetPreAllocatedVUs(et *lib.ExecutionTuple) int64 {
+	return et.ScaleInt64(aarc.PreAllocatedVUs.Int64)
+}
+
+// GetMaxVUs is just a helper method that returns the scaled max VUs.
+func (aarc AdaptiveArrivalRateConfig) GetMaxVUs(et *lib.ExecutionTuple) int64 {
+	return et.ScaleInt64(aarc.MaxVUs.Int64)
+}
+
+// GetRateIncrement returns by how much the rate is increased after every
+// step, which defaults to the start rate.
+func (aarc AdaptiveArrivalRateConfig) GetRateIncrement() int64 {
+	if aarc.RateIncrement.Valid {
+		return aarc.RateIncrement.Int64
+	}
+	return aarc.StartRate.Int64
+}
+
+// GetDescription returns a human-readable description of the executor options
+func (aarc AdaptiveArrivalRateConfig) GetDescription(et *lib.ExecutionTuple) string {
+	maxVUsRange := fmt.Sprintf("maxVUs: %d", et.ScaleInt64(aarc.PreAllocatedVUs.Int64))
+	if aarc.MaxVUs.Int64 > aarc.PreAllocatedVUs.Int64 {
+		maxVUsRange += fmt.Sprintf("-%d", et.ScaleInt64(aarc.MaxVUs.Int64))
+	}
+	startRatePerSec, _ := getArrivalRatePerSec(
+		getScaledArrivalRate(et.Segment, aarc.StartRate.Int64, aarc.TimeUnit.TimeDuration()),
+	).Float64()
+
+	return fmt.Sprintf("Adaptive from %.2f iterations/s in steps of %s for up to %s%s",
+		startRatePerSec, aarc.StepDuration.Duration, aarc.MaxDuration.Duration, aarc.getBaseInfo(maxVUsRange))
+}
+
+// Validate makes sure all options are configured and valid
+//
+//nolint:funlen,cyclop
+func (aarc *AdaptiveArrivalRateConfig) Validate() []error {
+	errors := aarc.BaseConfig.Validate()
+
+	if !aarc.StartRate.Valid {
+		errors = append(errors, fmt.Errorf("the startRate isn't specified"))
+	} else if aarc.StartRate.Int64 <= 0 {
+		errors = append(errors, fmt.Errorf("the startRate must be more than 0"))
+	}
+	if aarc.RateIncrement.Valid && aarc.RateIncrement.Int64 <= 0 {
+		errors = append(errors, fmt.Errorf("the rateIncrement must be more than 0"))
+	}
+	if aarc.MaxRate.Valid && aarc.MaxRate.Int64 < aarc.StartRate.Int64 {
+		errors = append(errors, fmt.Errorf("the maxRate can't be less than the startRate"))
+	}
+	if aarc.Precision.Int64 <= 0 {
+		errors = append(errors, fmt.Errorf("the precision must be more than 0"))
+	}
+	if aarc.TimeUnit.TimeDuration() <= 0 {
+		errors = append(errors, fmt.Errorf("the timeUnit must be more than 0"))
+	}
+
+	if aarc.StepDuration.TimeDuration() < minDuration {
+		errors = append(errors, fmt.Errorf(
+			"the stepDuration must be at least %s, but is %s", minDuration, aarc.StepDuration,
+		))
+	}
+	if !aarc.MaxDuration.Valid {
+		errors = append(errors, fmt.Errorf("the maxDuration is unspecified"))
+	} else if aarc.MaxDuration.TimeDuration() < aarc.StepDuration.TimeDuration() {
+		errors = append(errors, fmt.Errorf("the maxDuration can't be less than the stepDuration"))
+	}
+
+	if !aarc.LatencyTarget.Valid && !aarc.ErrorRateTarget.Valid {
+		errors = append(errors, fmt.Errorf("at least one of the latencyTarget or the errorRateTarget must be specified"))
+	}
+	if aarc.LatencyTarget.TimeDuration() < 0 {
+		errors = append(errors, fmt.Errorf("the latencyTarget can't be negative"))
+	}
+	if p := aarc.LatencyPercentile.Float64; p <= 0 || p > 100 {
+		errors = append(errors, fmt.Errorf("the latencyPercentile must be more than 0 and at most 100"))
+	}
+	if r := aarc.ErrorRateTarget.Float64; r < 0 || r > 1 {
+		errors = append(errors, fmt.Errorf("the errorRateTarget must be between 0 and 1"))
+	}
+
+	if !aarc.PreAllocatedVUs.Valid {
+		errors = append(errors, fmt.Errorf("the number of preAllocatedVUs isn't specified"))
+	} else if aarc.PreAllocatedVUs.Int64 < 0 {
+		errors = append(errors, fmt.Errorf("the number of preAllocatedVUs can't be negative"))
+	}
+
+	if !aarc.MaxVUs.Valid {
+		// TODO: don't change the config while validating
+		aarc.MaxVUs.Int64 = aarc.PreAllocatedVUs.Int64
+	} else if aarc.MaxVUs.Int64 < aarc.PreAllocatedVUs.Int64 {
+		errors = append(errors, fmt.Errorf("maxVUs can't be less than preAllocatedVUs"))
+	}
+
+	return errors
+}
+
+// GetExecutionRequirements returns the number of required VUs to run the
+// executor for its whole duration (disregarding any startTime), including the
+// maximum waiting time for any iterations to gracefully stop. This is used by
+// the execution scheduler in its VU reservation calculations, so it knows how
+// many VUs to pre-initialize.
+func (aarc AdaptiveArrivalRateConfig) GetExecutionRequirements(et *lib.ExecutionTuple) []lib.ExecutionStep {
+	return []lib.ExecutionStep{
+		{
+			TimeOffset:      0,
+			PlannedVUs:      uint64(et.ScaleInt64(aarc.PreAllocatedVUs.Int64)),
+			MaxUnplannedVUs: uint64(et.ScaleInt64(aarc.MaxVUs.Int64) - et.ScaleInt64(aarc.PreAllocatedVUs.Int64)),
+		},
+		{
+			TimeOffset:      aarc.MaxDuration.TimeDuration() + aarc.GracefulStop.TimeDuration(),
+			PlannedVUs:      0,
+			MaxUnplannedVUs: 0,
+		},
+	}
+}
+
+// NewExecutor creates a new AdaptiveArrivalRate executor
+func (aarc AdaptiveArrivalRateConfig) NewExecutor(
+	es *lib.ExecutionState, logger *logrus.Entry,
+) (lib.Executor, error) {
+	return &AdaptiveArrivalRate{
+		BaseExecutor: NewBaseExecutor(&aarc, es, logger),
+		config:       aarc,
+	}, nil
+}
+
+// HasWork reports whether there is any work to be done for the given execution segment.
+func (aarc AdaptiveArrivalRateConfig) HasWork(et *lib.ExecutionTuple) bool {
+	return aarc.GetMaxVUs(et) > 0
+}
+
+// rateSearch searches for the maximum sustainable rate. It increases the rate
+// by a fixed increment after every step that met the SLO, until a step
+// violates it. Then it bisects the range between the highest rate that met
+// the SLO and the lowest rate that violated it, until it's within the
+// precision.
+type rateSearch struct {
+	increment, max, precision int64
+
+	rate int64
+	// good is the highest rate that met the SLO, and bad is the lowest rate
+	// that violated it, or 0 if none did.
+	good, bad int64
+}
+
+// next records whether the current rate met the SLO and returns the rate of
+// the next step, or whether the search has converged on the good rate.
+func (rs *rateSearch) next(ok bool) (done bool) {
+	if ok {
+		if rs.rate > rs.good {
+			rs.good = rs.rate
+		}
+	} else if rs.bad == 0 || rs.rate < rs.bad {
+		rs.bad = rs.rate
+	}
+
+	if rs.bad == 0 {
+		if rs.max > 0 && rs.good >= rs.max {
+			return true
+		}
+		rs.rate = rs.good + rs.increment
+		if rs.max > 0 && rs.rate > rs.max {
+			rs.rate = rs.max
+		}
+		return false
+	}
+	if rs.bad-rs.good <= rs.precision {
+		return true
+	}
+	rs.rate = rs.good + (rs.bad-rs.good)/2
+	return false
+}
+
+// AdaptiveArrivalRate starts iterations at a constant rate during every step,
+// and adapts the rate of the next step to the values of the metrics in the
+// current one, until it finds the maximum sustainable rate.
+type AdaptiveArrivalRate struct {
+	*BaseExecutor
+	config AdaptiveArrivalRateConfig
+	et     *lib.ExecutionTuple
+
+	watchLatency, watchErrorRate func() metrics.Sink
+	maxRateMetric                *metrics.Metric
+}
+
+// Make sure we implement the lib.Executor interface.
+var _ lib.Executor = &AdaptiveArrivalRate{}
+
+// Init values needed for the execution
+func (aar *AdaptiveArrivalRate) Init(ctx context.Context) error {
+	// Every instance would search for its own rate, based on its own share of
+	// the metrics, so the rates of the instances would diverge.
+	if aar.executionState.ExecutionTuple.Segment.FloatLength() < 1 {
+		return fmt.Errorf("the %s executor can't be used with an execution segment, "+
+			"since the rate is adapted to the metrics of the local instance only", adaptiveArrivalRateType)
+	}
+
+	// err should always be nil, because Init() won't be called for executors
+	// with no work, as determined by their config's HasWork() method.
+	et, err := aar.BaseExecutor.executionState.ExecutionTuple.GetNewExecutionTupleFromValue(aar.config.MaxVUs.Int64)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	aar.et = et
+	aar.iterSegIndex = lib.NewSegmentedIndex(et)
+
+	test := aar.executionState.Test
+	liveMetrics := aar.executionState.LiveMetrics
+	if liveMetrics == nil {
+		return fmt.Errorf("the %s executor requires the metrics to be processed, "+
+			"so it can't be used when both the summary and the thresholds are disabled", adaptiveArrivalRateType)
+	}
+
+	// Only the scenario's own samples are taken into account, if they can
+	// be told apart.
+	var tags map[string]string
+	if test.Options.SystemTags.Has(metrics.TagScenario) {
+		tags = map[string]string{metrics.TagScenario.String(): aar.config.Name}
+	}
+	if aar.config.LatencyTarget.Valid {
+		aar.watchLatency, err = aar.watchMetric(liveMetrics, aar.config.LatencyMetric.String, metrics.Trend, tags)
+		if err != nil {
+			return err
+		}
+	}
+	if aar.config.ErrorRateTarget.Valid {
+		aar.watchErrorRate, err = aar.watchMetric(liveMetrics, aar.config.ErrorRateMetric.String, metrics.Rate, tags)
+		if err != nil {
+			return err
+		}
+	}
+
+	aar.maxRateMetric, err = test.Registry.NewMetric(maxSustainableRateMetric, metrics.Gauge)
+	return err //nolint:wrapcheck
+}
+
+func (aar *AdaptiveArrivalRate) watchMetric(
+	liveMetrics lib.LiveMetrics, name string, typ metrics.MetricType, tags map[string]string,
+) (func() metrics.Sink, error) {
+	if m := aar.executionState.Test.Registry.Get(name); m != nil && m.Type != typ {
+		return nil, fmt.Errorf("the metric '%s' of the %s scenario should be a %s, but it's a %s",
+			name, aar.config.Name, typ, m.Type)
+	}
+	return liveMetrics.WatchMetric(name, tags) //nolint:wrapcheck
+}
+
+// meetsSLO returns whether the values of the metrics since the previous call
+// meet the SLO, and if not, the reason why.
+func (aar *AdaptiveArrivalRate) meetsSLO(dropped int64) (bool, string) {
+	var reason string
+	if aar.watchLatency != nil {
+		sink, ok := aar.watchLatency().(*metrics.TrendSink)
+		if ok && !sink.IsEmpty() {
+			p := sink.P(aar.config.LatencyPercentile.Float64 / 100)
+			target := float64(aar.config.LatencyTarget.TimeDuration()) / float64(time.Millisecond)
+			if p > target {
+				reason = fmt.Sprintf("p(%g) of %s was %.2fms", aar.config.LatencyPercentile.Float64,
+					aar.config.LatencyMetric.String, p)
+			}
+		}
+	}
+	if aar.watchErrorRate != nil {
+		sink, ok := aar.watchErrorRate().(*metrics.RateSink)
+		if ok && sink.Total > 0 {
+			if rate := float64(sink.Trues) / float64(sink.Total); rate > aar.config.ErrorRateTarget.Float64 {
+				reason = fmt.Sprintf("the rate of %s was %.4f", aar.config.ErrorRateMetric.String, rate)
+			}
+		}
+	}
+	if dropped > 0 {
+		reason = fmt.Sprintf("%d iterations were dropped", dropped)
+	}
+	return reason == "", reason
+}
+
+// Run executes iterations at the rate of the current step, and adapts the rate
+// after every step, until it converges on the maximum sustainable rate or the
+// maxDuration is reached.
+//
+// The samples of the iterations that are still running at the end of a step
+// are counted in the next one, so the steps should be much longer than the
+// iterations.
+//
+//nolint:funlen,gocognit,cyclop
+func (aar AdaptiveArrivalRate) Run(parentCtx context.Context, out chan<- metrics.SampleContainer) (err error) {
+	gracefulStop := aar.config.GetGracefulStop()
+	duration := aar.config.MaxDuration.TimeDuration()
+	stepDuration := aar.config.StepDuration.TimeDuration()
+	timeUnit := aar.config.TimeUnit.TimeDuration()
+	preAllocatedVUs := aar.config.GetPreAllocatedVUs(aar.executionState.ExecutionTuple)
+	maxVUs := aar.config.GetMaxVUs(aar.executionState.ExecutionTuple)
+
+	search := &rateSearch{
+		increment: aar.config.GetRateIncrement(),
+		max:       aar.config.MaxRate.Int64,
+		precision: aar.config.Precision.Int64,
+		rate:      aar.config.StartRate.Int64,
+	}
+	ratePerSec := func(rate int64) float64 {
+		return float64(rate) * float64(time.Second) / float64(timeUnit)
+	}
+
+	aar.logger.WithFields(logrus.Fields{
+		"maxVUs": maxVUs, "preAllocatedVUs": preAllocatedVUs, "duration": duration,
+		"stepDuration": stepDuration, "type": aar.config.GetType(),
+	}).Debug("Starting executor run...")
+
+	activeVUsWg := &sync.WaitGroup{}
+
+	returnedVUs := make(chan struct{})
+	waitOnProgressChannel := make(chan struct{})
+	startTime, maxDurationCtx, regDurationCtx, cancel := getDurationContexts(parentCtx, duration, gracefulStop)
+	defer func() {
+		cancel()
+		<-waitOnProgressChannel
+	}()
+
+	vusPool := newActiveVUPool(aar.executionState)
+	defer func() {
+		// Make sure all VUs aren't executing iterations anymore, for the cancel()
+		// below to deactivate them.
+		<-returnedVUs
+		// first close the vusPool so we wait for the gracefulShutdown
+		vusPool.Close()
+		cancel()
+		activeVUsWg.Wait()
+	}()
+	activeVUsCount := uint64(0)
+	currentRate, goodRate := aar.config.StartRate.Int64, int64(0)
+
+	vusFmt := pb.GetFixedLengthIntFormat(maxVUs)
+	progressFn := func() (float64, []string) {
+		spent := time.Since(startTime)
+		currActiveVUs := atomic.LoadUint64(&activeVUsCount)
+		progVUs := fmt.Sprintf(vusFmt+"/"+vusFmt+" VUs", vusPool.Running(), currActiveVUs)
+		progRate := fmt.Sprintf("%.2f iters/s (max sustainable %.2f)",
+			ratePerSec(atomic.LoadInt64(&currentRate)), ratePerSec(atomic.LoadInt64(&goodRate)))
+
+		right := []string{progVUs, duration.String(), progRate}
+		if spent > duration {
+			return 1, right
+		}
+
+		spentDuration := pb.GetFixedLengthDuration(spent, duration)
+		right[1] = fmt.Sprintf("%s/%s", spentDuration, duration)
+
+		return math.Min(1, float64(spent)/float64(duration)), right
+	}
+	aar.progress.Modify(pb.WithProgress(progressFn))
+	maxDurationCtx = lib.WithScenarioState(maxDurationCtx, &lib.ScenarioState{
+		Name:       aar.config.Name,
+		Executor:   aar.config.Type,
+		StartTime:  startTime,
+		ProgressFn: progressFn,
+	})
+
+	go func() {
+		trackProgress(parentCtx, maxDurationCtx, regDurationCtx, &aar, progressFn)
+		close(waitOnProgressChannel)
+	}()
+
+	returnVU := func(u lib.InitializedVU) {
+		// Return the VU without decreasing the global active VU counter, which
+		// is done in the goroutine started by activeVUPool.AddVU, whenever the
+		// VU finishes running an iteration. This results in a more accurate
+		// report of VUs that are _actually_ active.
+		aar.executionState.ReturnVU(u, false)
+		activeVUsWg.Done()
+	}
+
+	runIterationBasic := getIterationRunner(aar.executionState, aar.logger)
+	activateVU := func(initVU lib.InitializedVU) lib.ActiveVU {
+		activeVUsWg.Add(1)
+		activeVU := initVU.Activate(getVUActivationParams(
+			maxDurationCtx, aar.config.BaseConfig, returnVU,
+			aar.nextIterationCounters,
+		))
+		atomic.AddUint64(&activeVUsCount, 1)
+		vusPool.AddVU(maxDurationCtx, activeVU, runIterationBasic)
+		return activeVU
+	}
+
+	remainingUnplannedVUs := maxVUs - preAllocatedVUs
+	makeUnplannedVUCh := make(chan struct{})
+	defer close(makeUnplannedVUCh)
+	go func() {
+		defer close(returnedVUs)
+		for range makeUnplannedVUCh {
+			aar.logger.Debug("Starting initialization of an unplanned VU...")
+			initVU, err := aar.executionState.GetUnplannedVU(maxDurationCtx, aar.logger)
+			if err != nil {
+				// TODO figure out how to return it to the Run goroutine
+				aar.logger.WithError(err).Error("Error while allocating unplanned VU")
+			} else {
+				aar.logger.Debug("The unplanned VU finished initializing successfully!")
+				activateVU(initVU)
+			}
+		}
+	}()
+
+	// Get the pre-allocated VUs in the local buffer
+	for i := int64(0); i < preAllocatedVUs; i++ {
+		initVU, err := aar.executionState.GetPlannedVU(aar.logger, false)
+		if err != nil {
+			return err
+		}
+		activateVU(initVU)
+	}
+
+	metricTags := aar.getMetricTags(nil)
+	pushMaxRate := func() {
+		metrics.PushIfNotDone(parentCtx, out, metrics.Sample{
+			TimeSeries: metrics.TimeSeries{Metric: aar.maxRateMetric, Tags: metricTags},
+			Time:       time.Now(),
+			Value:      ratePerSec(atomic.LoadInt64(&goodRate)),
+		})
+	}
+	converged := false
+	defer func() {
+		pushMaxRate()
+		logger := aar.logger.WithField("rate", fmt.Sprintf("%.2f iterations/s", ratePerSec(goodRate)))
+		if converged {
+			logger.Info("Found the maximum sustainable rate")
+		} else {
+			logger.Warn("The maximum duration was reached before the maximum sustainable rate was found, " +
+				"the rate is the highest one that met the targets")
+		}
+	}()
+
+	// Discard the samples from before the start.
+	aar.meetsSLO(0)
+
+	// The iterations of every step are split between the execution segments
+	// the same way as in the ramping-arrival-rate executor, with the striped
+	// offsets. i is the global number of the next iteration.
+	start, offsets, _ := aar.et.GetStripedOffsets()
+	li := -1
+	next := func() int64 {
+		li++
+		return offsets[li%len(offsets)]
+	}
+	i := float64(start + 1)
+	var doneSoFar float64
+
+	droppedIterationMetric := aar.executionState.Test.BuiltinMetrics.DroppedIterations
+	shownWarning := false
+	regDurationDone := regDurationCtx.Done()
+	timer := time.NewTimer(time.Hour)
+	waitUntil := func(offset time.Duration) bool {
+		if d := time.Until(startTime.Add(offset)); d > 0 {
+			timer.Reset(d)
+			select {
+			case <-timer.C:
+			case <-regDurationDone:
+				return false
+			}
+		}
+		return true
+	}
+
+	for stepStart := time.Duration(0); ; stepStart += stepDuration {
+		rate := float64(search.rate) / float64(timeUnit)
+		endCount := doneSoFar + float64(stepDuration)*rate
+		var dropped int64
+		for ; i <= endCount; i += float64(next()) {
+			if !waitUntil(stepStart + time.Duration((i-doneSoFar)/rate)) {
+				return nil
+			}
+			if vusPool.TryRunIteration() {
+				continue
+			}
+
+			// Since there aren't any free VUs available, consider this iteration
+			// dropped - we aren't going to try to recover it, but
+			dropped++
+			metrics.PushIfNotDone(parentCtx, out, metrics.Sample{
+				TimeSeries: metrics.TimeSeries{
+					Metric: droppedIterationMetric,
+					Tags:   metricTags,
+				},
+				Time:  time.Now(),
+				Value: 1,
+			})
+
+			// We'll try to start allocating another VU in the background,
+			// non-blockingly, if we have remainingUnplannedVUs...
+			if remainingUnplannedVUs == 0 {
+				if !shownWarning {
+					aar.logger.Warningf("Insufficient VUs, reached %d active VUs and cannot initialize more", maxVUs)
+					shownWarning = true
+				}
+				continue
+			}
+
+			select {
+			case makeUnplannedVUCh <- struct{}{}: // great!
+				remainingUnplannedVUs--
+			default: // we're already allocating a new VU
+			}
+		}
+		doneSoFar = endCount
+		if !waitUntil(stepStart + stepDuration) {
+			return nil
+		}
+
+		ok, reason := aar.meetsSLO(dropped)
+		stepLogger := aar.logger.WithField("rate", fmt.Sprintf("%.2f iterations/s", ratePerSec(search.rate)))
+		if ok {
+			stepLogger.Debug("The rate met the targets")
+		} else {
+			stepLogger.WithField("reason", reason).Debug("The rate violated the targets")
+		}
+		converged = search.next(ok)
+		atomic.StoreInt64(&goodRate, search.good)
+		atomic.StoreInt64(&currentRate, search.rate)
+		pushMaxRate()
+		if converged {
+			return nil
+		}
+	}
+}
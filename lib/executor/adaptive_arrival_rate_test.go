@@ -0,0 +1,178 @@
+package executor
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/ChipArtem/k6/lib"
+	"github.com/ChipArtem/k6/lib/testutils"
+	"github.com/ChipArtem/k6/lib/types"
+	"github.com/ChipArtem/k6/metrics"
+)
+
+func TestRateSearch(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		search    rateSearch
+		sustained int64
+		rates     []int64
+		good      int64
+	}{
+		{
+			name:      "increase and bisect",
+			search:    rateSearch{increment: 10, precision: 2, rate: 10},
+			sustained: 33,
+			rates:     []int64{10, 20, 30, 40, 35, 32, 33},
+			good:      33,
+		},
+		{
+			name:      "back off from the start",
+			search:    rateSearch{increment: 100, precision: 5, rate: 100},
+			sustained: 30,
+			rates:     []int64{100, 50, 25, 37, 31, 28},
+			good:      28,
+		},
+		{
+			name:      "max rate",
+			search:    rateSearch{increment: 10, max: 25, precision: 1, rate: 10},
+			sustained: 100,
+			rates:     []int64{10, 20, 25},
+			good:      25,
+		},
+		{
+			name:      "nothing sustainable",
+			search:    rateSearch{increment: 1, precision: 1, rate: 1},
+			sustained: 0,
+			rates:     []int64{1},
+			good:      0,
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			search := tc.search
+			var rates []int64
+			for done := false; !done; {
+				require.Less(t, len(rates), 100)
+				rates = append(rates, search.rate)
+				done = search.next(search.rate <= tc.sustained)
+			}
+			assert.Equal(t, tc.rates, rates)
+			assert.Equal(t, tc.good, search.good)
+		})
+	}
+}
+
+// fakeLiveMetrics returns the values of the metrics from a function.
+type fakeLiveMetrics struct {
+	values func(name string) metrics.Sink
+}
+
+func (flm fakeLiveMetrics) WatchMetric(name string, _ map[string]string) (func() metrics.Sink, error) {
+	return func() metrics.Sink { return flm.values(name) }, nil
+}
+
+func (flm fakeLiveMetrics) CheckThresholds([]string, time.Duration) ([]string, error) {
+	return nil, nil
+}
+
+func getTestAdaptiveArrivalRateConfig() *AdaptiveArrivalRateConfig {
+	config := NewAdaptiveArrivalRateConfig("adaptive")
+	config.GracefulStop = types.NullDurationFrom(time.Second)
+	config.StartRate = null.IntFrom(10)
+	config.Precision = null.IntFrom(5)
+	config.StepDuration = types.NullDurationFrom(time.Second)
+	config.MaxDuration = types.NullDurationFrom(20 * time.Second)
+	config.LatencyTarget = types.NullDurationFrom(37 * time.Millisecond)
+	config.PreAllocatedVUs = null.IntFrom(10)
+	config.MaxVUs = null.IntFrom(10)
+	return config
+}
+
+func TestAdaptiveArrivalRateRun(t *testing.T) {
+	t.Parallel()
+
+	var count int64
+	runner := simpleRunner(func(ctx context.Context, _ *lib.State) error {
+		atomic.AddInt64(&count, 1)
+		return nil
+	})
+	config := getTestAdaptiveArrivalRateConfig()
+	require.Empty(t, config.Validate())
+
+	et, err := lib.NewExecutionTuple(nil, nil)
+	require.NoError(t, err)
+	execReqs := config.GetExecutionRequirements(et)
+	es := lib.NewExecutionState(
+		getTestRunState(t, lib.Options{}, runner), et,
+		lib.GetMaxPlannedVUs(execReqs), lib.GetMaxPossibleVUs(execReqs),
+	)
+	// The latency in milliseconds is the number of iterations during the step.
+	es.LiveMetrics = fakeLiveMetrics{values: func(name string) metrics.Sink {
+		assert.Equal(t, metrics.HTTPReqDurationName, name)
+		sink := metrics.NewTrendSink()
+		sink.Add(metrics.Sample{Value: float64(atomic.SwapInt64(&count, 0))})
+		return sink
+	}}
+	ctx, cancel, executor, logHook := setupExecutor(t, config, es)
+	defer cancel()
+
+	engineOut := make(chan metrics.SampleContainer, 1000)
+	start := time.Now()
+	require.NoError(t, executor.Run(ctx, engineOut))
+	// The rates are 10, 20, 30, 40 and 35 iterations/s.
+	assert.InDelta(t, 5*time.Second, time.Since(start), float64(time.Second))
+	assert.Empty(t, logHook.Drain())
+
+	close(engineOut)
+	var maxRate float64
+	for container := range engineOut {
+		for _, sample := range container.GetSamples() {
+			if sample.Metric.Name == maxSustainableRateMetric {
+				maxRate = sample.Value
+			}
+		}
+	}
+	assert.Equal(t, 35.0, maxRate)
+}
+
+func TestAdaptiveArrivalRateInitWithoutLiveMetrics(t *testing.T) {
+	t.Parallel()
+
+	runner := simpleRunner(func(context.Context, *lib.State) error { return nil })
+	config := getTestAdaptiveArrivalRateConfig()
+	require.Empty(t, config.Validate())
+
+	et, err := lib.NewExecutionTuple(nil, nil)
+	require.NoError(t, err)
+	es := lib.NewExecutionState(getTestRunState(t, lib.Options{}, runner), et, 10, 10)
+	executor, err := config.NewExecutor(es, testutils.NewLogger(t).WithField("test", t.Name()))
+	require.NoError(t, err)
+	require.ErrorContains(t, executor.Init(context.Background()), "requires the metrics to be processed")
+}
+
+func TestAdaptiveArrivalRateInitWithExecutionSegment(t *testing.T) {
+	t.Parallel()
+
+	runner := simpleRunner(func(context.Context, *lib.State) error { return nil })
+	config := getTestAdaptiveArrivalRateConfig()
+	require.Empty(t, config.Validate())
+
+	segment, err := lib.NewExecutionSegmentFromString("0:1/2")
+	require.NoError(t, err)
+	et, err := lib.NewExecutionTuple(segment, nil)
+	require.NoError(t, err)
+	es := lib.NewExecutionState(getTestRunState(t, lib.Options{}, runner), et, 10, 10)
+	executor, err := config.NewExecutor(es, testutils.NewLogger(t).WithField("test", t.Name()))
+	require.NoError(t, err)
+	require.ErrorContains(t, executor.Init(context.Background()), "can't be used with an execution segment")
+}
@@ -0,0 +1,141 @@
+package executor
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"time"
+
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/ChipArtem/k6/lib/types"
+)
+
+// The distributions of the times between the starts of the iterations of the
+// arrival-rate executors.
+const (
+	arrivalUniform     = "uniform"
+	arrivalPoisson     = "poisson"
+	arrivalExponential = "exponential" // an alias of poisson
+	arrivalBursty      = "bursty"
+)
+
+// ArrivalDistributionConfig configures how the iterations of the arrival-rate
+// executors are spread over time, it's embedded in their configs. By default,
+// the iterations are evenly spaced.
+type ArrivalDistributionConfig struct {
+	ArrivalDistribution null.String        `json:"arrivalDistribution"`
+	BurstOn             types.NullDuration `json:"burstOn"`
+	BurstOff            types.NullDuration `json:"burstOff"`
+}
+
+// Validate makes sure the distribution is valid.
+func (adc ArrivalDistributionConfig) Validate() (errors []error) {
+	switch adc.ArrivalDistribution.String {
+	case "", arrivalUniform, arrivalPoisson, arrivalExponential:
+		if adc.BurstOn.Valid || adc.BurstOff.Valid {
+			errors = append(errors, fmt.Errorf("burstOn and burstOff can only be used with the bursty arrivalDistribution"))
+		}
+	case arrivalBursty:
+		if adc.BurstOn.TimeDuration() <= 0 {
+			errors = append(errors, fmt.Errorf("the burstOn duration must be more than 0"))
+		}
+		if adc.BurstOff.TimeDuration() <= 0 {
+			errors = append(errors, fmt.Errorf("the burstOff duration must be more than 0"))
+		}
+	default:
+		errors = append(errors, fmt.Errorf(
+			"invalid arrivalDistribution %q, it should be one of %s, %s, %s or %s",
+			adc.ArrivalDistribution.String, arrivalUniform, arrivalPoisson, arrivalExponential, arrivalBursty,
+		))
+	}
+	return errors
+}
+
+// getDescription returns the part of the executor's description about the
+// distribution, which is empty for the evenly spaced iterations.
+func (adc ArrivalDistributionConfig) getDescription() string {
+	switch adc.ArrivalDistribution.String {
+	case arrivalPoisson, arrivalExponential:
+		return " with poisson arrivals"
+	case arrivalBursty:
+		return fmt.Sprintf(" in bursts of %s every %s", adc.BurstOn.Duration, adc.BurstOn.Duration+adc.BurstOff.Duration)
+	default:
+		return ""
+	}
+}
+
+// newArrivals returns the arrivals for the executor with the given name.
+func (adc ArrivalDistributionConfig) newArrivals(name string) *arrivals {
+	a := &arrivals{}
+	switch adc.ArrivalDistribution.String {
+	case arrivalPoisson, arrivalExponential:
+		// The random numbers are the same in every k6 instance and in
+		// every test run, but they differ between the scenarios.
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(name))
+		a.rand = rand.New(rand.NewSource(int64(h.Sum64()))) //nolint:gosec
+	case arrivalBursty:
+		a.on = adc.BurstOn.TimeDuration()
+		a.cycle = a.on + adc.BurstOff.TimeDuration()
+	}
+	return a
+}
+
+// arrivals turns the evenly spaced starts of the iterations into the ones of
+// the configured distribution. The arrival-rate executors number all of the
+// iterations across the execution segments with global numbers, and the time
+// of each iteration is calculated from its global number, which is what makes
+// the segments not overlap. So the distributions are applied to the global
+// numbers and times of the iterations, the same way by every k6 instance:
+//
+//   - The poisson arrivals replace the global number n of an iteration with the
+//     sum of n random numbers from the exponential distribution, whose mean is
+//     1. So the times between the iterations are exponentially distributed,
+//     with the same mean as before. The random numbers are seeded, and they are
+//     generated for all of the global numbers, including the ones of the other
+//     segments, so every instance gets the same ones.
+//   - The bursty arrivals squeeze the iterations of every cycle of burstOn +
+//     burstOff into its first burstOn, so the average rate stays the same.
+//
+// The times stay in the same order as the numbers, but the iterations have
+// to be numbered in order. arrivals isn't safe for concurrent use.
+type arrivals struct {
+	rand  *rand.Rand
+	last  int64
+	count float64
+
+	on, cycle time.Duration
+}
+
+// getCount returns the position of the iteration with the given global number
+// among all of the iterations, i.e. the number itself, unless the arrivals
+// are poisson.
+func (a *arrivals) getCount(n int64) float64 {
+	if a.rand == nil {
+		return float64(n)
+	}
+	for ; a.last < n; a.last++ {
+		a.count += a.rand.ExpFloat64()
+	}
+	return a.count
+}
+
+// getOffset returns the time the iteration with the given global number starts
+// at, if the evenly spaced iterations start every period.
+func (a *arrivals) getOffset(period time.Duration, n int64) time.Duration {
+	if a.rand == nil {
+		return a.getTime(period * time.Duration(n))
+	}
+	return a.getTime(time.Duration(float64(period) * a.getCount(n)))
+}
+
+// getTime returns the time the iteration starts at, from the time it would
+// have started at if the iterations were evenly spaced over their counts.
+func (a *arrivals) getTime(t time.Duration) time.Duration {
+	if a.cycle == 0 {
+		return t
+	}
+	cycleStart := t - t%a.cycle
+	return cycleStart + time.Duration(float64(t-cycleStart)*float64(a.on)/float64(a.cycle))
+}
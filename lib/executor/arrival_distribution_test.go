@@ -0,0 +1,171 @@
+package executor
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/ChipArtem/k6/lib"
+	"github.com/ChipArtem/k6/lib/types"
+)
+
+func TestArrivalDistributionConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		config ArrivalDistributionConfig
+		errors []string
+	}{
+		{name: "default"},
+		{name: "uniform", config: ArrivalDistributionConfig{ArrivalDistribution: null.StringFrom("uniform")}},
+		{name: "poisson", config: ArrivalDistributionConfig{ArrivalDistribution: null.StringFrom("poisson")}},
+		{name: "exponential", config: ArrivalDistributionConfig{ArrivalDistribution: null.StringFrom("exponential")}},
+		{
+			name: "bursty",
+			config: ArrivalDistributionConfig{
+				ArrivalDistribution: null.StringFrom("bursty"),
+				BurstOn:             types.NullDurationFrom(time.Second),
+				BurstOff:            types.NullDurationFrom(4 * time.Second),
+			},
+		},
+		{
+			name:   "bursty without durations",
+			config: ArrivalDistributionConfig{ArrivalDistribution: null.StringFrom("bursty")},
+			errors: []string{"the burstOn duration must be more than 0", "the burstOff duration must be more than 0"},
+		},
+		{
+			name: "burst durations without bursty",
+			config: ArrivalDistributionConfig{
+				ArrivalDistribution: null.StringFrom("poisson"),
+				BurstOn:             types.NullDurationFrom(time.Second),
+			},
+			errors: []string{"can only be used with the bursty arrivalDistribution"},
+		},
+		{
+			name:   "unknown",
+			config: ArrivalDistributionConfig{ArrivalDistribution: null.StringFrom("gaussian")},
+			errors: []string{`invalid arrivalDistribution "gaussian"`},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			errs := tc.config.Validate()
+			require.Len(t, errs, len(tc.errors))
+			for i, err := range errs {
+				assert.Contains(t, err.Error(), tc.errors[i])
+			}
+		})
+	}
+}
+
+func TestArrivalsPoissonCount(t *testing.T) {
+	t.Parallel()
+
+	config := ArrivalDistributionConfig{ArrivalDistribution: null.StringFrom("poisson")}
+	all := config.newArrivals("test")
+	counts := make([]float64, 1000)
+	for i := range counts {
+		counts[i] = all.getCount(int64(i + 1))
+	}
+	assert.True(t, sort.Float64sAreSorted(counts))
+	assert.InDelta(t, 1000, counts[len(counts)-1], 100)
+
+	// The count of a number doesn't depend on the numbers before it.
+	some := config.newArrivals("test")
+	for _, n := range []int64{3, 10, 11, 500, 1000} {
+		assert.Equal(t, counts[n-1], some.getCount(n))
+	}
+
+	// Other scenarios get other counts.
+	assert.NotEqual(t, counts[0], config.newArrivals("other").getCount(1))
+}
+
+func TestArrivalsBurstyTime(t *testing.T) {
+	t.Parallel()
+
+	config := ArrivalDistributionConfig{
+		ArrivalDistribution: null.StringFrom("bursty"),
+		BurstOn:             types.NullDurationFrom(time.Second),
+		BurstOff:            types.NullDurationFrom(3 * time.Second),
+	}
+	a := config.newArrivals("test")
+	for in, out := range map[time.Duration]time.Duration{
+		0:                       0,
+		2 * time.Second:         500 * time.Millisecond,
+		4 * time.Second:         4 * time.Second,
+		6 * time.Second:         4500 * time.Millisecond,
+		7999 * time.Millisecond: 4999750 * time.Microsecond,
+	} {
+		assert.Equal(t, out, a.getTime(in), in)
+	}
+	assert.Equal(t, 4250*time.Millisecond, a.getOffset(time.Second, 5))
+}
+
+func TestRampingArrivalRateCalDistributions(t *testing.T) {
+	t.Parallel()
+
+	getTimes := func(t *testing.T, config RampingArrivalRateConfig, et *lib.ExecutionTuple) []time.Duration {
+		ch := make(chan time.Duration)
+		go config.cal(et, ch)
+		var times []time.Duration
+		for tm := range ch {
+			times = append(times, tm)
+		}
+		return times
+	}
+
+	distributions := map[string]ArrivalDistributionConfig{
+		"poisson": {ArrivalDistribution: null.StringFrom("poisson")},
+		"bursty": {
+			ArrivalDistribution: null.StringFrom("bursty"),
+			BurstOn:             types.NullDurationFrom(time.Second),
+			BurstOff:            types.NullDurationFrom(time.Second),
+		},
+	}
+	for name, distribution := range distributions {
+		name, distribution := name, distribution
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			config := RampingArrivalRateConfig{
+				BaseConfig:                BaseConfig{Name: "test"},
+				TimeUnit:                  types.NullDurationFrom(time.Second),
+				StartRate:                 null.IntFrom(10),
+				ArrivalDistributionConfig: distribution,
+				Stages: []Stage{
+					{Duration: types.NullDurationFrom(10 * time.Second), Target: null.IntFrom(50)},
+					{Duration: types.NullDurationFrom(10 * time.Second), Target: null.IntFrom(50)},
+				},
+			}
+			require.Empty(t, config.ArrivalDistributionConfig.Validate())
+
+			full := getTimes(t, config, mustNewExecutionTuple(nil, nil))
+			assert.Equal(t, full, getTimes(t, config, mustNewExecutionTuple(nil, nil)))
+			assert.True(t, sort.SliceIsSorted(full, func(i, j int) bool { return full[i] < full[j] }))
+			assert.InDelta(t, 800, len(full), 100)
+			for _, tm := range full {
+				assert.LessOrEqual(t, tm, 20*time.Second)
+				if name == "bursty" && tm < 20*time.Second {
+					assert.Less(t, tm%(2*time.Second), time.Second)
+				}
+			}
+
+			// The segments split the iterations between themselves.
+			seq := newExecutionSegmentSequenceFromString("0,1/3,2/3,1")
+			var union []time.Duration
+			for _, segment := range []string{"0:1/3", "1/3:2/3", "2/3:1"} {
+				et := mustNewExecutionTuple(newExecutionSegmentFromString(segment), seq)
+				union = append(union, getTimes(t, config, et)...)
+			}
+			sort.Slice(union, func(i, j int) bool { return union[i] < union[j] })
+			assert.Equal(t, full, union)
+		})
+	}
+}
@@ -27,6 +27,8 @@ type BaseConfig struct {
 	Name         string               `json:"-"` // set via the JS object key
 	Type         string               `json:"executor"`
 	StartTime    types.NullDuration   `json:"startTime"`
+	StartAfter   null.String          `json:"startAfter"` // scenario name, externally validated
+	OnlyIf       []string             `json:"onlyIf"`     // metrics with thresholds, externally validated
 	GracefulStop types.NullDuration   `json:"gracefulStop"`
 	Env          map[string]string    `json:"env"`
 	Exec         null.String          `json:"exec"` // function name, externally validated
@@ -58,6 +60,15 @@ func (bc BaseConfig) Validate() (errors []error) {
 	if bc.Exec.Valid && bc.Exec.String == "" {
 		errors = append(errors, fmt.Errorf("exec value cannot be empty"))
 	}
+	if bc.StartAfter.Valid && bc.StartAfter.String == "" {
+		errors = append(errors, fmt.Errorf("startAfter value cannot be empty"))
+	}
+	for _, metricName := range bc.OnlyIf {
+		if metricName == "" {
+			errors = append(errors, fmt.Errorf("onlyIf values cannot be empty"))
+			break
+		}
+	}
 	if bc.Type == "" {
 		errors = append(errors, fmt.Errorf("missing or empty type field"))
 	}
@@ -82,11 +93,26 @@ func (bc BaseConfig) GetType() string {
 }
 
 // GetStartTime returns the starting time, relative to the beginning of the
-// actual test, that this executor is supposed to execute.
+// actual test, that this executor is supposed to execute. For the executors
+// that start after another scenario, it's relative to the end of the scenario.
 func (bc BaseConfig) GetStartTime() time.Duration {
 	return bc.StartTime.TimeDuration()
 }
 
+// GetStartAfter returns the name of the scenario after whose end this executor
+// is supposed to start, if any. Its start time is then relative to that end,
+// instead of to the beginning of the test.
+func (bc BaseConfig) GetStartAfter() string {
+	return bc.StartAfter.ValueOrZero()
+}
+
+// GetOnlyIf returns the names of the metrics and sub-metrics whose thresholds
+// shouldn't be crossed when the executor is supposed to start. If any of them
+// are, the executor is skipped.
+func (bc BaseConfig) GetOnlyIf() []string {
+	return bc.OnlyIf
+}
+
 // GetGracefulStop returns how long k6 is supposed to wait for any still
 // running iterations to finish executing at the end of the normal executor
 // duration, before it actually kills them.
@@ -133,9 +159,15 @@ func (bc BaseConfig) getBaseInfo(facts ...string) string {
 	if bc.Exec.Valid {
 		facts = append(facts, fmt.Sprintf("exec: %s", bc.Exec.String))
 	}
+	if bc.StartAfter.Valid {
+		facts = append(facts, fmt.Sprintf("startAfter: %s", bc.StartAfter.String))
+	}
 	if bc.StartTime.Duration > 0 {
 		facts = append(facts, fmt.Sprintf("startTime: %s", bc.StartTime.Duration))
 	}
+	if len(bc.OnlyIf) > 0 {
+		facts = append(facts, fmt.Sprintf("onlyIf: %s", strings.Join(bc.OnlyIf, " & ")))
+	}
 	if bc.GracefulStop.Duration > 0 {
 		facts = append(facts, fmt.Sprintf("gracefulStop: %s", bc.GracefulStop.Duration))
 	}
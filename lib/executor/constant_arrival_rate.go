@@ -37,6 +37,7 @@ type ConstantArrivalRateConfig struct {
 	Rate     null.Int           `json:"rate"`
 	TimeUnit types.NullDuration `json:"timeUnit"`
 	Duration types.NullDuration `json:"duration"`
+	ArrivalDistributionConfig
 
 	// Initialize `PreAllocatedVUs` number of VUs, and if more than that are needed,
 	// they will be dynamically allocated, until `MaxVUs` is reached, which is an
@@ -83,8 +84,8 @@ func (carc ConstantArrivalRateConfig) GetDescription(et *lib.ExecutionTuple) str
 		arrRatePerSec, _ = getArrivalRatePerSec(arrRate).Float64()
 	}
 
-	return fmt.Sprintf("%.2f iterations/s for %s%s", arrRatePerSec, carc.Duration.Duration,
-		carc.getBaseInfo(maxVUsRange))
+	return fmt.Sprintf("%.2f iterations/s for %s%s%s", arrRatePerSec, carc.Duration.Duration,
+		carc.ArrivalDistributionConfig.getDescription(), carc.getBaseInfo(maxVUsRange))
 }
 
 // Validate makes sure all options are configured and valid
@@ -100,6 +101,8 @@ func (carc *ConstantArrivalRateConfig) Validate() []error {
 		errors = append(errors, fmt.Errorf("the timeUnit must be more than 0"))
 	}
 
+	errors = append(errors, carc.ArrivalDistributionConfig.Validate()...)
+
 	if !carc.Duration.Valid {
 		errors = append(errors, fmt.Errorf("the duration is unspecified"))
 	} else if carc.Duration.TimeDuration() < minDuration {
@@ -321,11 +324,12 @@ func (car ConstantArrivalRate) Run(parentCtx context.Context, out chan<- metrics
 			int64(car.config.TimeUnit.TimeDuration()),
 		)).TimeDuration()
 
+	arrivals := car.config.newArrivals(car.config.Name)
 	droppedIterationMetric := car.executionState.Test.BuiltinMetrics.DroppedIterations
 	shownWarning := false
 	metricTags := car.getMetricTags(nil)
 	for li, gi := 0, start; ; li, gi = li+1, gi+offsets[li%len(offsets)] {
-		t := notScaledTickerPeriod*time.Duration(gi) - time.Since(startTime)
+		t := arrivals.getOffset(notScaledTickerPeriod, gi) - time.Since(startTime)
 		timer.Reset(t)
 		select {
 		case <-timer.C:
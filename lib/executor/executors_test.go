@@ -189,7 +189,32 @@ var configMapTestCases = []configMapTestCase{
 			assert.Equal(t, uint64(11), lib.GetMaxPossibleVUs(schedReqs))
 		}},
 	},
+	{
+		`{"varloops": {"executor": "ramping-vus", "startVUs": 1, "gracefulStop": "0s", "gracefulRampDown": "0s",
+			"stages": [{"duration": "10s", "target": 5, "easing": "step"}, {"duration": "10s", "target": 20, "easing": "sine", "cycles": 1},
+				{"duration": "5s", "target": 30, "easing": "spike"}, {"duration": "10s", "target": 0, "easing": "exponential"}]}}`,
+		exp{custom: func(t *testing.T, cm lib.ScenarioConfigs) {
+			assert.Empty(t, cm["varloops"].Validate())
+			assert.Empty(t, cm.Validate())
+
+			et, err := lib.NewExecutionTuple(nil, nil)
+			require.NoError(t, err)
+			assert.Equal(t, "Up to 30 looping VUs for 35s over 4 stages (gracefulRampDown: 0s)", cm["varloops"].GetDescription(et))
+
+			schedReqs := cm.GetFullExecutionRequirements(et)
+			endOffset, isFinal := lib.GetEndOffset(schedReqs)
+			assert.Equal(t, 35*time.Second, endOffset)
+			assert.Equal(t, true, isFinal)
+			assert.Equal(t, uint64(30), lib.GetMaxPlannedVUs(schedReqs))
+			assert.Equal(t, uint64(30), lib.GetMaxPossibleVUs(schedReqs))
+		}},
+	},
 	{`{"varloops": {"executor": "ramping-vus", "startVUs": 0, "stages": [{"duration": "60s", "target": 0}]}}`, exp{validationError: true}},
+	{`{"varloops": {"executor": "ramping-vus", "stages": [{"duration": "60s", "target": 30, "easing": "cubic"}]}}`, exp{validationError: true}},
+	{`{"varloops": {"executor": "ramping-vus", "stages": [{"duration": "60s", "target": 30, "cycles": 2}]}}`, exp{validationError: true}},
+	{`{"varloops": {"executor": "ramping-vus", "stages": [{"duration": "60s", "target": 30, "easing": "sine", "cycles": -1}]}}`, exp{validationError: true}},
+	{`{"varloops": {"executor": "ramping-vus", "stages": [{"duration": "60s", "target": 30, "easing": "sine", "cycles": 1000000000}]}}`, exp{validationError: true}},
+	{`{"varloops": {"executor": "ramping-vus", "stages": [{"duration": "0s", "target": 30, "easing": "spike"}]}}`, exp{validationError: true}},
 	{`{"varloops": {"executor": "ramping-vus", "startVUs": -1, "stages": [{"duration": "60s", "target": 30}]}}`, exp{validationError: true}},
 	{`{"varloops": {"executor": "ramping-vus", "startVUs": 2, "stages": [{"duration": "-60s", "target": 30}]}}`, exp{validationError: true}},
 	{`{"varloops": {"executor": "ramping-vus", "startVUs": 2, "stages": [{"duration": "60s", "target": -30}]}}`, exp{validationError: true}},
@@ -355,6 +380,20 @@ var configMapTestCases = []configMapTestCase{
 	{`{"carrival": {"executor": "constant-arrival-rate", "rate": 10, "duration": "10m", "preAllocatedVUs": 20, "maxVUs": 15}}`, exp{validationError: true}},
 	{`{"carrival": {"executor": "constant-arrival-rate", "rate": 10, "duration": "0s", "preAllocatedVUs": 20, "maxVUs": 25}}`, exp{validationError: true}},
 	{`{"carrival": {"executor": "constant-arrival-rate", "rate": 10, "duration": "10m", "preAllocatedVUs": -2, "maxVUs": 25}}`, exp{validationError: true}},
+	{
+		`{"carrival": {"executor": "constant-arrival-rate", "rate": 10, "duration": "10m", "preAllocatedVUs": 20,
+		"arrivalDistribution": "bursty", "burstOn": "10s", "burstOff": "50s"}}`,
+		exp{custom: func(t *testing.T, cm lib.ScenarioConfigs) {
+			assert.Empty(t, cm["carrival"].Validate())
+			et, err := lib.NewExecutionTuple(nil, nil)
+			require.NoError(t, err)
+			assert.Equal(t, "10.00 iterations/s for 10m0s in bursts of 10s every 1m0s (maxVUs: 20, gracefulStop: 30s)",
+				cm["carrival"].GetDescription(et))
+		}},
+	},
+	{`{"carrival": {"executor": "constant-arrival-rate", "rate": 10, "duration": "10m", "preAllocatedVUs": 20, "arrivalDistribution": "exponential"}}`, exp{}},
+	{`{"carrival": {"executor": "constant-arrival-rate", "rate": 10, "duration": "10m", "preAllocatedVUs": 20, "arrivalDistribution": "bursty", "burstOn": "10s"}}`, exp{validationError: true}},
+	{`{"carrival": {"executor": "constant-arrival-rate", "rate": 10, "duration": "10m", "preAllocatedVUs": 20, "arrivalDistribution": "random"}}`, exp{validationError: true}},
 	// ramping-arrival-rate
 	{
 		`{"varrival": {"executor": "ramping-arrival-rate", "startRate": 10, "timeUnit": "30s", "preAllocatedVUs": 20,
@@ -402,8 +441,159 @@ var configMapTestCases = []configMapTestCase{
 	{`{"varrival": {"executor": "ramping-arrival-rate", "maxVUs": 50, "stages": [{"duration": "5m", "target": 10}]}}`, exp{validationError: true}},
 	{`{"varrival": {"executor": "ramping-arrival-rate", "preAllocatedVUs": 20, "maxVUs": 50}}`, exp{validationError: true}},
 	{`{"varrival": {"executor": "ramping-arrival-rate", "preAllocatedVUs": 20, "maxVUs": 50, "stages": []}}`, exp{validationError: true}},
+	{`{"varrival": {"executor": "ramping-arrival-rate", "preAllocatedVUs": 20, "maxVUs": 50, "stages": [{"duration": "5m", "target": 10, "easing": "sine", "cycles": 3}]}}`, exp{}},
+	{`{"varrival": {"executor": "ramping-arrival-rate", "preAllocatedVUs": 20, "maxVUs": 50, "stages": [{"duration": "5m", "target": 10, "easing": "sawtooth"}]}}`, exp{validationError: true}},
 	{`{"varrival": {"executor": "ramping-arrival-rate", "preAllocatedVUs": 20, "maxVUs": 50, "stages": [{"duration": "5m", "target": 10}], "timeUnit": "-1s"}}`, exp{validationError: true}},
 	{`{"varrival": {"executor": "ramping-arrival-rate", "preAllocatedVUs": 30, "maxVUs": 20, "stages": [{"duration": "5m", "target": 10}]}}`, exp{validationError: true}},
+	{
+		`{"varrival": {"executor": "ramping-arrival-rate", "preAllocatedVUs": 20, "stages": [{"duration": "5m", "target": 10}], "arrivalDistribution": "poisson"}}`,
+		exp{custom: func(t *testing.T, cm lib.ScenarioConfigs) {
+			assert.Empty(t, cm["varrival"].Validate())
+			et, err := lib.NewExecutionTuple(nil, nil)
+			require.NoError(t, err)
+			assert.Equal(t, "Up to 10.00 iterations/s for 5m0s over 1 stages with poisson arrivals (maxVUs: 20, gracefulStop: 30s)",
+				cm["varrival"].GetDescription(et))
+		}},
+	},
+	{`{"varrival": {"executor": "ramping-arrival-rate", "preAllocatedVUs": 20, "stages": [{"duration": "5m", "target": 10}], "burstOff": "1s"}}`, exp{validationError: true}},
+	// adaptive-arrival-rate
+	{
+		`{"adaptive": {"executor": "adaptive-arrival-rate", "startRate": 10, "rateIncrement": 5, "stepDuration": "10s",
+		"maxDuration": "5m", "latencyTarget": "500ms", "preAllocatedVUs": 20, "maxVUs": 50}}`,
+		exp{custom: func(t *testing.T, cm lib.ScenarioConfigs) {
+			assert.Empty(t, cm.Validate())
+			et, err := lib.NewExecutionTuple(nil, nil)
+			require.NoError(t, err)
+			assert.Equal(t, "Adaptive from 10.00 iterations/s in steps of 10s for up to 5m0s (maxVUs: 20-50, gracefulStop: 30s)",
+				cm["adaptive"].GetDescription(et))
+
+			schedReqs := cm["adaptive"].GetExecutionRequirements(et)
+			endOffset, isFinal := lib.GetEndOffset(schedReqs)
+			assert.Equal(t, 330*time.Second, endOffset)
+			assert.True(t, isFinal)
+			assert.Equal(t, uint64(20), lib.GetMaxPlannedVUs(schedReqs))
+			assert.Equal(t, uint64(50), lib.GetMaxPossibleVUs(schedReqs))
+		}},
+	},
+	{`{"adaptive": {"executor": "adaptive-arrival-rate", "startRate": 10, "maxDuration": "5m", "errorRateTarget": 0.01, "preAllocatedVUs": 20}}`, exp{}},
+	{`{"adaptive": {"executor": "adaptive-arrival-rate", "maxDuration": "5m", "errorRateTarget": 0.01, "preAllocatedVUs": 20}}`, exp{validationError: true}},
+	{`{"adaptive": {"executor": "adaptive-arrival-rate", "startRate": 10, "errorRateTarget": 0.01, "preAllocatedVUs": 20}}`, exp{validationError: true}},
+	{`{"adaptive": {"executor": "adaptive-arrival-rate", "startRate": 10, "maxDuration": "5m", "preAllocatedVUs": 20}}`, exp{validationError: true}},
+	{`{"adaptive": {"executor": "adaptive-arrival-rate", "startRate": 10, "maxDuration": "10s", "errorRateTarget": 0.01, "preAllocatedVUs": 20}}`, exp{validationError: true}},
+	{`{"adaptive": {"executor": "adaptive-arrival-rate", "startRate": 10, "maxRate": 5, "maxDuration": "5m", "errorRateTarget": 0.01, "preAllocatedVUs": 20}}`, exp{validationError: true}},
+	{`{"adaptive": {"executor": "adaptive-arrival-rate", "startRate": 10, "maxDuration": "5m", "errorRateTarget": 2, "preAllocatedVUs": 20}}`, exp{validationError: true}},
+	{`{"adaptive": {"executor": "adaptive-arrival-rate", "startRate": 10, "maxDuration": "5m", "latencyTarget": "1s", "latencyPercentile": 0, "preAllocatedVUs": 20}}`, exp{validationError: true}},
+	// trace-driven
+	{
+		`{"trace": {"executor": "trace-driven", "file": "./logs/access.ndjson", "timeScale": 0.5, "preAllocatedVUs": 20, "maxVUs": 30}}`,
+		exp{custom: func(t *testing.T, cm lib.ScenarioConfigs) {
+			sched := NewTraceDrivenConfig("trace")
+			sched.File = null.StringFrom("./logs/access.ndjson")
+			sched.TimeScale = null.FloatFrom(0.5)
+			sched.PreAllocatedVUs = null.IntFrom(20)
+			sched.MaxVUs = null.IntFrom(30)
+			require.Equal(t, cm, lib.ScenarioConfigs{"trace": sched})
+
+			assert.Empty(t, cm["trace"].Validate())
+			assert.Equal(t, traceFormatJSONL, sched.GetFormat())
+		}},
+	},
+	{
+		`{"trace": {"executor": "trace-driven", "file": "trace.csv", "preAllocatedVUs": 20}}`,
+		exp{custom: func(t *testing.T, cm lib.ScenarioConfigs) {
+			assert.Empty(t, cm["trace"].Validate())
+			require.EqualValues(t, 20, cm["trace"].(*TraceDrivenConfig).MaxVUs.Int64)
+		}},
+	},
+	{`{"trace": {"executor": "trace-driven", "file": "trace.log", "format": "csv", "timestampField": "time", "preAllocatedVUs": 20, "maxVUs": 30}}`, exp{}},
+	{`{"trace": {"executor": "trace-driven", "preAllocatedVUs": 20}}`, exp{validationError: true}},
+	{`{"trace": {"executor": "trace-driven", "file": "trace.log", "preAllocatedVUs": 20}}`, exp{validationError: true}},
+	{`{"trace": {"executor": "trace-driven", "file": "trace.csv", "format": "xml", "preAllocatedVUs": 20}}`, exp{validationError: true}},
+	{`{"trace": {"executor": "trace-driven", "file": "trace.csv", "timeScale": 0, "preAllocatedVUs": 20}}`, exp{validationError: true}},
+	{`{"trace": {"executor": "trace-driven", "file": "trace.csv"}}`, exp{validationError: true}},
+	{`{"trace": {"executor": "trace-driven", "file": "trace.csv", "preAllocatedVUs": 20, "maxVUs": 10}}`, exp{validationError: true}},
+	{`{"adaptive": {"executor": "adaptive-arrival-rate", "startRate": 10, "maxDuration": "5m", "errorRateTarget": 0.01}}`, exp{validationError: true}},
+	// scenario dependencies
+	{
+		`{"warmup": {"executor": "constant-vus", "vus": 5, "duration": "10s", "gracefulStop": "5s"},
+		  "main": {"executor": "constant-vus", "vus": 20, "duration": "30s", "startAfter": "warmup", "startTime": "5s",
+		    "onlyIf": ["http_req_failed{scenario:warmup}"]},
+		  "a-cooldown": {"executor": "shared-iterations", "vus": 2, "iterations": 2, "maxDuration": "10s", "startAfter": "main"}}`,
+		exp{custom: func(t *testing.T, cm lib.ScenarioConfigs) {
+			assert.Equal(t, "warmup", cm["main"].GetStartAfter())
+			assert.Equal(t, []string{"http_req_failed{scenario:warmup}"}, cm["main"].GetOnlyIf())
+
+			et, err := lib.NewExecutionTuple(nil, nil)
+			require.NoError(t, err)
+			assert.Equal(t, "20 looping VUs for 30s (startAfter: warmup, startTime: 5s, "+
+				"onlyIf: http_req_failed{scenario:warmup}, gracefulStop: 30s)", cm["main"].GetDescription(et))
+
+			assert.Equal(t, map[string]time.Duration{
+				"warmup":     0,
+				"main":       20 * time.Second,
+				"a-cooldown": 80 * time.Second,
+			}, cm.GetPlannedStartTimes(et))
+
+			var names []string
+			for _, config := range cm.GetSortedConfigs() {
+				names = append(names, config.GetName())
+			}
+			assert.Equal(t, []string{"warmup", "main", "a-cooldown"}, names)
+
+			totalReqs := cm.GetFullExecutionRequirements(et)
+			endOffset, isFinal := lib.GetEndOffset(totalReqs)
+			assert.Equal(t, 120*time.Second, endOffset)
+			assert.True(t, isFinal)
+			// main and a-cooldown could start as soon as warmup starts
+			assert.Equal(t, uint64(27), lib.GetMaxPlannedVUs(totalReqs))
+		}},
+	},
+	{
+		`{"first": {"executor": "shared-iterations", "vus": 2, "iterations": 2, "maxDuration": "10s"},
+		  "second": {"executor": "constant-vus", "vus": 3, "duration": "5s", "startAfter": "first"},
+		  "fixed": {"executor": "constant-vus", "vus": 4, "duration": "5s", "startTime": "2s"}}`,
+		exp{custom: func(t *testing.T, cm lib.ScenarioConfigs) {
+			assert.Empty(t, cm.Validate())
+			assert.Equal(t, map[string]time.Duration{"first": 0, "second": 0, "fixed": 2 * time.Second},
+				cm.GetEarliestStartTimes())
+
+			// first can end right away, so second can run at the same time as fixed
+			et, err := lib.NewExecutionTuple(nil, nil)
+			require.NoError(t, err)
+			assert.Equal(t, []lib.ExecutionStep{
+				{TimeOffset: 0, PlannedVUs: 2},
+				{TimeOffset: 0, PlannedVUs: 5},
+				{TimeOffset: 2 * time.Second, PlannedVUs: 9},
+				{TimeOffset: 37 * time.Second, PlannedVUs: 5},
+				{TimeOffset: 40 * time.Second, PlannedVUs: 3},
+				{TimeOffset: 75 * time.Second, PlannedVUs: 0},
+			}, cm.GetFullExecutionRequirements(et))
+		}},
+	},
+	{`{"main": {"executor": "constant-vus", "vus": 1, "duration": "1s", "startAfter": "warmup"}}`, exp{validationError: true}},
+	{`{"main": {"executor": "constant-vus", "vus": 1, "duration": "1s", "startAfter": "main"}}`, exp{validationError: true}},
+	{`{"main": {"executor": "constant-vus", "vus": 1, "duration": "1s", "startAfter": ""}}`, exp{validationError: true}},
+	{`{"main": {"executor": "constant-vus", "vus": 1, "duration": "1s", "onlyIf": [""]}}`, exp{validationError: true}},
+	{
+		`{"a": {"executor": "constant-vus", "vus": 1, "duration": "1s", "startAfter": "b"},
+		  "b": {"executor": "constant-vus", "vus": 1, "duration": "1s", "startAfter": "a"}}`,
+		exp{validationError: true, custom: func(t *testing.T, cm lib.ScenarioConfigs) {
+			errs := cm.Validate()
+			require.Len(t, errs, 2)
+			assert.EqualError(t, errs[0], "scenario a can't start after itself: a -> b -> a")
+			assert.EqualError(t, errs[1], "scenario b can't start after itself: b -> a -> b")
+		}},
+	},
+	{
+		`{"manual": {"executor": "externally-controlled", "vus": 1, "duration": "0s"},
+		  "main": {"executor": "constant-vus", "vus": 1, "duration": "1s", "startAfter": "manual"}}`,
+		exp{validationError: true},
+	},
+	{
+		`{"main": {"executor": "constant-vus", "vus": 1, "duration": "1s"},
+		  "manual": {"executor": "externally-controlled", "vus": 1, "duration": "1m", "startAfter": "main"}}`,
+		exp{validationError: true},
+	},
 	// TODO: more tests of mixed executors and execution plans
 
 	// scenario options
@@ -449,6 +639,25 @@ func TestConfigMapParsingAndValidation(t *testing.T) {
 	}
 }
 
+func TestScenarioOnlyIfValidation(t *testing.T) {
+	t.Parallel()
+
+	var opts lib.Options
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"thresholds": {"http_req_failed{scenario:warmup}": ["rate<0.01"]},
+		"scenarios": {
+			"warmup": {"executor": "constant-vus", "vus": 1, "duration": "10s"},
+			"main": {"executor": "constant-vus", "vus": 10, "duration": "1m", "startAfter": "warmup",
+				"onlyIf": ["http_req_failed{scenario:warmup}", "http_req_duration"]}
+		}
+	}`), &opts))
+
+	errs := opts.Validate()
+	require.Len(t, errs, 1)
+	assert.EqualError(t, errs[0],
+		"scenario main has an onlyIf condition on 'http_req_duration', which doesn't have any thresholds")
+}
+
 // Test that the executor configuration is properly written into an archive, and
 // then read back. The reason this test is not in lib/archive_test.go is to avoid
 // an import cycle (lib -> lib/executor -> lib), since we need to import a
@@ -100,6 +100,11 @@ func (mec ExternallyControlledConfig) Validate() []error {
 			"gracefulStop is not supported by the externally controlled executor",
 		))
 	}
+	if mec.StartAfter.Valid {
+		errors = append(errors, fmt.Errorf(
+			"startAfter is not supported by the externally controlled executor",
+		))
+	}
 	return errors
 }
 
@@ -53,6 +53,7 @@ func validateStages(stages []Stage) []error {
 		} else if s.Target.Int64 < 0 {
 			errors = append(errors, fmt.Errorf("the target for stage %d can't be negative", stageNum))
 		}
+		errors = append(errors, validateStageEasing(stageNum, s)...)
 	}
 	return errors
 }
@@ -37,6 +37,7 @@ type RampingArrivalRateConfig struct {
 	StartRate null.Int           `json:"startRate"`
 	TimeUnit  types.NullDuration `json:"timeUnit"`
 	Stages    []Stage            `json:"stages"`
+	ArrivalDistributionConfig
 
 	// Initialize `PreAllocatedVUs` number of VUs, and if more than that are needed,
 	// they will be dynamically allocated, until `MaxVUs` is reached, which is an
@@ -78,9 +79,9 @@ func (varc RampingArrivalRateConfig) GetDescription(et *lib.ExecutionTuple) stri
 		getScaledArrivalRate(et.Segment, maxUnscaledRate, varc.TimeUnit.TimeDuration()),
 	).Float64()
 
-	return fmt.Sprintf("Up to %.2f iterations/s for %s over %d stages%s",
-		maxArrRatePerSec, sumStagesDuration(varc.Stages),
-		len(varc.Stages), varc.getBaseInfo(maxVUsRange))
+	return fmt.Sprintf("Up to %.2f iterations/s for %s over %d stages%s%s",
+		maxArrRatePerSec, sumStagesDuration(varc.Stages), len(varc.Stages),
+		varc.ArrivalDistributionConfig.getDescription(), varc.getBaseInfo(maxVUsRange))
 }
 
 // Validate makes sure all options are configured and valid
@@ -96,6 +97,7 @@ func (varc *RampingArrivalRateConfig) Validate() []error {
 	}
 
 	errors = append(errors, validateStages(varc.Stages)...)
+	errors = append(errors, varc.ArrivalDistributionConfig.Validate()...)
 
 	if !varc.PreAllocatedVUs.Valid {
 		errors = append(errors, fmt.Errorf("the number of preAllocatedVUs isn't specified"))
@@ -240,36 +242,54 @@ func (varc RampingArrivalRateConfig) cal(et *lib.ExecutionTuple, ch chan<- time.
 		return offsets[li%len(offsets)]
 	}
 	defer close(ch) // TODO: maybe this is not a good design - closing a channel we get
+	arrivals := varc.newArrivals(varc.Name)
 	var (
-		stageStart                   time.Duration
-		timeUnit                     = float64(varc.TimeUnit.Duration)
-		doneSoFar, endCount, to, dur float64
-		from                         = float64(varc.StartRate.ValueOrZero()) / timeUnit
+		stageStart                         time.Duration
+		timeUnit                           = float64(varc.TimeUnit.Duration)
+		doneSoFar, endCount, from, to, dur float64
 		// start .. starts at 0 but the algorithm works with area so we need to start from 1 not 0
-		i = float64(start + 1)
+		i = start + 1
+		// c is the count of i, which is i itself unless the arrivals are poisson
+		c = arrivals.getCount(i)
 	)
+	advance := func() {
+		i += next()
+		c = arrivals.getCount(i)
+	}
 
-	for _, stage := range varc.Stages {
-		to = float64(stage.Target.ValueOrZero()) / timeUnit
-		dur = float64(stage.Duration.Duration)
-		if from != to { // ramp up/down
+	// The stages are split into pieces with a single easing curve, the linear
+	// stages are a single piece each
+	for _, piece := range getRampPieces(varc.StartRate.ValueOrZero(), varc.Stages) {
+		from = float64(piece.from) / timeUnit
+		to = float64(piece.to) / timeUnit
+		dur = float64(piece.duration)
+		switch {
+		case from == to:
+			endCount += dur * to
+			for ; c <= endCount; advance() {
+				ch <- arrivals.getTime(time.Duration((c-doneSoFar)/to) + stageStart)
+			}
+		case piece.curve == curveLinear: // ramp up/down
 			endCount += dur * ((to-from)/2 + from)
-			for ; i <= endCount; i += float64(next()) {
+			for ; c <= endCount; advance() {
 				// TODO: try to twist this in a way to be able to get i (the only changing part)
 				// somewhere where it is less in the middle of the equation
-				x := (from*dur - noNegativeSqrt(dur*(from*from*dur+2*(i-doneSoFar)*(to-from)))) / (from - to)
+				x := (from*dur - noNegativeSqrt(dur*(from*from*dur+2*(c-doneSoFar)*(to-from)))) / (from - to)
 
-				ch <- time.Duration(x) + stageStart
+				ch <- arrivals.getTime(time.Duration(x) + stageStart)
 			}
-		} else {
-			endCount += dur * to
-			for ; i <= endCount; i += float64(next()) {
-				ch <- time.Duration((i-doneSoFar)/to) + stageStart
+		default: // eased ramp up/down, there's no closed form for it
+			area := func(x float64) float64 {
+				return dur * (from*x + (to-from)*piece.curve.integral(x))
+			}
+			endCount += area(1)
+			for ; c <= endCount; advance() {
+				x := solveIncreasing(area, c-doneSoFar)
+				ch <- arrivals.getTime(time.Duration(x*dur) + stageStart)
 			}
 		}
 		doneSoFar = endCount
-		from = to
-		stageStart += stage.Duration.TimeDuration()
+		stageStart += piece.duration
 	}
 }
 
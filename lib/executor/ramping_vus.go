@@ -33,7 +33,11 @@ func init() {
 type Stage struct {
 	Duration types.NullDuration `json:"duration"`
 	Target   null.Int           `json:"target"` // TODO: maybe rename this to endVUs? something else?
-	// TODO: add a progression function?
+	// Easing is how the value changes during the stage, see getRampPieces().
+	Easing null.String `json:"easing"`
+	// Cycles is the number of times the sine easing goes back and forth, which
+	// is limited by the duration of the stage, see getMaxSineCycles().
+	Cycles null.Int `json:"cycles"`
 }
 
 // RampingVUsConfig stores the configuration for the stages executor
@@ -168,13 +172,13 @@ func (vlvc RampingVUsConfig) Validate() []error {
 func (vlvc RampingVUsConfig) getRawExecutionSteps(et *lib.ExecutionTuple, zeroEnd bool) []lib.ExecutionStep {
 	var (
 		timeTillEnd time.Duration
-		fromVUs     = vlvc.StartVUs.Int64
-		steps       = make([]lib.ExecutionStep, 0, vlvc.precalculateTheRequiredSteps(et, zeroEnd))
+		pieces      = getRampPieces(vlvc.StartVUs.Int64, vlvc.Stages)
+		steps       = make([]lib.ExecutionStep, 0, vlvc.precalculateTheRequiredSteps(et, pieces, zeroEnd))
 		index       = lib.NewSegmentedIndex(et)
 	)
 
 	// Reserve the scaled StartVUs at the beginning
-	scaled, unscaled := index.GoTo(fromVUs)
+	scaled, unscaled := index.GoTo(vlvc.StartVUs.Int64)
 	steps = append(steps, lib.ExecutionStep{TimeOffset: 0, PlannedVUs: uint64(scaled)})
 	addStep := func(timeOffset time.Duration, plannedVUs uint64) {
 		if steps[len(steps)-1].PlannedVUs != plannedVUs {
@@ -182,45 +186,43 @@ func (vlvc RampingVUsConfig) getRawExecutionSteps(et *lib.ExecutionTuple, zeroEn
 		}
 	}
 
-	for _, stage := range vlvc.Stages {
-		stageEndVUs := stage.Target.Int64
-		stageDuration := stage.Duration.TimeDuration()
-		timeTillEnd += stageDuration
+	// The stages are split into pieces with a single easing curve, the
+	// linear stages are a single piece each
+	for _, piece := range pieces {
+		pieceStart := timeTillEnd
+		timeTillEnd += piece.duration
 
-		stageVUDiff := stageEndVUs - fromVUs
-		if stageVUDiff == 0 {
+		if piece.to == piece.from {
 			continue
 		}
-		if stageDuration == 0 {
-			scaled, unscaled = index.GoTo(stageEndVUs)
+		if piece.duration == 0 {
+			scaled, unscaled = index.GoTo(piece.to)
 			addStep(timeTillEnd, uint64(scaled))
-			fromVUs = stageEndVUs
 			continue
 		}
 
 		// VU reservation for gracefully ramping down is handled as a
 		// separate method: reserveVUsForGracefulRampDowns()
-		if unscaled > stageEndVUs { // ramp down
-			// here we don't want to emit for the equal to stageEndVUs as it doesn't go below it
+		if unscaled > piece.to { // ramp down
+			// here we don't want to emit for the equal to piece.to as it doesn't go below it
 			// it will just go to it
-			for ; unscaled > stageEndVUs; scaled, unscaled = index.Prev() {
+			for ; unscaled > piece.to; scaled, unscaled = index.Prev() {
 				addStep(
 					// this is the time that we should go up 1 if we are ramping up
 					// but we are ramping down so we should go 1 down, but because we want to not
 					// stop VUs immediately we stop it on the next unscaled VU's time
-					timeTillEnd-time.Duration(int64(stageDuration)*(stageEndVUs-unscaled+1)/stageVUDiff),
+					pieceStart+piece.getTimeOf(unscaled-1),
 					uint64(scaled-1),
 				)
 			}
 		} else {
-			for ; unscaled <= stageEndVUs; scaled, unscaled = index.Next() {
+			for ; unscaled <= piece.to; scaled, unscaled = index.Next() {
 				addStep(
-					timeTillEnd-time.Duration(int64(stageDuration)*(stageEndVUs-unscaled)/stageVUDiff),
+					pieceStart+piece.getTimeOf(unscaled),
 					uint64(scaled),
 				)
 			}
 		}
-		fromVUs = stageEndVUs
 	}
 
 	if zeroEnd && steps[len(steps)-1].PlannedVUs != 0 {
@@ -237,7 +239,9 @@ func absInt64(a int64) int64 {
 	return a
 }
 
-func (vlvc RampingVUsConfig) precalculateTheRequiredSteps(et *lib.ExecutionTuple, zeroEnd bool) int {
+func (vlvc RampingVUsConfig) precalculateTheRequiredSteps(
+	et *lib.ExecutionTuple, pieces []rampPiece, zeroEnd bool,
+) int {
 	p := et.ScaleInt64(vlvc.StartVUs.Int64)
 	var result int64
 	result++ // for the first one
@@ -245,14 +249,14 @@ func (vlvc RampingVUsConfig) precalculateTheRequiredSteps(et *lib.ExecutionTuple
 	if zeroEnd {
 		result++ // for the last one - this one can be more then needed
 	}
-	for _, stage := range vlvc.Stages {
-		stageEndVUs := et.ScaleInt64(stage.Target.Int64)
-		if stage.Duration.Duration == 0 {
+	for _, piece := range pieces {
+		pieceEndVUs := et.ScaleInt64(piece.to)
+		if piece.duration == 0 {
 			result++
 		} else {
-			result += absInt64(p - stageEndVUs)
+			result += absInt64(p - pieceEndVUs)
 		}
-		p = stageEndVUs
+		p = pieceEndVUs
 	}
 	return int(result)
 }
@@ -0,0 +1,186 @@
+package executor
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// The easing modes of the stages, i.e. how the number of VUs or the iteration
+// rate changes during a stage. By default, it changes linearly from the value
+// at the start of the stage to the stage's target.
+const (
+	easingLinear      = "linear"
+	easingStep        = "step"
+	easingExponential = "exponential"
+	easingSine        = "sine"
+	easingSpike       = "spike"
+)
+
+// validateStageEasing makes sure the easing of the stage with the given number
+// is valid.
+func validateStageEasing(stageNum int, stage Stage) (errors []error) {
+	switch stage.Easing.String {
+	case "", easingLinear, easingStep, easingExponential, easingSine:
+	case easingSpike:
+		if stage.Duration.Valid && stage.Duration.Duration <= 0 {
+			errors = append(errors, fmt.Errorf("the duration of the spike stage %d must be more than 0", stageNum))
+		}
+	default:
+		errors = append(errors, fmt.Errorf(
+			"invalid easing %q for stage %d, it should be one of %s, %s, %s, %s or %s",
+			stage.Easing.String, stageNum, easingLinear, easingStep, easingExponential, easingSine, easingSpike,
+		))
+	}
+	if stage.Cycles.Valid {
+		if stage.Easing.String != easingSine {
+			errors = append(errors, fmt.Errorf("the cycles of stage %d can only be used with the sine easing", stageNum))
+		} else if stage.Cycles.Int64 < 0 {
+			errors = append(errors, fmt.Errorf("the cycles of stage %d can't be negative", stageNum))
+		} else if maxCycles := getMaxSineCycles(stage.Duration.TimeDuration()); stage.Cycles.Int64 > maxCycles {
+			// every cycle adds two half-waves to the first one, which all
+			// have to be long enough for the VUs or the rate to follow them
+			errors = append(errors, fmt.Errorf(
+				"the stage %d can have at most %d cycles, since each half-wave must be at least %s long",
+				stageNum, maxCycles, minDuration,
+			))
+		}
+	}
+	return errors
+}
+
+// getMaxSineCycles returns the most cycles a sine stage with the given
+// duration can have, so that its 2*cycles+1 half-waves are at least
+// minDuration long.
+func getMaxSineCycles(duration time.Duration) int64 {
+	halfWaves := int64(duration / minDuration)
+	if halfWaves < 1 {
+		return 0
+	}
+	return (halfWaves - 1) / 2
+}
+
+// easingCurve is the shape of the change of the value during a ramp piece. The
+// curves are normalized, i.e. they go from 0 to 1 as the time goes from 0 to 1.
+type easingCurve int
+
+const (
+	curveLinear easingCurve = iota
+	// curveExponential doubles the distance from the start every 1/10 of the
+	// time, so it starts slowly and ends steeply.
+	curveExponential
+	// curveSine is half of a cosine wave, so it starts and ends slowly.
+	curveSine
+)
+
+// inverse returns the fraction of the time at which the curve reaches y.
+func (c easingCurve) inverse(y float64) float64 {
+	y = math.Max(0, math.Min(1, y))
+	switch c {
+	case curveExponential:
+		return math.Log2(1+1023*y) / 10
+	case curveSine:
+		return math.Acos(1-2*y) / math.Pi
+	default:
+		return y
+	}
+}
+
+// integral returns the area under the curve from 0 to x.
+func (c easingCurve) integral(x float64) float64 {
+	switch c {
+	case curveExponential:
+		return ((math.Exp2(10*x)-1)/(10*math.Ln2) - x) / 1023
+	case curveSine:
+		return (x - math.Sin(math.Pi*x)/math.Pi) / 2
+	default:
+		return x * x / 2
+	}
+}
+
+// rampPiece is a part of the stages during which the value changes from `from`
+// to `to` along a single curve, so it only grows or only shrinks. The pieces
+// with a 0 duration are abrupt changes of the value.
+type rampPiece struct {
+	from, to int64
+	duration time.Duration
+	curve    easingCurve
+}
+
+// getTimeOf returns the time, since the start of the piece, at which the value
+// reaches v. The value has to be between `from` and `to`, which have to
+// differ. For the linear pieces, it's calculated with integers, so the
+// results are exact.
+func (p rampPiece) getTimeOf(v int64) time.Duration {
+	diff := p.to - p.from
+	if p.curve == curveLinear {
+		return p.duration - time.Duration(int64(p.duration)*(p.to-v)/diff)
+	}
+	return time.Duration(float64(p.duration) * p.curve.inverse(float64(v-p.from)/float64(diff)))
+}
+
+// getRampPieces splits the stages into the pieces during which the value
+// changes in a single direction, along a single curve. The value starts at
+// startValue, and every stage ends at its target, except for the spikes:
+//
+//   - linear: the value changes evenly over the stage.
+//   - step: the value jumps to the target at the start of the stage.
+//   - exponential: the change starts slowly and speeds up exponentially.
+//   - sine: the value follows half of a cosine wave, e.g. for daily patterns.
+//     With some cycles, it goes back and forth between the value at the start
+//     of the stage and the target that many times before ending at the target.
+//   - spike: the value jumps to the target at the start of the stage and back
+//     to the value before it at its end.
+//
+// All of the curves stay between the value at the start of the stage and its
+// target, so the maximum value is still the maximum of the targets.
+func getRampPieces(startValue int64, stages []Stage) []rampPiece {
+	pieces := make([]rampPiece, 0, len(stages))
+	from := startValue
+	for _, stage := range stages {
+		to, duration := stage.Target.Int64, stage.Duration.TimeDuration()
+		switch stage.Easing.String {
+		case easingStep:
+			pieces = append(pieces, rampPiece{from: from, to: to}, rampPiece{from: to, to: to, duration: duration})
+		case easingSpike:
+			pieces = append(pieces,
+				rampPiece{from: from, to: to}, rampPiece{from: to, to: to, duration: duration}, rampPiece{from: to, to: from})
+			to = from
+		case easingExponential:
+			pieces = append(pieces, rampPiece{from: from, to: to, duration: duration, curve: curveExponential})
+		case easingSine:
+			halfWaves := 2*stage.Cycles.Int64 + 1
+			for i := int64(0); i < halfWaves; i++ {
+				piece := rampPiece{
+					from: from, to: to, curve: curveSine,
+					duration: duration*time.Duration(i+1)/time.Duration(halfWaves) -
+						duration*time.Duration(i)/time.Duration(halfWaves),
+				}
+				if i%2 == 1 {
+					piece.from, piece.to = to, from
+				}
+				pieces = append(pieces, piece)
+			}
+		default:
+			pieces = append(pieces, rampPiece{from: from, to: to, duration: duration})
+		}
+		from = to
+	}
+	return pieces
+}
+
+// solveIncreasing returns the x between 0 and 1 for which the increasing
+// function f is equal to y. It bisects the interval a fixed number of times,
+// so the results are always the same for the same arguments.
+func solveIncreasing(f func(x float64) float64, y float64) float64 {
+	low, high := 0.0, 1.0
+	for i := 0; i < 64; i++ {
+		mid := (low + high) / 2
+		if f(mid) < y {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	return high
+}
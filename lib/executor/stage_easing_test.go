@@ -0,0 +1,290 @@
+package executor
+
+import (
+	"math"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/ChipArtem/k6/lib"
+	"github.com/ChipArtem/k6/lib/types"
+)
+
+func TestValidateStageEasing(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		stage  Stage
+		errors []string
+	}{
+		{name: "default", stage: Stage{Duration: types.NullDurationFrom(time.Second)}},
+		{name: "step", stage: Stage{Easing: null.StringFrom("step")}},
+		{
+			name:  "sine",
+			stage: Stage{Duration: types.NullDurationFrom(7 * time.Second), Easing: null.StringFrom("sine"), Cycles: null.IntFrom(3)},
+		},
+		{
+			name:   "unknown",
+			stage:  Stage{Easing: null.StringFrom("cubic")},
+			errors: []string{`invalid easing "cubic" for stage 1`},
+		},
+		{
+			name:   "spike without duration",
+			stage:  Stage{Duration: types.NullDurationFrom(0), Easing: null.StringFrom("spike")},
+			errors: []string{"the duration of the spike stage 1 must be more than 0"},
+		},
+		{
+			name:   "cycles without sine",
+			stage:  Stage{Easing: null.StringFrom("exponential"), Cycles: null.IntFrom(1)},
+			errors: []string{"can only be used with the sine easing"},
+		},
+		{
+			name:   "negative cycles",
+			stage:  Stage{Easing: null.StringFrom("sine"), Cycles: null.IntFrom(-1)},
+			errors: []string{"the cycles of stage 1 can't be negative"},
+		},
+		{
+			name:   "too many cycles",
+			stage:  Stage{Duration: types.NullDurationFrom(6 * time.Second), Easing: null.StringFrom("sine"), Cycles: null.IntFrom(3)},
+			errors: []string{"the stage 1 can have at most 2 cycles, since each half-wave must be at least 1s long"},
+		},
+		{
+			name:   "huge cycles",
+			stage:  Stage{Duration: types.NullDurationFrom(time.Hour), Easing: null.StringFrom("sine"), Cycles: null.IntFrom(1e18)},
+			errors: []string{"at most 1799 cycles"},
+		},
+		{
+			name:   "cycles without duration",
+			stage:  Stage{Easing: null.StringFrom("sine"), Cycles: null.IntFrom(1)},
+			errors: []string{"at most 0 cycles"},
+		},
+	}
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			errs := validateStageEasing(1, tc.stage)
+			require.Len(t, errs, len(tc.errors))
+			for i, err := range errs {
+				assert.Contains(t, err.Error(), tc.errors[i])
+			}
+		})
+	}
+}
+
+func TestEasingCurves(t *testing.T) {
+	t.Parallel()
+
+	for _, curve := range []easingCurve{curveLinear, curveExponential, curveSine} {
+		assert.Equal(t, 0.0, curve.inverse(0), curve)
+		assert.InDelta(t, 1.0, curve.inverse(1), 1e-12, curve)
+		assert.Equal(t, 0.0, curve.integral(0), curve)
+		prev := 0.0
+		for y := 0.1; y < 1; y += 0.1 {
+			x := curve.inverse(y)
+			assert.Greater(t, x, prev, curve)
+			prev = x
+		}
+	}
+	assert.InDelta(t, 0.5, curveSine.inverse(0.5), 1e-12)
+	assert.InDelta(t, math.Log2(512.5)/10, curveExponential.inverse(0.5), 1e-12)
+	assert.InDelta(t, 0.5, curveLinear.integral(1), 1e-12)
+	assert.InDelta(t, 0.5, curveSine.integral(1), 1e-12)
+	assert.InDelta(t, (1023/(10*math.Ln2)-1)/1023, curveExponential.integral(1), 1e-12)
+}
+
+func TestGetRampPieces(t *testing.T) {
+	t.Parallel()
+
+	stage := func(d time.Duration, target int64, easing string) Stage {
+		s := Stage{Duration: types.NullDurationFrom(d), Target: null.IntFrom(target)}
+		if easing != "" {
+			s.Easing = null.StringFrom(easing)
+		}
+		return s
+	}
+	sine := stage(3*time.Second, 2, "sine")
+	sine.Cycles = null.IntFrom(1)
+
+	pieces := getRampPieces(5, []Stage{
+		stage(10*time.Second, 10, ""),
+		stage(5*time.Second, 20, "step"),
+		stage(time.Second, 30, "spike"),
+		stage(2*time.Second, 40, "exponential"),
+		sine,
+	})
+	assert.Equal(t, []rampPiece{
+		{from: 5, to: 10, duration: 10 * time.Second},
+		{from: 10, to: 20},
+		{from: 20, to: 20, duration: 5 * time.Second},
+		{from: 20, to: 30},
+		{from: 30, to: 30, duration: time.Second},
+		{from: 30, to: 20},
+		{from: 20, to: 40, duration: 2 * time.Second, curve: curveExponential},
+		{from: 40, to: 2, duration: time.Second, curve: curveSine},
+		{from: 2, to: 40, duration: time.Second, curve: curveSine},
+		{from: 40, to: 2, duration: time.Second, curve: curveSine},
+	}, pieces)
+}
+
+// getPlannedVUsAt returns the planned VUs of the steps at the given time.
+func getPlannedVUsAt(steps []lib.ExecutionStep, offset time.Duration) uint64 {
+	i := sort.Search(len(steps), func(i int) bool { return steps[i].TimeOffset > offset })
+	if i == 0 {
+		return 0
+	}
+	return steps[i-1].PlannedVUs
+}
+
+func TestRampingVUsEasingSegments(t *testing.T) {
+	t.Parallel()
+
+	easings := []string{easingLinear, easingStep, easingExponential, easingSine, easingSpike}
+	seq := newExecutionSegmentSequenceFromString("0,1/4,1/3,2/3,1")
+	for _, easing := range easings {
+		easing := easing
+		t.Run(easing, func(t *testing.T) {
+			t.Parallel()
+
+			config := NewRampingVUsConfig("test")
+			config.StartVUs = null.IntFrom(3)
+			config.Stages = []Stage{
+				{Duration: types.NullDurationFrom(20 * time.Second), Target: null.IntFrom(37), Easing: null.StringFrom(easing)},
+				{Duration: types.NullDurationFrom(10 * time.Second), Target: null.IntFrom(11), Easing: null.StringFrom(easing)},
+			}
+			if easing == easingSine {
+				config.Stages[0].Cycles = null.IntFrom(2)
+			}
+			require.Empty(t, config.Validate())
+
+			full := config.getRawExecutionSteps(mustNewExecutionTuple(nil, nil), true)
+			assert.True(t, sort.SliceIsSorted(full, func(i, j int) bool { return full[i].TimeOffset < full[j].TimeOffset }))
+			assert.Equal(t, uint64(37), lib.GetMaxPlannedVUs(full))
+			assert.Equal(t, uint64(37), lib.GetMaxPlannedVUs(
+				config.GetExecutionRequirements(mustNewExecutionTuple(nil, nil))))
+
+			var segmentsSteps [][]lib.ExecutionStep
+			var maxVUsSum uint64
+			for i := range *seq {
+				et := mustNewExecutionTuple((*seq)[i], seq)
+				steps := config.getRawExecutionSteps(et, true)
+				segmentsSteps = append(segmentsSteps, steps)
+				maxVUsSum += lib.GetMaxPlannedVUs(config.GetExecutionRequirements(et))
+			}
+			assert.Equal(t, uint64(37), maxVUsSum)
+
+			// At any time, the segments together plan exactly as many VUs as
+			// the whole test does.
+			for i, step := range full {
+				if i+1 < len(full) && full[i+1].TimeOffset == step.TimeOffset {
+					continue // it's immediately replaced
+				}
+				var sum uint64
+				for _, steps := range segmentsSteps {
+					sum += getPlannedVUsAt(steps, step.TimeOffset)
+				}
+				assert.Equal(t, step.PlannedVUs, sum, step.TimeOffset)
+			}
+		})
+	}
+}
+
+func TestRampingVUsEasingShapes(t *testing.T) {
+	t.Parallel()
+
+	getSteps := func(easing string, cycles int64) []lib.ExecutionStep {
+		config := NewRampingVUsConfig("test")
+		config.StartVUs = null.IntFrom(0)
+		config.Stages = []Stage{
+			{Duration: types.NullDurationFrom(10 * time.Second), Target: null.IntFrom(10), Easing: null.StringFrom(easing)},
+		}
+		if cycles > 0 {
+			config.Stages[0].Cycles = null.IntFrom(cycles)
+		}
+		return config.getRawExecutionSteps(mustNewExecutionTuple(nil, nil), false)
+	}
+
+	assert.Equal(t, []lib.ExecutionStep{
+		{TimeOffset: 0, PlannedVUs: 0},
+		{TimeOffset: 0, PlannedVUs: 10},
+	}, getSteps(easingStep, 0))
+
+	assert.Equal(t, []lib.ExecutionStep{
+		{TimeOffset: 0, PlannedVUs: 0},
+		{TimeOffset: 0, PlannedVUs: 10},
+		{TimeOffset: 10 * time.Second, PlannedVUs: 0},
+	}, getSteps(easingSpike, 0))
+
+	exponential := getSteps(easingExponential, 0)
+	assert.Equal(t, uint64(7), getPlannedVUsAt(exponential, 9500*time.Millisecond))
+	assert.Equal(t, uint64(0), getPlannedVUsAt(exponential, 5*time.Second))
+
+	sine := getSteps(easingSine, 1)
+	assert.Equal(t, uint64(10), getPlannedVUsAt(sine, 10*time.Second/3))
+	assert.Equal(t, uint64(0), getPlannedVUsAt(sine, 20*time.Second/3))
+	assert.Equal(t, uint64(10), getPlannedVUsAt(sine, 10*time.Second))
+}
+
+func TestRampingArrivalRateCalEasings(t *testing.T) {
+	t.Parallel()
+
+	getTimes := func(config RampingArrivalRateConfig, et *lib.ExecutionTuple) []time.Duration {
+		ch := make(chan time.Duration)
+		go config.cal(et, ch)
+		var times []time.Duration
+		for tm := range ch {
+			times = append(times, tm)
+		}
+		return times
+	}
+
+	// The expected counts are the areas under the rates
+	expected := map[string]int{
+		easingStep:        1000,
+		easingSpike:       1000,
+		easingSine:        500,
+		easingExponential: int(1000 * curveExponential.integral(1)),
+	}
+	for easing, count := range expected {
+		easing, count := easing, count
+		t.Run(easing, func(t *testing.T) {
+			t.Parallel()
+
+			config := RampingArrivalRateConfig{
+				BaseConfig: BaseConfig{Name: "test"},
+				TimeUnit:   types.NullDurationFrom(time.Second),
+				StartRate:  null.IntFrom(0),
+				Stages: []Stage{
+					{Duration: types.NullDurationFrom(10 * time.Second), Target: null.IntFrom(100), Easing: null.StringFrom(easing)},
+				},
+			}
+			if easing == easingSine {
+				config.Stages[0].Cycles = null.IntFrom(1)
+			}
+			require.Empty(t, validateStages(config.Stages))
+
+			full := getTimes(config, mustNewExecutionTuple(nil, nil))
+			assert.Equal(t, full, getTimes(config, mustNewExecutionTuple(nil, nil)))
+			assert.True(t, sort.SliceIsSorted(full, func(i, j int) bool { return full[i] < full[j] }))
+			assert.InDelta(t, count, len(full), 1)
+			for _, tm := range full {
+				assert.LessOrEqual(t, tm, 10*time.Second)
+			}
+
+			// The segments split the iterations between themselves.
+			seq := newExecutionSegmentSequenceFromString("0,1/3,2/3,1")
+			var union []time.Duration
+			for _, segment := range []string{"0:1/3", "1/3:2/3", "2/3:1"} {
+				et := mustNewExecutionTuple(newExecutionSegmentFromString(segment), seq)
+				union = append(union, getTimes(config, et)...)
+			}
+			sort.Slice(union, func(i, j int) bool { return union[i] < union[j] })
+			assert.Equal(t, full, union)
+		})
+	}
+}
@@ -0,0 +1,569 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/ChipArtem/k6/lib"
+	"github.com/ChipArtem/k6/metrics"
+	"github.com/ChipArtem/k6/ui/pb"
+)
+
+const traceDrivenType = "trace-driven"
+
+// traceDrivenEndMargin is added to the offset of the last entry for the
+// regular duration of the executor, so the last iteration is started before it
+// ends, even without a gracefulStop or with a single entry.
+const traceDrivenEndMargin = 100 * time.Millisecond
+
+// The formats of the trace files.
+const (
+	traceFormatCSV   = "csv"
+	traceFormatJSONL = "jsonl"
+)
+
+func init() {
+	lib.RegisterExecutorConfigType(
+		traceDrivenType,
+		func(name string, rawJSON []byte) (lib.ExecutorConfig, error) {
+			config := NewTraceDrivenConfig(name)
+			err := lib.StrictJSONUnmarshal(rawJSON, &config)
+			return config, err
+		},
+	)
+}
+
+// TraceDrivenConfig stores the config for the trace-driven executor, which
+// starts an iteration for every entry of a trace, e.g. of an access log, at the
+// same time relative to the start of the scenario as the entry's timestamp is
+// relative to the first one in the trace.
+type TraceDrivenConfig struct {
+	BaseConfig
+	// The trace is a CSV file with a header, or a file with a JSON object on
+	// every line. The format is guessed from the extension, if it's not set.
+	File           null.String `json:"file"`
+	Format         null.String `json:"format"`
+	TimestampField null.String `json:"timestampField"`
+	// The offsets of the entries are multiplied by the timeScale, e.g. the
+	// trace is replayed twice as fast with 0.5.
+	TimeScale null.Float `json:"timeScale"`
+
+	// Initialize `PreAllocatedVUs` number of VUs, and if more than that are needed,
+	// they will be dynamically allocated, until `MaxVUs` is reached, which is an
+	// absolutely hard limit on the number of VUs the executor will use
+	PreAllocatedVUs null.Int `json:"preAllocatedVUs"`
+	MaxVUs          null.Int `json:"maxVUs"`
+
+	// The trace is loaded by the Runner, see LoadFiles.
+	trace []traceEntry
+}
+
+// traceEntry is an entry of the trace, i.e. an iteration of the executor.
+type traceEntry struct {
+	offset time.Duration
+	data   map[string]interface{}
+}
+
+// NewTraceDrivenConfig returns a TraceDrivenConfig with default values
+func NewTraceDrivenConfig(name string) *TraceDrivenConfig {
+	return &TraceDrivenConfig{
+		BaseConfig:     NewBaseConfig(name, traceDrivenType),
+		TimestampField: null.NewString("timestamp", false),
+		TimeScale:      null.NewFloat(1, false),
+	}
+}
+
+// Make sure we implement the lib.FileLoaderExecutorConfig interface
+var _ lib.FileLoaderExecutorConfig = &TraceDrivenConfig{}
+
+// GetPreAllocatedVUs is just a helper method that returns the scaled pre-allocated VUs.
+func (tdc TraceDrivenConfig) GetPreAllocatedVUs(et *lib.ExecutionTuple) int64 {
+	return et.ScaleInt64(tdc.PreAllocatedVUs.Int64)
+}
+
+// GetMaxVUs is just a helper method that returns the scaled max VUs.
+func (tdc TraceDrivenConfig) GetMaxVUs(et *lib.ExecutionTuple) int64 {
+	return et.ScaleInt64(tdc.MaxVUs.Int64)
+}
+
+// GetFormat returns the format of the trace file, which is guessed from its
+// extension if it isn't set.
+func (tdc TraceDrivenConfig) GetFormat() string {
+	if tdc.Format.Valid {
+		return tdc.Format.String
+	}
+	switch strings.ToLower(filepath.Ext(tdc.File.String)) {
+	case ".csv":
+		return traceFormatCSV
+	case ".json", ".jsonl", ".ndjson":
+		return traceFormatJSONL
+	default:
+		return ""
+	}
+}
+
+// getOffset returns the time the iteration of the given entry starts at,
+// relative to the start of the scenario.
+func (tdc TraceDrivenConfig) getOffset(i int) time.Duration {
+	return time.Duration(float64(tdc.trace[i].offset) * tdc.TimeScale.Float64)
+}
+
+// getDuration returns the offset of the last entry of the trace.
+func (tdc TraceDrivenConfig) getDuration() time.Duration {
+	if len(tdc.trace) == 0 {
+		return 0
+	}
+	return tdc.getOffset(len(tdc.trace) - 1)
+}
+
+// GetDescription returns a human-readable description of the executor options
+func (tdc TraceDrivenConfig) GetDescription(et *lib.ExecutionTuple) string {
+	maxVUsRange := fmt.Sprintf("maxVUs: %d", et.ScaleInt64(tdc.PreAllocatedVUs.Int64))
+	if tdc.MaxVUs.Int64 > tdc.PreAllocatedVUs.Int64 {
+		maxVUsRange += fmt.Sprintf("-%d", et.ScaleInt64(tdc.MaxVUs.Int64))
+	}
+
+	return fmt.Sprintf("%d iterations from %s over %s%s",
+		et.ScaleInt64(int64(len(tdc.trace))), tdc.File.String, tdc.getDuration(), tdc.getBaseInfo(maxVUsRange))
+}
+
+// Validate makes sure all options are configured and valid
+func (tdc *TraceDrivenConfig) Validate() []error {
+	errors := tdc.BaseConfig.Validate()
+
+	if tdc.File.String == "" {
+		errors = append(errors, fmt.Errorf("the trace file isn't specified"))
+	}
+	switch format := tdc.GetFormat(); format {
+	case traceFormatCSV, traceFormatJSONL:
+	case "":
+		errors = append(errors, fmt.Errorf(
+			"the format of the trace file %q can't be guessed from its extension, it should be specified", tdc.File.String,
+		))
+	default:
+		errors = append(errors, fmt.Errorf(
+			"invalid trace format %q, it should be %s or %s", format, traceFormatCSV, traceFormatJSONL,
+		))
+	}
+	if tdc.TimestampField.String == "" {
+		errors = append(errors, fmt.Errorf("the timestampField can't be empty"))
+	}
+	if tdc.TimeScale.Float64 <= 0 {
+		errors = append(errors, fmt.Errorf("the timeScale must be more than 0"))
+	}
+
+	if !tdc.PreAllocatedVUs.Valid {
+		errors = append(errors, fmt.Errorf("the number of preAllocatedVUs isn't specified"))
+	} else if tdc.PreAllocatedVUs.Int64 < 0 {
+		errors = append(errors, fmt.Errorf("the number of preAllocatedVUs can't be negative"))
+	}
+
+	if !tdc.MaxVUs.Valid {
+		// TODO: don't change the config while validating
+		tdc.MaxVUs.Int64 = tdc.PreAllocatedVUs.Int64
+	} else if tdc.MaxVUs.Int64 < tdc.PreAllocatedVUs.Int64 {
+		errors = append(errors, fmt.Errorf("maxVUs can't be less than preAllocatedVUs"))
+	}
+
+	return errors
+}
+
+// LoadFiles reads and parses the trace file.
+func (tdc *TraceDrivenConfig) LoadFiles(readFile func(filename string) ([]byte, error)) error {
+	data, err := readFile(tdc.File.String)
+	if err != nil {
+		return err
+	}
+	trace, err := parseTrace(data, tdc.GetFormat(), tdc.TimestampField.String)
+	if err != nil {
+		return fmt.Errorf("couldn't parse the trace file %q: %w", tdc.File.String, err)
+	}
+	if len(trace) == 0 {
+		return fmt.Errorf("the trace file %q doesn't have any entries", tdc.File.String)
+	}
+	tdc.trace = trace
+	return nil
+}
+
+// parseTrace parses the entries of a trace and sorts them by their timestamps.
+// The timestamps are either Unix timestamps in seconds or RFC3339 strings.
+func parseTrace(data []byte, format, timestampField string) ([]traceEntry, error) {
+	var (
+		rows []map[string]interface{}
+		err  error
+	)
+	switch format {
+	case traceFormatCSV:
+		rows, err = parseTraceCSV(data)
+	case traceFormatJSONL:
+		rows, err = parseTraceJSONL(data)
+	default:
+		err = fmt.Errorf("unsupported format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	times := make([]time.Time, len(rows))
+	for i, row := range rows {
+		times[i], err = parseTraceTimestamp(row[timestampField])
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: %w", i+1, err)
+		}
+	}
+
+	trace := make([]traceEntry, len(rows))
+	order := make([]int, len(rows))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return times[order[a]].Before(times[order[b]]) })
+	for i, j := range order {
+		trace[i] = traceEntry{offset: times[j].Sub(times[order[0]]), data: rows[j]}
+	}
+	return trace, nil
+}
+
+func parseTraceCSV(data []byte) ([]map[string]interface{}, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	header, err := r.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []map[string]interface{}
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		row := make(map[string]interface{}, len(header))
+		for i, name := range header {
+			row[name] = record[i]
+		}
+		rows = append(rows, row)
+	}
+}
+
+func parseTraceJSONL(data []byte) ([]map[string]interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	var rows []map[string]interface{}
+	for {
+		var row map[string]interface{}
+		err := dec.Decode(&row)
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: %w", len(rows)+1, err)
+		}
+		rows = append(rows, row)
+	}
+}
+
+func parseTraceTimestamp(value interface{}) (time.Time, error) {
+	var seconds float64
+	switch v := value.(type) {
+	case float64:
+		seconds = v
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			t, terr := time.Parse(time.RFC3339Nano, v)
+			if terr != nil {
+				return time.Time{}, fmt.Errorf("invalid timestamp %q, it should be a Unix timestamp or in the RFC3339 format", v)
+			}
+			return t, nil
+		}
+		seconds = f
+	case nil:
+		return time.Time{}, fmt.Errorf("the timestamp is missing")
+	default:
+		return time.Time{}, fmt.Errorf("invalid timestamp %v", v)
+	}
+	whole, frac := math.Modf(seconds)
+	return time.Unix(int64(whole), int64(frac*float64(time.Second))), nil
+}
+
+// GetExecutionRequirements returns the number of required VUs to run the
+// executor for its whole duration (disregarding any startTime), including the
+// maximum waiting time for any iterations to gracefully stop. This is used by
+// the execution scheduler in its VU reservation calculations, so it knows how
+// many VUs to pre-initialize.
+func (tdc TraceDrivenConfig) GetExecutionRequirements(et *lib.ExecutionTuple) []lib.ExecutionStep {
+	return []lib.ExecutionStep{
+		{
+			TimeOffset:      0,
+			PlannedVUs:      uint64(et.ScaleInt64(tdc.PreAllocatedVUs.Int64)),
+			MaxUnplannedVUs: uint64(et.ScaleInt64(tdc.MaxVUs.Int64) - et.ScaleInt64(tdc.PreAllocatedVUs.Int64)),
+		},
+		{
+			TimeOffset:      tdc.getDuration() + traceDrivenEndMargin + tdc.GracefulStop.TimeDuration(),
+			PlannedVUs:      0,
+			MaxUnplannedVUs: 0,
+		},
+	}
+}
+
+// NewExecutor creates a new TraceDriven executor
+func (tdc TraceDrivenConfig) NewExecutor(
+	es *lib.ExecutionState, logger *logrus.Entry,
+) (lib.Executor, error) {
+	if len(tdc.trace) == 0 {
+		return nil, fmt.Errorf("the trace file %q wasn't loaded", tdc.File.String)
+	}
+	return &TraceDriven{
+		BaseExecutor: NewBaseExecutor(&tdc, es, logger),
+		config:       tdc,
+	}, nil
+}
+
+// HasWork reports whether there is any work to be done for the given execution segment.
+func (tdc TraceDrivenConfig) HasWork(et *lib.ExecutionTuple) bool {
+	return tdc.GetMaxVUs(et) > 0 && et.ScaleInt64(int64(len(tdc.trace))) > 0
+}
+
+// TraceDriven starts an iteration for every entry of a trace, at the time of
+// the entry.
+type TraceDriven struct {
+	*BaseExecutor
+	config TraceDrivenConfig
+	et     *lib.ExecutionTuple
+}
+
+// Make sure we implement the lib.Executor interface.
+var _ lib.Executor = &TraceDriven{}
+
+// Init values needed for the execution
+func (td *TraceDriven) Init(_ context.Context) error {
+	// The entries are split between the execution segments with the striped
+	// offsets, the same way as the iterations of the arrival-rate executors.
+	// err should always be nil, because Init() won't be called for executors
+	// with no work, as determined by their config's HasWork() method.
+	et, err := td.executionState.ExecutionTuple.GetNewExecutionTupleFromValue(int64(len(td.config.trace)))
+	td.et = et
+	return err
+}
+
+// Run starts the iterations of the trace entries of the execution segment.
+// The number of an iteration in the test is the index of its entry in the
+// trace, and its data is available through the k6/execution module.
+//
+//nolint:funlen
+func (td TraceDriven) Run(parentCtx context.Context, out chan<- metrics.SampleContainer) (err error) {
+	gracefulStop := td.config.GetGracefulStop()
+	duration := td.config.getDuration()
+	preAllocatedVUs := td.config.GetPreAllocatedVUs(td.executionState.ExecutionTuple)
+	maxVUs := td.config.GetMaxVUs(td.executionState.ExecutionTuple)
+	totalIters := td.et.ScaleInt64(int64(len(td.config.trace)))
+
+	td.logger.WithFields(logrus.Fields{
+		"maxVUs": maxVUs, "preAllocatedVUs": preAllocatedVUs, "duration": duration,
+		"iterations": totalIters, "type": td.config.GetType(),
+	}).Debug("Starting executor run...")
+
+	activeVUsWg := &sync.WaitGroup{}
+	iterationsWg := &sync.WaitGroup{}
+
+	returnedVUs := make(chan struct{})
+	waitOnProgressChannel := make(chan struct{})
+	startTime, maxDurationCtx, regDurationCtx, cancel := getDurationContexts(
+		parentCtx, duration+traceDrivenEndMargin, gracefulStop,
+	)
+	defer func() {
+		cancel()
+		<-waitOnProgressChannel
+	}()
+
+	// The global numbers of the entries are sent to the VUs that are free to
+	// start their iterations.
+	entries := make(chan uint64)
+	defer func() {
+		// Make sure all VUs aren't executing iterations anymore, for the cancel()
+		// below to deactivate them.
+		<-returnedVUs
+		close(entries)
+		iterationsWg.Wait()
+		cancel()
+		activeVUsWg.Wait()
+	}()
+	activeVUsCount, runningVUsCount, doneIters := uint64(0), uint64(0), int64(0)
+
+	vusFmt := pb.GetFixedLengthIntFormat(maxVUs)
+	itersFmt := pb.GetFixedLengthIntFormat(totalIters)
+	progressFn := func() (float64, []string) {
+		spent := time.Since(startTime)
+		progVUs := fmt.Sprintf(vusFmt+"/"+vusFmt+" VUs",
+			atomic.LoadUint64(&runningVUsCount), atomic.LoadUint64(&activeVUsCount))
+		currentDoneIters := atomic.LoadInt64(&doneIters)
+		progIters := fmt.Sprintf(itersFmt+"/"+itersFmt+" iters", currentDoneIters, totalIters)
+
+		right := []string{progVUs, duration.String(), progIters}
+		if spent <= duration {
+			right[1] = fmt.Sprintf("%s/%s", pb.GetFixedLengthDuration(spent, duration), duration)
+		}
+
+		return float64(currentDoneIters) / float64(totalIters), right
+	}
+	td.progress.Modify(pb.WithProgress(progressFn))
+	maxDurationCtx = lib.WithScenarioState(maxDurationCtx, &lib.ScenarioState{
+		Name:       td.config.Name,
+		Executor:   td.config.Type,
+		StartTime:  startTime,
+		ProgressFn: progressFn,
+		GetIterationData: func(iterInTest uint64) interface{} {
+			if iterInTest >= uint64(len(td.config.trace)) {
+				return nil
+			}
+			return td.config.trace[iterInTest].data
+		},
+	})
+
+	go func() {
+		trackProgress(parentCtx, maxDurationCtx, regDurationCtx, &td, progressFn)
+		close(waitOnProgressChannel)
+	}()
+
+	returnVU := func(u lib.InitializedVU) {
+		// Return the VU without decreasing the global active VU counter, which
+		// is done in the goroutine started by activateVU, whenever the VU
+		// finishes running an iteration.
+		td.executionState.ReturnVU(u, false)
+		activeVUsWg.Done()
+	}
+
+	runIterationBasic := getIterationRunner(td.executionState, td.logger)
+	localIters := uint64(0)
+	activateVU := func(initVU lib.InitializedVU) {
+		activeVUsWg.Add(1)
+		// The entry is only changed by the goroutine below, before it runs the
+		// iteration, which is when the VU gets the iteration counters.
+		var entry uint64
+		activeVU := initVU.Activate(getVUActivationParams(
+			maxDurationCtx, td.config.BaseConfig, returnVU,
+			func() (uint64, uint64) {
+				return atomic.AddUint64(&localIters, 1) - 1, entry
+			},
+		))
+		atomic.AddUint64(&activeVUsCount, 1)
+
+		iterationsWg.Add(1)
+		started := make(chan struct{})
+		go func() {
+			defer iterationsWg.Done()
+
+			close(started)
+			for entry = range entries {
+				atomic.AddUint64(&runningVUsCount, 1)
+				td.executionState.ModCurrentlyActiveVUsCount(+1)
+				runIterationBasic(maxDurationCtx, activeVU)
+				td.executionState.ModCurrentlyActiveVUsCount(-1)
+				atomic.AddUint64(&runningVUsCount, ^uint64(0))
+				atomic.AddInt64(&doneIters, 1)
+			}
+		}()
+		<-started
+	}
+
+	remainingUnplannedVUs := maxVUs - preAllocatedVUs
+	makeUnplannedVUCh := make(chan struct{})
+	defer close(makeUnplannedVUCh)
+	go func() {
+		defer close(returnedVUs)
+		for range makeUnplannedVUCh {
+			td.logger.Debug("Starting initialization of an unplanned VU...")
+			initVU, err := td.executionState.GetUnplannedVU(maxDurationCtx, td.logger)
+			if err != nil {
+				// TODO figure out how to return it to the Run goroutine
+				td.logger.WithError(err).Error("Error while allocating unplanned VU")
+			} else {
+				td.logger.Debug("The unplanned VU finished initializing successfully!")
+				activateVU(initVU)
+			}
+		}
+	}()
+
+	// Get the pre-allocated VUs in the local buffer
+	for i := int64(0); i < preAllocatedVUs; i++ {
+		initVU, err := td.executionState.GetPlannedVU(td.logger, false)
+		if err != nil {
+			return err
+		}
+		activateVU(initVU)
+	}
+
+	start, offsets, _ := td.et.GetStripedOffsets()
+	timer := time.NewTimer(time.Hour * 24)
+	droppedIterationMetric := td.executionState.Test.BuiltinMetrics.DroppedIterations
+	shownWarning := false
+	metricTags := td.getMetricTags(nil)
+	for li, gi := 0, start; gi < int64(len(td.config.trace)); li, gi = li+1, gi+offsets[li%len(offsets)] {
+		t := td.config.getOffset(int(gi)) - time.Since(startTime)
+		timer.Reset(t)
+		// The regular duration ends shortly after the last entry, so this waits
+		// for the max duration instead, for the last iteration to be started.
+		select {
+		case <-timer.C:
+		case <-maxDurationCtx.Done():
+			return nil
+		}
+
+		select {
+		case entries <- uint64(gi):
+			continue
+		default:
+		}
+
+		// Since there aren't any free VUs available, consider this iteration
+		// dropped - we aren't going to try to recover it, but
+		atomic.AddInt64(&doneIters, 1)
+		metrics.PushIfNotDone(parentCtx, out, metrics.Sample{
+			TimeSeries: metrics.TimeSeries{
+				Metric: droppedIterationMetric,
+				Tags:   metricTags,
+			},
+			Time:  time.Now(),
+			Value: 1,
+		})
+
+		// We'll try to start allocating another VU in the background,
+		// non-blockingly, if we have remainingUnplannedVUs...
+		if remainingUnplannedVUs == 0 {
+			if !shownWarning {
+				td.logger.Warningf("Insufficient VUs, reached %d active VUs and cannot initialize more", maxVUs)
+				shownWarning = true
+			}
+			continue
+		}
+
+		select {
+		case makeUnplannedVUCh <- struct{}{}: // great!
+			remainingUnplannedVUs--
+		default: // we're already allocating a new VU
+		}
+	}
+
+	return nil
+}
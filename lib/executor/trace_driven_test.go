@@ -0,0 +1,156 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/ChipArtem/k6/lib"
+	"github.com/ChipArtem/k6/lib/types"
+	"github.com/ChipArtem/k6/metrics"
+)
+
+func TestParseTrace(t *testing.T) {
+	t.Parallel()
+
+	t.Run("csv", func(t *testing.T) {
+		t.Parallel()
+		trace, err := parseTrace([]byte("timestamp,path\n1700000001.5,/b\n1700000000,/a\n1700000001.5,/c\n"),
+			traceFormatCSV, "timestamp")
+		require.NoError(t, err)
+		require.Len(t, trace, 3)
+		assert.Equal(t, traceEntry{offset: 0, data: map[string]interface{}{"timestamp": "1700000000", "path": "/a"}}, trace[0])
+		assert.Equal(t, 1500*time.Millisecond, trace[1].offset)
+		assert.Equal(t, "/b", trace[1].data["path"])
+		assert.Equal(t, 1500*time.Millisecond, trace[2].offset)
+		assert.Equal(t, "/c", trace[2].data["path"])
+	})
+
+	t.Run("jsonl", func(t *testing.T) {
+		t.Parallel()
+		trace, err := parseTrace([]byte(
+			`{"time": "2023-11-14T22:13:20.25Z", "status": 200}`+"\n\n"+
+				`{"time": "2023-11-14T22:13:20Z", "status": 404}`+"\n"),
+			traceFormatJSONL, "time")
+		require.NoError(t, err)
+		require.Len(t, trace, 2)
+		assert.Equal(t, time.Duration(0), trace[0].offset)
+		assert.Equal(t, 404.0, trace[0].data["status"])
+		assert.Equal(t, 250*time.Millisecond, trace[1].offset)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		t.Parallel()
+		_, err := parseTrace([]byte("path\n/a\n"), traceFormatCSV, "timestamp")
+		assert.ErrorContains(t, err, "entry 1: the timestamp is missing")
+		_, err = parseTrace([]byte("timestamp\nyesterday\n"), traceFormatCSV, "timestamp")
+		assert.ErrorContains(t, err, `invalid timestamp "yesterday"`)
+		_, err = parseTrace([]byte(`{"timestamp": 1}`+"\n{"), traceFormatJSONL, "timestamp")
+		assert.ErrorContains(t, err, "entry 2")
+	})
+}
+
+func TestTraceDrivenConfigLoadFiles(t *testing.T) {
+	t.Parallel()
+
+	config := NewTraceDrivenConfig("trace")
+	config.File = null.StringFrom("trace.jsonl")
+	config.TimeScale = null.FloatFrom(2)
+	config.PreAllocatedVUs = null.IntFrom(1)
+	require.Empty(t, config.Validate())
+
+	errMissing := errors.New("missing")
+	require.ErrorIs(t, config.LoadFiles(func(string) ([]byte, error) { return nil, errMissing }), errMissing)
+	require.ErrorContains(t, config.LoadFiles(func(string) ([]byte, error) { return nil, nil }), "doesn't have any entries")
+
+	require.NoError(t, config.LoadFiles(func(filename string) ([]byte, error) {
+		assert.Equal(t, "trace.jsonl", filename)
+		return []byte(`{"timestamp": 10}` + "\n" + `{"timestamp": 13}` + "\n"), nil
+	}))
+	et, err := lib.NewExecutionTuple(nil, nil)
+	require.NoError(t, err)
+	endOffset, isFinal := lib.GetEndOffset(config.GetExecutionRequirements(et))
+	assert.Equal(t, 36*time.Second+traceDrivenEndMargin, endOffset)
+	assert.True(t, isFinal)
+	assert.Equal(t, "2 iterations from trace.jsonl over 6s (maxVUs: 1, gracefulStop: 30s)", config.GetDescription(et))
+}
+
+func TestTraceDrivenRun(t *testing.T) {
+	t.Parallel()
+
+	// Every 20ms, with a burst of 3 entries at 200ms.
+	trace := "timestamp,id\n"
+	for i := 0; i < 10; i++ {
+		ts := 1700000000 + float64(i)*0.02
+		if i >= 7 {
+			ts = 1700000000.2
+		}
+		trace += time.Unix(0, 0).Add(time.Duration(ts*float64(time.Second))).UTC().Format(time.RFC3339Nano) +
+			"," + string(rune('a'+i)) + "\n"
+	}
+
+	segments := []string{"0:1/3", "1/3:2/3", "2/3:1"}
+	var (
+		mu  sync.Mutex
+		ids []string
+	)
+	for _, segment := range segments {
+		runner := simpleRunner(func(ctx context.Context, state *lib.State) error {
+			data := lib.GetScenarioState(ctx).GetIterationData(state.GetScenarioGlobalVUIter())
+			mu.Lock()
+			ids = append(ids, data.(map[string]interface{})["id"].(string)) //nolint:forcetypeassert
+			mu.Unlock()
+			return nil
+		})
+		config := NewTraceDrivenConfig("trace")
+		config.File = null.StringFrom("trace.csv")
+		config.PreAllocatedVUs = null.IntFrom(3)
+		require.Empty(t, config.Validate())
+		require.NoError(t, config.LoadFiles(func(string) ([]byte, error) { return []byte(trace), nil }))
+
+		test := setupExecutorTest(t, segment, "0,1/3,2/3,1", lib.Options{}, runner, config)
+		engineOut := make(chan metrics.SampleContainer, 1000)
+		start := time.Now()
+		require.NoError(t, test.executor.Run(test.ctx, engineOut))
+		assert.InDelta(t, 200*time.Millisecond, time.Since(start), float64(100*time.Millisecond))
+		assert.Empty(t, test.logHook.Drain())
+		test.cancel()
+	}
+
+	sort.Strings(ids)
+	assert.Equal(t, []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"}, ids)
+}
+
+func TestTraceDrivenRunSingleEntryWithoutGracefulStop(t *testing.T) {
+	t.Parallel()
+
+	var iterations int64
+	runner := simpleRunner(func(ctx context.Context, state *lib.State) error {
+		atomic.AddInt64(&iterations, 1)
+		return nil
+	})
+	config := NewTraceDrivenConfig("trace")
+	config.File = null.StringFrom("trace.csv")
+	config.PreAllocatedVUs = null.IntFrom(1)
+	config.GracefulStop = types.NullDurationFrom(0)
+	require.Empty(t, config.Validate())
+	require.NoError(t, config.LoadFiles(func(string) ([]byte, error) {
+		return []byte("timestamp\n1700000000\n"), nil
+	}))
+
+	test := setupExecutorTest(t, "", "", lib.Options{}, runner, config)
+	defer test.cancel()
+	engineOut := make(chan metrics.SampleContainer, 1000)
+	require.NoError(t, test.executor.Run(test.ctx, engineOut))
+	assert.Equal(t, int64(1), atomic.LoadInt64(&iterations))
+	assert.Equal(t, uint64(1), test.state.GetFullIterationCount())
+	assert.Empty(t, test.logHook.Drain())
+}
@@ -63,6 +63,12 @@ type ExecutorConfig interface {
 	GetName() string
 	GetType() string
 	GetStartTime() time.Duration
+	// GetStartAfter returns the name of the scenario after whose end this one
+	// should start, if any. The start time is then relative to that end.
+	GetStartAfter() string
+	// GetOnlyIf returns the names of the metrics whose thresholds should not
+	// be crossed when the scenario starts, otherwise it's skipped.
+	GetOnlyIf() []string
 	GetGracefulStop() time.Duration
 
 	// This is used to validate whether a particular script can run in the cloud
@@ -93,6 +99,15 @@ type ExecutorConfig interface {
 	HasWork(*ExecutionTuple) bool
 }
 
+// FileLoaderExecutorConfig should be implemented by the executor configs that
+// depend on the data of some files, e.g. the trace-driven executor. The files
+// are loaded by the Runner when its options are set, before the execution
+// requirements are calculated, so the files are also included in the archives.
+type FileLoaderExecutorConfig interface {
+	ExecutorConfig
+	LoadFiles(readFile func(filename string) ([]byte, error)) error
+}
+
 // ScenarioOptions are options specific to a scenario. These include k6 browser
 // options, which are validated by the browser module, and not by k6 core.
 type ScenarioOptions struct {
@@ -105,6 +120,11 @@ type ScenarioState struct {
 	Name, Executor string
 	StartTime      time.Time
 	ProgressFn     func() (float64, []string)
+	// GetIterationData returns the data of the iteration with the given
+	// number in the test, if the executor has some, e.g. the trace-driven
+	// executor returns the entry of the trace. The data is shared between
+	// the VUs, so it must not be modified.
+	GetIterationData func(iterInTest uint64) interface{}
 }
 
 // InitVUFunc is just a shorthand so we don't have to type the function
@@ -203,19 +223,125 @@ func (scs ScenarioConfigs) Validate() (errors []error) {
 				fmt.Errorf("scenario %s has configuration errors: %s", name, ConcatErrors(execErr, ", ")))
 		}
 	}
+	return append(errors, scs.validateDependencies()...)
+}
+
+// validateDependencies checks that the scenarios only start after other
+// scenarios that exist and whose end is known in advance, and that they don't
+// depend on each other in a cycle.
+func (scs ScenarioConfigs) validateDependencies() (errors []error) {
+	et, err := NewExecutionTuple(nil, nil)
+	if err != nil {
+		return []error{err} // shouldn't happen without a segment
+	}
+
+	names := make([]string, 0, len(scs))
+	for name := range scs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		startAfter := scs[name].GetStartAfter()
+		if startAfter == "" {
+			continue
+		}
+		dependency, ok := scs[startAfter]
+		if !ok {
+			errors = append(errors, fmt.Errorf("scenario %s should start after scenario %s, which doesn't exist",
+				name, startAfter))
+			continue
+		}
+		if len(dependency.Validate()) != 0 {
+			continue // its requirements can't be calculated, but its errors are already reported
+		}
+		if _, isFinal := GetEndOffset(dependency.GetExecutionRequirements(et)); !isFinal {
+			errors = append(errors, fmt.Errorf(
+				"scenario %s can't start after scenario %s, since the end of its %s executor isn't known in advance",
+				name, startAfter, dependency.GetType()))
+			continue
+		}
+
+		chain := []string{name}
+		for next := startAfter; next != "" && scs[next] != nil; next = scs[next].GetStartAfter() {
+			chain = append(chain, next)
+			if next == name {
+				errors = append(errors, fmt.Errorf("scenario %s can't start after itself: %s",
+					name, strings.Join(chain, " -> ")))
+				break
+			}
+			if len(chain) > len(scs) {
+				break // a cycle that doesn't include this scenario, it's reported for its own scenarios
+			}
+		}
+	}
 	return errors
 }
 
+// GetPlannedStartTimes returns the times the scenarios are planned to start
+// at, relative to the beginning of the test. For the scenarios that start after
+// another one, that's the planned end of the other scenario, including its
+// gracefulStop, plus their own startTime. So the scenarios actually start at
+// these times or earlier, when the ones they depend on end before the end of
+// their gracefulStop, see GetEarliestStartTimes().
+func (scs ScenarioConfigs) GetPlannedStartTimes(et *ExecutionTuple) map[string]time.Duration {
+	return scs.getStartTimes(func(dependency ExecutorConfig) time.Duration {
+		endOffset, _ := GetEndOffset(dependency.GetExecutionRequirements(et))
+		return endOffset
+	})
+}
+
+// GetEarliestStartTimes returns the earliest times the scenarios could start
+// at, relative to the beginning of the test. For the scenarios that start after
+// another one, that's the earliest start of the other scenario plus their own
+// startTime, since the other scenario could end right after it starts, e.g.
+// when it runs out of iterations or it's skipped.
+func (scs ScenarioConfigs) GetEarliestStartTimes() map[string]time.Duration {
+	return scs.getStartTimes(func(ExecutorConfig) time.Duration { return 0 })
+}
+
+// getStartTimes returns the start times of the scenarios, when the ones that
+// start after another scenario start the given duration after its start.
+func (scs ScenarioConfigs) getStartTimes(
+	getDuration func(dependency ExecutorConfig) time.Duration,
+) map[string]time.Duration {
+	startTimes := make(map[string]time.Duration, len(scs))
+
+	var getStartTime func(name string, visiting map[string]bool) time.Duration
+	getStartTime = func(name string, visiting map[string]bool) time.Duration {
+		if startTime, ok := startTimes[name]; ok {
+			return startTime
+		}
+		config := scs[name]
+		startTime := config.GetStartTime()
+		// The visited scenarios guard against cycles in invalid configs
+		if dependency, ok := scs[config.GetStartAfter()]; ok && !visiting[config.GetStartAfter()] {
+			visiting[name] = true
+			startTime += getStartTime(config.GetStartAfter(), visiting) + getDuration(dependency)
+		}
+		startTimes[name] = startTime
+		return startTime
+	}
+
+	for name := range scs {
+		getStartTime(name, map[string]bool{})
+	}
+	return startTimes
+}
+
 // GetSortedConfigs returns a slice with the executor configurations,
 // sorted in a consistent and predictable manner. It is useful when we want or
 // have to avoid using maps with string keys (and tons of string lookups in
 // them) and avoid the unpredictable iterations over Go maps. Slices allow us
 // constant-time lookups and ordered iterations.
 //
-// The configs in the returned slice will be sorted by their start times in an
-// ascending order, and alphabetically by their names (which are unique) if
-// there are ties.
+// The configs in the returned slice will be sorted by their planned start
+// times in an ascending order, and alphabetically by their names (which are
+// unique) if there are ties. So the scenarios always come after the ones they
+// should start after.
 func (scs ScenarioConfigs) GetSortedConfigs() []ExecutorConfig {
+	et, _ := NewExecutionTuple(nil, nil) // can't fail without a segment
+	startTimes := scs.GetPlannedStartTimes(et)
 	configs := make([]ExecutorConfig, len(scs))
 
 	// Populate the configs slice with sorted executor configs
@@ -225,10 +351,11 @@ func (scs ScenarioConfigs) GetSortedConfigs() []ExecutorConfig {
 		i++
 	}
 	sort.Slice(configs, func(a, b int) bool { // sort by (start time, name)
+		startA, startB := startTimes[configs[a].GetName()], startTimes[configs[b].GetName()]
 		switch {
-		case configs[a].GetStartTime() < configs[b].GetStartTime():
+		case startA < startB:
 			return true
-		case configs[a].GetStartTime() == configs[b].GetStartTime():
+		case startA == startB:
 			return strings.Compare(configs[a].GetName(), configs[b].GetName()) < 0
 		default:
 			return false
@@ -239,25 +366,28 @@ func (scs ScenarioConfigs) GetSortedConfigs() []ExecutorConfig {
 }
 
 // GetFullExecutionRequirements combines the execution requirements from all of
-// the configured executors. It takes into account their start times and their
-// individual VU requirements and calculates the total VU requirements for each
-// moment in the test execution.
+// the configured executors. It takes into account their planned start times
+// and their individual VU requirements and calculates the total VU requirements
+// for each moment in the test execution. The scenarios that start after other
+// ones could start at any time between their earliest and planned start times,
+// so their VUs are reserved for the whole time they could need them.
 func (scs ScenarioConfigs) GetFullExecutionRequirements(et *ExecutionTuple) []ExecutionStep {
 	sortedConfigs := scs.GetSortedConfigs()
+	startTimes := scs.GetPlannedStartTimes(et)
+	earliestStartTimes := scs.GetEarliestStartTimes()
 
 	// Combine the steps and requirements from all different executors, and
-	// sort them by their time offset, counting the executors' startTimes as
-	// well.
+	// sort them by their time offset, counting the executors' planned start
+	// times as well.
 	type trackedStep struct {
 		ExecutionStep
 		configID int
 	}
 	trackedSteps := []trackedStep{}
 	for configID, config := range sortedConfigs { // orderly iteration over a slice
-		configStartTime := config.GetStartTime()
-		configSteps := config.GetExecutionRequirements(et)
+		configSteps := getShiftedExecutionSteps(
+			config.GetExecutionRequirements(et), earliestStartTimes[config.GetName()], startTimes[config.GetName()])
 		for _, cs := range configSteps {
-			cs.TimeOffset += configStartTime // add the executor start time to the step time offset
 			trackedSteps = append(trackedSteps, trackedStep{cs, configID})
 		}
 	}
@@ -313,6 +443,102 @@ func (scs ScenarioConfigs) GetFullExecutionRequirements(et *ExecutionTuple) []Ex
 	return consolidatedSteps
 }
 
+// getShiftedExecutionSteps returns the execution steps of an executor that
+// starts at any time between the earliest and the latest start time. At every
+// moment, they require the most VUs the executor could need at it.
+func getShiftedExecutionSteps(steps []ExecutionStep, earliest, latest time.Duration) []ExecutionStep {
+	if earliest >= latest {
+		result := make([]ExecutionStep, len(steps))
+		for i, step := range steps {
+			step.TimeOffset += latest // add the executor start time to the step time offset
+			result[i] = step
+		}
+		return result
+	}
+
+	// Every step lasts until the next one, so when the start is shifted, its
+	// values are needed from its earliest start to the latest start of the next
+	// step. The events that end the steps come before the ones that start them.
+	type event struct {
+		offset time.Duration
+		end    bool
+		step   ExecutionStep
+	}
+	events := make([]event, 0, 2*len(steps))
+	for i, step := range steps {
+		if i+1 < len(steps) && steps[i+1].TimeOffset == step.TimeOffset {
+			continue // it's immediately replaced by the next step
+		}
+		events = append(events, event{offset: step.TimeOffset + earliest, step: step})
+		if i+1 < len(steps) {
+			events = append(events, event{offset: steps[i+1].TimeOffset + latest, end: true, step: step})
+		}
+	}
+	sort.SliceStable(events, func(a, b int) bool {
+		if events[a].offset == events[b].offset {
+			return events[a].end && !events[b].end
+		}
+		return events[a].offset < events[b].offset
+	})
+
+	planned, unplanned := newMaxCounter(), newMaxCounter()
+	result := make([]ExecutionStep, 0, len(events))
+	for i, e := range events {
+		if e.end {
+			planned.remove(e.step.PlannedVUs)
+			unplanned.remove(e.step.MaxUnplannedVUs)
+		} else {
+			planned.add(e.step.PlannedVUs)
+			unplanned.add(e.step.MaxUnplannedVUs)
+		}
+		if i+1 < len(events) && events[i+1].offset == e.offset {
+			continue // the step is the result of all of the events at the same time
+		}
+		step := ExecutionStep{TimeOffset: e.offset, PlannedVUs: planned.max(), MaxUnplannedVUs: unplanned.max()}
+		if len(result) == 0 || result[len(result)-1].PlannedVUs != step.PlannedVUs ||
+			result[len(result)-1].MaxUnplannedVUs != step.MaxUnplannedVUs {
+			result = append(result, step)
+		}
+	}
+	return result
+}
+
+// maxCounter keeps track of the maximum of a multiset of values.
+type maxCounter struct {
+	counts map[uint64]int
+	values []uint64 // sorted in an ascending order
+}
+
+func newMaxCounter() *maxCounter {
+	return &maxCounter{counts: make(map[uint64]int)}
+}
+
+func (mc *maxCounter) add(value uint64) {
+	if mc.counts[value] == 0 {
+		i := sort.Search(len(mc.values), func(i int) bool { return mc.values[i] >= value })
+		mc.values = append(mc.values, 0)
+		copy(mc.values[i+1:], mc.values[i:])
+		mc.values[i] = value
+	}
+	mc.counts[value]++
+}
+
+func (mc *maxCounter) remove(value uint64) {
+	mc.counts[value]--
+	if mc.counts[value] == 0 {
+		delete(mc.counts, value)
+		i := sort.Search(len(mc.values), func(i int) bool { return mc.values[i] >= value })
+		mc.values = append(mc.values[:i], mc.values[i+1:]...)
+	}
+}
+
+func (mc *maxCounter) max() uint64 {
+	if len(mc.values) == 0 {
+		return 0
+	}
+	return mc.values[len(mc.values)-1]
+}
+
 // GetParsedExecutorConfig returns a struct instance corresponding to the supplied
 // config type. It will be fully initialized - with both the default values of
 // the type, as well as with whatever the user had specified in the JSON
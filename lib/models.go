@@ -8,11 +8,13 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"gopkg.in/guregu/null.v3"
 
 	"github.com/ChipArtem/k6/lib/types"
+	"github.com/ChipArtem/k6/metrics"
 )
 
 // Separator for group IDs.
@@ -162,6 +164,40 @@ func (g *Group) Check(name string) (*Check, error) {
 	return check, nil
 }
 
+// AddCheckSample adds the result of a check from its sample, which is looked
+// up, or created along with its groups, under this group by the check and
+// group tags of the sample, so this should be the root group. The samples
+// without a check tag are ignored.
+// This is safe to call from multiple goroutines simultaneously.
+func (g *Group) AddCheckSample(sample metrics.Sample) error {
+	name, ok := sample.Tags.Get(metrics.TagCheck.String())
+	if !ok {
+		return nil
+	}
+
+	group := g
+	if path, ok := sample.Tags.Get(metrics.TagGroup.String()); ok && path != "" {
+		// the path of the root group is empty, so the first segment is skipped
+		for _, groupName := range strings.Split(path, GroupSeparator)[1:] {
+			var err error
+			if group, err = group.Group(groupName); err != nil {
+				return err
+			}
+		}
+	}
+
+	check, err := group.Check(name)
+	if err != nil {
+		return err
+	}
+	if sample.Value != 0 {
+		atomic.AddInt64(&check.Passes, 1)
+	} else {
+		atomic.AddInt64(&check.Fails, 1)
+	}
+	return nil
+}
+
 // A Check stores a series of successful or failing tests against a value.
 //
 // For more information, refer to the js/modules/k6.K6.Check() function.
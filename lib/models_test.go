@@ -7,9 +7,11 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"gopkg.in/guregu/null.v3"
 
 	"github.com/ChipArtem/k6/lib/types"
+	"github.com/ChipArtem/k6/metrics"
 )
 
 func TestStageJSON(t *testing.T) {
@@ -73,3 +75,35 @@ func TestDataRaces(t *testing.T) {
 		assert.Equal(t, group1, group2, "Groups are the same")
 	})
 }
+
+func TestGroupAddCheckSample(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	checks := registry.MustNewMetric(metrics.ChecksName, metrics.Rate)
+	sample := func(value float64, tags ...string) metrics.Sample {
+		tagSet := registry.RootTagSet()
+		for i := 0; i+1 < len(tags); i += 2 {
+			tagSet = tagSet.With(tags[i], tags[i+1])
+		}
+		return metrics.Sample{TimeSeries: metrics.TimeSeries{Metric: checks, Tags: tagSet}, Value: value}
+	}
+
+	root, err := NewGroup("", nil)
+	require.NoError(t, err)
+	require.NoError(t, root.AddCheckSample(sample(1, "check", "is ok")))
+	require.NoError(t, root.AddCheckSample(sample(0, "check", "is ok", "group", "")))
+	require.NoError(t, root.AddCheckSample(sample(1, "check", "has token", "group", "::login::form")))
+	require.NoError(t, root.AddCheckSample(sample(1)), "the samples without a check tag are ignored")
+	assert.Error(t, root.AddCheckSample(sample(1, "check", "a::b")))
+
+	assert.Equal(t, int64(1), root.Checks["is ok"].Passes)
+	assert.Equal(t, int64(1), root.Checks["is ok"].Fails)
+	require.Contains(t, root.Groups, "login")
+	require.Contains(t, root.Groups["login"].Groups, "form")
+	check := root.Groups["login"].Groups["form"].Checks["has token"]
+	require.NotNil(t, check)
+	assert.Equal(t, "::login::form::has token", check.Path)
+	assert.Equal(t, int64(1), check.Passes)
+	assert.Len(t, root.Checks, 1)
+}
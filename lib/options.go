@@ -8,12 +8,40 @@ import (
 	"fmt"
 	"net"
 	"reflect"
+	"sort"
+	"time"
 
 	"github.com/ChipArtem/k6/lib/types"
 	"github.com/ChipArtem/k6/metrics"
 	"gopkg.in/guregu/null.v3"
 )
 
+// The possible policies for the samples of new time series that don't fit in
+// the budget set with the MaxTimeSeries and MaxTimeSeriesPerMetric options.
+const (
+	// TimeSeriesOverflowCollapse moves the samples to a single series per
+	// metric, tagged with the OverflowTag
+	TimeSeriesOverflowCollapse = "collapse"
+	// TimeSeriesOverflowDropTag removes the tags with the most unique values
+	// from the samples, until they match an existing series or have no tags left
+	TimeSeriesOverflowDropTag = "drop-tag"
+	// TimeSeriesOverflowAbort stops the test run
+	TimeSeriesOverflowAbort = "abort"
+)
+
+// The possible modes of the end-of-test summary.
+const (
+	// SummaryModeCompact aggregates the metrics of all scenarios together
+	SummaryModeCompact = "compact"
+	// SummaryModeFull also breaks the metrics and checks down per scenario
+	// and per group
+	SummaryModeFull = "full"
+)
+
+// OverflowTag is the tag of the series in which the samples are collapsed
+// when the time series budget is exceeded.
+const OverflowTag = "__overflow__"
+
 // DefaultScenarioName is used as the default key/ID of the scenario config entries
 // that were created due to the use of the shortcut execution control options (i.e. duration+vus,
 // iterations+vus, or stages)
@@ -278,6 +306,10 @@ type Options struct {
 	// metric on a nonexistent metric named 'real_metric{tagA:valueA,tagB:valueB}'.
 	Thresholds map[string]metrics.Thresholds `json:"thresholds" envconfig:"K6_THRESHOLDS"`
 
+	// Define thresholds that combine the values of several metrics or submetrics, e.g.
+	// 'errors.count / iterations.count < 0.01'.
+	ThresholdExpressions metrics.ExpressionThresholds `json:"thresholdExpressions" ignored:"true"`
+
 	// Blacklist IP ranges that tests may not contact. Mainly useful in hosted setups.
 	BlacklistIPs []*IPNet `json:"blacklistIPs" envconfig:"K6_BLACKLIST_IPS"`
 
@@ -309,6 +341,24 @@ type Options struct {
 	// Summary time unit for summary metrics (response times) in CLI output
 	SummaryTimeUnit null.String `json:"summaryTimeUnit" envconfig:"K6_SUMMARY_TIME_UNIT"`
 
+	// Relative error of the histograms used to store trend metrics for the
+	// summary and thresholds; 0 means all values are kept and percentiles are exact
+	TrendRelativeError null.Float `json:"trendRelativeError" envconfig:"K6_TREND_RELATIVE_ERROR"`
+
+	// Size of the intervals for which the metric values are aggregated in the
+	// timeline of the end-of-test summary data; the timeline is disabled if unset
+	SummaryTimelineInterval types.NullDuration `json:"summaryTimelineInterval" envconfig:"K6_SUMMARY_TIMELINE_INTERVAL"`
+
+	// Summary mode, the full mode adds a breakdown of the metrics and checks
+	// per scenario and per group to the end-of-test summary
+	SummaryMode null.String `json:"summaryMode" envconfig:"K6_SUMMARY_MODE"`
+
+	// Maximum number of unique time series, in total and for each metric, and
+	// what happens to the samples of new time series above these budgets
+	MaxTimeSeries          null.Int    `json:"maxTimeSeries" envconfig:"K6_MAX_TIME_SERIES"`
+	MaxTimeSeriesPerMetric null.Int    `json:"maxTimeSeriesPerMetric" envconfig:"K6_MAX_TIME_SERIES_PER_METRIC"`
+	TimeSeriesOverflow     null.String `json:"timeSeriesOverflow" envconfig:"K6_TIME_SERIES_OVERFLOW"`
+
 	// Which system tags to include with metrics ("method", "vu" etc.)
 	// Use pointer for identifying whether user provide any tag or not.
 	SystemTags *metrics.SystemTagSet `json:"systemTags" envconfig:"K6_SYSTEM_TAGS"`
@@ -437,6 +487,9 @@ func (o Options) Apply(opts Options) Options {
 	if opts.Thresholds != nil {
 		o.Thresholds = opts.Thresholds
 	}
+	if opts.ThresholdExpressions != nil {
+		o.ThresholdExpressions = opts.ThresholdExpressions
+	}
 	if opts.BlacklistIPs != nil {
 		o.BlacklistIPs = opts.BlacklistIPs
 	}
@@ -467,6 +520,24 @@ func (o Options) Apply(opts Options) Options {
 	if opts.SummaryTimeUnit.Valid {
 		o.SummaryTimeUnit = opts.SummaryTimeUnit
 	}
+	if opts.TrendRelativeError.Valid {
+		o.TrendRelativeError = opts.TrendRelativeError
+	}
+	if opts.SummaryTimelineInterval.Valid {
+		o.SummaryTimelineInterval = opts.SummaryTimelineInterval
+	}
+	if opts.SummaryMode.Valid {
+		o.SummaryMode = opts.SummaryMode
+	}
+	if opts.MaxTimeSeries.Valid {
+		o.MaxTimeSeries = opts.MaxTimeSeries
+	}
+	if opts.MaxTimeSeriesPerMetric.Valid {
+		o.MaxTimeSeriesPerMetric = opts.MaxTimeSeriesPerMetric
+	}
+	if opts.TimeSeriesOverflow.Valid {
+		o.TimeSeriesOverflow = opts.TimeSeriesOverflow
+	}
 	if opts.SystemTags != nil {
 		o.SystemTags = opts.SystemTags
 	}
@@ -517,7 +588,55 @@ func (o Options) Validate() []error {
 					o.ExecutionSegment, o.ExecutionSegmentSequence))
 		}
 	}
-	return append(errors, o.Scenarios.Validate()...)
+	if o.TrendRelativeError.Valid && (o.TrendRelativeError.Float64 < 0 || o.TrendRelativeError.Float64 >= 1) {
+		errors = append(errors,
+			fmt.Errorf("the trend relative error should be between 0 and 1, got %g", o.TrendRelativeError.Float64))
+	}
+	if o.SummaryTimelineInterval.Valid && o.SummaryTimelineInterval.Duration < types.Duration(time.Second) {
+		errors = append(errors,
+			fmt.Errorf("the summary timeline interval should be at least 1s, got %s", o.SummaryTimelineInterval.Duration))
+	}
+	if o.SummaryMode.Valid && o.SummaryMode.String != SummaryModeCompact && o.SummaryMode.String != SummaryModeFull {
+		errors = append(errors, fmt.Errorf("invalid summary mode '%s', use '%s' or '%s'",
+			o.SummaryMode.String, SummaryModeCompact, SummaryModeFull))
+	}
+	if o.MaxTimeSeries.Valid && o.MaxTimeSeries.Int64 < 0 {
+		errors = append(errors, fmt.Errorf("the max time series should not be negative, got %d", o.MaxTimeSeries.Int64))
+	}
+	if o.MaxTimeSeriesPerMetric.Valid && o.MaxTimeSeriesPerMetric.Int64 < 0 {
+		errors = append(errors, fmt.Errorf("the max time series per metric should not be negative, got %d",
+			o.MaxTimeSeriesPerMetric.Int64))
+	}
+	if o.TimeSeriesOverflow.Valid {
+		switch o.TimeSeriesOverflow.String {
+		case TimeSeriesOverflowCollapse, TimeSeriesOverflowDropTag, TimeSeriesOverflowAbort:
+		default:
+			errors = append(errors, fmt.Errorf("invalid time series overflow policy '%s', use '%s', '%s' or '%s'",
+				o.TimeSeriesOverflow.String, TimeSeriesOverflowCollapse, TimeSeriesOverflowDropTag, TimeSeriesOverflowAbort))
+		}
+	}
+	errors = append(errors, o.Scenarios.Validate()...)
+	return append(errors, o.validateScenarioConditions()...)
+}
+
+// validateScenarioConditions checks that the onlyIf conditions of the
+// scenarios refer to metrics with thresholds.
+func (o Options) validateScenarioConditions() (errors []error) {
+	names := make([]string, 0, len(o.Scenarios))
+	for name := range o.Scenarios {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, metricName := range o.Scenarios[name].GetOnlyIf() {
+			if _, ok := o.Thresholds[metricName]; !ok {
+				errors = append(errors, fmt.Errorf(
+					"scenario %s has an onlyIf condition on '%s', which doesn't have any thresholds", name, metricName))
+			}
+		}
+	}
+	return errors
 }
 
 // ForEachSpecified enumerates all struct fields and calls the supplied function with each
@@ -487,6 +487,12 @@ func TestOptions(t *testing.T) {
 		assert.NotNil(t, opts.Thresholds)
 		assert.NotEmpty(t, opts.Thresholds)
 	})
+	t.Run("ThresholdExpressions", func(t *testing.T) {
+		t.Parallel()
+		ets := metrics.ExpressionThresholds{{Source: "errors.count / iterations.count < 0.01"}}
+		opts := Options{}.Apply(Options{ThresholdExpressions: ets})
+		assert.Equal(t, ets, opts.ThresholdExpressions)
+	})
 	t.Run("External", func(t *testing.T) {
 		t.Parallel()
 		ext := map[string]json.RawMessage{"a": json.RawMessage("1")}
@@ -543,6 +549,55 @@ func TestOptions(t *testing.T) {
 		opts := Options{}.Apply(Options{SummaryTrendStats: stats})
 		assert.Equal(t, stats, opts.SummaryTrendStats)
 	})
+	t.Run("TrendRelativeError", func(t *testing.T) {
+		t.Parallel()
+		opts := Options{}.Apply(Options{TrendRelativeError: null.FloatFrom(0.01)})
+		assert.True(t, opts.TrendRelativeError.Valid)
+		assert.Equal(t, 0.01, opts.TrendRelativeError.Float64)
+		assert.Empty(t, opts.Validate())
+
+		opts = Options{}.Apply(Options{TrendRelativeError: null.FloatFrom(1)})
+		assert.Len(t, opts.Validate(), 1)
+	})
+	t.Run("TimeSeriesBudget", func(t *testing.T) {
+		t.Parallel()
+		opts := Options{}.Apply(Options{
+			MaxTimeSeries:          null.IntFrom(1000),
+			MaxTimeSeriesPerMetric: null.IntFrom(100),
+			TimeSeriesOverflow:     null.StringFrom(TimeSeriesOverflowDropTag),
+		})
+		assert.Equal(t, null.IntFrom(1000), opts.MaxTimeSeries)
+		assert.Equal(t, null.IntFrom(100), opts.MaxTimeSeriesPerMetric)
+		assert.Equal(t, null.StringFrom(TimeSeriesOverflowDropTag), opts.TimeSeriesOverflow)
+		assert.Empty(t, opts.Validate())
+
+		opts = Options{}.Apply(Options{
+			MaxTimeSeries:          null.IntFrom(-1),
+			MaxTimeSeriesPerMetric: null.IntFrom(-1),
+			TimeSeriesOverflow:     null.StringFrom("ignore"),
+		})
+		assert.Len(t, opts.Validate(), 3)
+	})
+	t.Run("SummaryTimelineInterval", func(t *testing.T) {
+		t.Parallel()
+		opts := Options{}.Apply(Options{SummaryTimelineInterval: types.NullDurationFrom(10 * time.Second)})
+		assert.True(t, opts.SummaryTimelineInterval.Valid)
+		assert.Equal(t, types.Duration(10*time.Second), opts.SummaryTimelineInterval.Duration)
+		assert.Empty(t, opts.Validate())
+
+		opts = Options{}.Apply(Options{SummaryTimelineInterval: types.NullDurationFrom(time.Millisecond)})
+		assert.Len(t, opts.Validate(), 1)
+	})
+	t.Run("SummaryMode", func(t *testing.T) {
+		t.Parallel()
+		opts := Options{}.Apply(Options{SummaryMode: null.StringFrom(SummaryModeFull)})
+		assert.True(t, opts.SummaryMode.Valid)
+		assert.Equal(t, SummaryModeFull, opts.SummaryMode.String)
+		assert.Empty(t, opts.Validate())
+
+		opts = Options{}.Apply(Options{SummaryMode: null.StringFrom("verbose")})
+		assert.Len(t, opts.Validate(), 1)
+	})
 	t.Run("RunTags", func(t *testing.T) {
 		t.Parallel()
 		tags := map[string]string{"myTag": "hello"}
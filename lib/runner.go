@@ -98,9 +98,55 @@ type UIState struct {
 
 // Summary contains all of the data the summary handler gets.
 type Summary struct {
-	Metrics         map[string]*metrics.Metric
-	RootGroup       *Group
-	TestRunDuration time.Duration // TODO: use lib.ExecutionState-based interface instead?
-	NoColor         bool          // TODO: drop this when noColor is part of the (runtime) options
-	UIState         UIState
+	Metrics              map[string]*metrics.Metric
+	ThresholdExpressions metrics.ExpressionThresholds
+	RootGroup            *Group
+	TestRunDuration      time.Duration // TODO: use lib.ExecutionState-based interface instead?
+	NoColor              bool          // TODO: drop this when noColor is part of the (runtime) options
+	UIState              UIState
+	Timeline             *SummaryTimeline
+	Scenarios            map[string]*SummaryScenario
+}
+
+// SummaryTimeline holds the values of the observed metrics, aggregated for
+// each interval of the test run.
+type SummaryTimeline struct {
+	Interval time.Duration
+	// Metrics has the intervals in which each metric had samples, ordered by
+	// their start time. Intervals without any samples are omitted.
+	Metrics map[string][]SummaryTimelineBucket
+}
+
+// SummaryTimelineBucket holds the values of a metric for the interval that
+// starts at Time.
+type SummaryTimelineBucket struct {
+	Time time.Time
+	Sink metrics.Sink
+}
+
+// SummaryScenario holds the values of the metrics and the results of the
+// checks of a single scenario, for the full summary mode.
+type SummaryScenario struct {
+	Name string
+	// Metrics has the values of the metrics from all the groups of the scenario
+	Metrics   map[*metrics.Metric]metrics.Sink
+	RootGroup *SummaryGroup
+}
+
+// SummaryGroup holds the values of the metrics and the results of the checks
+// of a single group in a scenario, without the ones of its subgroups.
+type SummaryGroup struct {
+	Name    string
+	Path    string
+	Metrics map[*metrics.Metric]metrics.Sink
+	// Checks and Groups are in the order they were first seen
+	Checks []*SummaryCheck
+	Groups []*SummaryGroup
+}
+
+// SummaryCheck holds the results of a check in a group of a scenario.
+type SummaryCheck struct {
+	Name   string
+	Passes int64
+	Fails  int64
 }
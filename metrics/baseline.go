@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Baseline holds the aggregated metric values of a previous test run, that
+// thresholds can be compared against. It maps the metric names, including any
+// submetric tags, to their values, keyed by the same names the thresholds use
+// for the aggregation methods, e.g. "count", "rate" or "p(95)".
+type Baseline map[string]map[string]float64
+
+// ParseBaseline parses a JSON end-of-test summary into a Baseline. Both the data
+// that is passed to handleSummary(), e.g. saved with JSON.stringify(data), and
+// the older format that --summary-export produces are supported.
+func ParseBaseline(data []byte) (Baseline, error) {
+	var summary struct {
+		Metrics map[string]map[string]json.RawMessage `json:"metrics"`
+	}
+	if err := json.Unmarshal(data, &summary); err != nil {
+		return nil, fmt.Errorf("couldn't parse the baseline summary: %w", err)
+	}
+	if summary.Metrics == nil {
+		return nil, fmt.Errorf("couldn't parse the baseline summary: it has no metrics")
+	}
+
+	baseline := make(Baseline, len(summary.Metrics))
+	for name, fields := range summary.Metrics {
+		values := make(map[string]float64)
+		if rawValues, ok := fields["values"]; ok {
+			// the handleSummary() data format
+			if err := json.Unmarshal(rawValues, &values); err != nil {
+				return nil, fmt.Errorf("couldn't parse the baseline values of metric %s: %w", name, err)
+			}
+			baseline[name] = values
+			continue
+		}
+
+		// the --summary-export format, where the values are mixed with
+		// other fields and the rate of the Rate metrics is called value
+		for key, raw := range fields {
+			var v float64
+			if err := json.Unmarshal(raw, &v); err != nil {
+				continue
+			}
+			values[key] = v
+		}
+		if _, isRate := values["passes"]; isRate {
+			if _, ok := values["rate"]; !ok {
+				values["rate"] = values["value"]
+			}
+		}
+		baseline[name] = values
+	}
+
+	return baseline, nil
+}
@@ -0,0 +1,111 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBaseline(t *testing.T) {
+	t.Parallel()
+
+	t.Run("handleSummary data", func(t *testing.T) {
+		t.Parallel()
+
+		data := `{"metrics": {
+			"http_req_duration": {"type": "trend", "contains": "time", "values": {"avg": 10, "p(95)": 20}},
+			"http_req_duration{name:login}": {"type": "trend", "contains": "time", "values": {"p(95)": 30}},
+			"checks": {"type": "rate", "values": {"rate": 0.5, "passes": 1, "fails": 1}, "thresholds": {"rate>0.9": {"ok": false}}}
+		}}`
+		baseline, err := ParseBaseline([]byte(data))
+		require.NoError(t, err)
+		assert.Equal(t, Baseline{
+			"http_req_duration":             {"avg": 10, "p(95)": 20},
+			"http_req_duration{name:login}": {"p(95)": 30},
+			"checks":                        {"rate": 0.5, "passes": 1, "fails": 1},
+		}, baseline)
+	})
+
+	t.Run("summary export", func(t *testing.T) {
+		t.Parallel()
+
+		data := `{"root_group": {}, "metrics": {
+			"http_req_duration": {"avg": 10, "p(95)": 20, "thresholds": {"p(95)<100": true}},
+			"checks": {"value": 0.5, "passes": 1, "fails": 1},
+			"vus": {"value": 3, "min": 1, "max": 5}
+		}}`
+		baseline, err := ParseBaseline([]byte(data))
+		require.NoError(t, err)
+		assert.Equal(t, Baseline{
+			"http_req_duration": {"avg": 10, "p(95)": 20},
+			"checks":            {"value": 0.5, "rate": 0.5, "passes": 1, "fails": 1},
+			"vus":               {"value": 3, "min": 1, "max": 5},
+		}, baseline)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := ParseBaseline([]byte(`{"something": "else"}`))
+		assert.Error(t, err)
+		_, err = ParseBaseline([]byte(`not json`))
+		assert.Error(t, err)
+	})
+}
+
+func TestThresholdsResolveBaseline(t *testing.T) {
+	t.Parallel()
+
+	newParsed := func(t *testing.T, sources ...string) Thresholds {
+		ths := NewThresholds(sources)
+		require.NoError(t, ths.Parse())
+		return ths
+	}
+	baseline := Baseline{"my_trend": {"p(95)": 100, "avg": 50}}
+
+	t.Run("resolved", func(t *testing.T) {
+		t.Parallel()
+
+		ths := newParsed(t, "p(95)<baseline*1.1", "avg<baseline + 5", "max<1000")
+		require.NoError(t, ths.ResolveBaseline("my_trend", baseline))
+		assert.Equal(t, 100.0, ths.Thresholds[0].Baseline.Float64)
+		assert.Equal(t, 50.0, ths.Thresholds[1].Baseline.Float64)
+		assert.False(t, ths.Thresholds[2].Baseline.Valid)
+
+		ok, err := ths.Run(getTrendSink(50, 54, 56), 0)
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		ok, err = ths.Run(getTrendSink(60, 60, 60), 0)
+		require.NoError(t, err)
+		assert.False(t, ok)
+		assert.False(t, ths.Thresholds[0].LastFailed)
+		assert.True(t, ths.Thresholds[1].LastFailed)
+		assert.Equal(t, 60.0, ths.Thresholds[1].LastValue.Float64)
+	})
+
+	t.Run("missing values", func(t *testing.T) {
+		t.Parallel()
+
+		ths := newParsed(t, "p(99)<baseline")
+		assert.ErrorIs(t, ths.ResolveBaseline("my_trend", baseline), ErrInvalidThreshold)
+		assert.ErrorIs(t, ths.ResolveBaseline("other_trend", baseline), ErrInvalidThreshold)
+		assert.ErrorIs(t, ths.ResolveBaseline("my_trend", nil), ErrInvalidThreshold)
+	})
+
+	t.Run("no baseline needed", func(t *testing.T) {
+		t.Parallel()
+
+		ths := newParsed(t, "p(99)<100")
+		assert.NoError(t, ths.ResolveBaseline("my_trend", nil))
+	})
+
+	t.Run("unresolved", func(t *testing.T) {
+		t.Parallel()
+
+		ths := newParsed(t, "avg<baseline")
+		_, err := ths.Run(getTrendSink(1), 0)
+		assert.Error(t, err)
+	})
+}
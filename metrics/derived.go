@@ -0,0 +1,152 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// parseArithmeticExpression parses the expression of a derived metric, which
+// has the same syntax as each side of a cross-metric threshold, for instance
+// `errors.count / http_reqs.count`.
+func parseArithmeticExpression(input string) (*arithmeticExpression, error) {
+	p := &assertionParser{input: input}
+
+	expr, err := p.parseExpression()
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing expression %q; reason: %w", input, err)
+	}
+
+	p.skipWhitespace()
+	if p.pos < len(input) {
+		return nil, fmt.Errorf("failed parsing expression %q; reason: "+
+			"unexpected %q at position %d", input, input[p.pos:], p.pos)
+	}
+
+	return expr, nil
+}
+
+// NewDerived returns a new derived metric registered to this registry. Derived
+// metrics are Gauge metrics whose values are periodically computed by the
+// metrics engine from the aggregated values of other metrics, as described by
+// the provided expression.
+func (r *Registry) NewDerived(name string, expression string) (*Metric, error) {
+	expression = strings.TrimSpace(expression)
+	parsed, err := parseArithmeticExpression(expression)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression for derived metric '%s': %w", name, err)
+	}
+
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	if !checkName(name) {
+		return nil, fmt.Errorf("Invalid metric name: '%s'. %s", name, badNameWarning) //nolint:golint,stylecheck
+	}
+	m, ok := r.metrics[name]
+	if !ok {
+		m = r.newMetric(name, Gauge)
+		m.Expression = expression
+		m.derived = parsed
+		r.metrics[name] = m
+		return m, nil
+	}
+	if m.derived == nil {
+		return nil, fmt.Errorf("metric '%s' already exists, but it isn't a derived metric", name)
+	}
+	if m.Expression != expression {
+		return nil, fmt.Errorf("metric '%s' already exists but with expression '%s', instead of '%s'",
+			name, m.Expression, expression)
+	}
+	return m, nil
+}
+
+// Derived returns all the registered derived metrics, sorted by name.
+func (r *Registry) Derived() []*Metric {
+	r.l.RLock()
+	defer r.l.RUnlock()
+
+	var result []*Metric
+	for _, m := range r.metrics {
+		if m.derived != nil {
+			result = append(result, m)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// IsDerived returns true if the metric values are computed from the values of
+// other metrics.
+func (m *Metric) IsDerived() bool {
+	return m.derived != nil
+}
+
+// ExpressionReferences returns the names of all the metrics and submetrics
+// used in the expression of a derived metric, for instance
+// `http_req_duration{name:login}`.
+func (m *Metric) ExpressionReferences() []string {
+	if m.derived == nil {
+		return nil
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	for _, ref := range m.derived.references() {
+		if seen[ref.MetricName] {
+			continue
+		}
+		seen[ref.MetricName] = true
+		names = append(names, ref.MetricName)
+	}
+	return names
+}
+
+// ResolveExpression sets the metrics the expression of a derived metric is
+// evaluated against, using the provided function to get each one of them by
+// name, as returned by ExpressionReferences.
+func (m *Metric) ResolveExpression(getMetric func(name string) (*Metric, error)) error {
+	if m.derived == nil {
+		return fmt.Errorf("metric '%s' isn't a derived metric", m.Name)
+	}
+
+	for _, ref := range m.derived.references() {
+		metric, err := getMetric(ref.MetricName)
+		if err != nil {
+			return err
+		}
+		if metric == m || (metric.Sub != nil && metric.Sub.Parent == m) {
+			return fmt.Errorf("the expression of derived metric '%s' can't reference the metric itself", m.Name)
+		}
+		if !metric.Type.supportsAggregationMethod(ref.AggregationMethod) {
+			return fmt.Errorf(
+				"unsupported aggregation method %s on metric %s of type %s in the expression of derived metric '%s'. "+
+					"supported aggregation methods for this metric are: %s",
+				ref.AggregationMethod, ref.MetricName, metric.Type, m.Name,
+				strings.Join(metric.Type.supportedAggregationMethods(), ", "),
+			)
+		}
+		if err := validateBucket(metric, ref.AggregationMethod, ref.AggregationValue); err != nil {
+			return fmt.Errorf("invalid expression of derived metric '%s': %w", m.Name, err)
+		}
+		ref.metric = metric
+	}
+	return nil
+}
+
+// EvaluateExpression computes the current value of a derived metric, given
+// the time spent in the test so far. It returns false if any of the
+// referenced metrics has no values yet, or if the result isn't a finite number.
+func (m *Metric) EvaluateExpression(duration time.Duration) (float64, bool) {
+	if m.derived == nil {
+		return 0, false
+	}
+
+	value, ok := m.derived.evaluate(duration)
+	if !ok || math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, false
+	}
+	return value, true
+}
@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryNewDerived(t *testing.T) {
+	t.Parallel()
+	r := NewRegistry()
+
+	m, err := r.NewDerived("error_ratio", " errors.count / http_reqs.count ")
+	require.NoError(t, err)
+	assert.Equal(t, Gauge, m.Type)
+	assert.Equal(t, "errors.count / http_reqs.count", m.Expression)
+	assert.True(t, m.IsDerived())
+	assert.Equal(t, []string{"errors", "http_reqs"}, m.ExpressionReferences())
+
+	same, err := r.NewDerived("error_ratio", "errors.count / http_reqs.count")
+	require.NoError(t, err)
+	assert.Same(t, m, same)
+
+	_, err = r.NewDerived("error_ratio", "errors.count")
+	assert.Error(t, err)
+	_, err = r.NewMetric("error_ratio", Counter)
+	assert.Error(t, err)
+	_, err = r.NewMetric("error_ratio", Gauge)
+	assert.Error(t, err)
+
+	gauge := r.MustNewMetric("gauge", Gauge)
+	assert.False(t, gauge.IsDerived())
+	_, err = r.NewDerived("gauge", "errors.count")
+	assert.Error(t, err)
+
+	for _, expression := range []string{"", "errors", "errors.count <", "errors.count / (1 + 2", "errors.foo"} {
+		_, err = r.NewDerived("invalid", expression)
+		assert.Error(t, err, expression)
+	}
+	_, err = r.NewDerived("1invalid", "errors.count")
+	assert.Error(t, err)
+
+	assert.Equal(t, []*Metric{m}, r.Derived())
+}
+
+func TestDerivedEvaluateExpression(t *testing.T) {
+	t.Parallel()
+	r := NewRegistry()
+
+	errs := r.MustNewMetric("errors", Counter)
+	reqs := r.MustNewMetric("http_reqs", Counter)
+	m, err := r.NewDerived("rps_without_errors", "(http_reqs.count - errors.count) / 2 + http_reqs.rate * 0")
+	require.NoError(t, err)
+	require.NoError(t, m.ResolveExpression(func(name string) (*Metric, error) { return r.Get(name), nil }))
+
+	_, ok := m.EvaluateExpression(time.Second)
+	assert.False(t, ok, "no values yet")
+
+	errs.Sink.Add(Sample{Time: time.Now(), Value: 2})
+	reqs.Sink.Add(Sample{Time: time.Now(), Value: 10})
+	value, ok := m.EvaluateExpression(time.Second)
+	require.True(t, ok)
+	assert.Equal(t, 4.0, value)
+
+	zero, err := r.NewDerived("division_by_zero", "errors.count / (http_reqs.count - 10)")
+	require.NoError(t, err)
+	require.NoError(t, zero.ResolveExpression(func(name string) (*Metric, error) { return r.Get(name), nil }))
+	_, ok = zero.EvaluateExpression(time.Second)
+	assert.False(t, ok, "infinite values are skipped")
+
+	_, ok = errs.EvaluateExpression(time.Second)
+	assert.False(t, ok)
+	assert.Error(t, errs.ResolveExpression(func(name string) (*Metric, error) { return r.Get(name), nil }))
+}
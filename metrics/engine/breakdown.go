@@ -0,0 +1,135 @@
+package engine
+
+import (
+	"strings"
+
+	"github.com/ChipArtem/k6/lib"
+	"github.com/ChipArtem/k6/metrics"
+)
+
+// breakdown keeps the values of the metrics and the results of the checks per
+// scenario and per group, so the full end-of-test summary can show them
+// separately.
+type breakdown struct {
+	scenarios map[string]*scenarioBreakdown
+}
+
+type scenarioBreakdown struct {
+	sinks map[*metrics.Metric]metrics.Sink
+	// groupPaths are in the order the groups were first seen
+	groups     map[string]*groupBreakdown
+	groupPaths []string
+}
+
+type groupBreakdown struct {
+	sinks  map[*metrics.Metric]metrics.Sink
+	checks map[string]*lib.SummaryCheck
+	// checkNames are in the order the checks were first seen
+	checkNames []string
+}
+
+func newBreakdown() *breakdown {
+	return &breakdown{scenarios: make(map[string]*scenarioBreakdown)}
+}
+
+// Add adds the sample of a top-level metric to the sinks of its scenario and
+// its group. Samples without a scenario tag are ignored.
+func (b *breakdown) Add(metric *metrics.Metric, s metrics.Sample) {
+	scenario, ok := s.Tags.Get(metrics.TagScenario.String())
+	if !ok {
+		return
+	}
+	sb, ok := b.scenarios[scenario]
+	if !ok {
+		sb = &scenarioBreakdown{
+			sinks:  make(map[*metrics.Metric]metrics.Sink),
+			groups: make(map[string]*groupBreakdown),
+		}
+		b.scenarios[scenario] = sb
+	}
+	addToSink(sb.sinks, metric, s)
+
+	// The samples without a group tag are in the root group
+	path, _ := s.Tags.Get(metrics.TagGroup.String())
+	gb, ok := sb.groups[path]
+	if !ok {
+		gb = &groupBreakdown{
+			sinks:  make(map[*metrics.Metric]metrics.Sink),
+			checks: make(map[string]*lib.SummaryCheck),
+		}
+		sb.groups[path] = gb
+		sb.groupPaths = append(sb.groupPaths, path)
+	}
+	addToSink(gb.sinks, metric, s)
+
+	if metric.Name != metrics.ChecksName {
+		return
+	}
+	name, ok := s.Tags.Get(metrics.TagCheck.String())
+	if !ok {
+		return
+	}
+	check, ok := gb.checks[name]
+	if !ok {
+		check = &lib.SummaryCheck{Name: name}
+		gb.checks[name] = check
+		gb.checkNames = append(gb.checkNames, name)
+	}
+	if s.Value != 0 {
+		check.Passes++
+	} else {
+		check.Fails++
+	}
+}
+
+func addToSink(sinks map[*metrics.Metric]metrics.Sink, metric *metrics.Metric, s metrics.Sample) {
+	sink, ok := sinks[metric]
+	if !ok {
+		sink = newSummarySink(metric)
+		sinks[metric] = sink
+	}
+	sink.Add(s)
+}
+
+// summary returns the breakdown data for the end-of-test summary.
+func (b *breakdown) summary() map[string]*lib.SummaryScenario {
+	result := make(map[string]*lib.SummaryScenario, len(b.scenarios))
+	for name, sb := range b.scenarios {
+		root := &lib.SummaryGroup{Metrics: make(map[*metrics.Metric]metrics.Sink)}
+		groups := map[string]*lib.SummaryGroup{"": root}
+
+		// getGroup returns the group with the given path, creating it and any
+		// missing parent groups, since parents may not have samples of their own
+		var getGroup func(path string) *lib.SummaryGroup
+		getGroup = func(path string) *lib.SummaryGroup {
+			if group, ok := groups[path]; ok {
+				return group
+			}
+			i := strings.LastIndex(path, lib.GroupSeparator)
+			if i < 0 {
+				i = 0
+			}
+			parent := getGroup(path[:i])
+			group := &lib.SummaryGroup{
+				Name:    strings.TrimPrefix(path[i:], lib.GroupSeparator),
+				Path:    path,
+				Metrics: make(map[*metrics.Metric]metrics.Sink),
+			}
+			parent.Groups = append(parent.Groups, group)
+			groups[path] = group
+			return group
+		}
+
+		for _, path := range sb.groupPaths {
+			gb := sb.groups[path]
+			group := getGroup(path)
+			group.Metrics = gb.sinks
+			for _, checkName := range gb.checkNames {
+				group.Checks = append(group.Checks, gb.checks[checkName])
+			}
+		}
+
+		result[name] = &lib.SummaryScenario{Name: name, Metrics: sb.sinks, RootGroup: root}
+	}
+	return result
+}
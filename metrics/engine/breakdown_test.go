@@ -0,0 +1,111 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ChipArtem/k6/lib"
+	"github.com/ChipArtem/k6/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+)
+
+func TestBreakdown(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	reqs := registry.MustNewMetric("http_reqs", metrics.Counter)
+	checks := registry.MustNewMetric(metrics.ChecksName, metrics.Rate)
+	vus := registry.MustNewMetric("vus", metrics.Gauge)
+	duration := registry.MustNewMetric("http_req_duration", metrics.Trend, metrics.Time)
+
+	b := newBreakdown()
+	add := func(m *metrics.Metric, value float64, tags ...string) {
+		b.Add(m, metrics.Sample{
+			TimeSeries: metrics.TimeSeries{Metric: m, Tags: registry.RootTagSet().WithTagsFromMap(tagsMap(tags...))},
+			Time:       time.Now(),
+			Value:      value,
+		})
+	}
+	add(reqs, 1, "scenario", "browse", "group", "")
+	add(reqs, 1, "scenario", "checkout", "group", "::cart::pay")
+	add(reqs, 1, "scenario", "checkout", "group", "::login")
+	add(reqs, 1, "scenario", "checkout")
+	add(checks, 1, "scenario", "checkout", "group", "::login", "check", "status is 200")
+	add(checks, 0, "scenario", "checkout", "group", "::login", "check", "status is 200")
+	add(checks, 1, "scenario", "checkout", "group", "::login", "check", "has token")
+	add(vus, 10)
+	add(duration, 100, "scenario", "browse")
+	add(duration, 300, "scenario", "browse")
+
+	summary := b.summary()
+	require.Len(t, summary, 2)
+	assert.Equal(t, 1.0, summary["browse"].Metrics[reqs].(*metrics.CounterSink).Value) //nolint:forcetypeassert
+	browseDuration := summary["browse"].Metrics[duration].(*metrics.TrendSink)         //nolint:forcetypeassert
+	assert.True(t, browseDuration.IsHistogram(), "the breakdown doesn't keep all of the values")
+	assert.Equal(t, uint64(2), browseDuration.Count())
+	assert.Equal(t, 300.0, browseDuration.Max())
+
+	checkout := summary["checkout"]
+	assert.Equal(t, "checkout", checkout.Name)
+	assert.Equal(t, 3.0, checkout.Metrics[reqs].(*metrics.CounterSink).Value) //nolint:forcetypeassert
+	assert.NotContains(t, checkout.Metrics, vus)
+
+	root := checkout.RootGroup
+	assert.Equal(t, 1.0, root.Metrics[reqs].(*metrics.CounterSink).Value) //nolint:forcetypeassert
+	require.Len(t, root.Groups, 2)
+
+	cart := root.Groups[0]
+	assert.Equal(t, "cart", cart.Name)
+	assert.Equal(t, "::cart", cart.Path)
+	assert.Empty(t, cart.Metrics, "the parent group doesn't have samples of its own")
+	require.Len(t, cart.Groups, 1)
+	assert.Equal(t, "pay", cart.Groups[0].Name)
+	assert.Equal(t, "::cart::pay", cart.Groups[0].Path)
+
+	login := root.Groups[1]
+	assert.Equal(t, "login", login.Name)
+	assert.Equal(t, []*lib.SummaryCheck{
+		{Name: "status is 200", Passes: 1, Fails: 1},
+		{Name: "has token", Passes: 1},
+	}, login.Checks)
+	assert.Len(t, login.Metrics, 2)
+}
+
+func TestMetricsEngineScenarios(t *testing.T) {
+	t.Parallel()
+
+	me := newTestMetricsEngine(t)
+	m, err := me.registry.NewMetric("counter1", metrics.Counter)
+	require.NoError(t, err)
+
+	require.NoError(t, me.InitSubMetricsAndThresholds(lib.Options{}, false))
+	assert.Nil(t, me.Scenarios())
+
+	opts := lib.Options{SummaryMode: null.StringFrom(lib.SummaryModeFull)}
+	require.NoError(t, me.InitSubMetricsAndThresholds(opts, false))
+	sm, err := m.AddSubmetric("scenario:default")
+	require.NoError(t, err)
+
+	sample := metrics.Sample{
+		TimeSeries: metrics.TimeSeries{Metric: m, Tags: me.registry.RootTagSet().With("scenario", "default")},
+		Time:       time.Now(),
+		Value:      1,
+	}
+	me.addToSinks(m, sample)
+	me.addToSinks(sm.Metric, sample)
+
+	scenarios := me.Scenarios()
+	require.Len(t, scenarios, 1)
+	require.Len(t, scenarios["default"].Metrics, 1, "submetrics aren't tracked separately")
+	assert.Equal(t, 1.0, scenarios["default"].Metrics[m].(*metrics.CounterSink).Value) //nolint:forcetypeassert
+}
+
+func tagsMap(keyValues ...string) map[string]string {
+	result := make(map[string]string, len(keyValues)/2)
+	for i := 0; i+1 < len(keyValues); i += 2 {
+		result[keyValues[i]] = keyValues[i+1]
+	}
+	return result
+}
@@ -0,0 +1,296 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/ChipArtem/k6/errext"
+	"github.com/ChipArtem/k6/errext/exitcodes"
+	"github.com/ChipArtem/k6/lib"
+	"github.com/ChipArtem/k6/metrics"
+	"github.com/ChipArtem/k6/output"
+)
+
+var (
+	_ output.Output           = &TimeSeriesBudget{}
+	_ output.WithSampleFilter = &TimeSeriesBudget{}
+	_ output.WithTestRunStop  = &TimeSeriesBudget{}
+)
+
+// TimeSeriesBudgetDescription is a short description for the time series
+// budget. Like with the ingester, it's used in the cmd/ui file to not show it
+// as one of the outputs.
+const TimeSeriesBudgetDescription = "Time Series Budget"
+
+// TimeSeriesBudget is a pseudo-Output that enforces the limits of the
+// maxTimeSeries and maxTimeSeriesPerMetric options on all of the samples,
+// before they are sent to any of the outputs. It doesn't depend on the
+// MetricsEngine, so it works even without the summary and the thresholds.
+type TimeSeriesBudget struct {
+	budget *cardinalityBudget
+	logger logrus.FieldLogger
+	report sync.Once
+}
+
+// NewTimeSeriesBudget returns the time series budget for the given options, or
+// nil if they don't limit the number of time series.
+func NewTimeSeriesBudget(
+	options lib.Options, registry *metrics.Registry, logger logrus.FieldLogger,
+) *TimeSeriesBudget {
+	if options.MaxTimeSeries.Int64 <= 0 && options.MaxTimeSeriesPerMetric.Int64 <= 0 {
+		return nil
+	}
+	return &TimeSeriesBudget{
+		budget: newCardinalityBudget(
+			int(options.MaxTimeSeries.Int64), int(options.MaxTimeSeriesPerMetric.Int64),
+			options.TimeSeriesOverflow.String, registry.RootTagSet(),
+		),
+		logger: logger.WithField("component", "time-series-budget"),
+	}
+}
+
+// Description returns a human-readable description of the output.
+func (tsb *TimeSeriesBudget) Description() string {
+	return TimeSeriesBudgetDescription
+}
+
+// Start is a no-op, the budget is enforced by FilterSamples().
+func (tsb *TimeSeriesBudget) Start() error {
+	return nil
+}
+
+// AddMetricSamples is a no-op, the budget only filters the samples.
+func (tsb *TimeSeriesBudget) AddMetricSamples([]metrics.SampleContainer) {}
+
+// Stop reports the metrics that exceeded the budget.
+func (tsb *TimeSeriesBudget) Stop() error {
+	tsb.report.Do(func() {
+		for _, line := range tsb.budget.Report() {
+			tsb.logger.Warn(line)
+		}
+	})
+	return nil
+}
+
+// FilterSamples enforces the time series budget on all of the samples before
+// they are sent to any output.
+func (tsb *TimeSeriesBudget) FilterSamples(containers []metrics.SampleContainer) []metrics.SampleContainer {
+	return tsb.budget.Filter(containers)
+}
+
+// SetTestRunStopCallback receives the function that is used to abort the test
+// run when the time series budget is exceeded with the abort policy.
+func (tsb *TimeSeriesBudget) SetTestRunStopCallback(abortRun func(error)) {
+	tsb.budget.abortRun = abortRun
+}
+
+// reportedTagKeys is the number of tag keys with the most unique values that
+// are mentioned in the report for each metric that exceeded its budget.
+const reportedTagKeys = 3
+
+// cardinalityBudget enforces a hard limit on the number of unique time series,
+// in total and per metric. The samples of new time series above the limits are
+// handled according to the overflow policy.
+type cardinalityBudget struct {
+	maxSeries          int // 0 means unlimited
+	maxSeriesPerMetric int // 0 means unlimited
+	policy             string
+	overflowTags       *metrics.TagSet
+
+	seen      map[metrics.TimeSeries]struct{}
+	perMetric map[*metrics.Metric]*metricCardinality
+
+	abortRun func(error)
+	aborted  bool
+}
+
+// metricCardinality keeps track of the time series of a single metric.
+type metricCardinality struct {
+	series int
+	// tagValues are the unique values of every tag key in the accepted series
+	tagValues map[string]map[string]struct{}
+	// overflows is the number of samples that didn't fit in the budget
+	overflows uint64
+}
+
+func newCardinalityBudget(maxSeries, maxSeriesPerMetric int, policy string, rootTags *metrics.TagSet) *cardinalityBudget {
+	if policy == "" {
+		policy = lib.TimeSeriesOverflowCollapse
+	}
+	return &cardinalityBudget{
+		maxSeries:          maxSeries,
+		maxSeriesPerMetric: maxSeriesPerMetric,
+		policy:             policy,
+		overflowTags:       rootTags.With(lib.OverflowTag, "true"),
+		seen:               make(map[metrics.TimeSeries]struct{}),
+		perMetric:          make(map[*metrics.Metric]*metricCardinality),
+	}
+}
+
+// Filter applies the budget to the samples of the given containers. The
+// containers with samples that were modified or dropped are replaced.
+func (cb *cardinalityBudget) Filter(containers []metrics.SampleContainer) []metrics.SampleContainer {
+	for i, container := range containers {
+		samples := container.GetSamples()
+
+		var filtered []metrics.Sample // only allocated if some sample changes
+		for j, sample := range samples {
+			ts, ok := cb.timeSeries(sample.TimeSeries)
+			if filtered == nil {
+				if ok && ts == sample.TimeSeries {
+					continue
+				}
+				filtered = make([]metrics.Sample, j, len(samples))
+				copy(filtered, samples[:j])
+			}
+			if ok {
+				sample.TimeSeries = ts
+				filtered = append(filtered, sample)
+			}
+		}
+		if filtered == nil {
+			continue
+		}
+
+		if connected, ok := container.(metrics.ConnectedSampleContainer); ok {
+			containers[i] = metrics.ConnectedSamples{
+				Samples: filtered,
+				Tags:    connected.GetTags(),
+				Time:    connected.GetTime(),
+			}
+		} else {
+			containers[i] = metrics.Samples(filtered)
+		}
+	}
+	return containers
+}
+
+// timeSeries returns the time series that the sample of the provided time
+// series should be emitted with, or false if the sample should be dropped.
+func (cb *cardinalityBudget) timeSeries(ts metrics.TimeSeries) (metrics.TimeSeries, bool) {
+	if _, ok := cb.seen[ts]; ok {
+		return ts, true
+	}
+
+	mc := cb.metricCardinality(ts.Metric)
+	if cb.fits(mc) {
+		cb.accept(mc, ts)
+		return ts, true
+	}
+
+	mc.overflows++
+	switch cb.policy {
+	case lib.TimeSeriesOverflowAbort:
+		if !cb.aborted && cb.abortRun != nil {
+			cb.aborted = true
+			err := fmt.Errorf("the metric '%s' exceeded the time series budget", ts.Metric.Name)
+			cb.abortRun(errext.WithAbortReasonIfNone(
+				errext.WithExitCodeIfNone(err, exitcodes.TimeSeriesLimitExceeded), errext.AbortedByOutput,
+			))
+		}
+		return ts, false
+	case lib.TimeSeriesOverflowDropTag:
+		// The tags are stripped until the series matches an existing one, or
+		// it has no tags left. Without any tags, the series is allowed to go
+		// over the budget, since there can only be one such series for each
+		// metric.
+		for !ts.Tags.IsEmpty() {
+			ts.Tags = ts.Tags.Without(mc.mostUniqueTag(ts.Tags))
+			if _, ok := cb.seen[ts]; ok {
+				return ts, true
+			}
+		}
+		cb.accept(mc, ts)
+		return ts, true
+	default:
+		ts.Tags = cb.overflowTags
+		if _, ok := cb.seen[ts]; !ok {
+			cb.seen[ts] = struct{}{}
+		}
+		return ts, true
+	}
+}
+
+func (cb *cardinalityBudget) metricCardinality(metric *metrics.Metric) *metricCardinality {
+	mc, ok := cb.perMetric[metric]
+	if !ok {
+		mc = &metricCardinality{tagValues: make(map[string]map[string]struct{})}
+		cb.perMetric[metric] = mc
+	}
+	return mc
+}
+
+// fits checks if there is space for one more series of the metric.
+func (cb *cardinalityBudget) fits(mc *metricCardinality) bool {
+	if cb.maxSeries > 0 && len(cb.seen) >= cb.maxSeries {
+		return false
+	}
+	return cb.maxSeriesPerMetric <= 0 || mc.series < cb.maxSeriesPerMetric
+}
+
+func (cb *cardinalityBudget) accept(mc *metricCardinality, ts metrics.TimeSeries) {
+	cb.seen[ts] = struct{}{}
+	mc.series++
+	for key, value := range ts.Tags.Map() {
+		values, ok := mc.tagValues[key]
+		if !ok {
+			values = make(map[string]struct{})
+			mc.tagValues[key] = values
+		}
+		values[value] = struct{}{}
+	}
+}
+
+// mostUniqueTag returns the key of the given tags that had the most unique
+// values in the accepted series of the metric.
+func (mc *metricCardinality) mostUniqueTag(tags *metrics.TagSet) string {
+	var result string
+	maxValues := -1
+	for key := range tags.Map() {
+		if n := len(mc.tagValues[key]); n > maxValues || (n == maxValues && key < result) {
+			result, maxValues = key, n
+		}
+	}
+	return result
+}
+
+// Report returns a description of every metric that exceeded the budget,
+// sorted by the metric name, with the tag keys that had the most unique values.
+func (cb *cardinalityBudget) Report() []string {
+	var report []string
+	for metric, mc := range cb.perMetric {
+		if mc.overflows == 0 {
+			continue
+		}
+
+		keys := make([]string, 0, len(mc.tagValues))
+		for key := range mc.tagValues {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			if len(mc.tagValues[keys[i]]) != len(mc.tagValues[keys[j]]) {
+				return len(mc.tagValues[keys[i]]) > len(mc.tagValues[keys[j]])
+			}
+			return keys[i] < keys[j]
+		})
+		if len(keys) > reportedTagKeys {
+			keys = keys[:reportedTagKeys]
+		}
+		for i, key := range keys {
+			keys[i] = fmt.Sprintf("%s (%d)", key, len(mc.tagValues[key]))
+		}
+
+		line := fmt.Sprintf("The metric '%s' exceeded the time series budget with %d series, %d samples were handled "+
+			"with the '%s' policy", metric.Name, mc.series, mc.overflows, cb.policy)
+		if len(keys) > 0 {
+			line += "; the tags with the most unique values were " + strings.Join(keys, ", ")
+		}
+		report = append(report, line)
+	}
+	sort.Strings(report)
+	return report
+}
@@ -0,0 +1,151 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ChipArtem/k6/errext"
+	"github.com/ChipArtem/k6/errext/exitcodes"
+	"github.com/ChipArtem/k6/lib"
+	"github.com/ChipArtem/k6/lib/testutils"
+	"github.com/ChipArtem/k6/metrics"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+)
+
+func TestCardinalityBudgetFilter(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	reqs := registry.MustNewMetric("http_reqs", metrics.Counter)
+	vus := registry.MustNewMetric("vus", metrics.Gauge)
+	root := registry.RootTagSet()
+
+	newSample := func(m *metrics.Metric, tags map[string]string) metrics.Sample {
+		return metrics.Sample{TimeSeries: metrics.TimeSeries{Metric: m, Tags: root.WithTagsFromMap(tags)}, Value: 1}
+	}
+	filter := func(cb *cardinalityBudget, samples ...metrics.Sample) []metrics.Sample {
+		var result []metrics.Sample
+		for _, c := range cb.Filter([]metrics.SampleContainer{metrics.Samples(samples)}) {
+			result = append(result, c.GetSamples()...)
+		}
+		return result
+	}
+
+	t.Run("collapse", func(t *testing.T) {
+		t.Parallel()
+
+		cb := newCardinalityBudget(0, 2, "", root)
+		got := filter(cb,
+			newSample(reqs, map[string]string{"url": "/1"}),
+			newSample(reqs, map[string]string{"url": "/2"}),
+			newSample(reqs, map[string]string{"url": "/3"}),
+			newSample(reqs, map[string]string{"url": "/1"}),
+			newSample(vus, nil),
+		)
+		require.Len(t, got, 5)
+		assert.Equal(t, root.With("url", "/2"), got[1].Tags)
+		assert.Equal(t, root.With(lib.OverflowTag, "true"), got[2].Tags)
+		assert.Equal(t, root.With("url", "/1"), got[3].Tags)
+		assert.Equal(t, root, got[4].Tags)
+		assert.Len(t, cb.seen, 4)
+	})
+
+	t.Run("drop tag", func(t *testing.T) {
+		t.Parallel()
+
+		cb := newCardinalityBudget(0, 3, lib.TimeSeriesOverflowDropTag, root)
+		got := filter(cb,
+			newSample(reqs, map[string]string{"url": "/1", "status": "200"}),
+			newSample(reqs, map[string]string{"status": "200"}),
+			newSample(reqs, map[string]string{"url": "/2", "status": "200"}),
+			newSample(reqs, map[string]string{"url": "/3", "status": "200"}),
+			newSample(reqs, map[string]string{"url": "/4", "status": "404"}),
+		)
+		require.Len(t, got, 5)
+		// the url tag has the most unique values, so it's dropped first
+		assert.Equal(t, root.With("status", "200"), got[3].Tags)
+		// and the status tag is dropped too, since there is no space for a new series
+		assert.Equal(t, root, got[4].Tags)
+		assert.Equal(t, []string{
+			"The metric 'http_reqs' exceeded the time series budget with 4 series, 2 samples were handled " +
+				"with the 'drop-tag' policy; the tags with the most unique values were url (2), status (1)",
+		}, cb.Report())
+	})
+
+	t.Run("abort", func(t *testing.T) {
+		t.Parallel()
+
+		var abortErrs []error
+		cb := newCardinalityBudget(1, 0, lib.TimeSeriesOverflowAbort, root)
+		cb.abortRun = func(err error) { abortErrs = append(abortErrs, err) }
+		got := filter(cb,
+			newSample(reqs, map[string]string{"url": "/1"}),
+			newSample(vus, nil),
+			newSample(reqs, map[string]string{"url": "/2"}),
+			newSample(reqs, map[string]string{"url": "/1"}),
+		)
+		require.Len(t, got, 2)
+		assert.Equal(t, root.With("url", "/1"), got[0].Tags)
+		assert.Equal(t, root.With("url", "/1"), got[1].Tags)
+
+		require.Len(t, abortErrs, 1)
+		var errWithExitCode errext.HasExitCode
+		require.True(t, errors.As(abortErrs[0], &errWithExitCode))
+		assert.Equal(t, exitcodes.TimeSeriesLimitExceeded, errWithExitCode.ExitCode())
+	})
+
+	t.Run("connected samples", func(t *testing.T) {
+		t.Parallel()
+
+		cb := newCardinalityBudget(1, 0, "", root)
+		connected := metrics.ConnectedSamples{
+			Samples: []metrics.Sample{
+				newSample(reqs, map[string]string{"url": "/1"}),
+				newSample(reqs, map[string]string{"url": "/2"}),
+			},
+			Tags: root.With("url", "/2"),
+		}
+		containers := cb.Filter([]metrics.SampleContainer{connected})
+		require.Len(t, containers, 1)
+		got, ok := containers[0].(metrics.ConnectedSamples)
+		require.True(t, ok)
+		assert.Equal(t, connected.Tags, got.Tags)
+		assert.Equal(t, root.With(lib.OverflowTag, "true"), got.Samples[1].Tags)
+	})
+}
+
+func TestTimeSeriesBudget(t *testing.T) {
+	t.Parallel()
+
+	logger, hook := testutils.NewLoggerWithHook(nil)
+	registry := metrics.NewRegistry()
+	testMetric, err := registry.NewMetric("test_metric", metrics.Counter)
+	require.NoError(t, err)
+
+	assert.Nil(t, NewTimeSeriesBudget(lib.Options{}, registry, logger))
+	budget := NewTimeSeriesBudget(lib.Options{MaxTimeSeriesPerMetric: null.IntFrom(1)}, registry, logger)
+	require.NotNil(t, budget)
+
+	require.NoError(t, budget.Start())
+	containers := budget.FilterSamples([]metrics.SampleContainer{metrics.Samples{
+		{TimeSeries: metrics.TimeSeries{Metric: testMetric, Tags: registry.RootTagSet().With("a", "1")}},
+		{TimeSeries: metrics.TimeSeries{Metric: testMetric, Tags: registry.RootTagSet().With("a", "2")}},
+	}})
+	require.Len(t, containers, 1)
+	assert.Equal(t, registry.RootTagSet().With(lib.OverflowTag, "true"), containers[0].GetSamples()[1].Tags)
+	require.NoError(t, budget.Stop())
+	require.NoError(t, budget.Stop())
+
+	entries := hook.Drain()
+	assert.True(t, testutils.LogContains(entries, logrus.WarnLevel, "The metric 'test_metric' exceeded the time series budget"))
+	var reports int
+	for _, entry := range entries {
+		if entry.Level == logrus.WarnLevel {
+			reports++
+		}
+	}
+	assert.Equal(t, 1, reports)
+}
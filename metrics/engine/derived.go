@@ -0,0 +1,115 @@
+package engine
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ChipArtem/k6/metrics"
+)
+
+const derivedMetricsRate = time.Second
+
+// initDerivedMetrics resolves the metrics and submetrics that are used in the
+// expressions of all the derived metrics in the registry.
+func (me *MetricsEngine) initDerivedMetrics() error {
+	derived := me.registry.Derived()
+	for _, m := range derived {
+		err := m.ResolveExpression(func(name string) (*metrics.Metric, error) {
+			metric, err := me.getThresholdMetricOrSubmetric(name)
+			if err != nil {
+				return nil, fmt.Errorf("invalid metric '%s' in the expression of derived metric '%s': %w", name, m.Name, err)
+			}
+			return metric, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	me.derivedMetrics = derived
+	return nil
+}
+
+// evaluateDerivedMetrics computes the current values of the derived metrics.
+// The ones that reference metrics without any values yet are skipped.
+func (me *MetricsEngine) evaluateDerivedMetrics(t time.Duration) metrics.Samples {
+	me.MetricsLock.Lock()
+	defer me.MetricsLock.Unlock()
+
+	now := time.Now()
+	samples := make(metrics.Samples, 0, len(me.derivedMetrics))
+	for _, m := range me.derivedMetrics {
+		value, ok := m.EvaluateExpression(t)
+		if !ok {
+			continue
+		}
+		samples = append(samples, metrics.Sample{
+			TimeSeries: metrics.TimeSeries{Metric: m, Tags: me.registry.RootTagSet()},
+			Time:       now,
+			Value:      value,
+		})
+	}
+	return samples
+}
+
+// addFinalDerivedMetrics adds the values of the derived metrics directly to
+// their sinks. It's called when all of the samples were ingested, since the
+// last values emitted as samples may not include the most recent ones.
+func (me *MetricsEngine) addFinalDerivedMetrics() {
+	if len(me.derivedMetrics) == 0 || me.getDerivedDuration == nil {
+		return
+	}
+
+	samples := me.evaluateDerivedMetrics(me.getDerivedDuration())
+
+	me.MetricsLock.Lock()
+	defer me.MetricsLock.Unlock()
+	for _, sample := range samples {
+		me.markObserved(sample.Metric)
+		me.addToSinks(sample.Metric, sample)
+	}
+}
+
+// StartDerivedMetricsCalculations spins up a new goroutine that periodically
+// computes the values of the derived metrics and emits them as samples, so
+// they reach all of the outputs and the engine itself, where they can be used
+// by thresholds. It returns a callback that stops the goroutine and has to be
+// called before the samples channel is closed. The final values are added by
+// the ingester when it stops, after all of the samples were ingested.
+func (me *MetricsEngine) StartDerivedMetricsCalculations(
+	samples chan<- metrics.SampleContainer,
+	getCurrentTestRunDuration func() time.Duration,
+) (stop func()) {
+	if len(me.derivedMetrics) == 0 {
+		return func() {}
+	}
+	me.getDerivedDuration = getCurrentTestRunDuration
+
+	emit := func() {
+		if derived := me.evaluateDerivedMetrics(getCurrentTestRunDuration()); len(derived) > 0 {
+			samples <- derived
+		}
+	}
+
+	stopCh := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(derivedMetricsRate)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				emit()
+			case <-stopCh:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(stopCh)
+		<-done
+	}
+}
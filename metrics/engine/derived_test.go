@@ -0,0 +1,110 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ChipArtem/k6/lib"
+	"github.com/ChipArtem/k6/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsEngineDerivedMetrics(t *testing.T) {
+	t.Parallel()
+
+	me := newTestMetricsEngine(t)
+	errs, err := me.registry.NewMetric("errors", metrics.Counter)
+	require.NoError(t, err)
+	reqs, err := me.registry.NewMetric("http_reqs", metrics.Counter)
+	require.NoError(t, err)
+	ratio, err := me.registry.NewDerived("error_ratio", "errors.count / http_reqs.count")
+	require.NoError(t, err)
+	_, err = me.registry.NewDerived("login_rps", "http_reqs{name:login}.rate")
+	require.NoError(t, err)
+
+	thresholds := metrics.NewThresholds([]string{"value<0.05"})
+	require.NoError(t, thresholds.Parse())
+	opts := lib.Options{Thresholds: map[string]metrics.Thresholds{"error_ratio": thresholds}}
+	require.NoError(t, me.InitSubMetricsAndThresholds(opts, false))
+	require.Len(t, me.derivedMetrics, 2)
+	require.Len(t, reqs.Submetrics, 1)
+
+	assert.Empty(t, me.evaluateDerivedMetrics(time.Second), "no values yet")
+
+	now := time.Now()
+	me.addToSinks(errs, metrics.Sample{Time: now, Value: 1})
+	me.addToSinks(reqs, metrics.Sample{Time: now, Value: 10})
+	me.addToSinks(reqs.Submetrics[0].Metric, metrics.Sample{Time: now, Value: 4})
+
+	samples := me.evaluateDerivedMetrics(2 * time.Second)
+	require.Len(t, samples, 2)
+	assert.Equal(t, ratio, samples[0].Metric)
+	assert.Equal(t, 0.1, samples[0].Value)
+	assert.Equal(t, me.registry.RootTagSet(), samples[0].Tags)
+	assert.Equal(t, "login_rps", samples[1].Metric.Name)
+	assert.Equal(t, 2.0, samples[1].Value)
+
+	// the emitted samples are ingested like any other, so they can be used by thresholds
+	me.addToSinks(ratio, samples[0])
+	breached, _ := me.evaluateThresholds(false, zeroTestRunDuration)
+	assert.Equal(t, []string{"error_ratio"}, breached)
+}
+
+func TestMetricsEngineDerivedMetricsInvalid(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]string{
+		"missing metric":  "missing.count / 2",
+		"self reference":  "my_derived.value * 2",
+		"invalid method":  "counter.p(95)",
+		"empty submetric": "counter{}.count",
+		"invalid bucket":  "histogram.le(150)",
+		"missing second":  "counter.count + missing.rate",
+	}
+	for name, expression := range testCases {
+		name, expression := name, expression
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			me := newTestMetricsEngine(t)
+			_, err := me.registry.NewMetric("counter", metrics.Counter)
+			require.NoError(t, err)
+			_, err = me.registry.NewHistogram("histogram", []float64{100, 200})
+			require.NoError(t, err)
+			_, err = me.registry.NewDerived("my_derived", expression)
+			require.NoError(t, err)
+			assert.Error(t, me.InitSubMetricsAndThresholds(lib.Options{}, false))
+		})
+	}
+}
+
+func TestMetricsEngineStartDerivedMetricsCalculations(t *testing.T) {
+	t.Parallel()
+
+	me := newTestMetricsEngine(t)
+	samples := make(chan metrics.SampleContainer, 10)
+	me.StartDerivedMetricsCalculations(samples, zeroTestRunDuration)()
+	assert.Empty(t, samples, "no derived metrics")
+
+	gauge, err := me.registry.NewMetric("gauge", metrics.Gauge)
+	require.NoError(t, err)
+	double, err := me.registry.NewDerived("double", "gauge.value * 2")
+	require.NoError(t, err)
+	require.NoError(t, me.InitSubMetricsAndThresholds(lib.Options{}, false))
+
+	ingester := me.CreateIngester()
+	require.NoError(t, ingester.Start())
+	stop := me.StartDerivedMetricsCalculations(samples, zeroTestRunDuration)
+	ingester.AddMetricSamples([]metrics.SampleContainer{
+		metrics.Sample{TimeSeries: metrics.TimeSeries{Metric: gauge}, Time: time.Now(), Value: 21},
+	})
+	stop()
+
+	// The final values are added when the ingester stops, after it has
+	// ingested all of the samples, even if none were emitted before
+	require.NoError(t, ingester.Stop())
+	require.NoError(t, ingester.Stop())
+	assert.Contains(t, me.ObservedMetrics, "double")
+	assert.Equal(t, 42.0, double.Sink.(*metrics.GaugeSink).Value) //nolint:forcetypeassert
+}
@@ -31,6 +31,31 @@ type MetricsEngine struct {
 	metricsWithThresholds   []*metrics.Metric
 	breachedThresholdsCount uint32
 
+	// windowedSinks keeps the most recent values of the metrics that have
+	// thresholds evaluated over a sliding time window, per window.
+	windowedSinks map[*metrics.Metric]map[time.Duration]*windowedSink
+
+	// expressionThresholds are the thresholds that combine the values of
+	// several metrics or submetrics
+	expressionThresholds metrics.ExpressionThresholds
+
+	// timeline keeps the values of the observed metrics per interval of the
+	// test run, it's nil when the summary timeline isn't enabled.
+	timeline *timeline
+
+	// breakdown keeps the values of the metrics per scenario and per group,
+	// it's nil when the summary isn't in the full mode.
+	breakdown *breakdown
+
+	// derivedMetrics are the metrics whose values are computed from the
+	// values of other metrics, their expressions are already resolved.
+	derivedMetrics     []*metrics.Metric
+	getDerivedDuration func() time.Duration
+
+	// watchers collect the values of the metrics for the executors that
+	// adapt the load to them, see WatchMetric.
+	watchers map[*metrics.Metric][]*metricWatcher
+
 	// TODO: completely refactor:
 	//   - make these private, add a method to export the raw data
 	//   - do not use an unnecessary map for the observed metrics
@@ -46,6 +71,8 @@ func NewMetricsEngine(registry *metrics.Registry, logger logrus.FieldLogger) (*M
 		registry:        registry,
 		logger:          logger.WithField("component", "metrics-engine"),
 		ObservedMetrics: make(map[string]*metrics.Metric),
+		windowedSinks:   make(map[*metrics.Metric]map[time.Duration]*windowedSink),
+		watchers:        make(map[*metrics.Metric][]*metricWatcher),
 	}
 
 	return me, nil
@@ -116,8 +143,20 @@ func (me *MetricsEngine) markObserved(metric *metrics.Metric) {
 
 // InitSubMetricsAndThresholds parses the thresholds from the test Options and
 // initializes both the thresholds themselves, as well as any submetrics that
-// were referenced in them.
+// were referenced in them. It also configures how the Trend metrics should be
+// stored, since that has to happen before any submetrics are created, and
+// resolves the expressions of the derived metrics.
 func (me *MetricsEngine) InitSubMetricsAndThresholds(options lib.Options, onlyLogErrors bool) error {
+	if options.TrendRelativeError.Valid && options.TrendRelativeError.Float64 > 0 {
+		me.registry.SetTrendRelativeError(options.TrendRelativeError.Float64)
+	}
+	if options.SummaryTimelineInterval.Valid && options.SummaryTimelineInterval.Duration > 0 {
+		me.timeline = newTimeline(options.SummaryTimelineInterval.TimeDuration())
+	}
+	if options.SummaryMode.String == lib.SummaryModeFull {
+		me.breakdown = newBreakdown()
+	}
+
 	for metricName, thresholds := range options.Thresholds {
 		metric, err := me.getThresholdMetricOrSubmetric(metricName)
 
@@ -134,6 +173,7 @@ func (me *MetricsEngine) InitSubMetricsAndThresholds(options lib.Options, onlyLo
 
 		metric.Thresholds = thresholds
 		me.metricsWithThresholds = append(me.metricsWithThresholds, metric)
+		me.initWindowedSinks(metric)
 
 		// Mark the metric (and the parent metric, if we're dealing with a
 		// submetric) as observed, so they are shown in the end-of-test summary,
@@ -144,6 +184,16 @@ func (me *MetricsEngine) InitSubMetricsAndThresholds(options lib.Options, onlyLo
 		}
 	}
 
+	if !onlyLogErrors {
+		if err := me.initExpressionThresholds(options.ThresholdExpressions); err != nil {
+			return err
+		}
+	}
+
+	if err := me.initDerivedMetrics(); err != nil {
+		return err
+	}
+
 	// TODO: refactor out of here when https://github.com/grafana/k6/issues/1321
 	// lands and there is a better way to enable a metric with tag
 	if options.SystemTags.Has(metrics.TagExpectedResponse) {
@@ -156,6 +206,189 @@ func (me *MetricsEngine) InitSubMetricsAndThresholds(options lib.Options, onlyLo
 	return nil
 }
 
+// initExpressionThresholds resolves the metrics and submetrics that are used
+// by the cross-metric thresholds, which are expected to be parsed already.
+func (me *MetricsEngine) initExpressionThresholds(expressionThresholds metrics.ExpressionThresholds) error {
+	for _, et := range expressionThresholds {
+		err := et.Resolve(func(name string) (*metrics.Metric, error) {
+			metric, err := me.getThresholdMetricOrSubmetric(name)
+			if err != nil {
+				return nil, fmt.Errorf("invalid metric '%s' in threshold expression '%s': %w", name, et.Source, err)
+			}
+
+			me.markObserved(metric)
+			if metric.Sub != nil {
+				me.markObserved(metric.Sub.Parent)
+			}
+			return metric, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	me.expressionThresholds = expressionThresholds
+	return nil
+}
+
+// ExpressionThresholds returns the cross-metric thresholds of the test, with
+// the results of their last evaluation.
+func (me *MetricsEngine) ExpressionThresholds() metrics.ExpressionThresholds {
+	return me.expressionThresholds
+}
+
+func (me *MetricsEngine) initWindowedSinks(metric *metrics.Metric) {
+	windows := metric.Thresholds.Windows()
+	if len(windows) == 0 {
+		return
+	}
+
+	sinks := make(map[time.Duration]*windowedSink, len(windows))
+	for _, window := range windows {
+		sinks[window] = newWindowedSink(window, func() metrics.Sink {
+			return me.registry.NewMetricSink(metric)
+		})
+	}
+	me.windowedSinks[metric] = sinks
+}
+
+// metricWatcher collects the values of the samples of a metric that have
+// some tags.
+type metricWatcher struct {
+	tags *metrics.TagSet
+	sink metrics.Sink
+}
+
+var _ lib.LiveMetrics = &MetricsEngine{}
+
+// WatchMetric starts collecting the values of the samples of the metric that
+// have all of the given tags. It returns a function that returns a sink with
+// the values collected since its previous call.
+func (me *MetricsEngine) WatchMetric(name string, tags map[string]string) (func() metrics.Sink, error) {
+	metric := me.registry.Get(name)
+	if metric == nil {
+		return nil, fmt.Errorf("metric '%s' does not exist in the script", name)
+	}
+	w := &metricWatcher{
+		tags: me.registry.RootTagSet().WithTagsFromMap(tags),
+		sink: me.registry.NewMetricSink(metric),
+	}
+
+	me.MetricsLock.Lock()
+	defer me.MetricsLock.Unlock()
+	me.watchers[metric] = append(me.watchers[metric], w)
+
+	return func() metrics.Sink {
+		me.MetricsLock.Lock()
+		defer me.MetricsLock.Unlock()
+		sink := w.sink
+		w.sink = me.registry.NewMetricSink(metric)
+		return sink
+	}, nil
+}
+
+// CheckThresholds evaluates the thresholds of the given metrics or sub-metrics
+// with their current values, and returns the names of the ones whose
+// thresholds are crossed. The metrics without any values yet aren't crossed.
+// It doesn't change the state of the thresholds, which are still only
+// evaluated by the periodic evaluation.
+func (me *MetricsEngine) CheckThresholds(names []string, testRunDuration time.Duration) ([]string, error) {
+	me.MetricsLock.Lock()
+	defer me.MetricsLock.Unlock()
+
+	now := time.Now()
+	var crossed []string
+	for _, name := range names {
+		m, err := me.getThresholdMetricOrSubmetric(name)
+		if err != nil {
+			return nil, err
+		}
+		if len(m.Thresholds.Thresholds) == 0 {
+			return nil, fmt.Errorf("the metric '%s' doesn't have any thresholds", name)
+		}
+		if m.Sink.IsEmpty() {
+			continue
+		}
+
+		windowSinks, err := me.getWindowSinks(m, now)
+		if err != nil {
+			return nil, err
+		}
+		succ, err := m.Thresholds.Check(m.Sink, windowSinks, testRunDuration)
+		if err != nil {
+			return nil, err
+		}
+		if !succ {
+			crossed = append(crossed, name)
+		}
+	}
+	return crossed, nil
+}
+
+// addToSinks adds the sample to the metric's sink, as well as to any sliding
+// time window sinks the metric has, to its watchers, to the summary timeline
+// and to the breakdown per scenario and per group.
+func (me *MetricsEngine) addToSinks(metric *metrics.Metric, sample metrics.Sample) {
+	metric.Sink.Add(sample)
+	for _, ws := range me.windowedSinks[metric] {
+		ws.Add(sample)
+	}
+	for _, w := range me.watchers[metric] {
+		if sample.Tags.Contains(w.tags) {
+			w.sink.Add(sample)
+		}
+	}
+	if me.timeline != nil {
+		me.timeline.Add(metric, sample)
+	}
+	if me.breakdown != nil && metric.Sub == nil {
+		me.breakdown.Add(metric, sample)
+	}
+}
+
+// Timeline returns the values of the observed metrics aggregated for each
+// interval of the test run, or nil if the summary timeline isn't enabled.
+func (me *MetricsEngine) Timeline() *lib.SummaryTimeline {
+	if me.timeline == nil {
+		return nil
+	}
+
+	me.MetricsLock.Lock()
+	defer me.MetricsLock.Unlock()
+	return me.timeline.summary()
+}
+
+// Scenarios returns the values of the metrics and the results of the checks
+// per scenario and per group, or nil if the summary isn't in the full mode.
+func (me *MetricsEngine) Scenarios() map[string]*lib.SummaryScenario {
+	if me.breakdown == nil {
+		return nil
+	}
+
+	me.MetricsLock.Lock()
+	defer me.MetricsLock.Unlock()
+	return me.breakdown.summary()
+}
+
+// getWindowSinks returns the sinks with the values of the most recent time
+// windows for the metric, or nil if it has no thresholds with time windows.
+func (me *MetricsEngine) getWindowSinks(metric *metrics.Metric, now time.Time) (map[time.Duration]metrics.Sink, error) {
+	windowedSinks := me.windowedSinks[metric]
+	if len(windowedSinks) == 0 {
+		return nil, nil //nolint:nilnil
+	}
+
+	result := make(map[time.Duration]metrics.Sink, len(windowedSinks))
+	for window, ws := range windowedSinks {
+		sink, err := ws.Sink(now)
+		if err != nil {
+			return nil, err
+		}
+		result[window] = sink
+	}
+	return result, nil
+}
+
 // StartThresholdCalculations spins up a new goroutine to crunch thresholds and
 // returns a callback that will stop the goroutine and finalizes calculations.
 func (me *MetricsEngine) StartThresholdCalculations(
@@ -163,7 +396,7 @@ func (me *MetricsEngine) StartThresholdCalculations(
 	abortRun func(error),
 	getCurrentTestRunDuration func() time.Duration,
 ) (finalize func() (breached []string)) {
-	if len(me.metricsWithThresholds) == 0 {
+	if len(me.metricsWithThresholds) == 0 && len(me.expressionThresholds) == 0 {
 		return nil // no thresholds were defined
 	}
 
@@ -223,6 +456,7 @@ func (me *MetricsEngine) evaluateThresholds(
 	defer me.MetricsLock.Unlock()
 
 	t := getCurrentTestRunDuration()
+	now := time.Now()
 
 	me.logger.Debugf("Running thresholds on %d metrics...", len(me.metricsWithThresholds))
 	for _, m := range me.metricsWithThresholds {
@@ -233,7 +467,13 @@ func (me *MetricsEngine) evaluateThresholds(
 		}
 		m.Tainted = null.BoolFrom(false)
 
-		succ, err := m.Thresholds.Run(m.Sink, t)
+		windowSinks, err := me.getWindowSinks(m, now)
+		if err != nil {
+			me.logger.WithField("metric_name", m.Name).WithError(err).Error("Threshold error")
+			continue
+		}
+
+		succ, err := m.Thresholds.RunWithWindows(m.Sink, windowSinks, t)
 		if err != nil {
 			me.logger.WithField("metric_name", m.Name).WithError(err).Error("Threshold error")
 			continue
@@ -247,6 +487,22 @@ func (me *MetricsEngine) evaluateThresholds(
 			shouldAbort = true
 		}
 	}
+
+	for _, et := range me.expressionThresholds {
+		succ, err := et.Run(t)
+		if err != nil {
+			me.logger.WithField("threshold", et.Source).WithError(err).Error("Threshold error")
+			continue
+		}
+		if succ {
+			continue // threshold passed
+		}
+		breachedThresholds = append(breachedThresholds, et.Source)
+		if et.ShouldAbort(t) {
+			shouldAbort = true
+		}
+	}
+
 	if len(breachedThresholds) > 0 {
 		sort.Strings(breachedThresholds)
 		me.logger.Debugf("Thresholds on %d metrics crossed: %v", len(breachedThresholds), breachedThresholds)
@@ -1,14 +1,17 @@
 package engine
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 
 	"github.com/ChipArtem/k6/lib"
 	"github.com/ChipArtem/k6/lib/testutils"
+	"github.com/ChipArtem/k6/lib/types"
 	"github.com/ChipArtem/k6/metrics"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
 )
 
 func TestNewMetricsEngineWithThresholds(t *testing.T) {
@@ -41,6 +44,50 @@ func TestNewMetricsEngineWithThresholds(t *testing.T) {
 	assert.Len(t, me.metricsWithThresholds, 2)
 }
 
+func TestMetricsEngineTrendRelativeError(t *testing.T) {
+	t.Parallel()
+
+	me := newTestMetricsEngine(t)
+	m, err := me.registry.NewMetric("trend1", metrics.Trend)
+	require.NoError(t, err)
+
+	opts := lib.Options{
+		TrendRelativeError: null.FloatFrom(0.01),
+		Thresholds: map[string]metrics.Thresholds{
+			"trend1{a:b}": {Thresholds: []*metrics.Threshold{}},
+		},
+	}
+	require.NoError(t, me.InitSubMetricsAndThresholds(opts, false))
+
+	assert.True(t, m.Sink.(*metrics.TrendSink).IsHistogram())
+	require.Len(t, m.Submetrics, 1)
+	assert.True(t, m.Submetrics[0].Metric.Sink.(*metrics.TrendSink).IsHistogram())
+}
+
+func TestMetricsEngineTimeline(t *testing.T) {
+	t.Parallel()
+
+	me := newTestMetricsEngine(t)
+	m, err := me.registry.NewMetric("counter1", metrics.Counter)
+	require.NoError(t, err)
+
+	require.NoError(t, me.InitSubMetricsAndThresholds(lib.Options{}, false))
+	assert.Nil(t, me.Timeline())
+
+	opts := lib.Options{SummaryTimelineInterval: types.NullDurationFrom(time.Second)}
+	require.NoError(t, me.InitSubMetricsAndThresholds(opts, false))
+
+	now := time.Now()
+	me.addToSinks(m, metrics.Sample{TimeSeries: metrics.TimeSeries{Metric: m}, Time: now, Value: 1})
+	me.addToSinks(m, metrics.Sample{TimeSeries: metrics.TimeSeries{Metric: m}, Time: now.Add(time.Second), Value: 2})
+
+	timeline := me.Timeline()
+	require.NotNil(t, timeline)
+	assert.Equal(t, time.Second, timeline.Interval)
+	require.Len(t, timeline.Metrics["counter1"], 2)
+	assert.Equal(t, 3.0, m.Sink.(*metrics.CounterSink).Value) //nolint:forcetypeassert
+}
+
 func TestMetricsEngineGetThresholdMetricOrSubmetricError(t *testing.T) {
 	t.Parallel()
 
@@ -153,6 +200,190 @@ func TestMetricsEngineEvaluateIgnoreEmptySink(t *testing.T) {
 	assert.Empty(t, breached)
 }
 
+func TestMetricsEngineEvaluateThresholdsWithWindow(t *testing.T) {
+	t.Parallel()
+
+	me := newTestMetricsEngine(t)
+	m1, err := me.registry.NewMetric("m1", metrics.Trend)
+	require.NoError(t, err)
+
+	ths := metrics.NewThresholds([]string{"max<100 over 1m"})
+	require.NoError(t, ths.Parse())
+	m1.Thresholds = ths
+	me.metricsWithThresholds = []*metrics.Metric{m1}
+	me.initWindowedSinks(m1)
+
+	now := time.Now()
+	me.addToSinks(m1, metrics.Sample{Time: now.Add(-10 * time.Minute), Value: 500})
+	me.addToSinks(m1, metrics.Sample{Time: now, Value: 50})
+
+	breached, _ := me.evaluateThresholds(false, zeroTestRunDuration)
+	assert.Empty(t, breached)
+	assert.Equal(t, 500.0, m1.Sink.(*metrics.TrendSink).Max())
+
+	me.addToSinks(m1, metrics.Sample{Time: now, Value: 150})
+	breached, _ = me.evaluateThresholds(false, zeroTestRunDuration)
+	assert.Equal(t, []string{"m1"}, breached)
+}
+
+func TestMetricsEngineEvaluateExpressionThresholds(t *testing.T) {
+	t.Parallel()
+
+	me := newTestMetricsEngine(t)
+	errs, err := me.registry.NewMetric("errors", metrics.Counter)
+	require.NoError(t, err)
+	iterations, err := me.registry.NewMetric("iterations", metrics.Counter)
+	require.NoError(t, err)
+	duration, err := me.registry.NewMetric("http_req_duration", metrics.Trend)
+	require.NoError(t, err)
+
+	var ets metrics.ExpressionThresholds
+	require.NoError(t, json.Unmarshal([]byte(`[
+		"errors.count / iterations.count < 0.01",
+		{"threshold": "http_req_duration{name:login}.max < 2 * http_req_duration{name:home}.max", "abortOnFail": true}
+	]`), &ets))
+	require.NoError(t, ets.Parse())
+	require.NoError(t, me.InitSubMetricsAndThresholds(lib.Options{ThresholdExpressions: ets}, false))
+	assert.Contains(t, me.ObservedMetrics, "http_req_duration{name:login}")
+	assert.Contains(t, me.ObservedMetrics, "errors")
+
+	breached, abort := me.evaluateThresholds(false, zeroTestRunDuration)
+	assert.Empty(t, breached)
+	assert.False(t, abort)
+
+	errs.Sink.Add(metrics.Sample{Time: time.Now(), Value: 1})
+	iterations.Sink.Add(metrics.Sample{Time: time.Now(), Value: 10})
+	login, err := me.getThresholdMetricOrSubmetric("http_req_duration{name:login}")
+	require.NoError(t, err)
+	home, err := me.getThresholdMetricOrSubmetric("http_req_duration{name:home}")
+	require.NoError(t, err)
+	login.Sink.Add(metrics.Sample{Time: time.Now(), Value: 100})
+	home.Sink.Add(metrics.Sample{Time: time.Now(), Value: 100})
+	duration.Sink.Add(metrics.Sample{Time: time.Now(), Value: 100})
+
+	breached, abort = me.evaluateThresholds(false, zeroTestRunDuration)
+	assert.Equal(t, []string{"errors.count / iterations.count < 0.01"}, breached)
+	assert.False(t, abort)
+	assert.Equal(t, 0.1, ets[0].LastValue.Float64)
+
+	login.Sink.Add(metrics.Sample{Time: time.Now(), Value: 300})
+	breached, abort = me.evaluateThresholds(false, zeroTestRunDuration)
+	assert.Len(t, breached, 2)
+	assert.True(t, abort)
+	assert.Equal(t, ets, me.ExpressionThresholds())
+}
+
+func TestMetricsEngineWatchMetric(t *testing.T) {
+	t.Parallel()
+
+	me := newTestMetricsEngine(t)
+	duration, err := me.registry.NewMetric("http_req_duration", metrics.Trend)
+	require.NoError(t, err)
+
+	_, err = me.WatchMetric("missing", nil)
+	require.Error(t, err)
+	watch, err := me.WatchMetric("http_req_duration", map[string]string{"scenario": "s1"})
+	require.NoError(t, err)
+
+	add := func(scenario string, value float64) {
+		me.addToSinks(duration, metrics.Sample{
+			TimeSeries: metrics.TimeSeries{
+				Metric: duration,
+				Tags:   me.registry.RootTagSet().With("scenario", scenario).With("name", "home"),
+			},
+			Time:  time.Now(),
+			Value: value,
+		})
+	}
+	add("s1", 100)
+	add("s2", 500)
+	add("s1", 300)
+
+	sink, ok := watch().(*metrics.TrendSink)
+	require.True(t, ok)
+	assert.Equal(t, uint64(2), sink.Count())
+	assert.Equal(t, 300.0, sink.Max())
+	assert.True(t, watch().IsEmpty())
+}
+
+func TestMetricsEngineCheckThresholds(t *testing.T) {
+	t.Parallel()
+
+	me := newTestMetricsEngine(t)
+	failed, err := me.registry.NewMetric("http_req_failed", metrics.Rate)
+	require.NoError(t, err)
+	_, err = me.registry.NewMetric("http_reqs", metrics.Counter)
+	require.NoError(t, err)
+
+	ths := metrics.NewThresholds([]string{"rate<0.5"})
+	require.NoError(t, ths.Parse())
+	require.NoError(t, me.InitSubMetricsAndThresholds(lib.Options{
+		Thresholds: map[string]metrics.Thresholds{"http_req_failed{scenario:warmup}": ths},
+	}, false))
+
+	_, err = me.CheckThresholds([]string{"http_reqs"}, time.Second)
+	require.ErrorContains(t, err, "doesn't have any thresholds")
+
+	// The metrics without any values aren't crossed
+	crossed, err := me.CheckThresholds([]string{"http_req_failed{scenario:warmup}"}, time.Second)
+	require.NoError(t, err)
+	assert.Empty(t, crossed)
+
+	sub := failed.Submetrics[0].Metric
+	for _, value := range []float64{1, 1, 0} {
+		me.addToSinks(sub, metrics.Sample{
+			TimeSeries: metrics.TimeSeries{
+				Metric: failed,
+				Tags:   me.registry.RootTagSet().With("scenario", "warmup"),
+			},
+			Time:  time.Now(),
+			Value: value,
+		})
+	}
+	crossed, err = me.CheckThresholds([]string{"http_req_failed{ scenario: warmup }"}, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"http_req_failed{ scenario: warmup }"}, crossed)
+}
+
+func TestMetricsEngineCheckThresholdsNoSideEffects(t *testing.T) {
+	t.Parallel()
+
+	me := newTestMetricsEngine(t)
+	m1, err := me.registry.NewMetric("m1", metrics.Trend)
+	require.NoError(t, err)
+
+	var ths metrics.Thresholds
+	require.NoError(t, json.Unmarshal([]byte(`[{"threshold": "max<100 over 1m", "abortOnFail": true}]`), &ths))
+	require.NoError(t, ths.Parse())
+	require.NoError(t, me.InitSubMetricsAndThresholds(lib.Options{
+		Thresholds: map[string]metrics.Thresholds{"m1": ths},
+	}, false))
+
+	me.addToSinks(m1, metrics.Sample{Time: time.Now(), Value: 150})
+	crossed, err := me.CheckThresholds([]string{"m1"}, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"m1"}, crossed)
+
+	// Only the periodic evaluation changes the state of the thresholds
+	threshold := m1.Thresholds.Thresholds[0]
+	assert.Zero(t, threshold.WindowBreaches)
+	assert.False(t, threshold.LastFailed)
+	assert.False(t, threshold.LastValue.Valid)
+	assert.False(t, m1.Thresholds.Abort)
+
+	breached, abort := me.evaluateThresholds(false, zeroTestRunDuration)
+	assert.Equal(t, []string{"m1"}, breached)
+	assert.True(t, abort)
+	assert.Equal(t, uint64(1), threshold.WindowBreaches)
+	assert.True(t, threshold.LastFailed)
+
+	// The breached window thresholds stay crossed
+	crossed, err = me.CheckThresholds([]string{"m1"}, time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"m1"}, crossed)
+	assert.Equal(t, uint64(1), threshold.WindowBreaches)
+}
+
 func newTestMetricsEngine(t *testing.T) *MetricsEngine {
 	m, err := NewMetricsEngine(metrics.NewRegistry(), testutils.NewLogger(t))
 	require.NoError(t, err)
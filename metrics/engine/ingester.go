@@ -1,6 +1,7 @@
 package engine
 
 import (
+	"sync"
 	"time"
 
 	"github.com/ChipArtem/k6/metrics"
@@ -29,6 +30,8 @@ type OutputIngester struct {
 	metricsEngine   *MetricsEngine
 	periodicFlusher *output.PeriodicFlusher
 	cardinality     *cardinalityControl
+
+	addFinalDerivedMetrics sync.Once
 }
 
 // Description returns a human-readable description of the output.
@@ -55,6 +58,7 @@ func (oi *OutputIngester) Stop() error {
 	oi.logger.Debug("Stopping...")
 	defer oi.logger.Debug("Stopped!")
 	oi.periodicFlusher.Stop()
+	oi.addFinalDerivedMetrics.Do(oi.metricsEngine.addFinalDerivedMetrics)
 	return nil
 }
 
@@ -85,9 +89,9 @@ func (oi *OutputIngester) flushMetrics() {
 		}
 
 		for _, sample := range samples {
-			m := sample.Metric               // this should have come from the Registry, no need to look it up
-			oi.metricsEngine.markObserved(m) // mark it as observed so it shows in the end-of-test summary
-			m.Sink.Add(sample)               // finally, add its value to its own sink
+			m := sample.Metric                     // this should have come from the Registry, no need to look it up
+			oi.metricsEngine.markObserved(m)       // mark it as observed so it shows in the end-of-test summary
+			oi.metricsEngine.addToSinks(m, sample) // finally, add its value to its own sink
 
 			// and also to the same for any submetrics that match the metric sample
 			for _, sm := range m.Submetrics {
@@ -95,7 +99,7 @@ func (oi *OutputIngester) flushMetrics() {
 					continue
 				}
 				oi.metricsEngine.markObserved(sm.Metric)
-				sm.Metric.Sink.Add(sample)
+				oi.metricsEngine.addToSinks(sm.Metric, sample)
 			}
 
 			oi.cardinality.Add(sample.TimeSeries)
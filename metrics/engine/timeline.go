@@ -0,0 +1,78 @@
+package engine
+
+import (
+	"time"
+
+	"github.com/ChipArtem/k6/lib"
+	"github.com/ChipArtem/k6/metrics"
+)
+
+// summaryTrendRelativeError is the relative error of the percentiles of the
+// Trend metrics in the timeline and breakdown of the end-of-test summary.
+const summaryTrendRelativeError = 0.01
+
+// newSummarySink returns a new empty sink for the metric, which stores the
+// values of the Trend metrics in a histogram, so the timeline and breakdown
+// don't need to keep all of them once more for every bucket.
+func newSummarySink(m *metrics.Metric) metrics.Sink {
+	switch m.Type {
+	case metrics.Trend:
+		return metrics.NewHistogramTrendSink(summaryTrendRelativeError)
+	case metrics.Histogram:
+		return metrics.NewHistogramSink(m.Buckets)
+	default:
+		return metrics.NewSink(m.Type)
+	}
+}
+
+// timeline keeps the values of the observed metrics aggregated in intervals of
+// a fixed size, so the end-of-test summary can show how they changed over time.
+type timeline struct {
+	interval time.Duration
+
+	// buckets are ordered by their start time
+	buckets map[*metrics.Metric][]windowBucket
+}
+
+func newTimeline(interval time.Duration) *timeline {
+	return &timeline{
+		interval: interval,
+		buckets:  make(map[*metrics.Metric][]windowBucket),
+	}
+}
+
+// Add adds the sample to the metric's bucket for the interval of its time.
+func (tl *timeline) Add(metric *metrics.Metric, s metrics.Sample) {
+	start := s.Time.Truncate(tl.interval)
+	buckets := tl.buckets[metric]
+
+	// Samples mostly arrive in order, so search from the end
+	i := len(buckets)
+	for i > 0 && buckets[i-1].start.After(start) {
+		i--
+	}
+	if i == 0 || !buckets[i-1].start.Equal(start) {
+		buckets = append(buckets, windowBucket{})
+		copy(buckets[i+1:], buckets[i:])
+		buckets[i] = windowBucket{start: start, sink: newSummarySink(metric)}
+		tl.buckets[metric] = buckets
+		i++
+	}
+	buckets[i-1].sink.Add(s)
+}
+
+// summary returns the timeline data for the end-of-test summary.
+func (tl *timeline) summary() *lib.SummaryTimeline {
+	result := &lib.SummaryTimeline{
+		Interval: tl.interval,
+		Metrics:  make(map[string][]lib.SummaryTimelineBucket, len(tl.buckets)),
+	}
+	for metric, buckets := range tl.buckets {
+		points := make([]lib.SummaryTimelineBucket, len(buckets))
+		for i, b := range buckets {
+			points[i] = lib.SummaryTimelineBucket{Time: b.start, Sink: b.sink}
+		}
+		result.Metrics[metric.Name] = points
+	}
+	return result
+}
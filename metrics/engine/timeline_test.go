@@ -0,0 +1,47 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ChipArtem/k6/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeline(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	counter := registry.MustNewMetric("counter", metrics.Counter)
+	trend := registry.MustNewMetric("trend", metrics.Trend)
+
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	tl := newTimeline(10 * time.Second)
+	for i := 0; i < 25; i++ {
+		tl.Add(counter, metrics.Sample{Time: start.Add(time.Duration(i) * time.Second), Value: 1})
+	}
+	// an out of order sample, for an interval without any other samples
+	tl.Add(trend, metrics.Sample{Time: start.Add(35 * time.Second), Value: 2})
+	tl.Add(trend, metrics.Sample{Time: start.Add(5 * time.Second), Value: 1})
+
+	summary := tl.summary()
+	assert.Equal(t, 10*time.Second, summary.Interval)
+	require.Len(t, summary.Metrics, 2)
+
+	counterBuckets := summary.Metrics["counter"]
+	require.Len(t, counterBuckets, 3)
+	for i, count := range []float64{10, 10, 5} {
+		assert.Equal(t, start.Add(time.Duration(i)*10*time.Second), counterBuckets[i].Time)
+		assert.Equal(t, count, counterBuckets[i].Sink.(*metrics.CounterSink).Value) //nolint:forcetypeassert
+	}
+
+	trendBuckets := summary.Metrics["trend"]
+	require.Len(t, trendBuckets, 2)
+	assert.Equal(t, start, trendBuckets[0].Time)
+	assert.True(t, trendBuckets[0].Sink.(*metrics.TrendSink).IsHistogram(), //nolint:forcetypeassert
+		"the buckets don't keep all of the values")
+	assert.Equal(t, 1.0, trendBuckets[0].Sink.(*metrics.TrendSink).Max()) //nolint:forcetypeassert
+	assert.Equal(t, start.Add(30*time.Second), trendBuckets[1].Time)
+	assert.Equal(t, 2.0, trendBuckets[1].Sink.(*metrics.TrendSink).Max()) //nolint:forcetypeassert
+}
@@ -0,0 +1,149 @@
+package engine
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ChipArtem/k6/metrics"
+)
+
+// windowBucketsCount is the number of time buckets a sliding window is split
+// into. The window moves forward one bucket at a time, so a higher number
+// makes it more precise, at the cost of more memory and merging.
+const windowBucketsCount = 10
+
+// windowBucket holds the metric values for a single slice of a window.
+type windowBucket struct {
+	start time.Time
+	sink  metrics.Sink
+}
+
+// windowedSink keeps the metric values for the most recent time window, so
+// thresholds can be evaluated over it instead of over the whole test run.
+type windowedSink struct {
+	window     time.Duration
+	bucketSize time.Duration
+	newSink    func() metrics.Sink
+
+	// buckets are ordered by their start time
+	buckets []windowBucket
+}
+
+func newWindowedSink(window time.Duration, newSink func() metrics.Sink) *windowedSink {
+	bucketSize := window / windowBucketsCount
+	if bucketSize <= 0 {
+		bucketSize = window
+	}
+	return &windowedSink{
+		window:     window,
+		bucketSize: bucketSize,
+		newSink:    newSink,
+	}
+}
+
+// Add adds the sample to the bucket for its time, and drops the buckets that
+// are already too old to be a part of the window.
+func (ws *windowedSink) Add(s metrics.Sample) {
+	start := s.Time.Truncate(ws.bucketSize)
+
+	// Samples mostly arrive in order, so search from the end
+	i := len(ws.buckets)
+	for i > 0 && ws.buckets[i-1].start.After(start) {
+		i--
+	}
+	if i == 0 || !ws.buckets[i-1].start.Equal(start) {
+		if i == 0 && len(ws.buckets) > 0 && start.Before(ws.buckets[0].start.Add(-ws.window)) {
+			return // the sample is too old to matter
+		}
+		ws.buckets = append(ws.buckets, windowBucket{})
+		copy(ws.buckets[i+1:], ws.buckets[i:])
+		ws.buckets[i] = windowBucket{start: start, sink: ws.newSink()}
+		i++
+	}
+	ws.buckets[i-1].sink.Add(s)
+
+	ws.dropBefore(ws.buckets[len(ws.buckets)-1].start.Add(-ws.window))
+}
+
+// dropBefore removes all the buckets that finished before the provided time.
+func (ws *windowedSink) dropBefore(t time.Time) {
+	n := 0
+	for n < len(ws.buckets) && !ws.buckets[n].start.Add(ws.bucketSize).After(t) {
+		n++
+	}
+	if n > 0 {
+		ws.buckets = append(ws.buckets[:0], ws.buckets[n:]...)
+	}
+}
+
+// Sink returns a new sink with the merged values of all the buckets within
+// the window that ends at the provided time.
+func (ws *windowedSink) Sink(now time.Time) (metrics.Sink, error) {
+	ws.dropBefore(now.Add(-ws.window))
+
+	result := ws.newSink()
+	for _, b := range ws.buckets {
+		if err := mergeSinks(result, b.sink); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// mergeSinks adds all the values from src into dst, which must be
+// a sink for the same metric type and should hold older values.
+func mergeSinks(dst, src metrics.Sink) error {
+	if src.IsEmpty() {
+		return nil
+	}
+
+	switch dstImpl := dst.(type) {
+	case *metrics.CounterSink:
+		srcImpl, ok := src.(*metrics.CounterSink)
+		if !ok {
+			break
+		}
+		dstImpl.Value += srcImpl.Value
+		if dstImpl.First.IsZero() || srcImpl.First.Before(dstImpl.First) {
+			dstImpl.First = srcImpl.First
+		}
+		return nil
+	case *metrics.GaugeSink:
+		srcImpl, ok := src.(*metrics.GaugeSink)
+		if !ok {
+			break
+		}
+		wasEmpty := dstImpl.IsEmpty()
+		// Adding the min and max values first keeps the latest value
+		// from the source as the gauge value.
+		dstImpl.Add(metrics.Sample{Value: srcImpl.Min})
+		dstImpl.Add(metrics.Sample{Value: srcImpl.Max})
+		dstImpl.Add(metrics.Sample{Value: srcImpl.Value})
+		if wasEmpty {
+			dstImpl.Max = srcImpl.Max
+		}
+		return nil
+	case *metrics.RateSink:
+		srcImpl, ok := src.(*metrics.RateSink)
+		if !ok {
+			break
+		}
+		dstImpl.Trues += srcImpl.Trues
+		dstImpl.Total += srcImpl.Total
+		return nil
+	case *metrics.TrendSink:
+		srcImpl, ok := src.(*metrics.TrendSink)
+		if !ok {
+			break
+		}
+		return dstImpl.Merge(srcImpl)
+	case *metrics.HistogramSink:
+		srcImpl, ok := src.(*metrics.HistogramSink)
+		if !ok {
+			break
+		}
+		return dstImpl.Merge(srcImpl)
+	}
+
+	return fmt.Errorf("can't merge sinks of types %T and %T", src, dst)
+}
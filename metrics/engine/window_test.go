@@ -0,0 +1,65 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ChipArtem/k6/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWindowedSink(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	ws := newWindowedSink(time.Minute, func() metrics.Sink { return metrics.NewTrendSink() })
+	assert.Equal(t, 6*time.Second, ws.bucketSize)
+
+	for i := 0; i < 120; i++ {
+		ws.Add(metrics.Sample{Time: start.Add(time.Duration(i) * time.Second), Value: float64(i)})
+	}
+	// an out of order sample, that is still within the window
+	ws.Add(metrics.Sample{Time: start.Add(100 * time.Second), Value: 1000})
+	// and one that is already too old
+	ws.Add(metrics.Sample{Time: start, Value: -1000})
+
+	sink, err := ws.Sink(start.Add(120 * time.Second))
+	require.NoError(t, err)
+	trend, ok := sink.(*metrics.TrendSink)
+	require.True(t, ok)
+	assert.Equal(t, uint64(61), trend.Count())
+	assert.Equal(t, 60.0, trend.Min())
+	assert.Equal(t, 1000.0, trend.Max())
+
+	sink, err = ws.Sink(start.Add(10 * time.Minute))
+	require.NoError(t, err)
+	assert.True(t, sink.IsEmpty())
+}
+
+func TestMergeSinks(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+
+	counter := &metrics.CounterSink{}
+	require.NoError(t, mergeSinks(counter, &metrics.CounterSink{Value: 3, First: now}))
+	require.NoError(t, mergeSinks(counter, &metrics.CounterSink{Value: 2, First: now.Add(time.Second)}))
+	assert.Equal(t, &metrics.CounterSink{Value: 5, First: now}, counter)
+
+	gauge := &metrics.GaugeSink{}
+	src := &metrics.GaugeSink{}
+	src.Add(metrics.Sample{Value: 5})
+	src.Add(metrics.Sample{Value: 1})
+	src.Add(metrics.Sample{Value: 3})
+	require.NoError(t, mergeSinks(gauge, src))
+	assert.Equal(t, 3.0, gauge.Value)
+	assert.Equal(t, 1.0, gauge.Min)
+	assert.Equal(t, 5.0, gauge.Max)
+
+	rate := &metrics.RateSink{Trues: 1, Total: 2}
+	require.NoError(t, mergeSinks(rate, &metrics.RateSink{Trues: 3, Total: 4}))
+	assert.Equal(t, &metrics.RateSink{Trues: 4, Total: 6}, rate)
+
+	assert.Error(t, mergeSinks(rate, &metrics.CounterSink{Value: 1, First: now}))
+}
@@ -15,6 +15,13 @@ type Metric struct {
 	Type     MetricType `json:"type"`
 	Contains ValueType  `json:"contains"`
 
+	// Buckets are the upper bounds of the buckets of Histogram metrics
+	Buckets []float64 `json:"buckets,omitempty"`
+	// Expression is the source of the values of derived metrics
+	Expression string `json:"expression,omitempty"`
+	// derived is the parsed Expression, it's nil for regular metrics
+	derived *arithmeticExpression
+
 	// TODO: decouple the metrics from the sinks and thresholds... have them
 	// linked, but not in the same struct?
 	Tainted    null.Bool    `json:"tainted"`
@@ -73,6 +80,10 @@ func (m *Metric) AddSubmetric(keyValues string) (*Submetric, error) {
 		Parent: m,
 	}
 	subMetricMetric := m.registry.newMetric(subMetric.Name, m.Type, m.Contains)
+	if m.Type == Histogram {
+		subMetricMetric.Buckets = m.Buckets
+		subMetricMetric.Sink = NewHistogramSink(m.Buckets)
+	}
 	subMetricMetric.Sub = subMetric // sigh
 	subMetric.Metric = subMetricMetric
 
@@ -13,10 +13,11 @@ func TestNewMetric(t *testing.T) {
 		Type     MetricType
 		SinkType Sink
 	}{
-		"Counter": {Counter, &CounterSink{}},
-		"Gauge":   {Gauge, &GaugeSink{}},
-		"Trend":   {Trend, NewTrendSink()},
-		"Rate":    {Rate, &RateSink{}},
+		"Counter":   {Counter, &CounterSink{}},
+		"Gauge":     {Gauge, &GaugeSink{}},
+		"Trend":     {Trend, NewTrendSink()},
+		"Rate":      {Rate, &RateSink{}},
+		"Histogram": {Histogram, &HistogramSink{}},
 	}
 
 	for name, data := range testdata {
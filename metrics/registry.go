@@ -14,6 +14,10 @@ type Registry struct {
 	l       sync.RWMutex
 
 	rootTagSet *atlas.Node
+
+	// trendRelativeError, if non-zero, makes all Trend metrics use a
+	// histogram sink with the given relative error.
+	trendRelativeError float64
 }
 
 // NewRegistry returns a new registry
@@ -57,6 +61,9 @@ func (r *Registry) NewMetric(name string, typ MetricType, t ...ValueType) (*Metr
 	if oldMetric.Type != typ {
 		return nil, fmt.Errorf("metric '%s' already exists but with type %s, instead of %s", name, oldMetric.Type, typ)
 	}
+	if oldMetric.derived != nil {
+		return nil, fmt.Errorf("metric '%s' already exists as a derived metric, its values can't be added", name)
+	}
 	if len(t) > 0 {
 		if t[0] != oldMetric.Contains {
 			return nil, fmt.Errorf("metric '%s' already exists but with a value type %s, instead of %s",
@@ -66,6 +73,29 @@ func (r *Registry) NewMetric(name string, typ MetricType, t ...ValueType) (*Metr
 	return oldMetric, nil
 }
 
+// NewHistogram returns a new Histogram metric with the provided bucket upper
+// bounds, registered to this registry
+func (r *Registry) NewHistogram(name string, buckets []float64, t ...ValueType) (*Metric, error) {
+	if err := ValidateBuckets(buckets); err != nil {
+		return nil, fmt.Errorf("invalid buckets for histogram metric '%s': %w", name, err)
+	}
+
+	m, err := r.NewMetric(name, Histogram, t...)
+	if err != nil {
+		return nil, err
+	}
+
+	r.l.Lock()
+	defer r.l.Unlock()
+	if m.Buckets == nil {
+		m.Buckets = append([]float64(nil), buckets...)
+		m.Sink = NewHistogramSink(m.Buckets)
+	} else if !equalBuckets(m.Buckets, buckets) {
+		return nil, fmt.Errorf("metric '%s' already exists but with buckets %v, instead of %v", name, m.Buckets, buckets)
+	}
+	return m, nil
+}
+
 // MustNewMetric is like NewMetric, but will panic if there is an error
 func (r *Registry) MustNewMetric(name string, typ MetricType, t ...ValueType) *Metric {
 	m, err := r.NewMetric(name, typ, t...)
@@ -96,13 +126,56 @@ func (r *Registry) newMetric(name string, mt MetricType, vt ...ValueType) *Metri
 		valueType = vt[0]
 	}
 
-	sink := NewSink(mt)
 	return &Metric{
 		registry: r,
 		Name:     name,
 		Type:     mt,
 		Contains: valueType,
-		Sink:     sink,
+		Sink:     r.NewSink(mt),
+	}
+}
+
+// NewSink creates a new empty Sink for the provided MetricType, the same way
+// the sinks of the metrics created by the registry are.
+func (r *Registry) NewSink(mt MetricType) Sink {
+	if mt == Trend && r.trendRelativeError > 0 {
+		return NewHistogramTrendSink(r.trendRelativeError)
+	}
+	return NewSink(mt)
+}
+
+// NewMetricSink creates a new empty Sink for the provided metric, the same way
+// its own sink was created. Unlike NewSink, it takes the buckets of Histogram
+// metrics into account.
+func (r *Registry) NewMetricSink(m *Metric) Sink {
+	if m.Type == Histogram {
+		return NewHistogramSink(m.Buckets)
+	}
+	return r.NewSink(m.Type)
+}
+
+// SetTrendRelativeError makes all Trend metrics, and their submetrics, store
+// their values in histograms with the given relative error instead of keeping
+// every single value; a zero value restores the default behavior. It affects
+// the metrics that are created after it is called, as well as the already
+// existing ones that are still empty.
+func (r *Registry) SetTrendRelativeError(relativeError float64) {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	r.trendRelativeError = relativeError
+	for _, m := range r.metrics {
+		if m.Type != Trend {
+			continue
+		}
+		if m.Sink.IsEmpty() {
+			m.Sink = r.NewSink(Trend)
+		}
+		for _, sm := range m.Submetrics {
+			if sm.Metric.Sink.IsEmpty() {
+				sm.Metric.Sink = r.NewSink(Trend)
+			}
+		}
 	}
 }
 
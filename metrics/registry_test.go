@@ -101,3 +101,54 @@ func TestRegistryAll(t *testing.T) {
 		assert.ElementsMatch(t, exp, names(metrics))
 	})
 }
+
+func TestRegistrySetTrendRelativeError(t *testing.T) {
+	t.Parallel()
+	r := NewRegistry()
+
+	empty := r.MustNewMetric("empty", Trend)
+	used := r.MustNewMetric("used", Trend)
+	used.Sink.Add(Sample{Value: 1})
+	counter := r.MustNewMetric("counter", Counter)
+
+	r.SetTrendRelativeError(0.01)
+	assert.True(t, empty.Sink.(*TrendSink).IsHistogram())
+	assert.False(t, used.Sink.(*TrendSink).IsHistogram())
+	assert.IsType(t, &CounterSink{}, counter.Sink)
+
+	created := r.MustNewMetric("created", Trend)
+	assert.True(t, created.Sink.(*TrendSink).IsHistogram())
+
+	sm, err := used.AddSubmetric("a:b")
+	require.NoError(t, err)
+	assert.True(t, sm.Metric.Sink.(*TrendSink).IsHistogram())
+}
+
+func TestRegistryNewHistogram(t *testing.T) {
+	t.Parallel()
+	r := NewRegistry()
+
+	m, err := r.NewHistogram("latency", []float64{100, 200, 300}, Time)
+	require.NoError(t, err)
+	assert.Equal(t, Histogram, m.Type)
+	assert.Equal(t, Time, m.Contains)
+	assert.Equal(t, []float64{100, 200, 300}, m.Buckets)
+	assert.Equal(t, NewHistogramSink([]float64{100, 200, 300}), m.Sink)
+
+	same, err := r.NewHistogram("latency", []float64{100, 200, 300}, Time)
+	require.NoError(t, err)
+	assert.Same(t, m, same)
+
+	_, err = r.NewHistogram("latency", []float64{100, 200}, Time)
+	assert.Error(t, err)
+	_, err = r.NewHistogram("latency", []float64{100, 200, 300}, Default)
+	assert.Error(t, err)
+	_, err = r.NewHistogram("unsorted", []float64{200, 100})
+	assert.Error(t, err)
+
+	sm, err := m.AddSubmetric("a:b")
+	require.NoError(t, err)
+	assert.Equal(t, m.Buckets, sm.Metric.Buckets)
+	assert.Equal(t, NewHistogramSink(m.Buckets), sm.Metric.Sink)
+	assert.Equal(t, NewHistogramSink(m.Buckets), r.NewMetricSink(m))
+}
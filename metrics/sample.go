@@ -140,17 +140,25 @@ func PushIfNotDone(ctx context.Context, output chan<- SampleContainer, sample Sa
 // the summary output and then returns a map of the corresponding resolvers.
 func GetResolversForTrendColumns(trendColumns []string) (map[string]func(s *TrendSink) float64, error) {
 	staticResolvers := map[string]func(s *TrendSink) float64{
-		"avg":   func(s *TrendSink) float64 { return s.Avg() },
-		"min":   func(s *TrendSink) float64 { return s.Min() },
-		"med":   func(s *TrendSink) float64 { return s.P(0.5) },
-		"max":   func(s *TrendSink) float64 { return s.Max() },
-		"count": func(s *TrendSink) float64 { return float64(s.Count()) },
+		"avg":    func(s *TrendSink) float64 { return s.Avg() },
+		"min":    func(s *TrendSink) float64 { return s.Min() },
+		"med":    func(s *TrendSink) float64 { return s.P(0.5) },
+		"max":    func(s *TrendSink) float64 { return s.Max() },
+		"count":  func(s *TrendSink) float64 { return float64(s.Count()) },
+		"sum":    func(s *TrendSink) float64 { return s.Total() },
+		"stddev": func(s *TrendSink) float64 { return s.StdDev() },
+		"iqr":    func(s *TrendSink) float64 { return s.IQR() },
 	}
 	dynamicResolver := func(percentile float64) func(s *TrendSink) float64 {
 		return func(s *TrendSink) float64 {
 			return s.P(percentile / 100)
 		}
 	}
+	trimmedMeanResolver := func(trimmed float64) func(s *TrendSink) float64 {
+		return func(s *TrendSink) float64 {
+			return s.TrimmedMean(trimmed / 100)
+		}
+	}
 
 	result := make(map[string]func(s *TrendSink) float64, len(trendColumns))
 
@@ -160,6 +168,15 @@ func GetResolversForTrendColumns(trendColumns []string) (map[string]func(s *Tren
 			continue
 		}
 
+		if strings.HasPrefix(stat, "tmean(") {
+			trimmed, err := parseTrimmedMean(stat)
+			if err != nil {
+				return nil, err
+			}
+			result[stat] = trimmedMeanResolver(trimmed)
+			continue
+		}
+
 		percentile, err := parsePercentile(stat)
 		if err != nil {
 			return nil, err
@@ -184,3 +201,19 @@ func parsePercentile(stat string) (float64, error) {
 
 	return percentile, nil
 }
+
+// parseTrimmedMean is a helper function to parse and validate trimmed mean
+// notations, e.g. tmean(10) for the mean without the lowest and highest 10%.
+func parseTrimmedMean(stat string) (float64, error) {
+	if !strings.HasPrefix(stat, "tmean(") || !strings.HasSuffix(stat, ")") {
+		return 0, fmt.Errorf("invalid trend stat '%s', unknown format", stat)
+	}
+
+	trimmed, err := strconv.ParseFloat(stat[6:len(stat)-1], 64)
+
+	if err != nil || (trimmed < 0) || (trimmed >= 50) {
+		return 0, fmt.Errorf("invalid trimmed mean trend stat value '%s', provide a number between 0 and 50", stat)
+	}
+
+	return trimmed, nil
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestSampleImplementations(t *testing.T) {
@@ -53,6 +54,11 @@ func TestGetResolversForTrendColumnsValidation(t *testing.T) {
 		{[]string{"p(-1)"}, true},
 		{[]string{"p(101)"}, true},
 		{[]string{"p(1)"}, false},
+		{[]string{"sum", "stddev", "iqr", "tmean(0)", "tmean(10)", "tmean(49.9)"}, false},
+		{[]string{"tmean(50)"}, true},
+		{[]string{"tmean(-1)"}, true},
+		{[]string{"tmean(err)"}, true},
+		{[]string{"tmean"}, true},
 	}
 
 	for _, tc := range validateTests {
@@ -98,6 +104,20 @@ func TestGetResolversForTrendColumnsCalculation(t *testing.T) {
 	}
 }
 
+func TestGetResolversForTrendColumnsExtraStats(t *testing.T) {
+	t.Parallel()
+
+	sink := createTestTrendSink(100)
+	res, err := GetResolversForTrendColumns([]string{"count", "sum", "stddev", "iqr", "tmean(10)"})
+	require.NoError(t, err)
+
+	assert.Equal(t, 100.0, res["count"](sink))
+	assert.Equal(t, 4950.0, res["sum"](sink))
+	assert.InDelta(t, sink.StdDev(), res["stddev"](sink), 0.000001)
+	assert.InDelta(t, 49.5, res["iqr"](sink), 0.000001)
+	assert.InDelta(t, 49.5, res["tmean(10)"](sink), 0.000001)
+}
+
 func createTestTrendSink(count int) *TrendSink {
 	sink := NewTrendSink()
 
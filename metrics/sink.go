@@ -1,9 +1,11 @@
 package metrics
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"sort"
+	"strconv"
 	"time"
 )
 
@@ -12,6 +14,7 @@ var (
 	_ Sink = &GaugeSink{}
 	_ Sink = NewTrendSink()
 	_ Sink = &RateSink{}
+	_ Sink = NewHistogramSink(nil)
 )
 
 type Sink interface {
@@ -33,6 +36,10 @@ func NewSink(mt MetricType) Sink {
 		sink = NewTrendSink()
 	case Rate:
 		sink = &RateSink{}
+	case Histogram:
+		// Histogram metrics have their own buckets, so their sinks should
+		// be created with NewHistogramSink(); this one only has a +Inf bucket.
+		sink = NewHistogramSink(nil)
 	default:
 		// Should not be possible to create
 		// an invalid metric type except for specific
@@ -88,39 +95,108 @@ func (g *GaugeSink) Format(t time.Duration) map[string]float64 {
 	return map[string]float64{"value": g.Value}
 }
 
-// NewTrendSink makes a Trend sink with the OpenHistogram circllhist histogram.
+// NewTrendSink makes a Trend sink that keeps every observed value, so its
+// percentiles are exact.
 func NewTrendSink() *TrendSink {
 	return &TrendSink{}
 }
 
+// NewHistogramTrendSink makes a Trend sink that doesn't keep the observed
+// values, but instead stores them in a histogram with the given relative error
+// (e.g. 0.01 for 1%). Its memory usage is bounded regardless of the number of
+// observed values and only its percentiles are approximated, the count, min,
+// max, sum and avg are still exact.
+func NewHistogramTrendSink(relativeError float64) *TrendSink {
+	return &TrendSink{hist: newTrendHistogram(relativeError)}
+}
+
 type TrendSink struct {
 	values []float64
 	sorted bool
 
+	// hist is only set for sinks created with NewHistogramTrendSink(), and
+	// when it is, values is never used.
+	hist *trendHistogram
+
 	count    uint64
 	min, max float64
 	sum      float64
+
+	// m2 is the sum of the squared differences from the mean, which is
+	// updated incrementally to calculate the standard deviation.
+	m2 float64
 }
 
 // IsEmpty indicates whether the TrendSink is empty.
 func (t *TrendSink) IsEmpty() bool { return t.count == 0 }
 
 func (t *TrendSink) Add(s Sample) {
+	t.addValue(s.Value)
+}
+
+func (t *TrendSink) addValue(v float64) {
 	if t.count == 0 {
-		t.max, t.min = s.Value, s.Value
+		t.max, t.min = v, v
 	} else {
-		if s.Value > t.max {
-			t.max = s.Value
+		if v > t.max {
+			t.max = v
 		}
-		if s.Value < t.min {
-			t.min = s.Value
+		if v < t.min {
+			t.min = v
 		}
 	}
 
-	t.values = append(t.values, s.Value)
-	t.sorted = false
+	if t.hist != nil {
+		t.hist.add(v)
+	} else {
+		t.values = append(t.values, v)
+		t.sorted = false
+	}
+	oldAvg := t.Avg()
 	t.count++
-	t.sum += s.Value
+	t.sum += v
+	t.m2 += (v - oldAvg) * (v - t.Avg())
+}
+
+// IsHistogram returns true if the sink was created with NewHistogramTrendSink()
+// and its percentiles are approximated.
+func (t *TrendSink) IsHistogram() bool {
+	return t.hist != nil
+}
+
+// Merge adds all of the values observed by the other sink to this one. A sink
+// that keeps every value can't be merged with a histogram one, since the
+// original values are not available anymore.
+func (t *TrendSink) Merge(other *TrendSink) error {
+	if other.count == 0 {
+		return nil
+	}
+
+	switch {
+	case other.hist == nil:
+		for _, v := range other.values {
+			t.addValue(v)
+		}
+		return nil
+	case t.hist == nil:
+		return fmt.Errorf("can't merge a histogram trend sink into one that keeps all values")
+	}
+
+	if err := t.hist.merge(other.hist); err != nil {
+		return err
+	}
+	if t.count == 0 || other.max > t.max {
+		t.max = other.max
+	}
+	if t.count == 0 || other.min < t.min {
+		t.min = other.min
+	}
+	delta := other.Avg() - t.Avg()
+	total := float64(t.count + other.count)
+	t.m2 += other.m2 + delta*delta*float64(t.count)*float64(other.count)/total
+	t.count += other.count
+	t.sum += other.sum
+	return nil
 }
 
 // P calculates the given percentile from sink values.
@@ -129,8 +205,11 @@ func (t *TrendSink) P(pct float64) float64 {
 	case 0:
 		return 0
 	case 1:
-		return t.values[0]
+		return t.min
 	default:
+		if t.hist != nil {
+			return t.histogramP(pct)
+		}
 		if !t.sorted {
 			sort.Float64s(t.values)
 			t.sorted = true
@@ -147,6 +226,19 @@ func (t *TrendSink) P(pct float64) float64 {
 	}
 }
 
+// histogramP approximates the given percentile from the histogram buckets,
+// keeping the result within the exactly known min and max values.
+func (t *TrendSink) histogramP(pct float64) float64 {
+	if pct <= 0 {
+		return t.min
+	}
+	if pct >= 1 {
+		return t.max
+	}
+	v := t.hist.quantile(pct * (float64(t.count) - 1.0))
+	return math.Max(t.min, math.Min(t.max, v))
+}
+
 // Min returns the minimum value.
 func (t *TrendSink) Min() float64 {
 	return t.min
@@ -175,8 +267,52 @@ func (t *TrendSink) Total() float64 {
 	return t.sum
 }
 
+// StdDev returns the population standard deviation of the recorded values.
+func (t *TrendSink) StdDev() float64 {
+	if t.count == 0 {
+		return 0
+	}
+	return math.Sqrt(t.m2 / float64(t.count))
+}
+
+// IQR returns the interquartile range, i.e. the difference between the 75th
+// and the 25th percentiles.
+func (t *TrendSink) IQR() float64 {
+	return t.P(0.75) - t.P(0.25)
+}
+
+// TrimmedMean returns the average of the recorded values, after the given
+// proportion (between 0 and 0.5) of the lowest and of the highest values are
+// discarded. For example, TrimmedMean(0.1) ignores the lowest and the highest
+// 10% of the values.
+func (t *TrendSink) TrimmedMean(proportion float64) float64 {
+	if t.count == 0 {
+		return 0
+	}
+
+	trimmed := uint64(math.Floor(proportion * float64(t.count)))
+	if 2*trimmed >= t.count {
+		return t.P(0.5)
+	}
+	if trimmed == 0 {
+		return t.Avg()
+	}
+
+	if t.hist != nil {
+		return t.hist.mean(trimmed, t.count-trimmed)
+	}
+	if !t.sorted {
+		sort.Float64s(t.values)
+		t.sorted = true
+	}
+	var sum float64
+	for _, v := range t.values[trimmed : t.count-trimmed] {
+		sum += v
+	}
+	return sum / float64(t.count-2*trimmed)
+}
+
 func (t *TrendSink) Format(tt time.Duration) map[string]float64 {
-	// TODO: respect the summaryTrendStats for REST API
 	return map[string]float64{
 		"min":   t.Min(),
 		"max":   t.Max(),
@@ -210,3 +346,154 @@ func (r RateSink) Format(t time.Duration) map[string]float64 {
 
 	return map[string]float64{"rate": rate}
 }
+
+// HistogramSink counts the observed values in buckets with fixed upper bounds,
+// the same way Prometheus histograms do. Besides the buckets, it also keeps the
+// exact count, sum, min and max of the values.
+type HistogramSink struct {
+	// Buckets are the upper bounds of the buckets, in ascending order. Values
+	// greater than the last one are counted in an implicit +Inf bucket.
+	Buckets []float64
+	// Counts are the number of values in each bucket, they aren't cumulative.
+	// The last one is for the +Inf bucket.
+	Counts []uint64
+
+	Count    uint64
+	Sum      float64
+	Min, Max float64
+}
+
+// NewHistogramSink makes a Histogram sink with the provided bucket upper
+// bounds, which are expected to be already validated with ValidateBuckets().
+func NewHistogramSink(buckets []float64) *HistogramSink {
+	return &HistogramSink{
+		Buckets: buckets,
+		Counts:  make([]uint64, len(buckets)+1),
+	}
+}
+
+// IsEmpty indicates whether the HistogramSink is empty.
+func (h *HistogramSink) IsEmpty() bool { return h.Count == 0 }
+
+func (h *HistogramSink) Add(s Sample) {
+	if h.Count == 0 || s.Value < h.Min {
+		h.Min = s.Value
+	}
+	if h.Count == 0 || s.Value > h.Max {
+		h.Max = s.Value
+	}
+	h.Counts[sort.SearchFloat64s(h.Buckets, s.Value)]++
+	h.Count++
+	h.Sum += s.Value
+}
+
+// Avg returns the average (i.e. mean) value.
+func (h *HistogramSink) Avg() float64 {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / float64(h.Count)
+}
+
+// CumulativeCount returns the number of values that are less than or equal to
+// the provided bucket upper bound.
+func (h *HistogramSink) CumulativeCount(le float64) uint64 {
+	var count uint64
+	for i, bound := range h.Buckets {
+		if bound > le {
+			break
+		}
+		count += h.Counts[i]
+	}
+	if math.IsInf(le, 1) {
+		count += h.Counts[len(h.Buckets)]
+	}
+	return count
+}
+
+// Ratio returns the proportion, between 0 and 1, of the values that are less
+// than or equal to the provided bucket upper bound.
+func (h *HistogramSink) Ratio(le float64) float64 {
+	if h.Count == 0 {
+		return 0
+	}
+	return float64(h.CumulativeCount(le)) / float64(h.Count)
+}
+
+// Merge adds all of the values observed by the other sink to this one. Both
+// of them should have the same buckets.
+func (h *HistogramSink) Merge(other *HistogramSink) error {
+	if !equalBuckets(h.Buckets, other.Buckets) {
+		return fmt.Errorf("can't merge histograms with different buckets (%v and %v)", h.Buckets, other.Buckets)
+	}
+	if other.Count == 0 {
+		return nil
+	}
+
+	if h.Count == 0 || other.Min < h.Min {
+		h.Min = other.Min
+	}
+	if h.Count == 0 || other.Max > h.Max {
+		h.Max = other.Max
+	}
+	for i, c := range other.Counts {
+		h.Counts[i] += c
+	}
+	h.Count += other.Count
+	h.Sum += other.Sum
+	return nil
+}
+
+func (h *HistogramSink) Format(t time.Duration) map[string]float64 {
+	result := map[string]float64{
+		"count": float64(h.Count),
+		"sum":   h.Sum,
+		"avg":   h.Avg(),
+		"min":   h.Min,
+		"max":   h.Max,
+	}
+	for _, bound := range h.Buckets {
+		result[fmt.Sprintf("%s(%g)", tokenBucket, bound)] = h.Ratio(bound)
+	}
+	return result
+}
+
+// ValidateBuckets checks that the provided histogram bucket upper bounds
+// are finite numbers, in a strictly ascending order.
+func ValidateBuckets(buckets []float64) error {
+	if len(buckets) == 0 {
+		return errors.New("a histogram needs at least one bucket")
+	}
+	for i, bound := range buckets {
+		if math.IsNaN(bound) || math.IsInf(bound, 0) {
+			return fmt.Errorf("the histogram bucket bound %g isn't a finite number", bound)
+		}
+		if i > 0 && bound <= buckets[i-1] {
+			return fmt.Errorf("the histogram buckets should be in a strictly ascending order, but %g follows %g",
+				bound, buckets[i-1])
+		}
+	}
+	return nil
+}
+
+// HistogramBucket returns the upper bound of the histogram bucket the value
+// falls in, formatted as the Prometheus `le` label, e.g. "300" or "+Inf".
+func HistogramBucket(buckets []float64, value float64) string {
+	i := sort.SearchFloat64s(buckets, value)
+	if i == len(buckets) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(buckets[i], 'g', -1, 64)
+}
+
+func equalBuckets(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -20,6 +20,7 @@ func TestNewSink(t *testing.T) {
 		{mt: Gauge, sink: &GaugeSink{}},
 		{mt: Rate, sink: &RateSink{}},
 		{mt: Trend, sink: NewTrendSink()},
+		{mt: Histogram, sink: NewHistogramSink(nil)},
 	}
 	for _, tc := range tests {
 		assert.Equal(t, tc.sink, NewSink(tc.mt))
@@ -224,6 +225,213 @@ func TestTrendSink(t *testing.T) {
 			assert.InDelta(t, expV, result[k], tolerance)
 		}
 	})
+	t.Run("extra stats", func(t *testing.T) {
+		t.Parallel()
+
+		sink := NewTrendSink()
+		assert.Equal(t, 0.0, sink.StdDev())
+		assert.Equal(t, 0.0, sink.TrimmedMean(0.1))
+
+		for _, s := range unsortedSamples10 {
+			sink.Add(Sample{TimeSeries: TimeSeries{Metric: &Metric{}}, Value: s})
+		}
+		assert.InDelta(t, 30.397368, sink.StdDev(), tolerance)
+		assert.InDelta(t, 45.0, sink.IQR(), tolerance)
+		assert.InDelta(t, 54.0, sink.TrimmedMean(0), tolerance)
+		assert.InDelta(t, 55.0, sink.TrimmedMean(0.1), tolerance)
+		assert.InDelta(t, 55.0, sink.TrimmedMean(0.49), tolerance)
+
+		other := NewTrendSink()
+		other.Add(Sample{TimeSeries: TimeSeries{Metric: &Metric{}}, Value: 1000})
+		require.NoError(t, sink.Merge(other))
+		assert.InDelta(t, 273.495887, sink.StdDev(), tolerance)
+	})
+}
+
+func TestHistogramTrendSink(t *testing.T) {
+	t.Parallel()
+
+	const relErr = 0.01
+	addValues := func(sink *TrendSink, values ...float64) {
+		for _, v := range values {
+			sink.Add(Sample{TimeSeries: TimeSeries{Metric: &Metric{}}, Value: v})
+		}
+	}
+
+	t.Run("exact stats", func(t *testing.T) {
+		t.Parallel()
+
+		sink := NewHistogramTrendSink(relErr)
+		addValues(sink, 0.0, 100.0, 30.0, 80.0, 70.0, 60.0, 50.0, 40.0, 90.0, 20.0)
+		assert.True(t, sink.IsHistogram())
+		assert.Empty(t, sink.values)
+		assert.Equal(t, uint64(10), sink.Count())
+		assert.Equal(t, 0.0, sink.Min())
+		assert.Equal(t, 100.0, sink.Max())
+		assert.Equal(t, 54.0, sink.Avg())
+		assert.Equal(t, 540.0, sink.Total())
+		assert.Equal(t, 0.0, sink.P(0))
+		assert.Equal(t, 100.0, sink.P(1))
+	})
+	t.Run("one value", func(t *testing.T) {
+		t.Parallel()
+
+		sink := NewHistogramTrendSink(relErr)
+		addValues(sink, 10.0)
+		for i := 1; i <= 100; i++ {
+			assert.Equal(t, 10.0, sink.P(float64(i)/100.0))
+		}
+	})
+	t.Run("percentiles", func(t *testing.T) {
+		t.Parallel()
+
+		exact, hist := NewTrendSink(), NewHistogramTrendSink(relErr)
+		for i := -1000; i <= 100000; i++ {
+			v := float64(i) * 0.37
+			addValues(exact, v)
+			addValues(hist, v)
+		}
+		// the exact sink interpolates between values, so allow for the
+		// difference between two neighbouring values on top of the error
+		for _, pct := range []float64{0.01, 0.1, 0.5, 0.9, 0.95, 0.99, 0.999} {
+			expected := exact.P(pct)
+			assert.InDelta(t, expected, hist.P(pct), math.Abs(expected)*relErr+0.37, "p(%g)", pct*100)
+		}
+	})
+	t.Run("merge", func(t *testing.T) {
+		t.Parallel()
+
+		a, b := NewHistogramTrendSink(relErr), NewHistogramTrendSink(relErr)
+		addValues(a, 1, 2, 3)
+		addValues(b, -4, 50)
+		require.NoError(t, a.Merge(b))
+		assert.Equal(t, uint64(5), a.Count())
+		assert.Equal(t, -4.0, a.Min())
+		assert.Equal(t, 50.0, a.Max())
+		assert.Equal(t, 52.0, a.Total())
+		assert.InDelta(t, 2.0, a.P(0.5), 2.0*relErr)
+
+		exact := NewTrendSink()
+		addValues(exact, 100)
+		require.NoError(t, a.Merge(exact))
+		assert.Equal(t, uint64(6), a.Count())
+		assert.Equal(t, 100.0, a.Max())
+
+		assert.Error(t, exact.Merge(a))
+		assert.Error(t, a.Merge(func() *TrendSink {
+			s := NewHistogramTrendSink(0.05)
+			addValues(s, 1)
+			return s
+		}()))
+	})
+	t.Run("extra stats", func(t *testing.T) {
+		t.Parallel()
+
+		exact, hist, other := NewTrendSink(), NewHistogramTrendSink(relErr), NewHistogramTrendSink(relErr)
+		for i := 1; i <= 1000; i++ {
+			addValues(exact, float64(i))
+			if i%2 == 0 {
+				addValues(hist, float64(i))
+			} else {
+				addValues(other, float64(i))
+			}
+		}
+		require.NoError(t, hist.Merge(other))
+
+		assert.InDelta(t, exact.StdDev(), hist.StdDev(), 0.000001)
+		assert.InDelta(t, exact.IQR(), hist.IQR(), exact.P(0.75)*2*relErr)
+		assert.InDelta(t, exact.TrimmedMean(0.1), hist.TrimmedMean(0.1), exact.TrimmedMean(0.1)*relErr)
+		assert.InDelta(t, exact.TrimmedMean(0.25), hist.TrimmedMean(0.25), exact.TrimmedMean(0.25)*relErr)
+	})
+}
+
+func TestHistogramSink(t *testing.T) {
+	t.Parallel()
+
+	buckets := []float64{100, 200, 300}
+	samples := []float64{50.0, 100.0, 150.0, 250.0, 300.0, 1000.0}
+
+	newSink := func(values ...float64) *HistogramSink {
+		sink := NewHistogramSink(buckets)
+		for _, v := range values {
+			sink.Add(Sample{TimeSeries: TimeSeries{Metric: &Metric{}}, Value: v})
+		}
+		return sink
+	}
+
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+		sink := newSink()
+		assert.True(t, sink.IsEmpty())
+		assert.Equal(t, 0.0, sink.Avg())
+		assert.Equal(t, 0.0, sink.Ratio(300))
+	})
+	t.Run("add", func(t *testing.T) {
+		t.Parallel()
+		sink := newSink(samples...)
+		assert.False(t, sink.IsEmpty())
+		assert.Equal(t, []uint64{2, 1, 2, 1}, sink.Counts)
+		assert.Equal(t, uint64(6), sink.Count)
+		assert.Equal(t, 1850.0, sink.Sum)
+		assert.Equal(t, 50.0, sink.Min)
+		assert.Equal(t, 1000.0, sink.Max)
+	})
+	t.Run("ratio", func(t *testing.T) {
+		t.Parallel()
+		sink := newSink(samples...)
+		assert.Equal(t, uint64(2), sink.CumulativeCount(100))
+		assert.Equal(t, uint64(3), sink.CumulativeCount(200))
+		assert.Equal(t, uint64(5), sink.CumulativeCount(300))
+		assert.Equal(t, uint64(6), sink.CumulativeCount(math.Inf(1)))
+		assert.Equal(t, 0.5, sink.Ratio(200))
+		assert.Equal(t, 1.0, sink.Ratio(math.Inf(1)))
+	})
+	t.Run("merge", func(t *testing.T) {
+		t.Parallel()
+		sink := newSink(samples[:3]...)
+		require.NoError(t, sink.Merge(newSink(samples[3:]...)))
+		assert.Equal(t, newSink(samples...), sink)
+
+		require.NoError(t, sink.Merge(newSink()))
+		assert.Equal(t, newSink(samples...), sink)
+
+		assert.Error(t, sink.Merge(NewHistogramSink([]float64{100, 200})))
+	})
+	t.Run("format", func(t *testing.T) {
+		t.Parallel()
+		sink := newSink(samples...)
+		assert.Equal(t, map[string]float64{
+			"count":   6,
+			"sum":     1850,
+			"avg":     1850.0 / 6,
+			"min":     50,
+			"max":     1000,
+			"le(100)": 2.0 / 6,
+			"le(200)": 0.5,
+			"le(300)": 5.0 / 6,
+		}, sink.Format(0))
+	})
+}
+
+func TestValidateBuckets(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, ValidateBuckets([]float64{-1, 0, 0.5, 100}))
+	assert.Error(t, ValidateBuckets(nil))
+	assert.Error(t, ValidateBuckets([]float64{100, 100}))
+	assert.Error(t, ValidateBuckets([]float64{200, 100}))
+	assert.Error(t, ValidateBuckets([]float64{100, math.Inf(1)}))
+	assert.Error(t, ValidateBuckets([]float64{math.NaN()}))
+}
+
+func TestHistogramBucket(t *testing.T) {
+	t.Parallel()
+
+	buckets := []float64{0.5, 100, 300}
+	assert.Equal(t, "0.5", HistogramBucket(buckets, -10))
+	assert.Equal(t, "100", HistogramBucket(buckets, 100))
+	assert.Equal(t, "300", HistogramBucket(buckets, 100.1))
+	assert.Equal(t, "+Inf", HistogramBucket(buckets, 301))
 }
 
 func TestRateSink(t *testing.T) {
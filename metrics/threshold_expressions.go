@@ -0,0 +1,556 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ChipArtem/k6/errext"
+	"github.com/ChipArtem/k6/errext/exitcodes"
+	"github.com/ChipArtem/k6/lib/types"
+	"gopkg.in/guregu/null.v3"
+)
+
+// ExpressionThreshold is a threshold that combines the aggregated values of
+// several metrics and submetrics in a single arithmetic assertion, for
+// instance `errors.count / iterations.count < 0.01`.
+type ExpressionThreshold struct {
+	// Source is the text based source of the threshold
+	Source string
+	// LastFailed is a marker if the last testing of this threshold failed
+	LastFailed bool
+	// AbortOnFail marks if a given threshold fails that the whole test should be aborted
+	AbortOnFail bool
+	// AbortGracePeriod is a the minimum amount of time a test should be running before a failing
+	// this threshold will abort the test
+	AbortGracePeriod types.NullDuration
+	// LastValue is the value of the left hand side of the assertion the last
+	// time the threshold was evaluated
+	LastValue null.Float
+	// parsed is the assertion parsed from the Source
+	parsed *metricsAssertion
+}
+
+// ExpressionThresholds is the list of all the cross-metric thresholds of a test.
+type ExpressionThresholds []*ExpressionThreshold
+
+// metricsAssertion holds the parsed result of a cross-metric threshold.
+type metricsAssertion struct {
+	lhs, rhs arithmeticExpression
+	operator string
+}
+
+// arithmeticExpression is a node of a parsed arithmetic expression. Leafs
+// are either a literal value or a reference to an aggregated metric value.
+type arithmeticExpression struct {
+	operator    byte // one of '+', '-', '*' and '/', or 0 for leafs
+	left, right *arithmeticExpression
+
+	value     float64
+	reference *metricReference
+}
+
+// metricReference is an aggregated value of a metric or a submetric, for
+// instance `http_req_duration{name:login}.p(95)`.
+type metricReference struct {
+	MetricName        string
+	AggregationMethod string
+	AggregationValue  null.Float
+
+	// metric is resolved by the metrics engine before the test starts
+	metric *Metric
+}
+
+// parseMetricsAssertion parses a cross-metric threshold, as defined by the
+// following BNF:
+// ```
+// assertion  -> expression whitespace* operator whitespace* expression
+// expression -> term (whitespace* ("+" | "-") whitespace* term)*
+// term       -> factor (whitespace* ("*" | "/") whitespace* factor)*
+// factor     -> float | "(" expression ")" | "-" factor | reference
+// reference  -> metric_name ("{" tags "}")? "." aggregation_method
+// ```
+// Where operator and aggregation_method are the same as in the single metric
+// thresholds, see parseThresholdExpression.
+func parseMetricsAssertion(input string) (*metricsAssertion, error) {
+	p := &assertionParser{input: input}
+
+	lhs, err := p.parseExpression()
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing threshold expression %q; reason: %w", input, err)
+	}
+
+	p.skipWhitespace()
+	operator := ""
+	for _, op := range operatorTokens {
+		if strings.HasPrefix(input[p.pos:], op) {
+			operator = op
+			break
+		}
+	}
+	if operator == "" {
+		return nil, fmt.Errorf("failed parsing threshold expression %q; reason: "+
+			"expected a comparison operator at position %d", input, p.pos)
+	}
+	p.pos += len(operator)
+
+	rhs, err := p.parseExpression()
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing threshold expression %q; reason: %w", input, err)
+	}
+
+	p.skipWhitespace()
+	if p.pos < len(input) {
+		return nil, fmt.Errorf("failed parsing threshold expression %q; reason: "+
+			"unexpected %q at position %d", input, input[p.pos:], p.pos)
+	}
+
+	return &metricsAssertion{lhs: *lhs, rhs: *rhs, operator: operator}, nil
+}
+
+// assertionParser is a recursive descent parser for cross-metric thresholds.
+type assertionParser struct {
+	input string
+	pos   int
+}
+
+func (p *assertionParser) skipWhitespace() {
+	for p.pos < len(p.input) && p.input[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+// next returns the next non-whitespace character, or 0 at the end of the input.
+func (p *assertionParser) next() byte {
+	p.skipWhitespace()
+	if p.pos >= len(p.input) {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func (p *assertionParser) parseExpression() (*arithmeticExpression, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+
+	for op := p.next(); op == '+' || op == '-'; op = p.next() {
+		p.pos++
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		left = &arithmeticExpression{operator: op, left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *assertionParser) parseTerm() (*arithmeticExpression, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+
+	for op := p.next(); op == '*' || op == '/'; op = p.next() {
+		p.pos++
+		right, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		left = &arithmeticExpression{operator: op, left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *assertionParser) parseFactor() (*arithmeticExpression, error) {
+	c := p.next()
+	switch {
+	case c == '(':
+		p.pos++
+		expr, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ')' {
+			return nil, fmt.Errorf("missing closing parenthesis at position %d", p.pos)
+		}
+		p.pos++
+		return expr, nil
+	case c == '-':
+		p.pos++
+		factor, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		return &arithmeticExpression{operator: '-', left: &arithmeticExpression{}, right: factor}, nil
+	case c == '.' || (c >= '0' && c <= '9'):
+		start := p.pos
+		for p.pos < len(p.input) && (p.input[p.pos] == '.' || (p.input[p.pos] >= '0' && p.input[p.pos] <= '9')) {
+			p.pos++
+		}
+		value, err := strconv.ParseFloat(p.input[start:p.pos], 64)
+		if err != nil {
+			return nil, fmt.Errorf("malformed number %q", p.input[start:p.pos])
+		}
+		return &arithmeticExpression{value: value}, nil
+	case c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+		ref, err := p.parseReference()
+		if err != nil {
+			return nil, err
+		}
+		return &arithmeticExpression{reference: ref}, nil
+	case c == 0:
+		return nil, fmt.Errorf("unexpected end of expression")
+	default:
+		return nil, fmt.Errorf("unexpected %q at position %d", c, p.pos)
+	}
+}
+
+func (p *assertionParser) parseReference() (*metricReference, error) {
+	start := p.pos
+	for p.pos < len(p.input) && isMetricNameChar(p.input[p.pos]) {
+		p.pos++
+	}
+
+	// The tags of a submetric can contain any character, so they are
+	// taken verbatim up to the closing brace.
+	if p.pos < len(p.input) && p.input[p.pos] == '{' {
+		end := strings.IndexByte(p.input[p.pos:], '}')
+		if end == -1 {
+			return nil, fmt.Errorf("missing closing curly brace for metric %q", p.input[start:])
+		}
+		p.pos += end + 1
+	}
+	name := p.input[start:p.pos]
+
+	if p.pos >= len(p.input) || p.input[p.pos] != '.' {
+		return nil, fmt.Errorf("metric %q should be followed by an aggregation method, e.g. %s.count", name, name)
+	}
+	p.pos++
+
+	methodStart := p.pos
+	for p.pos < len(p.input) && p.input[p.pos] >= 'a' && p.input[p.pos] <= 'z' {
+		p.pos++
+	}
+	method := p.input[methodStart:p.pos]
+	if (method == tokenPercentile || method == tokenTrimmedMean || method == tokenBucket) && p.pos < len(p.input) && p.input[p.pos] == '(' {
+		end := strings.IndexByte(p.input[p.pos:], ')')
+		if end == -1 {
+			return nil, fmt.Errorf("missing closing parenthesis for the %s of metric %q", method, name)
+		}
+		p.pos += end + 1
+	}
+
+	method, methodValue, err := parseThresholdAggregationMethod(p.input[methodStart:p.pos])
+	if err != nil {
+		return nil, fmt.Errorf("invalid aggregation method %q for metric %q; reason: %w",
+			p.input[methodStart:p.pos], name, err)
+	}
+
+	return &metricReference{MetricName: name, AggregationMethod: method, AggregationValue: methodValue}, nil
+}
+
+func isMetricNameChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// references returns all the metric references used in the expression.
+func (e *arithmeticExpression) references() []*metricReference {
+	if e.reference != nil {
+		return []*metricReference{e.reference}
+	}
+	if e.operator == 0 {
+		return nil
+	}
+	return append(e.left.references(), e.right.references()...)
+}
+
+// evaluate computes the value of the expression. It returns false if any of
+// the referenced metrics has no values yet.
+func (e *arithmeticExpression) evaluate(duration time.Duration) (float64, bool) {
+	if e.reference != nil {
+		return e.reference.value(duration)
+	}
+	if e.operator == 0 {
+		return e.value, true
+	}
+
+	left, ok := e.left.evaluate(duration)
+	if !ok {
+		return 0, false
+	}
+	right, ok := e.right.evaluate(duration)
+	if !ok {
+		return 0, false
+	}
+
+	switch e.operator {
+	case '+':
+		return left + right, true
+	case '-':
+		return left - right, true
+	case '*':
+		return left * right, true
+	default:
+		return left / right, true
+	}
+}
+
+// value returns the aggregated value of the referenced metric, or false if
+// the metric has no values yet.
+func (r *metricReference) value(duration time.Duration) (float64, bool) {
+	if r.metric == nil || r.metric.Sink == nil || r.metric.Sink.IsEmpty() {
+		return 0, false
+	}
+
+	switch sink := r.metric.Sink.(type) {
+	case *CounterSink:
+		if r.AggregationMethod == tokenRate {
+			return sink.Value / (float64(duration) / float64(time.Second)), true
+		}
+		return sink.Value, true
+	case *GaugeSink:
+		return sink.Value, true
+	case *RateSink:
+		return float64(sink.Trues) / float64(sink.Total), true
+	case *HistogramSink:
+		switch r.AggregationMethod {
+		case tokenCount:
+			return float64(sink.Count), true
+		case tokenSum:
+			return sink.Sum, true
+		case tokenAvg:
+			return sink.Avg(), true
+		case tokenMin:
+			return sink.Min, true
+		case tokenMax:
+			return sink.Max, true
+		case tokenBucket:
+			return sink.Ratio(r.AggregationValue.Float64), true
+		}
+	case *TrendSink:
+		switch r.AggregationMethod {
+		case tokenMin:
+			return sink.Min(), true
+		case tokenMax:
+			return sink.Max(), true
+		case tokenAvg:
+			return sink.Avg(), true
+		case tokenMed:
+			return sink.P(0.5), true
+		case tokenCount:
+			return float64(sink.Count()), true
+		case tokenSum:
+			return sink.Total(), true
+		case tokenStdDev:
+			return sink.StdDev(), true
+		case tokenIQR:
+			return sink.IQR(), true
+		case tokenPercentile:
+			return sink.P(r.AggregationValue.Float64 / 100), true
+		case tokenTrimmedMean:
+			return sink.TrimmedMean(r.AggregationValue.Float64 / 100), true
+		}
+	}
+
+	return 0, false
+}
+
+// References returns the names of all the metrics and submetrics used by the
+// threshold, for instance `http_req_duration{name:login}`. It expects the
+// threshold to have been parsed already.
+func (et *ExpressionThreshold) References() []string {
+	if et.parsed == nil {
+		return nil
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	for _, ref := range append(et.parsed.lhs.references(), et.parsed.rhs.references()...) {
+		if seen[ref.MetricName] {
+			continue
+		}
+		seen[ref.MetricName] = true
+		names = append(names, ref.MetricName)
+	}
+	return names
+}
+
+// Resolve sets the metrics the threshold is evaluated against, using the
+// provided function to get each one of them by name, as returned by
+// References.
+func (et *ExpressionThreshold) Resolve(getMetric func(name string) (*Metric, error)) error {
+	if et.parsed == nil {
+		return fmt.Errorf("unable to resolve threshold %q; reason: it wasn't parsed", et.Source)
+	}
+
+	for _, ref := range append(et.parsed.lhs.references(), et.parsed.rhs.references()...) {
+		metric, err := getMetric(ref.MetricName)
+		if err != nil {
+			return err
+		}
+		ref.metric = metric
+	}
+	return nil
+}
+
+// Run evaluates the threshold at the provided time and returns whether it
+// passes. A threshold that references a metric without any values yet is
+// considered as passing, the same as the single metric thresholds.
+func (et *ExpressionThreshold) Run(duration time.Duration) (bool, error) {
+	passes, err := et.runNoTaint(duration)
+	et.LastFailed = !passes
+	return passes, err
+}
+
+func (et *ExpressionThreshold) runNoTaint(duration time.Duration) (bool, error) {
+	if et.parsed == nil {
+		return false, fmt.Errorf("unable to apply threshold %s over metrics; reason: it wasn't parsed", et.Source)
+	}
+
+	lhs, ok := et.parsed.lhs.evaluate(duration)
+	if !ok {
+		return true, nil
+	}
+	et.LastValue = null.FloatFrom(lhs)
+
+	rhs, ok := et.parsed.rhs.evaluate(duration)
+	if !ok {
+		return true, nil
+	}
+
+	switch et.parsed.operator {
+	case ">":
+		return lhs > rhs, nil
+	case ">=":
+		return lhs >= rhs, nil
+	case "<=":
+		return lhs <= rhs, nil
+	case "<":
+		return lhs < rhs, nil
+	case "==", "===":
+		return lhs == rhs, nil
+	case "!=":
+		return lhs != rhs, nil
+	default:
+		return false, fmt.Errorf("unable to apply threshold %s over metrics; "+
+			"reason: %s is an invalid operator", et.Source, et.parsed.operator)
+	}
+}
+
+// ShouldAbort returns whether the test should be aborted after the threshold
+// failed, given the time spent in the test so far.
+func (et *ExpressionThreshold) ShouldAbort(timeSpentInTest time.Duration) bool {
+	if !et.LastFailed || !et.AbortOnFail {
+		return false
+	}
+	return !et.AbortGracePeriod.Valid || et.AbortGracePeriod.Duration < types.Duration(timeSpentInTest)
+}
+
+// Parse parses all the thresholds, asserting they are syntaxically correct.
+func (ets ExpressionThresholds) Parse() error {
+	for _, et := range ets {
+		parsed, err := parseMetricsAssertion(et.Source)
+		if err != nil {
+			return err
+		}
+		et.parsed = parsed
+	}
+	return nil
+}
+
+// Validate ensures that all the metrics referenced by the thresholds exist in
+// the registry, and that they support the aggregation methods used on them.
+// It parses the thresholds that weren't parsed yet.
+func (ets ExpressionThresholds) Validate(r *Registry) error {
+	for _, et := range ets {
+		if et.parsed == nil {
+			parsed, err := parseMetricsAssertion(et.Source)
+			if err != nil {
+				return errext.WithExitCodeIfNone(err, exitcodes.InvalidConfig)
+			}
+			et.parsed = parsed
+		}
+
+		for _, ref := range append(et.parsed.lhs.references(), et.parsed.rhs.references()...) {
+			metricName, _, err := ParseMetricName(ref.MetricName)
+			if err != nil {
+				err = fmt.Errorf("%w %q; reason: %s", ErrInvalidThreshold, et.Source, err.Error())
+				return errext.WithExitCodeIfNone(err, exitcodes.InvalidConfig)
+			}
+
+			metric := r.Get(metricName)
+			if metric == nil {
+				err := fmt.Errorf("%w %q; reason: no metric name %q found", ErrInvalidThreshold, et.Source, metricName)
+				return errext.WithExitCodeIfNone(err, exitcodes.InvalidConfig)
+			}
+
+			if !metric.Type.supportsAggregationMethod(ref.AggregationMethod) {
+				err := fmt.Errorf(
+					"%w %q; reason: unsupported aggregation method %s on metric %s of type %s. "+
+						"supported aggregation methods for this metric are: %s",
+					ErrInvalidThreshold, et.Source, ref.AggregationMethod, ref.MetricName, metric.Type,
+					strings.Join(metric.Type.supportedAggregationMethods(), ", "),
+				)
+				return errext.WithExitCodeIfNone(err, exitcodes.InvalidConfig)
+			}
+
+			if err := validateBucket(metric, ref.AggregationMethod, ref.AggregationValue); err != nil {
+				err = fmt.Errorf("%w %q; reason: %s", ErrInvalidThreshold, et.Source, err)
+				return errext.WithExitCodeIfNone(err, exitcodes.InvalidConfig)
+			}
+		}
+	}
+
+	return nil
+}
+
+// UnmarshalJSON is implementation of json.Unmarshaler
+func (ets *ExpressionThresholds) UnmarshalJSON(data []byte) error {
+	var configs []thresholdConfig
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return err
+	}
+	if configs == nil {
+		*ets = nil
+		return nil
+	}
+
+	result := make(ExpressionThresholds, len(configs))
+	for i, config := range configs {
+		result[i] = &ExpressionThreshold{
+			Source:           config.Threshold,
+			AbortOnFail:      config.AbortOnFail,
+			AbortGracePeriod: config.AbortGracePeriod,
+		}
+	}
+	*ets = result
+
+	return nil
+}
+
+// MarshalJSON is implementation of json.Marshaler
+func (ets ExpressionThresholds) MarshalJSON() ([]byte, error) {
+	if ets == nil {
+		return []byte("null"), nil
+	}
+
+	configs := make([]thresholdConfig, len(ets))
+	for i, et := range ets {
+		configs[i].Threshold = et.Source
+		configs[i].AbortOnFail = et.AbortOnFail
+		configs[i].AbortGracePeriod = et.AbortGracePeriod
+	}
+
+	return MarshalJSONWithoutHTMLEscape(configs)
+}
+
+var (
+	_ json.Unmarshaler = &ExpressionThresholds{}
+	_ json.Marshaler   = ExpressionThresholds{}
+)
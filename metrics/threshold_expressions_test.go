@@ -0,0 +1,226 @@
+package metrics
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ChipArtem/k6/lib/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+)
+
+func TestParseMetricsAssertion(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		input          string
+		wantOperator   string
+		wantReferences []string
+		wantErr        bool
+	}{
+		{
+			name:           "ratio of two counters",
+			input:          "errors.count / iterations.count < 0.01",
+			wantOperator:   "<",
+			wantReferences: []string{"errors", "iterations"},
+		},
+		{
+			name:           "submetrics on both sides",
+			input:          "http_req_duration{name:login}.p(95) < http_req_duration{name:home}.p(95) * 2",
+			wantOperator:   "<",
+			wantReferences: []string{"http_req_duration{name:login}", "http_req_duration{name:home}"},
+		},
+		{
+			name:           "submetric tags with operator characters",
+			input:          "checks{check:status is <300}.rate>=0.99",
+			wantOperator:   ">=",
+			wantReferences: []string{"checks{check:status is <300}"},
+		},
+		{
+			name:           "parentheses and negation",
+			input:          "(a.count - -b.count) * 0.5 <= -1",
+			wantOperator:   "<=",
+			wantReferences: []string{"a", "b"},
+		},
+		{
+			name:           "repeated references are returned once",
+			input:          "a.min + a.max === 2 * a.avg",
+			wantOperator:   "===",
+			wantReferences: []string{"a"},
+		},
+		{name: "no operator", input: "errors.count / iterations.count", wantErr: true},
+		{name: "no aggregation method", input: "errors / iterations.count < 1", wantErr: true},
+		{name: "unknown aggregation method", input: "errors.total < 1", wantErr: true},
+		{name: "malformed percentile", input: "a.p(foo) < 1", wantErr: true},
+		{name: "missing closing parenthesis", input: "(a.count + 1 < 1", wantErr: true},
+		{name: "missing closing curly brace", input: "a{tag:value.count < 1", wantErr: true},
+		{name: "missing right hand side", input: "a.count <", wantErr: true},
+		{name: "trailing characters", input: "a.count < 1 2", wantErr: true},
+		{name: "malformed number", input: "a.count < 1.2.3", wantErr: true},
+	}
+
+	for _, testCase := range tests {
+		testCase := testCase
+
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseMetricsAssertion(testCase.input)
+			if testCase.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testCase.wantOperator, got.operator)
+
+			et := &ExpressionThreshold{Source: testCase.input, parsed: got}
+			assert.Equal(t, testCase.wantReferences, et.References())
+		})
+	}
+}
+
+func TestExpressionThresholdRun(t *testing.T) {
+	t.Parallel()
+
+	newMetric := func(mt MetricType, values ...float64) *Metric {
+		m := &Metric{Type: mt, Sink: NewSink(mt)}
+		for _, v := range values {
+			m.Sink.Add(Sample{Time: time.Now(), Value: v})
+		}
+		return m
+	}
+
+	testMetrics := map[string]*Metric{
+		"errors":     newMetric(Counter, 1),
+		"iterations": newMetric(Counter, 100, 100),
+		"vus":        newMetric(Gauge, 10),
+		"checks":     newMetric(Rate, 1, 1, 1, 0),
+		"login":      newMetric(Trend, 100, 200, 300),
+		"home":       newMetric(Trend, 50, 60, 70),
+		"empty":      newMetric(Trend),
+		"latency":    {Type: Histogram, Buckets: []float64{100, 300}, Sink: getHistogramSink([]float64{100, 300}, 50, 250)},
+	}
+
+	tests := []struct {
+		input     string
+		wantPass  bool
+		wantValue null.Float
+	}{
+		{input: "errors.count / iterations.count < 0.01", wantPass: true, wantValue: null.FloatFrom(0.01 * 0.5)},
+		{input: "errors.count / iterations.count > 0.01", wantPass: false, wantValue: null.FloatFrom(0.01 * 0.5)},
+		{input: "errors.rate == errors.count", wantPass: true, wantValue: null.FloatFrom(1)},
+		{input: "login.p(95) < home.max * 2", wantPass: false, wantValue: null.FloatFrom(290)},
+		{input: "login.med <= home.avg * 4", wantPass: true, wantValue: null.FloatFrom(200)},
+		{input: "login.min - home.min != 50", wantPass: false, wantValue: null.FloatFrom(50)},
+		{input: "checks.rate * vus.value >= 7.5", wantPass: true, wantValue: null.FloatFrom(7.5)},
+		{input: "-(vus.value + 2) < -11", wantPass: true, wantValue: null.FloatFrom(-12)},
+		{input: "empty.max < home.max", wantPass: true},
+		{input: "home.max < empty.max", wantPass: true, wantValue: null.FloatFrom(70)},
+		{input: "latency.le(100) * latency.count >= 1", wantPass: true, wantValue: null.FloatFrom(1)},
+		{input: "latency.le(300) > checks.rate", wantPass: true, wantValue: null.FloatFrom(1)},
+	}
+
+	for _, testCase := range tests {
+		testCase := testCase
+
+		t.Run(testCase.input, func(t *testing.T) {
+			t.Parallel()
+
+			ets := ExpressionThresholds{{Source: testCase.input}}
+			require.NoError(t, ets.Parse())
+			require.NoError(t, ets[0].Resolve(func(name string) (*Metric, error) {
+				return testMetrics[name], nil
+			}))
+
+			passes, err := ets[0].Run(time.Second)
+			require.NoError(t, err)
+			assert.Equal(t, testCase.wantPass, passes)
+			assert.Equal(t, !testCase.wantPass, ets[0].LastFailed)
+			assert.Equal(t, testCase.wantValue.Valid, ets[0].LastValue.Valid)
+			assert.InDelta(t, testCase.wantValue.Float64, ets[0].LastValue.Float64, 1e-9)
+		})
+	}
+}
+
+func TestExpressionThresholdShouldAbort(t *testing.T) {
+	t.Parallel()
+
+	et := &ExpressionThreshold{LastFailed: true}
+	assert.False(t, et.ShouldAbort(time.Minute))
+
+	et.AbortOnFail = true
+	assert.True(t, et.ShouldAbort(time.Minute))
+
+	et.AbortGracePeriod = types.NullDurationFrom(2 * time.Minute)
+	assert.False(t, et.ShouldAbort(time.Minute))
+	assert.True(t, et.ShouldAbort(3*time.Minute))
+
+	et.LastFailed = false
+	assert.False(t, et.ShouldAbort(3*time.Minute))
+}
+
+func TestExpressionThresholdsValidate(t *testing.T) {
+	t.Parallel()
+
+	registry := NewRegistry()
+	_, err := registry.NewMetric("errors", Counter)
+	require.NoError(t, err)
+	_, err = registry.NewMetric("duration", Trend)
+	require.NoError(t, err)
+	_, err = registry.NewHistogram("latency", []float64{100, 300})
+	require.NoError(t, err)
+
+	tests := []struct {
+		input   string
+		wantErr bool
+	}{
+		{input: "errors.count / duration.rate < 1", wantErr: true},
+		{input: "errors.count / duration{name:home}.p(99) < 1"},
+		{input: "unknown.count > errors.count", wantErr: true},
+		{input: "duration{name:home.avg < 1", wantErr: true},
+		{input: "errors.value < 1", wantErr: true},
+		{input: "latency.le(300) > 0.95"},
+		{input: "latency.le(200) > 0.95", wantErr: true},
+		{input: "duration.le(300) > 0.95", wantErr: true},
+	}
+
+	for _, testCase := range tests {
+		testCase := testCase
+
+		t.Run(testCase.input, func(t *testing.T) {
+			t.Parallel()
+
+			err := ExpressionThresholds{{Source: testCase.input}}.Validate(registry)
+			if testCase.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestExpressionThresholdsJSON(t *testing.T) {
+	t.Parallel()
+
+	data := `["a.count < b.count",{"threshold":"a.count > 1","abortOnFail":true,"delayAbortEval":"10s"}]`
+
+	var ets ExpressionThresholds
+	require.NoError(t, json.Unmarshal([]byte(data), &ets))
+	require.Len(t, ets, 2)
+	assert.Equal(t, "a.count < b.count", ets[0].Source)
+	assert.False(t, ets[0].AbortOnFail)
+	assert.Equal(t, "a.count > 1", ets[1].Source)
+	assert.True(t, ets[1].AbortOnFail)
+	assert.Equal(t, types.NullDurationFrom(10*time.Second), ets[1].AbortGracePeriod)
+
+	out, err := json.Marshal(ets)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[
+		"a.count < b.count",
+		{"threshold":"a.count > 1","abortOnFail":true,"delayAbortEval":"10s"}
+	]`, string(out))
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/ChipArtem/k6/errext"
 	"github.com/ChipArtem/k6/errext/exitcodes"
 	"github.com/ChipArtem/k6/lib/types"
+	"gopkg.in/guregu/null.v3"
 )
 
 // Threshold is a representation of a single threshold for a single metric
@@ -24,8 +25,18 @@ type Threshold struct {
 	// AbortGracePeriod is a the minimum amount of time a test should be running before a failing
 	// this threshold will abort the test
 	AbortGracePeriod types.NullDuration
+	// WindowBreaches counts how many times the sliding time window of a
+	// threshold went from passing to failing during the test
+	WindowBreaches uint64
+	// Baseline is the value from a previous test run the threshold is compared
+	// against, if its expression references it
+	Baseline null.Float
+	// LastValue is the metric value the threshold was last evaluated against
+	LastValue null.Float
 	// parsed is the threshold expression parsed from the Source
 	parsed *thresholdExpression
+	// windowFailing is whether the last evaluation of the window failed
+	windowFailing bool
 }
 
 func newThreshold(src string, abortOnFail bool, gracePeriod types.NullDuration) *Threshold {
@@ -47,24 +58,33 @@ func (t *Threshold) runNoTaint(sinks map[string]float64) (bool, error) {
 		return true, nil
 	}
 
+	rhs := t.parsed.Value
+	if t.parsed.BaselineOperator != "" {
+		if !t.Baseline.Valid {
+			return false, fmt.Errorf("unable to apply threshold %s over metrics; "+
+				"reason: its baseline value wasn't resolved", t.Source)
+		}
+		rhs = t.parsed.applyBaseline(t.Baseline.Float64)
+	}
+
 	// Apply the threshold expression operator to the left and
 	// right hand side values
 	var passes bool
 	switch t.parsed.Operator {
 	case ">":
-		passes = lhs > t.parsed.Value
+		passes = lhs > rhs
 	case ">=":
-		passes = lhs >= t.parsed.Value
+		passes = lhs >= rhs
 	case "<=":
-		passes = lhs <= t.parsed.Value
+		passes = lhs <= rhs
 	case "<":
-		passes = lhs < t.parsed.Value
+		passes = lhs < rhs
 	case "==", "===":
 		// Considering a sink always maps to float64 values,
 		// strictly equal is equivalent to loosely equal
-		passes = lhs == t.parsed.Value
+		passes = lhs == rhs
 	case "!=":
-		passes = lhs != t.parsed.Value
+		passes = lhs != rhs
 	default:
 		// The parseThresholdExpression function should ensure that no invalid
 		// operator gets through, but let's protect our future selves anyhow.
@@ -79,9 +99,30 @@ func (t *Threshold) runNoTaint(sinks map[string]float64) (bool, error) {
 	return passes, nil
 }
 
+// Window returns the length of the sliding time window the threshold is
+// evaluated over, or zero if it applies to the whole test run.
+func (t *Threshold) Window() time.Duration {
+	if t.parsed == nil {
+		return 0
+	}
+	return t.parsed.Window
+}
+
+// run evaluates the threshold and returns whether it passes right now. A
+// threshold with a time window stays failed once any of its windows was
+// breached, so a temporary spike isn't forgotten by the end of the test.
 func (t *Threshold) run(sinks map[string]float64) (bool, error) {
+	if value, ok := sinks[t.parsed.SinkKey()]; ok {
+		t.LastValue = null.FloatFrom(value)
+	}
 	passes, err := t.runNoTaint(sinks)
-	t.LastFailed = !passes
+	if t.Window() > 0 {
+		if !passes && !t.windowFailing {
+			t.WindowBreaches++
+		}
+		t.windowFailing = !passes
+	}
+	t.LastFailed = !passes || t.WindowBreaches > 0
 	return passes, err
 }
 
@@ -118,6 +159,10 @@ type Thresholds struct {
 	Thresholds []*Threshold
 	Abort      bool
 	sinked     map[string]float64
+
+	// windowSinked holds the sinked values for each of the time windows
+	// used by the thresholds
+	windowSinked map[time.Duration]map[string]float64
 }
 
 // NewThresholds returns Thresholds objects representing the provided source strings
@@ -139,37 +184,132 @@ func newThresholdsWithConfig(configs []thresholdConfig) Thresholds {
 		thresholds[i] = t
 	}
 
-	return Thresholds{thresholds, false, sinked}
+	return Thresholds{Thresholds: thresholds, Abort: false, sinked: sinked}
 }
 
 func (ts *Thresholds) runAll(timeSpentInTest time.Duration) (bool, error) {
 	succeeded := true
 	for i, threshold := range ts.Thresholds {
-		b, err := threshold.run(ts.sinked)
+		sinked := ts.sinked
+		if window := threshold.Window(); window > 0 {
+			// A missing window means there are no values in it yet, and a
+			// missing sink entry is ignored by the threshold.
+			sinked = ts.windowSinked[window]
+		}
+
+		b, err := threshold.run(sinked)
 		if err != nil {
 			return false, fmt.Errorf("threshold %d run error: %w", i, err)
 		}
 
-		if !b {
+		if threshold.LastFailed {
 			succeeded = false
+		}
 
-			if ts.Abort || !threshold.AbortOnFail {
-				continue
-			}
-
-			ts.Abort = !threshold.AbortGracePeriod.Valid ||
-				threshold.AbortGracePeriod.Duration < types.Duration(timeSpentInTest)
+		if b || ts.Abort || !threshold.AbortOnFail {
+			continue
 		}
+
+		ts.Abort = !threshold.AbortGracePeriod.Valid ||
+			threshold.AbortGracePeriod.Duration < types.Duration(timeSpentInTest)
 	}
 
 	return succeeded, nil
 }
 
+// Windows returns the distinct sliding time windows used by the thresholds.
+func (ts *Thresholds) Windows() []time.Duration {
+	var windows []time.Duration
+	seen := make(map[time.Duration]bool)
+	for _, threshold := range ts.Thresholds {
+		window := threshold.Window()
+		if window == 0 || seen[window] {
+			continue
+		}
+		seen[window] = true
+		windows = append(windows, window)
+	}
+	return windows
+}
+
 // Run processes all the thresholds with the provided Sink at the provided time and returns if any
 // of them fails
 func (ts *Thresholds) Run(sink Sink, duration time.Duration) (bool, error) {
-	// Initialize the sinks store
-	ts.sinked = make(map[string]float64)
+	return ts.RunWithWindows(sink, nil, duration)
+}
+
+// RunWithWindows is like Run, but the thresholds with a sliding time window
+// are evaluated against the sinks from windowSinks with the same window,
+// which should only contain the values from that most recent period of time.
+func (ts *Thresholds) RunWithWindows(
+	sink Sink, windowSinks map[time.Duration]Sink, duration time.Duration,
+) (bool, error) {
+	var err error
+	ts.sinked, ts.windowSinked, err = ts.allSinkValues(sink, windowSinks, duration)
+	if err != nil {
+		return false, err
+	}
+
+	return ts.runAll(duration)
+}
+
+// Check is like RunWithWindows, but it doesn't change the state of the
+// thresholds, so it can be called in between their regular evaluations. The
+// thresholds with a time window that were already breached are still failed.
+func (ts *Thresholds) Check(
+	sink Sink, windowSinks map[time.Duration]Sink, duration time.Duration,
+) (bool, error) {
+	sinked, windowSinked, err := ts.allSinkValues(sink, windowSinks, duration)
+	if err != nil {
+		return false, err
+	}
+
+	for i, threshold := range ts.Thresholds {
+		thresholdSinked := sinked
+		if window := threshold.Window(); window > 0 {
+			thresholdSinked = windowSinked[window]
+		}
+		passes, err := threshold.runNoTaint(thresholdSinked)
+		if err != nil {
+			return false, fmt.Errorf("threshold %d run error: %w", i, err)
+		}
+		if !passes || threshold.WindowBreaches > 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// allSinkValues extracts the values the thresholds need from the sink and
+// from the sinks of their time windows.
+func (ts *Thresholds) allSinkValues(
+	sink Sink, windowSinks map[time.Duration]Sink, duration time.Duration,
+) (map[string]float64, map[time.Duration]map[string]float64, error) {
+	sinked, err := ts.sinkValues(sink, duration)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	windowSinked := make(map[time.Duration]map[string]float64, len(windowSinks))
+	for window, windowSink := range windowSinks {
+		if windowSink.IsEmpty() {
+			continue
+		}
+		windowDuration := window
+		if duration < windowDuration {
+			windowDuration = duration
+		}
+		windowSinked[window], err = ts.sinkValues(windowSink, windowDuration)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return sinked, windowSinked, nil
+}
+
+// sinkValues extracts the values from the sink that the thresholds need.
+func (ts *Thresholds) sinkValues(sink Sink, duration time.Duration) (map[string]float64, error) {
+	sinked := make(map[string]float64)
 
 	// FIXME: Remove this comment as soon as the metrics.Sink does not expose Format anymore.
 	//
@@ -180,37 +320,53 @@ func (ts *Thresholds) Run(sink Sink, duration time.Duration) (bool, error) {
 	// For more details, see https://github.com/grafana/k6/issues/2320
 	switch sinkImpl := sink.(type) {
 	case *CounterSink:
-		ts.sinked["count"] = sinkImpl.Value
-		ts.sinked["rate"] = sinkImpl.Value / (float64(duration) / float64(time.Second))
+		sinked["count"] = sinkImpl.Value
+		sinked["rate"] = sinkImpl.Value / (float64(duration) / float64(time.Second))
 	case *GaugeSink:
-		ts.sinked["value"] = sinkImpl.Value
+		sinked["value"] = sinkImpl.Value
 	case *TrendSink:
-		ts.sinked["min"] = sinkImpl.Min()
-		ts.sinked["max"] = sinkImpl.Max()
-		ts.sinked["avg"] = sinkImpl.Avg()
-		ts.sinked["med"] = sinkImpl.P(0.5)
-
-		// Parse the percentile thresholds and insert them in
-		// the sinks mapping.
+		sinked["min"] = sinkImpl.Min()
+		sinked["max"] = sinkImpl.Max()
+		sinked["avg"] = sinkImpl.Avg()
+		sinked["med"] = sinkImpl.P(0.5)
+		sinked["count"] = float64(sinkImpl.Count())
+		sinked["sum"] = sinkImpl.Total()
+		sinked["stddev"] = sinkImpl.StdDev()
+		sinked["iqr"] = sinkImpl.IQR()
+
+		// Parse the percentile and trimmed mean thresholds and insert
+		// them in the sinks mapping.
 		for _, threshold := range ts.Thresholds {
-			if threshold.parsed.AggregationMethod != tokenPercentile {
-				continue
+			switch threshold.parsed.AggregationMethod {
+			case tokenPercentile:
+				sinked[threshold.parsed.SinkKey()] = sinkImpl.P(threshold.parsed.AggregationValue.Float64 / 100)
+			case tokenTrimmedMean:
+				sinked[threshold.parsed.SinkKey()] = sinkImpl.TrimmedMean(threshold.parsed.AggregationValue.Float64 / 100)
 			}
+		}
+	case *HistogramSink:
+		sinked["count"] = float64(sinkImpl.Count)
+		sinked["sum"] = sinkImpl.Sum
+		sinked["avg"] = sinkImpl.Avg()
+		sinked["min"] = sinkImpl.Min
+		sinked["max"] = sinkImpl.Max
 
-			key := fmt.Sprintf("p(%g)", threshold.parsed.AggregationValue.Float64)
-			ts.sinked[key] = sinkImpl.P(threshold.parsed.AggregationValue.Float64 / 100)
+		for _, threshold := range ts.Thresholds {
+			if threshold.parsed.AggregationMethod == tokenBucket {
+				sinked[threshold.parsed.SinkKey()] = sinkImpl.Ratio(threshold.parsed.AggregationValue.Float64)
+			}
 		}
 	case *RateSink:
 		// We want to avoid division by zero, which
 		// would lead to [#2520](https://github.com/grafana/k6/issues/2520)
 		if sinkImpl.Total > 0 {
-			ts.sinked["rate"] = float64(sinkImpl.Trues) / float64(sinkImpl.Total)
+			sinked["rate"] = float64(sinkImpl.Trues) / float64(sinkImpl.Total)
 		}
 	default:
-		return false, fmt.Errorf("unable to run Thresholds; reason: unknown sink type")
+		return nil, fmt.Errorf("unable to run Thresholds; reason: unknown sink type")
 	}
 
-	return ts.runAll(duration)
+	return sinked, nil
 }
 
 // Parse parses the Thresholds and fills each Threshold.parsed field with the result.
@@ -280,6 +436,56 @@ func (ts *Thresholds) Validate(metricName string, r *Registry) error {
 			)
 			return errext.WithExitCodeIfNone(err, exitcodes.InvalidConfig)
 		}
+
+		if err := validateBucket(metric, threshold.parsed.AggregationMethod, threshold.parsed.AggregationValue); err != nil {
+			err = fmt.Errorf("%w %q applied on metric %s; reason: %s", ErrInvalidThreshold, threshold.Source, metricName, err)
+			return errext.WithExitCodeIfNone(err, exitcodes.InvalidConfig)
+		}
+	}
+
+	return nil
+}
+
+// validateBucket checks that a histogram bucket aggregation method references
+// one of the buckets of the metric.
+func validateBucket(metric *Metric, aggregationMethod string, aggregationValue null.Float) error {
+	if aggregationMethod != tokenBucket {
+		return nil
+	}
+	for _, bound := range metric.Buckets {
+		if bound == aggregationValue.Float64 {
+			return nil
+		}
+	}
+	return fmt.Errorf("metric %s has no histogram bucket with the upper bound %g, its buckets are: %v",
+		metric.Name, aggregationValue.Float64, metric.Buckets)
+}
+
+// ResolveBaseline sets the baseline values of all the thresholds that reference
+// it in their expressions, from the values of the metric with the given name
+// in the provided baseline. It returns an error if a value is missing, or if
+// the baseline is nil while some of the thresholds need it. It expects the
+// thresholds to have been parsed already.
+func (ts *Thresholds) ResolveBaseline(metricName string, baseline Baseline) error {
+	for _, threshold := range ts.Thresholds {
+		if threshold.parsed == nil || threshold.parsed.BaselineOperator == "" {
+			continue
+		}
+
+		if baseline == nil {
+			err := fmt.Errorf("%w %q applied on metric %s; reason: it references a baseline, "+
+				"but no baseline summary was specified with --baseline", ErrInvalidThreshold, threshold.Source, metricName)
+			return errext.WithExitCodeIfNone(err, exitcodes.InvalidConfig)
+		}
+
+		key := threshold.parsed.SinkKey()
+		value, ok := baseline[metricName][key]
+		if !ok {
+			err := fmt.Errorf("%w %q applied on metric %s; reason: the baseline summary has no %s value for it",
+				ErrInvalidThreshold, threshold.Source, metricName, key)
+			return errext.WithExitCodeIfNone(err, exitcodes.InvalidConfig)
+		}
+		threshold.Baseline = null.FloatFrom(value)
 	}
 
 	return nil
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"gopkg.in/guregu/null.v3"
 )
@@ -27,6 +28,17 @@ type thresholdExpression struct {
 
 	// Value holds the value parsed from the threshold expression.
 	Value float64
+
+	// Window holds the length of the sliding time window the expression
+	// should be evaluated over, parsed from an optional `over duration` suffix.
+	// It is zero when the expression applies to the whole test run.
+	Window time.Duration
+
+	// BaselineOperator is set when the right hand side of the expression
+	// references the baseline value of a previous test run. It holds the
+	// arithmetic operator ("*", "/", "+" or "-") that is applied to the
+	// baseline value and Value; plain `baseline` is parsed as `baseline*1`.
+	BaselineOperator string
 }
 
 // SinkKey computes the key used to index a thresholdExpression in the engine's sinks.
@@ -38,12 +50,13 @@ type thresholdExpression struct {
 // Because a threshold expression's aggregation method can either be
 // a static keyword ("count", "rate", etc...), or a parametric
 // expression ("p(somefloatingpointvalue)"), we need to handle this
-// case specifically. If we encounter the percentile aggregation method token,
-// we recompute the whole "p(value)" expression in order to look for it in the
-// sinks.
+// case specifically. If we encounter a parametric aggregation method token,
+// like the percentile one, we recompute the whole "p(value)" expression in
+// order to look for it in the sinks.
 func (te *thresholdExpression) SinkKey() string {
-	if te.AggregationMethod == tokenPercentile {
-		return fmt.Sprintf("%s(%g)", tokenPercentile, te.AggregationValue.Float64)
+	switch te.AggregationMethod {
+	case tokenPercentile, tokenTrimmedMean, tokenBucket:
+		return fmt.Sprintf("%s(%g)", te.AggregationMethod, te.AggregationValue.Float64)
 	}
 
 	return te.AggregationMethod
@@ -53,25 +66,37 @@ func (te *thresholdExpression) SinkKey() string {
 // as defined in a JS script (for instance p(95)<1000), into a thresholdExpression
 // instance.
 //
-// It is expected to be of the form: `aggregation_method operator value`.
-// As defined by the following BNF:
+// It is expected to be of the form: `aggregation_method operator value`,
+// optionally followed by `over duration`. As defined by the following BNF:
 // ```
-// assertion           -> aggregation_method whitespace* operator whitespace* float
-// aggregation_method  -> trend | rate | gauge | counter
+// assertion           -> aggregation_method whitespace* operator whitespace* (float | baseline) window?
+// baseline            -> "baseline" (whitespace* arithmetic whitespace* float)?
+// arithmetic          -> "*" | "/" | "+" | "-"
+// window              -> whitespace+ "over" whitespace+ duration
+// duration            -> a positive Go duration string, e.g. "30s", "1m" or "1h30m"
+// aggregation_method  -> trend | rate | gauge | counter | histogram
 // counter             -> "count" | "rate"
 // gauge               -> "value"
 // rate                -> "rate"
-// trend               -> "avg" | "min" | "max" | "med" | percentile
+// trend               -> "avg" | "min" | "max" | "med" | "count" | "sum" | "stddev" | "iqr" | percentile | trimmed_mean
 // percentile          -> "p(" float ")"
+// trimmed_mean        -> "tmean(" float ")"
+// histogram           -> "count" | "sum" | "avg" | "min" | "max" | bucket
+// bucket              -> "le(" float ")"
 // operator            -> ">" | ">=" | "<=" | "<" | "==" | "===" | "!="
 // float               -> digit+ ("." digit+)?
 // digit               -> "0" | "1" | "2" | "3" | "4" | "5" | "6" | "7" | "8" | "9"
 // whitespace          -> " "
 // ```
 func parseThresholdExpression(input string) (*thresholdExpression, error) {
+	assertion, window, err := scanThresholdWindow(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing threshold expression %q; reason: %w", input, err)
+	}
+
 	// Scanning makes no assumption on the underlying values, and only
 	// checks that the expression has the right format.
-	method, operator, value, err := scanThresholdExpression(input)
+	method, operator, value, err := scanThresholdExpression(assertion)
 	if err != nil {
 		return nil, fmt.Errorf("failed parsing threshold expression %q; reason: %w", input, err)
 	}
@@ -84,7 +109,7 @@ func parseThresholdExpression(input string) (*thresholdExpression, error) {
 		return nil, err
 	}
 
-	parsedValue, err := strconv.ParseFloat(value, 64)
+	parsedValue, baselineOperator, err := parseThresholdValue(value)
 	if err != nil {
 		err = fmt.Errorf("failed parsing threshold expresion's %q right hand side; "+
 			"reason: %w", input, err,
@@ -97,6 +122,8 @@ func parseThresholdExpression(input string) (*thresholdExpression, error) {
 		AggregationValue:  parsedMethodValue,
 		Operator:          operator,
 		Value:             parsedValue,
+		Window:            window,
+		BaselineOperator:  baselineOperator,
 	}
 
 	return condition, nil
@@ -151,17 +178,94 @@ func scanThresholdExpression(input string) (string, string, string, error) {
 	return "", "", "", fmt.Errorf("malformed threshold expression")
 }
 
+// tokenBaseline references the baseline value of a previous test run.
+const tokenBaseline = "baseline"
+
+// parseThresholdValue parses the right hand side of a threshold expression,
+// which is either a float literal, or a reference to the baseline value with
+// an optional arithmetic operation on it. For the latter, it also returns the
+// arithmetic operator.
+func parseThresholdValue(input string) (float64, string, error) {
+	if !strings.HasPrefix(input, tokenBaseline) {
+		value, err := strconv.ParseFloat(input, 64)
+		return value, "", err
+	}
+
+	rest := strings.TrimSpace(strings.TrimPrefix(input, tokenBaseline))
+	if rest == "" {
+		return 1, "*", nil
+	}
+
+	operator := rest[:1]
+	if !strings.Contains("*/+-", operator) {
+		return 0, "", fmt.Errorf("unsupported baseline operator %q", operator)
+	}
+	value, err := strconv.ParseFloat(strings.TrimSpace(rest[1:]), 64)
+	if err != nil {
+		return 0, "", err
+	}
+	if operator == "/" && value == 0 {
+		return 0, "", fmt.Errorf("the baseline can't be divided by zero")
+	}
+	return value, operator, nil
+}
+
+// applyBaseline computes the value the threshold expression should be compared
+// against, from the provided baseline value.
+func (te *thresholdExpression) applyBaseline(baseline float64) float64 {
+	switch te.BaselineOperator {
+	case "/":
+		return baseline / te.Value
+	case "+":
+		return baseline + te.Value
+	case "-":
+		return baseline - te.Value
+	default:
+		return baseline * te.Value
+	}
+}
+
+// tokenOver separates a threshold assertion from its sliding time window.
+const tokenOver = "over"
+
+// scanThresholdWindow splits the optional `over duration` suffix from a
+// threshold condition expression. It returns the remaining assertion, and
+// the parsed window duration, or zero if the expression had no window.
+func scanThresholdWindow(input string) (string, time.Duration, error) {
+	fields := strings.Fields(input)
+	if len(fields) < 2 || fields[len(fields)-2] != tokenOver {
+		return input, 0, nil
+	}
+
+	window, err := time.ParseDuration(fields[len(fields)-1])
+	if err != nil {
+		return "", 0, fmt.Errorf("malformed time window; reason: %w", err)
+	}
+	if window <= 0 {
+		return "", 0, fmt.Errorf("the time window should be positive, got %s", window)
+	}
+
+	assertion := strings.TrimSpace(input[:strings.LastIndex(input, tokenOver)])
+	return assertion, window, nil
+}
+
 // Define accepted threshold expression aggregation tokens
-// Percentile token `p(..)` is accepted too but handled separately.
+// Percentile token `p(..)`, trimmed mean token `tmean(..)` and histogram
+// bucket token `le(..)` are accepted too but handled separately.
 const (
-	tokenValue      = "value"
-	tokenCount      = "count"
-	tokenRate       = "rate"
-	tokenAvg        = "avg"
-	tokenMin        = "min"
-	tokenMed        = "med"
-	tokenMax        = "max"
-	tokenPercentile = "p"
+	tokenValue       = "value"
+	tokenCount       = "count"
+	tokenRate        = "rate"
+	tokenAvg         = "avg"
+	tokenMin         = "min"
+	tokenMed         = "med"
+	tokenMax         = "max"
+	tokenSum         = "sum"
+	tokenStdDev      = "stddev"
+	tokenIQR         = "iqr"
+	tokenPercentile  = "p"
+	tokenTrimmedMean = "tmean"
+	tokenBucket      = "le"
 )
 
 // aggregationMethodTokens defines the list of aggregation method
@@ -170,7 +274,7 @@ const (
 // It is meant to be used during the parsing of threshold expressions.
 // Although declared as a `var`, being an array, it is effectively
 // immutable and can be considered constant.
-var aggregationMethodTokens = [8]string{ //nolint:gochecknoglobals
+var aggregationMethodTokens = [13]string{ //nolint:gochecknoglobals
 	tokenValue,
 	tokenCount,
 	tokenRate,
@@ -178,7 +282,12 @@ var aggregationMethodTokens = [8]string{ //nolint:gochecknoglobals
 	tokenMin,
 	tokenMed,
 	tokenMax,
+	tokenSum,
+	tokenStdDev,
+	tokenIQR,
 	tokenPercentile,
+	tokenTrimmedMean,
+	tokenBucket,
 }
 
 // parseThresholdMethod will parse a threshold condition expression's method.
@@ -206,6 +315,29 @@ func parseThresholdAggregationMethod(input string) (string, null.Float, error) {
 		return tokenPercentile, null.FloatFrom(aggregationValue), nil
 	}
 
+	// Or a trimmed mean expression
+	if strings.HasPrefix(input, tokenTrimmedMean+"(") && strings.HasSuffix(input, ")") {
+		aggregationValue, err := strconv.ParseFloat(trimDelimited("tmean(", input, ")"), 64)
+		if err != nil {
+			return "", null.Float{}, fmt.Errorf("malformed trimmed mean value; reason: %w", err)
+		}
+		if aggregationValue < 0 || aggregationValue >= 50 {
+			return "", null.Float{}, fmt.Errorf("the trimmed mean value should be between 0 and 50, got %g", aggregationValue)
+		}
+
+		return tokenTrimmedMean, null.FloatFrom(aggregationValue), nil
+	}
+
+	// Or a histogram bucket expression
+	if strings.HasPrefix(input, tokenBucket+"(") && strings.HasSuffix(input, ")") {
+		aggregationValue, err := strconv.ParseFloat(trimDelimited("le(", input, ")"), 64)
+		if err != nil {
+			return "", null.Float{}, fmt.Errorf("malformed histogram bucket value; reason: %w", err)
+		}
+
+		return tokenBucket, null.FloatFrom(aggregationValue), nil
+	}
+
 	return "", null.Float{}, fmt.Errorf("failed parsing method from expression")
 }
 
@@ -2,6 +2,7 @@ package metrics
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/guregu/null.v3"
@@ -40,6 +41,66 @@ func TestParseThresholdExpression(t *testing.T) {
 			wantExpression: &thresholdExpression{AggregationMethod: "count", Operator: ">", Value: 20},
 			wantErr:        false,
 		},
+		{
+			name:  "valid threshold expression with a time window",
+			input: "p(95) < 500 over 1m30s",
+			wantExpression: &thresholdExpression{
+				AggregationMethod: tokenPercentile,
+				AggregationValue:  null.FloatFrom(95),
+				Operator:          "<",
+				Value:             500,
+				Window:            90 * time.Second,
+			},
+			wantErr: false,
+		},
+		{
+			name:  "valid threshold expression with a baseline",
+			input: "p(95) < baseline * 1.1",
+			wantExpression: &thresholdExpression{
+				AggregationMethod: tokenPercentile,
+				AggregationValue:  null.FloatFrom(95),
+				Operator:          "<",
+				Value:             1.1,
+				BaselineOperator:  "*",
+			},
+			wantErr: false,
+		},
+		{
+			name:  "valid threshold expression with a plain baseline and a time window",
+			input: "avg<=baseline over 5m",
+			wantExpression: &thresholdExpression{
+				AggregationMethod: tokenAvg,
+				Operator:          "<=",
+				Value:             1,
+				Window:            5 * time.Minute,
+				BaselineOperator:  "*",
+			},
+			wantErr: false,
+		},
+		{
+			name:           "unsupported baseline operator fails",
+			input:          "avg<baseline%2",
+			wantExpression: nil,
+			wantErr:        true,
+		},
+		{
+			name:           "baseline divided by zero fails",
+			input:          "avg<baseline/0",
+			wantExpression: nil,
+			wantErr:        true,
+		},
+		{
+			name:           "malformed time window fails",
+			input:          "count>20 over 1",
+			wantExpression: nil,
+			wantErr:        true,
+		},
+		{
+			name:           "negative time window fails",
+			input:          "count>20 over -1m",
+			wantExpression: nil,
+			wantErr:        true,
+		},
 	}
 	for _, testCase := range tests {
 		testCase := testCase
@@ -143,6 +204,62 @@ func TestParseThresholdAggregationMethod(t *testing.T) {
 			wantMethodValue: null.FloatFrom(99.9),
 			wantErr:         false,
 		},
+		{
+			name:            "stddev method is parsed",
+			input:           "stddev",
+			wantMethod:      tokenStdDev,
+			wantMethodValue: null.Float{},
+			wantErr:         false,
+		},
+		{
+			name:            "sum method is parsed",
+			input:           "sum",
+			wantMethod:      tokenSum,
+			wantMethodValue: null.Float{},
+			wantErr:         false,
+		},
+		{
+			name:            "iqr method is parsed",
+			input:           "iqr",
+			wantMethod:      tokenIQR,
+			wantMethodValue: null.Float{},
+			wantErr:         false,
+		},
+		{
+			name:            "trimmed mean method is parsed",
+			input:           "tmean(12.5)",
+			wantMethod:      tokenTrimmedMean,
+			wantMethodValue: null.FloatFrom(12.5),
+			wantErr:         false,
+		},
+		{
+			name:            "parsing out of range trimmed mean value fails",
+			input:           "tmean(50)",
+			wantMethod:      "",
+			wantMethodValue: null.Float{},
+			wantErr:         true,
+		},
+		{
+			name:            "parsing non-numerical trimmed mean value fails",
+			input:           "tmean(foo)",
+			wantMethod:      "",
+			wantMethodValue: null.Float{},
+			wantErr:         true,
+		},
+		{
+			name:            "histogram bucket method is parsed",
+			input:           "le(300)",
+			wantMethod:      tokenBucket,
+			wantMethodValue: null.FloatFrom(300),
+			wantErr:         false,
+		},
+		{
+			name:            "parsing non-numerical histogram bucket value fails",
+			input:           "le(foo)",
+			wantMethod:      "",
+			wantMethodValue: null.Float{},
+			wantErr:         true,
+		},
 		{
 			name:            "parsing invalid method fails",
 			input:           "foo",
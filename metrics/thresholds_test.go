@@ -10,7 +10,6 @@ import (
 	"github.com/ChipArtem/k6/lib/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	"gopkg.in/guregu/null.v3"
 )
 
 func TestNewThreshold(t *testing.T) {
@@ -42,7 +41,7 @@ func TestThreshold_runNoTaint(t *testing.T) {
 	}{
 		{
 			name:             "valid expression using the > operator over passing threshold",
-			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenGreater, 0.01},
+			parsed:           &thresholdExpression{AggregationMethod: tokenRate, Operator: tokenGreater, Value: 0.01},
 			abortGracePeriod: types.NullDurationFrom(0 * time.Second),
 			sinks:            map[string]float64{"rate": 1},
 			wantOk:           true,
@@ -50,7 +49,7 @@ func TestThreshold_runNoTaint(t *testing.T) {
 		},
 		{
 			name:             "valid expression using the > operator over passing threshold and defined abort grace period",
-			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenGreater, 0.01},
+			parsed:           &thresholdExpression{AggregationMethod: tokenRate, Operator: tokenGreater, Value: 0.01},
 			abortGracePeriod: types.NullDurationFrom(2 * time.Second),
 			sinks:            map[string]float64{"rate": 1},
 			wantOk:           true,
@@ -58,7 +57,7 @@ func TestThreshold_runNoTaint(t *testing.T) {
 		},
 		{
 			name:             "valid expression using the >= operator over passing threshold",
-			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenGreaterEqual, 0.01},
+			parsed:           &thresholdExpression{AggregationMethod: tokenRate, Operator: tokenGreaterEqual, Value: 0.01},
 			abortGracePeriod: types.NullDurationFrom(0 * time.Second),
 			sinks:            map[string]float64{"rate": 0.01},
 			wantOk:           true,
@@ -66,7 +65,7 @@ func TestThreshold_runNoTaint(t *testing.T) {
 		},
 		{
 			name:             "valid expression using the <= operator over passing threshold",
-			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenLessEqual, 0.01},
+			parsed:           &thresholdExpression{AggregationMethod: tokenRate, Operator: tokenLessEqual, Value: 0.01},
 			abortGracePeriod: types.NullDurationFrom(0 * time.Second),
 			sinks:            map[string]float64{"rate": 0.01},
 			wantOk:           true,
@@ -74,7 +73,7 @@ func TestThreshold_runNoTaint(t *testing.T) {
 		},
 		{
 			name:             "valid expression using the < operator over passing threshold",
-			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenLess, 0.01},
+			parsed:           &thresholdExpression{AggregationMethod: tokenRate, Operator: tokenLess, Value: 0.01},
 			abortGracePeriod: types.NullDurationFrom(0 * time.Second),
 			sinks:            map[string]float64{"rate": 0.00001},
 			wantOk:           true,
@@ -82,7 +81,7 @@ func TestThreshold_runNoTaint(t *testing.T) {
 		},
 		{
 			name:             "valid expression using the == operator over passing threshold",
-			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenLooselyEqual, 0.01},
+			parsed:           &thresholdExpression{AggregationMethod: tokenRate, Operator: tokenLooselyEqual, Value: 0.01},
 			abortGracePeriod: types.NullDurationFrom(0 * time.Second),
 			sinks:            map[string]float64{"rate": 0.01},
 			wantOk:           true,
@@ -90,7 +89,7 @@ func TestThreshold_runNoTaint(t *testing.T) {
 		},
 		{
 			name:             "valid expression using the === operator over passing threshold",
-			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenStrictlyEqual, 0.01},
+			parsed:           &thresholdExpression{AggregationMethod: tokenRate, Operator: tokenStrictlyEqual, Value: 0.01},
 			abortGracePeriod: types.NullDurationFrom(0 * time.Second),
 			sinks:            map[string]float64{"rate": 0.01},
 			wantOk:           true,
@@ -98,7 +97,7 @@ func TestThreshold_runNoTaint(t *testing.T) {
 		},
 		{
 			name:             "valid expression using != operator over passing threshold",
-			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenBangEqual, 0.01},
+			parsed:           &thresholdExpression{AggregationMethod: tokenRate, Operator: tokenBangEqual, Value: 0.01},
 			abortGracePeriod: types.NullDurationFrom(0 * time.Second),
 			sinks:            map[string]float64{"rate": 0.02},
 			wantOk:           true,
@@ -106,7 +105,7 @@ func TestThreshold_runNoTaint(t *testing.T) {
 		},
 		{
 			name:             "valid expression over failing threshold",
-			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenGreater, 0.01},
+			parsed:           &thresholdExpression{AggregationMethod: tokenRate, Operator: tokenGreater, Value: 0.01},
 			abortGracePeriod: types.NullDurationFrom(0 * time.Second),
 			sinks:            map[string]float64{"rate": 0.00001},
 			wantOk:           false,
@@ -114,7 +113,7 @@ func TestThreshold_runNoTaint(t *testing.T) {
 		},
 		{
 			name:             "valid expression over non-existing sink",
-			parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenGreater, 0.01},
+			parsed:           &thresholdExpression{AggregationMethod: tokenRate, Operator: tokenGreater, Value: 0.01},
 			abortGracePeriod: types.NullDurationFrom(0 * time.Second),
 			sinks:            map[string]float64{"med": 27.2},
 			wantOk:           true,
@@ -124,7 +123,7 @@ func TestThreshold_runNoTaint(t *testing.T) {
 			// The ParseThresholdCondition constructor should ensure that no invalid
 			// operator gets through, but let's protect our future selves anyhow.
 			name:             "invalid expression operator",
-			parsed:           &thresholdExpression{tokenRate, null.Float{}, "&", 0.01},
+			parsed:           &thresholdExpression{AggregationMethod: tokenRate, Operator: "&", Value: 0.01},
 			abortGracePeriod: types.NullDurationFrom(0 * time.Second),
 			sinks:            map[string]float64{"rate": 0.00001},
 			wantOk:           false,
@@ -168,7 +167,7 @@ func BenchmarkRunNoTaint(b *testing.B) {
 		LastFailed:       false,
 		AbortOnFail:      false,
 		AbortGracePeriod: types.NullDurationFrom(2 * time.Second),
-		parsed:           &thresholdExpression{tokenRate, null.Float{}, tokenGreater, 0.01},
+		parsed:           &thresholdExpression{AggregationMethod: tokenRate, Operator: tokenGreater, Value: 0.01},
 	}
 
 	sinks := map[string]float64{"rate": 1}
@@ -390,6 +389,11 @@ func TestThresholdsValidate(t *testing.T) {
 		_, err = testRegistry.NewMetric("test_trend", Trend)
 		require.NoError(t, err)
 
+		testHistogram, err := testRegistry.NewHistogram("test_histogram", []float64{100, 300})
+		require.NoError(t, err)
+		_, err = testHistogram.AddSubmetric("foo:bar")
+		require.NoError(t, err)
+
 		tests := []struct {
 			name       string
 			metricName string
@@ -484,6 +488,46 @@ func TestThresholdsValidate(t *testing.T) {
 				},
 				wantErr: false,
 			},
+			{
+				name:       "threshold expression using 'le(bucket)' is valid against a histogram metric",
+				metricName: "test_histogram",
+				thresholds: Thresholds{
+					Thresholds: []*Threshold{newThreshold("le(300)>0.95", false, types.NullDuration{})},
+				},
+				wantErr: false,
+			},
+			{
+				name:       "threshold expression using 'le(bucket)' is valid against a histogram submetric",
+				metricName: "test_histogram{foo:bar}",
+				thresholds: Thresholds{
+					Thresholds: []*Threshold{newThreshold("le(100)>0.5", false, types.NullDuration{})},
+				},
+				wantErr: false,
+			},
+			{
+				name:       "threshold expression using 'le(bucket)' with an unknown bucket is invalid",
+				metricName: "test_histogram",
+				thresholds: Thresholds{
+					Thresholds: []*Threshold{newThreshold("le(200)>0.95", false, types.NullDuration{})},
+				},
+				wantErr: true,
+			},
+			{
+				name:       "threshold expression using 'le(bucket)' is invalid against a trend metric",
+				metricName: "test_trend",
+				thresholds: Thresholds{
+					Thresholds: []*Threshold{newThreshold("le(300)>0.95", false, types.NullDuration{})},
+				},
+				wantErr: true,
+			},
+			{
+				name:       "threshold expression using 'p(value)' is invalid against a histogram metric",
+				metricName: "test_histogram",
+				thresholds: Thresholds{
+					Thresholds: []*Threshold{newThreshold("p(99)==1", false, types.NullDuration{})},
+				},
+				wantErr: true,
+			},
 		}
 
 		for _, testCase := range tests {
@@ -647,6 +691,14 @@ func getTrendSink(values ...float64) *TrendSink {
 	return sink
 }
 
+func getHistogramSink(buckets []float64, values ...float64) *HistogramSink {
+	sink := NewHistogramSink(buckets)
+	for _, v := range values {
+		sink.Add(Sample{Value: v})
+	}
+	return sink
+}
+
 func TestThresholdsRun(t *testing.T) {
 	t.Parallel()
 
@@ -711,6 +763,50 @@ func TestThresholdsRun(t *testing.T) {
 			want:    false,
 			wantErr: false,
 		},
+		{
+			name: "Running thresholds with the extra trend aggregation methods succeeds",
+			args: args{
+				sink: getTrendSink(10, 20, 30, 40, 1000),
+				thresholdExpressions: []string{
+					"count==5", "sum==1100", "stddev>390", "stddev<391", "iqr==20", "tmean(20)==30",
+				},
+				duration: 0,
+			},
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name: "Running thresholds on histogram sink with bucket aggregation methods succeeds",
+			args: args{
+				sink: getHistogramSink([]float64{100, 300}, 50, 150, 250, 1000),
+				thresholdExpressions: []string{
+					"le(100)==0.25", "le(300)>=0.75", "count==4", "sum==1450", "max==1000",
+				},
+				duration: 0,
+			},
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name: "Running a failing histogram bucket threshold fails",
+			args: args{
+				sink:                 getHistogramSink([]float64{100, 300}, 50, 150, 250, 1000),
+				thresholdExpressions: []string{"le(300)>0.95"},
+				duration:             0,
+			},
+			want:    false,
+			wantErr: false,
+		},
+		{
+			name: "Running a failing trimmed mean threshold fails",
+			args: args{
+				sink:                 getTrendSink(10, 20, 30, 40, 1000),
+				thresholdExpressions: []string{"tmean(20)<30"},
+				duration:             0,
+			},
+			want:    false,
+			wantErr: false,
+		},
 		{
 			name: "Running threshold on trend sink with values and passing med statement succeeds",
 			args: args{
@@ -749,6 +845,70 @@ func TestThresholdsRun(t *testing.T) {
 	}
 }
 
+func TestThresholdsRunWithWindows(t *testing.T) {
+	t.Parallel()
+
+	thresholds := NewThresholds([]string{"p(95)<100", "p(95)<100 over 1m", "count<3 over 10s"})
+	require.NoError(t, thresholds.Parse())
+	assert.Equal(t, []time.Duration{time.Minute, 10 * time.Second}, thresholds.Windows())
+
+	sink := getTrendSink(10, 20, 30)
+	windowSinks := map[time.Duration]Sink{
+		time.Minute:      getTrendSink(200, 300),
+		10 * time.Second: getTrendSink(),
+	}
+	ok, err := thresholds.RunWithWindows(sink, windowSinks, time.Hour)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.False(t, thresholds.Thresholds[0].LastFailed)
+	assert.True(t, thresholds.Thresholds[1].LastFailed)
+	assert.Equal(t, uint64(1), thresholds.Thresholds[1].WindowBreaches)
+	assert.False(t, thresholds.Thresholds[2].LastFailed) // the empty window is ignored
+
+	// Once the window recovers, the breach is still remembered
+	windowSinks[time.Minute] = getTrendSink(10)
+	ok, err = thresholds.RunWithWindows(sink, windowSinks, time.Hour)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.True(t, thresholds.Thresholds[1].LastFailed)
+	assert.Equal(t, uint64(1), thresholds.Thresholds[1].WindowBreaches)
+
+	// Only the changes from passing to failing are counted as breaches
+	windowSinks[time.Minute] = getTrendSink(200)
+	for i := 0; i < 3; i++ {
+		_, err = thresholds.RunWithWindows(sink, windowSinks, time.Hour)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, uint64(2), thresholds.Thresholds[1].WindowBreaches)
+}
+
+func TestThresholdsRunWithWindowsAbort(t *testing.T) {
+	t.Parallel()
+
+	thresholds := newThresholdsWithConfig([]thresholdConfig{{
+		Threshold:        "rate<0.5 over 1m",
+		AbortOnFail:      true,
+		AbortGracePeriod: types.NullDurationFrom(10 * time.Second),
+	}})
+	require.NoError(t, thresholds.Parse())
+
+	failing := map[time.Duration]Sink{time.Minute: &RateSink{Trues: 2, Total: 2}}
+	passing := map[time.Duration]Sink{time.Minute: &RateSink{Trues: 0, Total: 2}}
+
+	_, err := thresholds.RunWithWindows(&RateSink{}, failing, 5*time.Second)
+	require.NoError(t, err)
+	assert.False(t, thresholds.Abort, "the grace period should still apply")
+
+	// a window that is passing again shouldn't abort the test after the grace period
+	_, err = thresholds.RunWithWindows(&RateSink{}, passing, 20*time.Second)
+	require.NoError(t, err)
+	assert.False(t, thresholds.Abort)
+
+	_, err = thresholds.RunWithWindows(&RateSink{}, failing, 30*time.Second)
+	require.NoError(t, err)
+	assert.True(t, thresholds.Abort)
+}
+
 func TestThresholdsJSON(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,151 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// minTrendHistogramValue is the smallest absolute value that gets its own
+// bucket in a trendHistogram; anything closer to zero is counted as a zero.
+const minTrendHistogramValue = 1e-9
+
+// trendHistogram is a mergeable histogram with logarithmically sized buckets,
+// which guarantees that every value it returns is within a configurable
+// relative error of the real value that was observed.
+//
+// Each bucket i covers the (gamma^(i-1), gamma^i] range, where
+// gamma = (1+relativeError)/(1-relativeError), so the amount of memory it uses
+// only depends on the range of the observed values and not on their count.
+// Positive and negative values are tracked in separate sets of buckets.
+type trendHistogram struct {
+	relativeError float64
+	gamma         float64
+	logGamma      float64
+
+	positive map[int32]uint64
+	negative map[int32]uint64
+	zeros    uint64
+}
+
+func newTrendHistogram(relativeError float64) *trendHistogram {
+	gamma := (1 + relativeError) / (1 - relativeError)
+	return &trendHistogram{
+		relativeError: relativeError,
+		gamma:         gamma,
+		logGamma:      math.Log(gamma),
+		positive:      make(map[int32]uint64),
+		negative:      make(map[int32]uint64),
+	}
+}
+
+// index returns the bucket index for the provided absolute value.
+func (h *trendHistogram) index(v float64) int32 {
+	return int32(math.Ceil(math.Log(v) / h.logGamma))
+}
+
+// value returns the representative value for the bucket with the provided
+// index, chosen so that the relative error is the same for both its ends.
+func (h *trendHistogram) value(index int32) float64 {
+	return 2 * math.Pow(h.gamma, float64(index)) / (h.gamma + 1)
+}
+
+func (h *trendHistogram) add(v float64) {
+	switch {
+	case v >= minTrendHistogramValue:
+		h.positive[h.index(v)]++
+	case v <= -minTrendHistogramValue:
+		h.negative[h.index(-v)]++
+	default:
+		h.zeros++
+	}
+}
+
+// merge adds all the counters from the other histogram into this one.
+func (h *trendHistogram) merge(other *trendHistogram) error {
+	if h.relativeError != other.relativeError {
+		return fmt.Errorf(
+			"can't merge histograms with different relative errors (%g and %g)",
+			h.relativeError, other.relativeError,
+		)
+	}
+	for i, c := range other.positive {
+		h.positive[i] += c
+	}
+	for i, c := range other.negative {
+		h.negative[i] += c
+	}
+	h.zeros += other.zeros
+	return nil
+}
+
+// quantile returns the approximate value with the provided rank, i.e. the
+// zero-based position it would have in the sorted list of the observed values.
+func (h *trendHistogram) quantile(rank float64) float64 {
+	var seen uint64
+
+	// Negative values are ordered from the largest absolute value, i.e. from
+	// the highest bucket index, to the smallest one.
+	negIndexes := sortedBucketIndexes(h.negative)
+	for i := len(negIndexes) - 1; i >= 0; i-- {
+		seen += h.negative[negIndexes[i]]
+		if float64(seen) > rank {
+			return -h.value(negIndexes[i])
+		}
+	}
+
+	seen += h.zeros
+	if float64(seen) > rank {
+		return 0
+	}
+
+	posIndexes := sortedBucketIndexes(h.positive)
+	for _, i := range posIndexes {
+		seen += h.positive[i]
+		if float64(seen) > rank {
+			return h.value(i)
+		}
+	}
+
+	// This is only reachable with rounding errors for the highest ranks.
+	if len(posIndexes) > 0 {
+		return h.value(posIndexes[len(posIndexes)-1])
+	}
+	return 0
+}
+
+// mean returns the approximate average of the observed values with ranks
+// in the [fromRank, toRank) range.
+func (h *trendHistogram) mean(fromRank, toRank uint64) float64 {
+	var seen, sum float64
+	add := func(count uint64, value float64) {
+		from := math.Max(seen, float64(fromRank))
+		seen += float64(count)
+		to := math.Min(seen, float64(toRank))
+		if to > from {
+			sum += (to - from) * value
+		}
+	}
+
+	negIndexes := sortedBucketIndexes(h.negative)
+	for i := len(negIndexes) - 1; i >= 0; i-- {
+		add(h.negative[negIndexes[i]], -h.value(negIndexes[i]))
+	}
+	add(h.zeros, 0)
+	for _, i := range sortedBucketIndexes(h.positive) {
+		add(h.positive[i], h.value(i))
+	}
+
+	return sum / float64(toRank-fromRank)
+}
+
+func sortedBucketIndexes(buckets map[int32]uint64) []int32 {
+	indexes := make([]int32, 0, len(buckets))
+	for i := range buckets {
+		indexes = append(indexes, i)
+	}
+	sort.Slice(indexes, func(i, j int) bool {
+		return indexes[i] < indexes[j]
+	})
+	return indexes
+}
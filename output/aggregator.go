@@ -0,0 +1,256 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ChipArtem/k6/metrics"
+)
+
+// DefaultAggregationWait is how long a bucket is kept after its end, if it
+// isn't configured.
+const DefaultAggregationWait = time.Second
+
+// DefaultAggregationPercentiles are the percentiles of the Trend metrics that
+// are calculated by an Aggregator, if none are configured.
+//
+//nolint:gochecknoglobals
+var DefaultAggregationPercentiles = []float64{50, 90, 95, 99}
+
+// AggregationConfig is the configuration of an Aggregator.
+type AggregationConfig struct {
+	// Period is the duration of the time buckets.
+	Period time.Duration
+	// Wait is how long a bucket is kept after its end, so the samples that
+	// are a bit late can still be aggregated in it.
+	Wait time.Duration
+	// Percentiles of the Trend metrics.
+	Percentiles []float64
+}
+
+// Aggregator rolls the samples into fixed time buckets per time series, and
+// replaces them with a few samples per bucket with the statistics of their
+// values. The statistics depend on the type of the metric:
+//
+//   - Counter: count and sum
+//   - Gauge: min, max and last
+//   - Rate: count, sum (of the non-zero values) and rate
+//   - Trend: count, sum, min, max, avg and the configured percentiles
+//
+// Every statistic is emitted as a sample of its own Gauge metric, named after
+// the original metric and the statistic, e.g. http_req_duration_p95 or
+// http_req_duration_p99_9 for p(99.9), so the outputs that treat the samples
+// by the type of their metric don't mistake them for the original values. The
+// aggregated samples keep the tags of their time series, and their time is the
+// start of their bucket. The statistics whose metric can't be registered, e.g.
+// because there's already a metric with that name and another type, are
+// dropped. The samples of the Histogram metrics are passed through as they
+// are, since their buckets are determined by their values.
+//
+// The time of the aggregation is the time of the newest sample, advanced by
+// the wall-clock time that has passed since it was added, so the recorded
+// results are aggregated the same way as the live ones, and the buckets still
+// expire when no samples are added. Aggregator isn't safe for concurrent use.
+type Aggregator struct {
+	conf     AggregationConfig
+	registry *metrics.Registry
+	buckets  map[int64]map[metrics.TimeSeries]*aggregate
+	latest   time.Time // the time of the newest sample
+	latestAt time.Time // the wall-clock time the newest sample was added at
+	now      func() time.Time
+
+	// gauges are the metrics of the statistics, by original metric and
+	// statistic, or nil if they couldn't be registered.
+	gauges map[*metrics.Metric]map[string]*metrics.Metric
+}
+
+type aggregate struct {
+	count               uint64
+	sum, min, max, last float64
+	trend               *metrics.TrendSink
+}
+
+// NewAggregator returns a new Aggregator with the given config, which
+// registers the metrics of the statistics in the given registry.
+func NewAggregator(conf AggregationConfig, registry *metrics.Registry) (*Aggregator, error) {
+	if conf.Period <= 0 {
+		return nil, errors.New("the aggregation period should be positive")
+	}
+	if conf.Wait < 0 {
+		return nil, errors.New("the aggregation wait period can't be negative")
+	}
+	for _, p := range conf.Percentiles {
+		if p < 0 || p > 100 {
+			return nil, fmt.Errorf("invalid percentile %g, it should be between 0 and 100", p)
+		}
+	}
+	if conf.Percentiles == nil {
+		conf.Percentiles = DefaultAggregationPercentiles
+	}
+	return &Aggregator{
+		conf:     conf,
+		registry: registry,
+		buckets:  make(map[int64]map[metrics.TimeSeries]*aggregate),
+		now:      time.Now,
+		gauges:   make(map[*metrics.Metric]map[string]*metrics.Metric),
+	}, nil
+}
+
+// AggregateSamples adds the samples to their buckets, and returns the
+// aggregated samples of the buckets that have expired, along with the samples
+// that aren't aggregated. It should be called periodically, even without any
+// samples, for the buckets to expire during the idle periods.
+func (a *Aggregator) AggregateSamples(containers []metrics.SampleContainer) []metrics.SampleContainer {
+	var passed metrics.Samples
+	for _, container := range containers {
+		for _, sample := range container.GetSamples() {
+			if sample.Metric.Type == metrics.Histogram {
+				passed = append(passed, sample)
+				continue
+			}
+			a.add(sample)
+		}
+	}
+
+	var result []metrics.SampleContainer
+	if len(passed) > 0 {
+		result = append(result, passed)
+	}
+	if a.latest.IsZero() {
+		return result
+	}
+	current := a.latest.Add(a.now().Sub(a.latestAt))
+	cutoff := current.Add(-a.conf.Wait).Add(-a.conf.Period).UnixNano()
+	return append(result, a.flush(func(start int64) bool { return start <= cutoff })...)
+}
+
+// Flush returns the aggregated samples of all of the buckets.
+func (a *Aggregator) Flush() []metrics.SampleContainer {
+	return a.flush(func(int64) bool { return true })
+}
+
+func (a *Aggregator) add(sample metrics.Sample) {
+	if sample.Time.After(a.latest) {
+		a.latest = sample.Time
+		a.latestAt = a.now()
+	}
+	start := sample.Time.Truncate(a.conf.Period).UnixNano()
+	bucket, ok := a.buckets[start]
+	if !ok {
+		bucket = make(map[metrics.TimeSeries]*aggregate)
+		a.buckets[start] = bucket
+	}
+	agg, ok := bucket[sample.TimeSeries]
+	if !ok {
+		agg = &aggregate{min: math.Inf(1), max: math.Inf(-1)}
+		if sample.Metric.Type == metrics.Trend {
+			agg.trend = metrics.NewTrendSink()
+		}
+		bucket[sample.TimeSeries] = agg
+	}
+
+	value := sample.Value
+	if sample.Metric.Type == metrics.Rate && value != 0 {
+		value = 1
+	}
+	agg.count++
+	agg.sum += value
+	agg.min = math.Min(agg.min, value)
+	agg.max = math.Max(agg.max, value)
+	agg.last = value
+	if agg.trend != nil {
+		agg.trend.Add(sample)
+	}
+}
+
+// flush returns the aggregated samples of the expired buckets, ordered by
+// their time, and removes the buckets.
+func (a *Aggregator) flush(expired func(start int64) bool) []metrics.SampleContainer {
+	var starts []int64
+	for start := range a.buckets {
+		if expired(start) {
+			starts = append(starts, start)
+		}
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i] < starts[j] })
+
+	result := make([]metrics.SampleContainer, 0, len(starts))
+	for _, start := range starts {
+		t := time.Unix(0, start)
+		var samples metrics.Samples
+		for series, agg := range a.buckets[start] {
+			for _, stat := range a.stats(series.Metric.Type, agg) {
+				gauge := a.gauge(series.Metric, stat)
+				if gauge == nil {
+					continue
+				}
+				samples = append(samples, metrics.Sample{
+					TimeSeries: metrics.TimeSeries{Metric: gauge, Tags: series.Tags},
+					Time:       t,
+					Value:      stat.value,
+				})
+			}
+		}
+		delete(a.buckets, start)
+		result = append(result, samples)
+	}
+	return result
+}
+
+// gauge returns the metric of the given statistic of the metric, or nil if it
+// can't be registered.
+func (a *Aggregator) gauge(m *metrics.Metric, s stat) *metrics.Metric {
+	gauges, ok := a.gauges[m]
+	if !ok {
+		gauges = make(map[string]*metrics.Metric)
+		a.gauges[m] = gauges
+	}
+	gauge, ok := gauges[s.name]
+	if !ok {
+		valueType := metrics.Default
+		if s.keepsValueType {
+			valueType = m.Contains
+		}
+		gauge, _ = a.registry.NewMetric(m.Name+"_"+s.name, metrics.Gauge, valueType)
+		gauges[s.name] = gauge
+	}
+	return gauge
+}
+
+type stat struct {
+	name  string
+	value float64
+	// keepsValueType is whether the statistic has the value type of the
+	// original metric, e.g. the max of a Trend of times is a time, but its
+	// count isn't.
+	keepsValueType bool
+}
+
+func (a *Aggregator) stats(mt metrics.MetricType, agg *aggregate) []stat {
+	count := float64(agg.count)
+	switch mt {
+	case metrics.Counter:
+		return []stat{{"count", count, false}, {"sum", agg.sum, true}}
+	case metrics.Gauge:
+		return []stat{{"min", agg.min, true}, {"max", agg.max, true}, {"last", agg.last, true}}
+	case metrics.Rate:
+		return []stat{{"count", count, false}, {"sum", agg.sum, false}, {"rate", agg.sum / count, false}}
+	case metrics.Trend:
+		stats := []stat{
+			{"count", count, false}, {"sum", agg.sum, true}, {"min", agg.min, true},
+			{"max", agg.max, true}, {"avg", agg.sum / count, true},
+		}
+		for _, p := range a.conf.Percentiles {
+			name := "p" + strings.ReplaceAll(strconv.FormatFloat(p, 'f', -1, 64), ".", "_")
+			stats = append(stats, stat{name, agg.trend.P(p / 100), true})
+		}
+		return stats
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,201 @@
+package output
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ChipArtem/k6/metrics"
+)
+
+// statValues returns the values of the aggregated samples, by metric.
+func statValues(containers []metrics.SampleContainer) map[string]float64 {
+	result := make(map[string]float64)
+	for _, container := range containers {
+		for _, sample := range container.GetSamples() {
+			result[sample.Metric.Name] = sample.Value
+		}
+	}
+	return result
+}
+
+func TestAggregator(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	reqs := registry.MustNewMetric("http_reqs", metrics.Counter)
+	vus := registry.MustNewMetric("vus", metrics.Gauge)
+	failed := registry.MustNewMetric("http_req_failed", metrics.Rate)
+	duration := registry.MustNewMetric("http_req_duration", metrics.Trend, metrics.Time)
+	histogram, err := registry.NewHistogram("my_histogram", []float64{10})
+	require.NoError(t, err)
+
+	start := time.Date(2024, 1, 2, 15, 4, 0, 0, time.UTC)
+	tags := registry.RootTagSet().With("name", "test")
+	sample := func(m *metrics.Metric, offset time.Duration, value float64) metrics.Sample {
+		return metrics.Sample{
+			TimeSeries: metrics.TimeSeries{Metric: m, Tags: tags},
+			Time:       start.Add(offset),
+			Value:      value,
+		}
+	}
+
+	a, err := NewAggregator(
+		AggregationConfig{Period: 10 * time.Second, Wait: time.Second, Percentiles: []float64{50, 12.5}}, registry,
+	)
+	require.NoError(t, err)
+
+	result := a.AggregateSamples([]metrics.SampleContainer{
+		metrics.Samples{
+			sample(reqs, 0, 1), sample(reqs, time.Second, 1), sample(reqs, 2*time.Second, 1),
+			sample(vus, 0, 5), sample(vus, time.Second, 10), sample(vus, 2*time.Second, 7),
+			sample(failed, 0, 0), sample(failed, time.Second, 1), sample(failed, 2*time.Second, 0),
+			sample(failed, 3*time.Second, 0),
+		},
+		metrics.ConnectedSamples{Samples: []metrics.Sample{
+			sample(duration, 0, 10), sample(duration, time.Second, 20), sample(duration, 2*time.Second, 30),
+		}},
+		sample(histogram, 0, 42),
+	})
+	require.Len(t, result, 1, "only the histogram samples are passed through")
+	require.Len(t, result[0].GetSamples(), 1)
+	assert.Equal(t, histogram, result[0].GetSamples()[0].Metric)
+
+	// the first bucket is still within the wait period
+	result = a.AggregateSamples([]metrics.SampleContainer{sample(reqs, 10500*time.Millisecond, 1)})
+	assert.Empty(t, result)
+
+	result = a.AggregateSamples([]metrics.SampleContainer{sample(reqs, 11*time.Second, 1)})
+	require.Len(t, result, 1)
+	for _, s := range result[0].GetSamples() {
+		assert.True(t, start.Equal(s.Time), s.Time)
+		assert.Equal(t, tags, s.Tags)
+		assert.Equal(t, metrics.Gauge, s.Metric.Type, s.Metric.Name)
+		assert.Equal(t, s.Metric, registry.Get(s.Metric.Name))
+	}
+	assert.Equal(t, map[string]float64{
+		"http_reqs_count":         3,
+		"http_reqs_sum":           3,
+		"vus_min":                 5,
+		"vus_max":                 10,
+		"vus_last":                7,
+		"http_req_failed_count":   4,
+		"http_req_failed_sum":     1,
+		"http_req_failed_rate":    0.25,
+		"http_req_duration_count": 3,
+		"http_req_duration_sum":   60,
+		"http_req_duration_min":   10,
+		"http_req_duration_max":   30,
+		"http_req_duration_avg":   20,
+		"http_req_duration_p50":   20,
+		"http_req_duration_p12_5": 12.5,
+	}, statValues(result))
+	assert.Equal(t, metrics.Time, registry.Get("http_req_duration_p12_5").Contains)
+	assert.Equal(t, metrics.Default, registry.Get("http_req_duration_count").Contains)
+
+	result = a.Flush()
+	require.Len(t, result, 1)
+	assert.Equal(t, map[string]float64{"http_reqs_count": 2, "http_reqs_sum": 2}, statValues(result))
+	assert.Empty(t, a.Flush())
+}
+
+func TestNewAggregatorErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, conf := range []AggregationConfig{
+		{},
+		{Period: time.Second, Wait: -time.Second},
+		{Period: time.Second, Percentiles: []float64{101}},
+	} {
+		_, err := NewAggregator(conf, metrics.NewRegistry())
+		assert.Error(t, err, conf)
+	}
+}
+
+func TestParsePipeline(t *testing.T) {
+	t.Parallel()
+
+	filter, aggregator, err := ParsePipeline("include=http_*;aggregate=10s;percentiles=95,99", metrics.NewRegistry())
+	require.NoError(t, err)
+	require.NotNil(t, filter)
+	assert.Equal(t, []filterPattern{{name: "http_*"}}, filter.include)
+	require.NotNil(t, aggregator)
+	assert.Equal(t, AggregationConfig{
+		Period: 10 * time.Second, Wait: DefaultAggregationWait, Percentiles: []float64{95, 99},
+	}, aggregator.conf)
+
+	filter, aggregator, err = ParsePipeline("aggregate=1m;aggregateWait=5s", metrics.NewRegistry())
+	require.NoError(t, err)
+	assert.Nil(t, filter)
+	require.NotNil(t, aggregator)
+	assert.Equal(t, AggregationConfig{
+		Period: time.Minute, Wait: 5 * time.Second, Percentiles: DefaultAggregationPercentiles,
+	}, aggregator.conf)
+
+	filter, aggregator, err = ParsePipeline("exclude=vus", metrics.NewRegistry())
+	require.NoError(t, err)
+	assert.NotNil(t, filter)
+	assert.Nil(t, aggregator)
+
+	for _, spec := range []string{
+		"aggregate",
+		"aggregate=soon",
+		"aggregate=0s",
+		"percentiles=95",
+		"aggregate=1s;percentiles=a",
+		"aggregates=1s",
+		"include=[vus",
+	} {
+		_, _, err := ParsePipeline(spec, metrics.NewRegistry())
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestAggregatorMetricConflict(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	reqs := registry.MustNewMetric("reqs", metrics.Counter)
+	registry.MustNewMetric("reqs_count", metrics.Trend)
+
+	a, err := NewAggregator(AggregationConfig{Period: time.Second}, registry)
+	require.NoError(t, err)
+	a.AggregateSamples([]metrics.SampleContainer{metrics.Sample{
+		TimeSeries: metrics.TimeSeries{Metric: reqs, Tags: registry.RootTagSet()},
+		Time:       time.Now(),
+		Value:      1,
+	}})
+	assert.Equal(t, map[string]float64{"reqs_sum": 1}, statValues(a.Flush()),
+		"the count can't be a Gauge, so it's dropped")
+}
+
+func TestAggregatorExpiresWhenIdle(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	reqs := registry.MustNewMetric("reqs", metrics.Counter)
+
+	a, err := NewAggregator(AggregationConfig{Period: 10 * time.Second, Wait: time.Second}, registry)
+	require.NoError(t, err)
+	wallClock := time.Date(2024, 1, 2, 15, 4, 0, 0, time.UTC)
+	a.now = func() time.Time { return wallClock }
+
+	// the samples are older than the wall clock, as if they were recorded
+	start := time.Date(2023, 5, 6, 7, 8, 0, 0, time.UTC)
+	assert.Empty(t, a.AggregateSamples([]metrics.SampleContainer{metrics.Sample{
+		TimeSeries: metrics.TimeSeries{Metric: reqs, Tags: registry.RootTagSet()},
+		Time:       start.Add(5 * time.Second),
+		Value:      1,
+	}}))
+
+	wallClock = wallClock.Add(5 * time.Second)
+	assert.Empty(t, a.AggregateSamples(nil), "the bucket is still within the wait period")
+
+	wallClock = wallClock.Add(time.Second)
+	result := a.AggregateSamples(nil)
+	assert.Equal(t, map[string]float64{"reqs_count": 1, "reqs_sum": 1}, statValues(result))
+	require.Len(t, result, 1)
+	assert.True(t, start.Equal(result[0].GetSamples()[0].Time))
+}
@@ -58,7 +58,7 @@ func mapMetricTypeProto(mt metrics.MetricType) pbcloud.MetricType {
 		mtype = pbcloud.MetricType_METRIC_TYPE_GAUGE
 	case metrics.Rate:
 		mtype = pbcloud.MetricType_METRIC_TYPE_RATE
-	case metrics.Trend:
+	case metrics.Trend, metrics.Histogram:
 		mtype = pbcloud.MetricType_METRIC_TYPE_TREND
 	}
 	return mtype
@@ -122,7 +122,7 @@ func initTimeSeriesSamples(timeSeries *pbcloud.TimeSeries, mt metrics.MetricType
 		timeSeries.Samples = &pbcloud.TimeSeries_RateSamples{
 			RateSamples: &pbcloud.RateSamples{},
 		}
-	case metrics.Trend:
+	case metrics.Trend, metrics.Histogram:
 		timeSeries.Samples = &pbcloud.TimeSeries_TrendHdrSamples{
 			TrendHdrSamples: &pbcloud.TrendHdrSamples{},
 		}
@@ -21,7 +21,7 @@ func newMetricValue(mt metrics.MetricType) metricValue {
 		am = &gauge{}
 	case metrics.Rate:
 		am = &rate{}
-	case metrics.Trend:
+	case metrics.Trend, metrics.Histogram:
 		am = newHistogram()
 	default:
 		// Should not be possible to create
@@ -9,6 +9,7 @@ import (
 	"gopkg.in/guregu/null.v3"
 
 	"github.com/ChipArtem/k6/lib/types"
+	"github.com/ChipArtem/k6/output/rotation"
 	"github.com/mstoykov/envconfig"
 	"github.com/sirupsen/logrus"
 )
@@ -19,6 +20,12 @@ type Config struct {
 	FileName     null.String        `json:"file_name" envconfig:"K6_CSV_FILENAME"`
 	SaveInterval types.NullDuration `json:"save_interval" envconfig:"K6_CSV_SAVE_INTERVAL"`
 	TimeFormat   null.String        `json:"time_format" envconfig:"K6_CSV_TIME_FORMAT"`
+
+	// File rotation.
+	RotateMaxSize     null.String        `json:"rotate_max_size" envconfig:"K6_CSV_ROTATE_MAX_SIZE"`
+	RotateMaxDuration types.NullDuration `json:"rotate_max_duration" envconfig:"K6_CSV_ROTATE_MAX_DURATION"`
+	RotateMaxFiles    null.Int           `json:"rotate_max_files" envconfig:"K6_CSV_ROTATE_MAX_FILES"`
+	RotateNaming      null.String        `json:"rotate_naming" envconfig:"K6_CSV_ROTATE_NAMING"`
 }
 
 // TimeFormat custom enum type
@@ -56,9 +63,28 @@ func (c Config) Apply(cfg Config) Config {
 	if cfg.TimeFormat.Valid {
 		c.TimeFormat = cfg.TimeFormat
 	}
+	if cfg.RotateMaxSize.Valid {
+		c.RotateMaxSize = cfg.RotateMaxSize
+	}
+	if cfg.RotateMaxDuration.Valid {
+		c.RotateMaxDuration = cfg.RotateMaxDuration
+	}
+	if cfg.RotateMaxFiles.Valid {
+		c.RotateMaxFiles = cfg.RotateMaxFiles
+	}
+	if cfg.RotateNaming.Valid {
+		c.RotateNaming = cfg.RotateNaming
+	}
 	return c
 }
 
+// Rotation returns the config of the file rotation.
+func (c Config) Rotation() (rotation.Config, error) {
+	return rotation.NewConfig(
+		c.RotateMaxSize.String, c.RotateMaxDuration.TimeDuration(), c.RotateMaxFiles.Int64, c.RotateNaming.String,
+	)
+}
+
 // ParseArg takes an arg string and converts it to a config
 func ParseArg(arg string, logger logrus.FieldLogger) (Config, error) {
 	c := NewConfig()
@@ -84,6 +110,18 @@ func ParseArg(arg string, logger logrus.FieldLogger) (Config, error) {
 			c.FileName = null.StringFrom(r[1])
 		case "timeFormat":
 			c.TimeFormat = null.StringFrom(r[1])
+		case "rotateMaxSize":
+			c.RotateMaxSize = null.StringFrom(r[1])
+		case "rotateMaxDuration":
+			if err := c.RotateMaxDuration.UnmarshalText([]byte(r[1])); err != nil {
+				return c, err
+			}
+		case "rotateMaxFiles":
+			if err := c.RotateMaxFiles.UnmarshalText([]byte(r[1])); err != nil {
+				return c, err
+			}
+		case "rotateNaming":
+			c.RotateNaming = null.StringFrom(r[1])
 		default:
 			return c, fmt.Errorf("unknown key %q as argument for csv output", r[0])
 		}
@@ -90,6 +90,20 @@ func TestParseArg(t *testing.T) {
 		"filename=test.csv,saveInterval=5s": {
 			expectedErr: true,
 		},
+		"fileName=test.csv.gz,rotateMaxSize=1GB,rotateMaxDuration=1h,rotateMaxFiles=24,rotateNaming=timestamp": {
+			config: Config{
+				FileName:          null.StringFrom("test.csv.gz"),
+				SaveInterval:      types.NewNullDuration(1*time.Second, false),
+				TimeFormat:        null.NewString("unix", false),
+				RotateMaxSize:     null.StringFrom("1GB"),
+				RotateMaxDuration: types.NullDurationFrom(time.Hour),
+				RotateMaxFiles:    null.IntFrom(24),
+				RotateNaming:      null.StringFrom("timestamp"),
+			},
+		},
+		"fileName=test.csv,rotateMaxFiles=all": {
+			expectedErr: true,
+		},
 		"fileName=test.csv,timeFormat=rfc3339": {
 			config: Config{
 				FileName:     null.StringFrom("test.csv"),
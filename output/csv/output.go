@@ -2,13 +2,12 @@ package csv
 
 import (
 	"bytes"
-	"compress/gzip"
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"os"
 	"sort"
 	"strconv"
-	"strings"
 	"sync"
 	"time"
 
@@ -16,6 +15,7 @@ import (
 
 	"github.com/ChipArtem/k6/metrics"
 	"github.com/ChipArtem/k6/output"
+	"github.com/ChipArtem/k6/output/rotation"
 )
 
 // Output implements the lib.Output interface for saving to CSV files.
@@ -29,6 +29,8 @@ type Output struct {
 	fname     string
 	csvWriter *csv.Writer
 	csvLock   sync.Mutex
+	file      *rotation.File // only set if the file is rotated
+	bySize    bool           // whether the file is rotated by its size
 	closeFn   func() error
 
 	resTags      []string
@@ -85,10 +87,18 @@ func newOutput(params output.Params) (*Output, error) {
 		return nil, err
 	}
 
+	rotationConf, err := config.Rotation()
+	if err != nil {
+		return nil, err
+	}
+
 	saveInterval := config.SaveInterval.TimeDuration()
 	fname := config.FileName.String
 
 	if fname == "" || fname == "-" {
+		if rotationConf.Enabled() {
+			return nil, errors.New("the rotation of the csv output requires a file name")
+		}
 		stdoutWriter := csv.NewWriter(os.Stdout)
 		return &Output{
 			fname:        "-",
@@ -104,37 +114,28 @@ func newOutput(params output.Params) (*Output, error) {
 		}, nil
 	}
 
-	logFile, err := params.FS.Create(fname)
+	file, err := rotation.Create(params.FS, fname, rotationConf)
 	if err != nil {
 		return nil, err
 	}
 
-	c := Output{
+	c := &Output{
 		fname:        fname,
 		resTags:      resTags,
 		ignoredTags:  ignoredTags,
+		csvWriter:    csv.NewWriter(file),
 		row:          make([]string, 3+len(resTags)+2),
 		saveInterval: saveInterval,
 		timeFormat:   timeFormat,
+		closeFn:      file.Close,
 		logger:       logger,
 		params:       params,
 	}
-
-	if strings.HasSuffix(fname, ".gz") {
-		outfile := gzip.NewWriter(logFile)
-		csvWriter := csv.NewWriter(outfile)
-		c.csvWriter = csvWriter
-		c.closeFn = func() error {
-			_ = outfile.Close()
-			return logFile.Close()
-		}
-	} else {
-		csvWriter := csv.NewWriter(logFile)
-		c.csvWriter = csvWriter
-		c.closeFn = logFile.Close
+	if rotationConf.Enabled() {
+		c.file = file
+		c.bySize = rotationConf.MaxSize > 0
 	}
-
-	return &c, nil
+	return c, nil
 }
 
 // Description returns a human-readable description of the output.
@@ -149,12 +150,7 @@ func (o *Output) Description() string {
 func (o *Output) Start() error {
 	o.logger.Debug("Starting...")
 
-	header := MakeHeader(o.resTags)
-	err := o.csvWriter.Write(header)
-	if err != nil {
-		o.logger.WithField("filename", o.fname).Error("CSV: Error writing column names to file")
-	}
-	o.csvWriter.Flush()
+	o.writeHeader()
 
 	pf, err := output.NewPeriodicFlusher(o.saveInterval, o.flushMetrics)
 	if err != nil {
@@ -184,6 +180,7 @@ func (o *Output) flushMetrics() {
 		for _, sc := range samples {
 			for _, sample := range sc.GetSamples() {
 				sample := sample
+				o.rotateIfNeeded()
 				row := SampleToRow(&sample, o.resTags, o.ignoredTags, o.row, o.timeFormat)
 				err := o.csvWriter.Write(row)
 				if err != nil {
@@ -195,6 +192,38 @@ func (o *Output) flushMetrics() {
 	}
 }
 
+func (o *Output) writeHeader() {
+	header := MakeHeader(o.resTags)
+	err := o.csvWriter.Write(header)
+	if err != nil {
+		o.logger.WithField("filename", o.fname).Error("CSV: Error writing column names to file")
+	}
+	o.csvWriter.Flush()
+}
+
+// rotateIfNeeded starts a new file, with its own header, if the current one
+// has reached the limits of the rotation.
+func (o *Output) rotateIfNeeded() {
+	if o.file == nil {
+		return
+	}
+	// the size of the rows buffered by the csv writer isn't known, so they
+	// are written to the file first when it's rotated by its size
+	if o.bySize {
+		o.csvWriter.Flush()
+	}
+	if !o.file.ShouldRotate(0) {
+		return
+	}
+	// the buffered rows belong to the current file
+	o.csvWriter.Flush()
+	if err := o.file.Rotate(); err != nil {
+		o.logger.WithError(err).Error("CSV: Error rotating the file")
+		return
+	}
+	o.writeHeader()
+}
+
 // MakeHeader creates list of column names for csv file
 func MakeHeader(tags []string) []string {
 	tags = append(tags, "extra_tags")
@@ -227,6 +256,9 @@ func SampleToRow(sample *metrics.Sample, resTags []string, ignoredTags []string,
 	// TODO: optimize all of this - do not use tags.Map(), flip resTags, fix the
 	// for loops, get rid of IsStringInSlice(), etc.
 	sampleTags := sample.Tags.Map()
+	if sample.Metric.Type == metrics.Histogram {
+		sampleTags["le"] = metrics.HistogramBucket(sample.Metric.Buckets, sample.Value)
+	}
 	for ind, tag := range resTags {
 		row[ind+3] = sampleTags[tag]
 	}
@@ -49,6 +49,8 @@ func TestSampleToRow(t *testing.T) {
 	registry := metrics.NewRegistry()
 	testMetric, err := registry.NewMetric("my_metric", metrics.Gauge)
 	require.NoError(t, err)
+	testHistogram, err := registry.NewHistogram("my_histogram", []float64{100, 300})
+	require.NoError(t, err)
 
 	testData := []struct {
 		testname    string
@@ -200,6 +202,23 @@ func TestSampleToRow(t *testing.T) {
 			ignoredTags: []string{"tag4", "tag6"},
 			timeFormat:  "rfc3339_nano",
 		},
+		{
+			testname: "Histogram sample with its bucket as an extra tag",
+			sample: &metrics.Sample{
+				TimeSeries: metrics.TimeSeries{
+					Metric: testHistogram,
+					Tags: registry.RootTagSet().WithTagsFromMap(map[string]string{
+						"tag1": "val1",
+						"tag2": "val2",
+					}),
+				},
+				Time:  time.Unix(1562324644, 0),
+				Value: 150,
+			},
+			resTags:     []string{"tag1"},
+			ignoredTags: []string{},
+			timeFormat:  "unix",
+		},
 	}
 
 	expected := []struct {
@@ -296,6 +315,18 @@ func TestSampleToRow(t *testing.T) {
 				"tag5=val5",
 			},
 		},
+		{
+			baseRow: []string{
+				"my_histogram",
+				"1562324644",
+				"150.000000",
+				"val1",
+			},
+			extraRow: []string{
+				"tag2=val2",
+				"le=300",
+			},
+		},
 	}
 
 	for i := range testData {
@@ -522,3 +553,54 @@ func sortExtraTagsForTest(t *testing.T, input string) string {
 	w.Flush()
 	return b.String()
 }
+
+func TestRunRotation(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	testMetric, err := registry.NewMetric("my_metric", metrics.Gauge)
+	require.NoError(t, err)
+
+	mem := fsext.NewMemMapFs()
+	output, err := newOutput(output.Params{
+		Logger: testutils.NewLogger(t),
+		FS:     mem,
+		Environment: map[string]string{
+			"K6_CSV_ROTATE_MAX_SIZE":  "100",
+			"K6_CSV_ROTATE_MAX_FILES": "2",
+		},
+		ConfigArgument: "/results.csv.gz",
+		ScriptOptions:  lib.Options{SystemTags: metrics.NewSystemTagSet()},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, output.Start())
+	samples := metrics.Samples{}
+	for i := 0; i < 5; i++ {
+		samples = append(samples, metrics.Sample{
+			TimeSeries: metrics.TimeSeries{Metric: testMetric, Tags: registry.RootTagSet()},
+			Time:       time.Unix(1562324643+int64(i), 0),
+			Value:      float64(i),
+		})
+	}
+	output.AddMetricSamples([]metrics.SampleContainer{samples})
+	require.NoError(t, output.Stop())
+
+	// the header and a row are 84 bytes, so every file fits two rows
+	exists, err := fsext.Exists(mem, "/results.00001.csv.gz")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	header := "metric_name,timestamp,metric_value,extra_tags,metadata\n"
+	assert.Equal(t, header+
+		"my_metric,1562324645,2.000000,,\n"+
+		"my_metric,1562324646,3.000000,,\n",
+		readCompressedFile("/results.00002.csv.gz", mem))
+	assert.Equal(t, header+
+		"my_metric,1562324647,4.000000,,\n",
+		readCompressedFile("/results.00003.csv.gz", mem))
+
+	manifest := readUnCompressedFile("/results.csv.manifest.json", mem)
+	assert.Contains(t, manifest, `"name": "results.00003.csv.gz"`)
+	assert.Contains(t, manifest, `"deleted": 1`)
+}
@@ -0,0 +1,186 @@
+package output
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/ChipArtem/k6/metrics"
+)
+
+// Filter selects the metric samples that are sent to an output. A sample is
+// sent if its metric matches any of the included patterns, or there are none
+// of them, and it doesn't match any of the excluded patterns. The dropped tags
+// are removed from the samples that are sent.
+//
+// The patterns are globs of the metric names, optionally followed by tags in
+// the submetric syntax, e.g. http_req_* or http_req_duration{status:200},
+// which only match the samples that have all of the tags.
+type Filter struct {
+	include  []filterPattern
+	exclude  []filterPattern
+	dropTags []string
+}
+
+type filterPattern struct {
+	name string
+	tags map[string]string
+}
+
+func (p filterPattern) matches(sample metrics.Sample) bool {
+	if ok, _ := path.Match(p.name, sample.Metric.Name); !ok {
+		return false
+	}
+	for key, value := range p.tags {
+		if v, ok := sample.Tags.Get(key); !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseFilter parses a filter from its specification, which is
+// a semicolon-separated list of key=value pairs, where the values are
+// comma-separated lists, e.g.:
+//
+//	include=http_req_duration{expected_response:true},http_reqs;dropTags=url,name
+//
+// The supported keys are include, exclude and dropTags.
+func ParseFilter(spec string) (*Filter, error) {
+	f := &Filter{}
+	for _, pair := range strings.Split(spec, ";") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("couldn't parse %q as an output filter option, it should be key=value", pair)
+		}
+		key, values := strings.TrimSpace(kv[0]), splitOutsideBraces(kv[1])
+		switch key {
+		case "include", "exclude":
+			patterns, err := parseFilterPatterns(values)
+			if err != nil {
+				return nil, err
+			}
+			if key == "include" {
+				f.include = append(f.include, patterns...)
+			} else {
+				f.exclude = append(f.exclude, patterns...)
+			}
+		case "dropTags":
+			f.dropTags = append(f.dropTags, values...)
+		default:
+			return nil, fmt.Errorf("unknown output filter option %q, it should be one of include, exclude or dropTags", key)
+		}
+	}
+	return f, nil
+}
+
+func parseFilterPatterns(values []string) ([]filterPattern, error) {
+	patterns := make([]filterPattern, 0, len(values))
+	for _, value := range values {
+		name, tags, err := metrics.ParseMetricName(value)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := path.Match(name, ""); err != nil {
+			return nil, fmt.Errorf("invalid metric name pattern %q: %w", name, err)
+		}
+
+		p := filterPattern{name: name}
+		for _, tag := range tags {
+			// the tags are already validated to be key:value
+			kv := strings.SplitN(tag, ":", 2)
+			if p.tags == nil {
+				p.tags = make(map[string]string, len(tags))
+			}
+			p.tags[strings.TrimSpace(kv[0])] = strings.Trim(strings.TrimSpace(kv[1]), `"'`)
+		}
+		patterns = append(patterns, p)
+	}
+	return patterns, nil
+}
+
+// splitOutsideBraces splits the comma-separated values, ignoring the commas
+// between the tags of a metric pattern, and skipping the empty values.
+func splitOutsideBraces(s string) []string {
+	var (
+		result []string
+		depth  int
+		start  int
+	)
+	add := func(value string) {
+		if value = strings.TrimSpace(value); value != "" {
+			result = append(result, value)
+		}
+	}
+	for i, r := range s {
+		switch r {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				add(s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	add(s[start:])
+	return result
+}
+
+// FilterSamples returns the sample containers with only the samples that pass
+// the filter, without the dropped tags. The containers that are passed as
+// a whole are kept as they are, the rest are replaced by metrics.Samples.
+func (f *Filter) FilterSamples(containers []metrics.SampleContainer) []metrics.SampleContainer {
+	result := make([]metrics.SampleContainer, 0, len(containers))
+	for _, container := range containers {
+		samples := container.GetSamples()
+
+		kept := 0
+		for _, sample := range samples {
+			if f.passes(sample) {
+				kept++
+			}
+		}
+		if kept == len(samples) && len(f.dropTags) == 0 {
+			result = append(result, container)
+			continue
+		}
+		if kept == 0 {
+			continue
+		}
+
+		filtered := make(metrics.Samples, 0, kept)
+		for _, sample := range samples {
+			if !f.passes(sample) {
+				continue
+			}
+			for _, tag := range f.dropTags {
+				sample.Tags = sample.Tags.Without(tag)
+			}
+			filtered = append(filtered, sample)
+		}
+		result = append(result, filtered)
+	}
+	return result
+}
+
+func (f *Filter) passes(sample metrics.Sample) bool {
+	if len(f.include) > 0 && !matchesAny(f.include, sample) {
+		return false
+	}
+	return !matchesAny(f.exclude, sample)
+}
+
+func matchesAny(patterns []filterPattern, sample metrics.Sample) bool {
+	for _, p := range patterns {
+		if p.matches(sample) {
+			return true
+		}
+	}
+	return false
+}
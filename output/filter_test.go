@@ -0,0 +1,78 @@
+package output
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ChipArtem/k6/metrics"
+)
+
+func TestParseFilter(t *testing.T) {
+	t.Parallel()
+
+	f, err := ParseFilter("include=http_req_*, iterations ;exclude=http_req_duration{status:500,method:GET};dropTags=url,name")
+	require.NoError(t, err)
+	assert.Equal(t, &Filter{
+		include:  []filterPattern{{name: "http_req_*"}, {name: "iterations"}},
+		exclude:  []filterPattern{{name: "http_req_duration", tags: map[string]string{"status": "500", "method": "GET"}}},
+		dropTags: []string{"url", "name"},
+	}, f)
+
+	for _, spec := range []string{
+		"include",
+		"includes=vus",
+		"include=http_req_duration{status}",
+		"exclude=[vus",
+	} {
+		_, err := ParseFilter(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestFilterSamples(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	duration := registry.MustNewMetric("http_req_duration", metrics.Trend, metrics.Time)
+	reqs := registry.MustNewMetric("http_reqs", metrics.Counter)
+	vus := registry.MustNewMetric("vus", metrics.Gauge)
+
+	sample := func(m *metrics.Metric, status string) metrics.Sample {
+		return metrics.Sample{
+			TimeSeries: metrics.TimeSeries{
+				Metric: m,
+				Tags:   registry.RootTagSet().WithTagsFromMap(map[string]string{"status": status, "url": "http://test"}),
+			},
+			Time:  time.Now(),
+			Value: 1,
+		}
+	}
+	whole := metrics.ConnectedSamples{Samples: []metrics.Sample{sample(duration, "200"), sample(reqs, "200")}}
+	containers := []metrics.SampleContainer{
+		whole,
+		metrics.ConnectedSamples{Samples: []metrics.Sample{sample(duration, "500"), sample(reqs, "500")}},
+		sample(vus, ""),
+	}
+
+	f, err := ParseFilter("include=http_*;exclude=http_req_duration{status:500}")
+	require.NoError(t, err)
+	result := f.FilterSamples(containers)
+	require.Len(t, result, 2)
+	assert.Equal(t, whole, result[0], "the containers with all of their samples are kept")
+	require.Len(t, result[1].GetSamples(), 1)
+	assert.Equal(t, reqs, result[1].GetSamples()[0].Metric)
+
+	f, err = ParseFilter("include=http_req_duration{status:200};dropTags=url")
+	require.NoError(t, err)
+	result = f.FilterSamples(containers)
+	require.Len(t, result, 1)
+	samples := result[0].GetSamples()
+	require.Len(t, samples, 1)
+	assert.Equal(t, map[string]string{"status": "200"}, samples[0].Tags.Map())
+
+	// the samples of the original containers aren't changed
+	assert.Equal(t, "http://test", containers[0].GetSamples()[0].Tags.Map()["url"])
+}
@@ -1,7 +1,9 @@
 package output
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"sync"
 	"time"
 
@@ -103,3 +105,72 @@ func NewPeriodicFlusher(period time.Duration, flushCallback func()) (*PeriodicFl
 
 	return pf, nil
 }
+
+// ReportFile is a helper for the outputs that write a report to a file when
+// they are stopped, which includes the final results of the thresholds. It
+// implements the SetThresholds method of WithThresholds, so it can be
+// embedded by such outputs. The thresholds are evaluated by the metrics engine
+// before the outputs are stopped, so their final results are available when
+// the report is written.
+type ReportFile struct {
+	// Filename is the path of the report file.
+	Filename string
+	// Thresholds are the thresholds received by SetThresholds, or nil if
+	// there aren't any or they are disabled.
+	Thresholds map[string]metrics.Thresholds
+
+	params Params
+	out    io.WriteCloser
+}
+
+// NewReportFile returns a new ReportFile with the path given as the argument
+// of the output, or with the given default one.
+func NewReportFile(params Params, defaultFilename string) *ReportFile {
+	filename := params.ConfigArgument
+	if filename == "" {
+		filename = defaultFilename
+	}
+	return &ReportFile{Filename: filename, params: params}
+}
+
+// SetThresholds receives the thresholds before the output is Start()-ed.
+func (rf *ReportFile) SetThresholds(thresholds map[string]metrics.Thresholds) {
+	if len(thresholds) == 0 || rf.params.RuntimeOptions.NoThresholds.Bool {
+		return
+	}
+	rf.Thresholds = make(map[string]metrics.Thresholds, len(thresholds))
+	for name, t := range thresholds {
+		rf.Thresholds[name] = t
+	}
+}
+
+// Create creates the report file, which is done when the output is started,
+// so an invalid path is reported before the test run.
+func (rf *ReportFile) Create() error {
+	f, err := rf.params.FS.Create(rf.Filename)
+	if err != nil {
+		return err
+	}
+	rf.out = f
+	return nil
+}
+
+// Write writes the report with the given function to the file, and closes it.
+func (rf *ReportFile) Write(write func(w io.Writer) error) error {
+	w := bufio.NewWriter(rf.out)
+	err := write(w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		_ = rf.out.Close()
+		return err
+	}
+	return rf.out.Close()
+}
+
+// Close closes the report file without writing the report, e.g. when the
+// output fails to start.
+func (rf *ReportFile) Close() error {
+	return rf.out.Close()
+}
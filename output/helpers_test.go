@@ -1,6 +1,8 @@
 package output
 
 import (
+	"errors"
+	"io"
 	"math/rand"
 	"sync"
 	"testing"
@@ -8,7 +10,10 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
 
+	"github.com/ChipArtem/k6/lib"
+	"github.com/ChipArtem/k6/lib/fsext"
 	"github.com/ChipArtem/k6/metrics"
 )
 
@@ -175,3 +180,35 @@ func TestPeriodicFlusherConcurrency(t *testing.T) {
 	stopWG.Wait()
 	assert.True(t, count >= 101) // due to the short intervals, we might not get exactly 101
 }
+
+func TestReportFile(t *testing.T) {
+	t.Parallel()
+
+	fs := fsext.NewMemMapFs()
+	thresholds := map[string]metrics.Thresholds{"iterations": metrics.NewThresholds([]string{"count>1"})}
+
+	rf := NewReportFile(Params{FS: fs}, "report.txt")
+	assert.Equal(t, "report.txt", rf.Filename)
+	rf.SetThresholds(thresholds)
+	assert.Equal(t, thresholds, rf.Thresholds)
+	require.NoError(t, rf.Create())
+	require.NoError(t, rf.Write(func(w io.Writer) error {
+		_, err := io.WriteString(w, "report")
+		return err
+	}))
+	data, err := fsext.ReadFile(fs, "report.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "report", string(data))
+
+	rf = NewReportFile(Params{
+		FS:             fs,
+		ConfigArgument: "other.txt",
+		RuntimeOptions: lib.RuntimeOptions{NoThresholds: null.BoolFrom(true)},
+	}, "report.txt")
+	assert.Equal(t, "other.txt", rf.Filename)
+	rf.SetThresholds(thresholds)
+	assert.Nil(t, rf.Thresholds, "the thresholds are disabled")
+	require.NoError(t, rf.Create())
+	errWrite := errors.New("write error")
+	assert.ErrorIs(t, rf.Write(func(io.Writer) error { return errWrite }), errWrite)
+}
@@ -0,0 +1,134 @@
+package html
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	chartWidth   = 760
+	chartHeight  = 220
+	chartLeft    = 60
+	chartRight   = 10
+	chartTop     = 10
+	chartBottom  = 30
+	chartYLabels = 4
+)
+
+// seriesColors are the colors of the lines of each chart, in order.
+var seriesColors = []string{"#7d64ff", "#ff9830", "#3cb44b", "#e6194b"} //nolint:gochecknoglobals
+
+type chartSeries struct {
+	Name   string
+	Values []float64 // NaN values are gaps in the line
+}
+
+// chart is a line chart of one or more series, with one value per period of
+// time since the start of the test.
+type chart struct {
+	Title  string
+	Unit   string
+	Series []chartSeries
+
+	period time.Duration
+}
+
+// Color returns the color of the series with the provided index.
+func (c chart) Color(i int) string {
+	return seriesColors[i%len(seriesColors)]
+}
+
+// SVG returns the chart as an inline SVG image.
+func (c chart) SVG() template.HTML {
+	n := 0
+	maxValue := 0.0
+	for _, s := range c.Series {
+		if len(s.Values) > n {
+			n = len(s.Values)
+		}
+		for _, v := range s.Values {
+			if !math.IsNaN(v) && v > maxValue {
+				maxValue = v
+			}
+		}
+	}
+	maxValue = niceCeil(maxValue)
+
+	plotWidth := float64(chartWidth - chartLeft - chartRight)
+	plotHeight := float64(chartHeight - chartTop - chartBottom)
+	x := func(i int) float64 {
+		if n <= 1 {
+			return chartLeft + plotWidth/2
+		}
+		return chartLeft + plotWidth*float64(i)/float64(n-1)
+	}
+	y := func(v float64) float64 {
+		return chartTop + plotHeight*(1-v/maxValue)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg viewBox="0 0 %d %d" role="img" aria-label="%s">`,
+		chartWidth, chartHeight, html.EscapeString(c.Title))
+
+	for i := 0; i <= chartYLabels; i++ {
+		v := maxValue * float64(i) / chartYLabels
+		fmt.Fprintf(&b, `<line class="grid" x1="%d" x2="%d" y1="%.1f" y2="%.1f"/>`,
+			chartLeft, chartWidth-chartRight, y(v), y(v))
+		fmt.Fprintf(&b, `<text class="label" x="%d" y="%.1f" text-anchor="end">%s</text>`,
+			chartLeft-6, y(v)+4, formatNumber(v))
+	}
+	end := time.Duration(n) * c.period
+	fmt.Fprintf(&b, `<text class="label" x="%d" y="%d">0s</text>`, chartLeft, chartHeight-8)
+	fmt.Fprintf(&b, `<text class="label" x="%d" y="%d" text-anchor="end">%s</text>`,
+		chartWidth-chartRight, chartHeight-8, end)
+
+	type point struct{ x, y float64 }
+	for i, s := range c.Series {
+		var line []point
+		flush := func() {
+			switch len(line) {
+			case 0:
+			case 1:
+				fmt.Fprintf(&b, `<circle cx="%.1f" cy="%.1f" r="2" fill="%s"/>`, line[0].x, line[0].y, c.Color(i))
+			default:
+				coords := make([]string, len(line))
+				for j, p := range line {
+					coords[j] = fmt.Sprintf("%.1f,%.1f", p.x, p.y)
+				}
+				fmt.Fprintf(&b, `<polyline fill="none" stroke="%s" stroke-width="1.5" points="%s"/>`,
+					c.Color(i), strings.Join(coords, " "))
+			}
+			line = line[:0]
+		}
+		for j, v := range s.Values {
+			if math.IsNaN(v) {
+				flush()
+				continue
+			}
+			line = append(line, point{x(j), y(v)})
+		}
+		flush()
+	}
+
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String()) //nolint:gosec // all of the dynamic values are numbers or escaped
+}
+
+// niceCeil rounds the value up to 1, 2 or 5 times a power of ten, so the
+// labels of the y axis are round numbers.
+func niceCeil(v float64) float64 {
+	if v <= 0 {
+		return 1
+	}
+	magnitude := math.Pow(10, math.Floor(math.Log10(v)))
+	for _, m := range []float64{1, 2, 5, 10} {
+		if v <= m*magnitude {
+			return m * magnitude
+		}
+	}
+	return 10 * magnitude
+}
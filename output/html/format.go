@@ -0,0 +1,160 @@
+package html
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ChipArtem/k6/metrics"
+)
+
+// reportData is what the HTML template renders.
+type reportData struct {
+	Title      string
+	Generated  string
+	Duration   time.Duration
+	Charts     []chart
+	Thresholds []thresholdResult
+	Checks     []checkResult
+	Metrics    []metricResult
+}
+
+type thresholdResult struct {
+	Metric string
+	Source string
+	Value  string
+	Passed bool
+}
+
+type checkResult struct {
+	Group  string
+	Name   string
+	Passes int64
+	Fails  int64
+}
+
+type metricResult struct {
+	Name   string
+	Type   string
+	Values []string
+}
+
+// data returns the data of the report at the end of the test run. The
+// thresholds are nil if they weren't evaluated.
+func (r *report) data(
+	title string, end time.Time, thresholds map[string]metrics.Thresholds,
+) reportData {
+	duration := end.Sub(r.start)
+	result := reportData{
+		Title:     path.Base(title),
+		Generated: end.Format(time.RFC1123),
+		Duration:  duration.Round(time.Millisecond),
+		Charts:    r.charts(end),
+	}
+
+	names := make([]string, 0, len(thresholds))
+	for name := range thresholds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, t := range thresholds[name].Thresholds {
+			value := "-"
+			if t.LastValue.Valid {
+				value = formatNumber(t.LastValue.Float64)
+			}
+			result.Thresholds = append(result.Thresholds, thresholdResult{
+				Metric: name,
+				Source: t.Source,
+				Value:  value,
+				Passed: !t.LastFailed,
+			})
+		}
+	}
+
+	for _, key := range r.checkOrder {
+		c := r.checks[key]
+		result.Checks = append(result.Checks, checkResult{Group: c.group, Name: c.name, Passes: c.passes, Fails: c.fails})
+	}
+
+	for m, sink := range r.sinks {
+		result.Metrics = append(result.Metrics, metricResult{
+			Name:   m.Name,
+			Type:   m.Type.String(),
+			Values: formatSink(sink, m.Contains, duration),
+		})
+	}
+	sort.Slice(result.Metrics, func(i, j int) bool { return result.Metrics[i].Name < result.Metrics[j].Name })
+
+	return result
+}
+
+func formatSink(sink metrics.Sink, vt metrics.ValueType, duration time.Duration) []string {
+	switch s := sink.(type) {
+	case *metrics.CounterSink:
+		return []string{
+			"count=" + formatValue(s.Value, vt),
+			"rate=" + formatValue(s.Value/duration.Seconds(), vt) + "/s",
+		}
+	case *metrics.GaugeSink:
+		return []string{
+			"value=" + formatValue(s.Value, vt),
+			"min=" + formatValue(s.Min, vt),
+			"max=" + formatValue(s.Max, vt),
+		}
+	case *metrics.RateSink:
+		rate := 0.0
+		if s.Total > 0 {
+			rate = float64(s.Trues) / float64(s.Total)
+		}
+		return []string{fmt.Sprintf("%s%% (%d of %d)", formatNumber(rate*100), s.Trues, s.Total)}
+	case *metrics.TrendSink:
+		return []string{
+			"avg=" + formatValue(s.Avg(), vt),
+			"min=" + formatValue(s.Min(), vt),
+			"med=" + formatValue(s.P(0.5), vt),
+			"max=" + formatValue(s.Max(), vt),
+			"p(90)=" + formatValue(s.P(0.9), vt),
+			"p(95)=" + formatValue(s.P(0.95), vt),
+		}
+	case *metrics.HistogramSink:
+		return []string{
+			"count=" + strconv.FormatUint(s.Count, 10),
+			"avg=" + formatValue(s.Avg(), vt),
+			"min=" + formatValue(s.Min, vt),
+			"max=" + formatValue(s.Max, vt),
+		}
+	default:
+		return nil
+	}
+}
+
+// formatValue returns the value with its unit, time values are in
+// milliseconds and data values in bytes.
+func formatValue(v float64, vt metrics.ValueType) string {
+	switch vt {
+	case metrics.Time:
+		if math.Abs(v) < 1000 {
+			return formatNumber(v) + "ms"
+		}
+		return formatNumber(v/1000) + "s"
+	case metrics.Data:
+		units := []string{"B", "kB", "MB", "GB", "TB"}
+		i := 0
+		for math.Abs(v) >= 1000 && i < len(units)-1 {
+			v /= 1000
+			i++
+		}
+		return formatNumber(v) + " " + units[i]
+	default:
+		return formatNumber(v)
+	}
+}
+
+// formatNumber returns the number rounded to two decimal places.
+func formatNumber(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
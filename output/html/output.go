@@ -0,0 +1,111 @@
+// Package html implements an output that writes a self-contained HTML report
+// with charts of the test run, and the results of its thresholds and checks.
+package html
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/ChipArtem/k6/output"
+)
+
+const (
+	flushPeriod     = 200 * time.Millisecond
+	defaultFilename = "report.html"
+)
+
+//go:embed report.html
+var reportTemplateSource string
+
+var reportTemplate = template.Must(template.New("report").Parse(reportTemplateSource)) //nolint:gochecknoglobals
+
+// Output aggregates the metrics during the test run and writes the report
+// to a file when it's stopped.
+type Output struct {
+	output.SampleBuffer
+	*output.ReportFile
+
+	params          output.Params
+	periodicFlusher *output.PeriodicFlusher
+
+	logger logrus.FieldLogger
+	report *report
+}
+
+var (
+	_ output.Output         = new(Output)
+	_ output.WithThresholds = new(Output)
+)
+
+// New returns a new HTML report output.
+func New(params output.Params) (output.Output, error) {
+	reportFile := output.NewReportFile(params, defaultFilename)
+	return &Output{
+		ReportFile: reportFile,
+		params:     params,
+		logger: params.Logger.WithFields(logrus.Fields{
+			"output":   "html",
+			"filename": reportFile.Filename,
+		}),
+	}, nil
+}
+
+// Description returns a human-readable description of the output.
+func (o *Output) Description() string {
+	return fmt.Sprintf("html (%s)", o.Filename)
+}
+
+// Start creates the report file and starts the goroutine for metric
+// aggregation.
+func (o *Output) Start() error {
+	o.logger.Debug("Starting...")
+
+	if err := o.Create(); err != nil {
+		return err
+	}
+	o.report = newReport(time.Now())
+
+	pf, err := output.NewPeriodicFlusher(flushPeriod, o.flushMetrics)
+	if err != nil {
+		_ = o.Close()
+		return err
+	}
+	o.logger.Debug("Started!")
+	o.periodicFlusher = pf
+
+	return nil
+}
+
+// Stop aggregates any remaining metrics and writes the report.
+func (o *Output) Stop() error {
+	o.logger.Debug("Stopping...")
+	defer o.logger.Debug("Stopped!")
+	o.periodicFlusher.Stop()
+
+	title := "script"
+	if u := o.params.ScriptPath; u != nil && u.Path != "" {
+		title = u.Path
+	}
+	data := o.report.data(title, time.Now(), o.Thresholds)
+
+	err := o.Write(func(w io.Writer) error {
+		return reportTemplate.Execute(w, data)
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't write the HTML report: %w", err)
+	}
+	return nil
+}
+
+func (o *Output) flushMetrics() {
+	for _, sc := range o.GetBufferedSamples() {
+		for _, sample := range sc.GetSamples() {
+			o.report.add(sample)
+		}
+	}
+}
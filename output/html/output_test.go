@@ -0,0 +1,96 @@
+package html
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/ChipArtem/k6/lib/fsext"
+	"github.com/ChipArtem/k6/lib/testutils"
+	"github.com/ChipArtem/k6/metrics"
+	"github.com/ChipArtem/k6/output"
+)
+
+func TestOutput(t *testing.T) {
+	t.Parallel()
+
+	fs := fsext.NewMemMapFs()
+	o, err := New(output.Params{
+		Logger:         testutils.NewLogger(t),
+		FS:             fs,
+		ConfigArgument: "/report.html",
+		ScriptPath:     &url.URL{Scheme: "file", Path: "/tests/<script>.js"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "html (/report.html)", o.Description())
+
+	thresholds := metrics.NewThresholds([]string{"p(95)<100", "avg<1"})
+	thresholds.Thresholds[0].LastValue = null.FloatFrom(42)
+	thresholds.Thresholds[1].LastFailed = true
+	o.(output.WithThresholds).SetThresholds(map[string]metrics.Thresholds{"http_req_duration": thresholds})
+
+	require.NoError(t, o.Start())
+
+	registry := metrics.NewRegistry()
+	builtin := metrics.RegisterBuiltinMetrics(registry)
+	now := time.Now()
+	o.AddMetricSamples([]metrics.SampleContainer{metrics.Samples{
+		{
+			TimeSeries: metrics.TimeSeries{Metric: builtin.HTTPReqDuration, Tags: registry.RootTagSet()},
+			Time:       now,
+			Value:      42,
+		},
+		{
+			TimeSeries: metrics.TimeSeries{
+				Metric: builtin.Checks,
+				Tags:   registry.RootTagSet().With("check", "body contains <b>"),
+			},
+			Time:  now,
+			Value: 1,
+		},
+	}})
+	require.NoError(t, o.Stop())
+
+	report, err := fsext.ReadFile(fs, "/report.html")
+	require.NoError(t, err)
+	content := string(report)
+
+	assert.True(t, strings.HasPrefix(content, "<!DOCTYPE html>"))
+	assert.Contains(t, content, "<title>k6 report - &lt;script&gt;.js</title>")
+	assert.Contains(t, content, "<h3>Request duration (ms)</h3>")
+	assert.Contains(t, content, `<i style="background: #7d64ff"></i>p(50)`)
+	assert.Contains(t, content, "<td><code>p(95)&lt;100</code></td>\n      <td class=\"num\">42</td>\n"+
+		`      <td><span class="pass">passed</span></td>`)
+	assert.Contains(t, content, "<td><code>avg&lt;1</code></td>\n      <td class=\"num\">-</td>\n"+
+		`      <td><span class="fail">failed</span></td>`)
+	assert.Contains(t, content, "body contains &lt;b&gt;")
+	assert.Contains(t, content, "<span>avg=42ms</span>")
+	assert.NotContains(t, content, "ZgotmplZ")
+}
+
+func TestOutputNoThresholds(t *testing.T) {
+	t.Parallel()
+
+	fs := fsext.NewMemMapFs()
+	params := output.Params{Logger: testutils.NewLogger(t), FS: fs}
+	params.RuntimeOptions.NoThresholds = null.BoolFrom(true)
+	o, err := New(params)
+	require.NoError(t, err)
+	assert.Equal(t, "html (report.html)", o.Description())
+
+	o.(output.WithThresholds).SetThresholds(map[string]metrics.Thresholds{
+		"http_req_duration": metrics.NewThresholds([]string{"p(95)<100"}),
+	})
+	require.NoError(t, o.Start())
+	require.NoError(t, o.Stop())
+
+	report, err := fsext.ReadFile(fs, "report.html")
+	require.NoError(t, err)
+	assert.NotContains(t, string(report), "<h2>Thresholds</h2>")
+	assert.NotContains(t, string(report), "<h2>Checks</h2>")
+}
@@ -0,0 +1,256 @@
+package html
+
+import (
+	"math"
+	"time"
+
+	"github.com/ChipArtem/k6/metrics"
+)
+
+const (
+	// initialPeriod is the length of the time intervals at the start of the
+	// test, it's doubled every time there would be more than maxIntervals.
+	initialPeriod = time.Second
+	maxIntervals  = 600
+
+	// trendRelativeError is the relative error of the percentiles of the
+	// Trend metrics, which are kept in histograms to bound the memory usage.
+	trendRelativeError = 0.01
+)
+
+// interval holds the aggregated values of the charted metrics for a single
+// period of time.
+type interval struct {
+	vus            float64
+	hasVUs         bool
+	requests       float64
+	iterations     float64
+	failedRequests float64
+	failedChecks   float64
+	duration       *metrics.TrendSink
+}
+
+func newInterval() *interval {
+	return &interval{duration: metrics.NewHistogramTrendSink(trendRelativeError)}
+}
+
+// merge adds the values of the other, consecutive, interval to this one.
+func (i *interval) merge(other *interval) {
+	if other.hasVUs && (!i.hasVUs || other.vus > i.vus) {
+		i.vus, i.hasVUs = other.vus, true
+	}
+	i.requests += other.requests
+	i.iterations += other.iterations
+	i.failedRequests += other.failedRequests
+	i.failedChecks += other.failedChecks
+	_ = i.duration.Merge(other.duration) // both are histogram sinks, so it can't fail
+}
+
+type check struct {
+	group  string
+	name   string
+	passes int64
+	fails  int64
+}
+
+// report aggregates the samples into time intervals for the charts, and into
+// sinks and checks for the end-of-test tables.
+type report struct {
+	start     time.Time
+	period    time.Duration
+	intervals []*interval
+
+	sinks  map[*metrics.Metric]metrics.Sink
+	checks map[string]*check
+	// checkOrder has the keys of the checks, in the order they were first seen
+	checkOrder []string
+}
+
+func newReport(start time.Time) *report {
+	return &report{
+		start:  start,
+		period: initialPeriod,
+		sinks:  make(map[*metrics.Metric]metrics.Sink),
+		checks: make(map[string]*check),
+	}
+}
+
+func (r *report) add(sample metrics.Sample) {
+	sink, ok := r.sinks[sample.Metric]
+	if !ok {
+		sink = newSink(sample.Metric)
+		r.sinks[sample.Metric] = sink
+	}
+	sink.Add(sample)
+
+	if sample.Metric.Name == metrics.ChecksName {
+		r.addCheck(sample)
+	}
+
+	i := r.intervalAt(sample.Time)
+	switch sample.Metric.Name {
+	case metrics.VUsName:
+		if !i.hasVUs || sample.Value > i.vus {
+			i.vus, i.hasVUs = sample.Value, true
+		}
+	case metrics.HTTPReqsName:
+		i.requests += sample.Value
+	case metrics.IterationsName:
+		i.iterations += sample.Value
+	case metrics.HTTPReqFailedName:
+		if sample.Value != 0 {
+			i.failedRequests++
+		}
+	case metrics.ChecksName:
+		if sample.Value == 0 {
+			i.failedChecks++
+		}
+	case metrics.HTTPReqDurationName:
+		i.duration.Add(sample)
+	}
+}
+
+func (r *report) addCheck(sample metrics.Sample) {
+	name, _ := sample.Tags.Get("check")
+	group, _ := sample.Tags.Get("group")
+	key := group + "\x00" + name
+
+	c, ok := r.checks[key]
+	if !ok {
+		c = &check{group: group, name: name}
+		r.checks[key] = c
+		r.checkOrder = append(r.checkOrder, key)
+	}
+	if sample.Value != 0 {
+		c.passes++
+	} else {
+		c.fails++
+	}
+}
+
+// intervalAt returns the interval that contains the provided time, merging
+// the existing intervals first if there would be too many of them.
+func (r *report) intervalAt(t time.Time) *interval {
+	index := int(t.Sub(r.start) / r.period)
+	if index < 0 {
+		index = 0
+	}
+	for index >= maxIntervals {
+		r.downsample()
+		index = int(t.Sub(r.start) / r.period)
+	}
+
+	for len(r.intervals) <= index {
+		r.intervals = append(r.intervals, newInterval())
+	}
+	return r.intervals[index]
+}
+
+// downsample doubles the period, merging each pair of consecutive intervals.
+func (r *report) downsample() {
+	merged := make([]*interval, 0, (len(r.intervals)+1)/2)
+	for i := 0; i < len(r.intervals); i += 2 {
+		in := r.intervals[i]
+		if i+1 < len(r.intervals) {
+			in.merge(r.intervals[i+1])
+		}
+		merged = append(merged, in)
+	}
+	r.intervals = merged
+	r.period *= 2
+}
+
+// charts returns the charts of the test run, the last interval is considered
+// to end at the provided time.
+func (r *report) charts(end time.Time) []chart {
+	n := len(r.intervals)
+	seconds := make([]float64, n)
+	for i := range seconds {
+		seconds[i] = r.period.Seconds()
+	}
+	if n > 0 {
+		last := end.Sub(r.start) - time.Duration(n-1)*r.period
+		if last > 0 && last < r.period {
+			seconds[n-1] = last.Seconds()
+		}
+	}
+
+	vus := make([]float64, n)
+	requests := make([]float64, n)
+	iterations := make([]float64, n)
+	failedRequests := make([]float64, n)
+	failedChecks := make([]float64, n)
+	percentiles := []float64{0.5, 0.9, 0.95, 0.99}
+	durations := make([][]float64, len(percentiles))
+	for p := range durations {
+		durations[p] = make([]float64, n)
+	}
+
+	lastVUs := math.NaN()
+	for i, in := range r.intervals {
+		// the VUs are only emitted once per second, so the
+		// intervals without a value keep the previous one
+		if in.hasVUs {
+			lastVUs = in.vus
+		}
+		vus[i] = lastVUs
+		requests[i] = in.requests / seconds[i]
+		iterations[i] = in.iterations / seconds[i]
+		failedRequests[i] = in.failedRequests / seconds[i]
+		failedChecks[i] = in.failedChecks / seconds[i]
+		for p, pct := range percentiles {
+			durations[p][i] = math.NaN()
+			if !in.duration.IsEmpty() {
+				durations[p][i] = in.duration.P(pct)
+			}
+		}
+	}
+
+	return []chart{
+		{
+			Title:  "Virtual users",
+			period: r.period,
+			Series: []chartSeries{{Name: "VUs", Values: vus}},
+		},
+		{
+			Title:  "Request rate",
+			Unit:   "/s",
+			period: r.period,
+			Series: []chartSeries{
+				{Name: "requests", Values: requests},
+				{Name: "iterations", Values: iterations},
+			},
+		},
+		{
+			Title:  "Request duration",
+			Unit:   "ms",
+			period: r.period,
+			Series: []chartSeries{
+				{Name: "p(50)", Values: durations[0]},
+				{Name: "p(90)", Values: durations[1]},
+				{Name: "p(95)", Values: durations[2]},
+				{Name: "p(99)", Values: durations[3]},
+			},
+		},
+		{
+			Title:  "Errors",
+			Unit:   "/s",
+			period: r.period,
+			Series: []chartSeries{
+				{Name: "failed requests", Values: failedRequests},
+				{Name: "failed checks", Values: failedChecks},
+			},
+		},
+	}
+}
+
+func newSink(m *metrics.Metric) metrics.Sink {
+	switch m.Type {
+	case metrics.Trend:
+		return metrics.NewHistogramTrendSink(trendRelativeError)
+	case metrics.Histogram:
+		return metrics.NewHistogramSink(m.Buckets)
+	default:
+		return metrics.NewSink(m.Type)
+	}
+}
@@ -0,0 +1,112 @@
+package html
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ChipArtem/k6/metrics"
+)
+
+func TestReport(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	builtin := metrics.RegisterBuiltinMetrics(registry)
+	tags := registry.RootTagSet()
+	start := time.Now()
+	sample := func(m *metrics.Metric, offset time.Duration, v float64, tags *metrics.TagSet) metrics.Sample {
+		return metrics.Sample{TimeSeries: metrics.TimeSeries{Metric: m, Tags: tags}, Time: start.Add(offset), Value: v}
+	}
+
+	r := newReport(start)
+	checkTags := tags.With("group", "::login").With("check", "status is 200")
+	for _, s := range []metrics.Sample{
+		sample(builtin.VUs, time.Second, 2, tags),
+		sample(builtin.HTTPReqs, 100*time.Millisecond, 1, tags),
+		sample(builtin.HTTPReqs, 200*time.Millisecond, 1, tags),
+		sample(builtin.HTTPReqDuration, 100*time.Millisecond, 10, tags),
+		sample(builtin.HTTPReqDuration, 200*time.Millisecond, 30, tags),
+		sample(builtin.HTTPReqDuration, 300*time.Millisecond, 20, tags),
+		sample(builtin.HTTPReqFailed, 200*time.Millisecond, 1, tags),
+		sample(builtin.Checks, 1100*time.Millisecond, 1, checkTags),
+		sample(builtin.Checks, 2100*time.Millisecond, 0, checkTags),
+		sample(builtin.HTTPReqs, 2200*time.Millisecond, 4, tags),
+	} {
+		r.add(s)
+	}
+
+	require.Len(t, r.intervals, 3)
+	charts := r.charts(start.Add(2500 * time.Millisecond))
+	require.Len(t, charts, 4)
+
+	vus := charts[0].Series[0].Values
+	assert.True(t, math.IsNaN(vus[0]))
+	assert.Equal(t, []float64{2, 2}, vus[1:], "the intervals without VUs keep the last value")
+
+	// the last interval is only half a second long
+	assert.Equal(t, []float64{2, 0, 8}, charts[1].Series[0].Values)
+
+	p50 := charts[2].Series[0].Values
+	assert.InDelta(t, 20, p50[0], 0.5)
+	assert.True(t, math.IsNaN(p50[1]))
+
+	assert.Equal(t, []float64{1, 0, 0}, charts[3].Series[0].Values)
+	assert.Equal(t, []float64{0, 0, 2}, charts[3].Series[1].Values)
+
+	data := r.data("file:///tmp/script.js", start.Add(2500*time.Millisecond), nil)
+	assert.Equal(t, "script.js", data.Title)
+	assert.Equal(t, []checkResult{{Group: "::login", Name: "status is 200", Passes: 1, Fails: 1}}, data.Checks)
+	assert.Nil(t, data.Thresholds)
+
+	names := make([]string, 0, len(data.Metrics))
+	for _, m := range data.Metrics {
+		names = append(names, m.Name)
+	}
+	assert.Equal(t, []string{"checks", "http_req_duration", "http_req_failed", "http_reqs", "vus"}, names)
+	assert.Equal(t, []string{"count=6", "rate=2.4/s"}, data.Metrics[3].Values)
+	assert.Equal(t, []string{"50% (1 of 2)"}, data.Metrics[0].Values)
+}
+
+func TestReportDownsample(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	builtin := metrics.RegisterBuiltinMetrics(registry)
+	start := time.Now()
+
+	r := newReport(start)
+	for i := 0; i < 2*maxIntervals; i++ {
+		r.add(metrics.Sample{
+			TimeSeries: metrics.TimeSeries{Metric: builtin.HTTPReqs, Tags: registry.RootTagSet()},
+			Time:       start.Add(time.Duration(i)*initialPeriod + initialPeriod/2),
+			Value:      1,
+		})
+	}
+
+	assert.Equal(t, 2*initialPeriod, r.period)
+	require.Len(t, r.intervals, maxIntervals)
+	for _, in := range r.intervals {
+		assert.Equal(t, 2.0, in.requests)
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, "12.35ms", formatValue(12.345, metrics.Time))
+	assert.Equal(t, "1.5s", formatValue(1500, metrics.Time))
+	assert.Equal(t, "999 B", formatValue(999, metrics.Data))
+	assert.Equal(t, "1.23 MB", formatValue(1234567, metrics.Data))
+	assert.Equal(t, "0.33", formatValue(1.0/3, metrics.Default))
+}
+
+func TestNiceCeil(t *testing.T) {
+	t.Parallel()
+	testCases := map[float64]float64{0: 1, 0.3: 0.5, 1: 1, 1.1: 2, 3: 5, 7: 10, 120: 200, 4999: 5000}
+	for v, expected := range testCases {
+		assert.InDelta(t, expected, niceCeil(v), 1e-9, "%v", v)
+	}
+}
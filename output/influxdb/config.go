@@ -12,6 +12,8 @@ import (
 	"gopkg.in/guregu/null.v3"
 
 	"github.com/ChipArtem/k6/lib/types"
+	"github.com/ChipArtem/k6/output/rotation"
+	"github.com/ChipArtem/k6/output/spool"
 )
 
 type Config struct {
@@ -31,8 +33,22 @@ type Config struct {
 	Retention    null.String `json:"retention,omitempty" envconfig:"K6_INFLUXDB_RETENTION"`
 	Consistency  null.String `json:"consistency,omitempty" envconfig:"K6_INFLUXDB_CONSISTENCY"`
 	TagsAsFields []string    `json:"tagsAsFields,omitempty" envconfig:"K6_INFLUXDB_TAGS_AS_FIELDS"`
+
+	// InfluxDB v2, the bucket defaults to the DB.
+	APIVersion   null.Int    `json:"apiVersion,omitempty" envconfig:"K6_INFLUXDB_API_VERSION"`
+	Organization null.String `json:"organization,omitempty" envconfig:"K6_INFLUXDB_ORGANIZATION"`
+	Bucket       null.String `json:"bucket,omitempty" envconfig:"K6_INFLUXDB_BUCKET"`
+	Token        null.String `json:"token,omitempty" envconfig:"K6_INFLUXDB_TOKEN"`
+
+	// Spooling of the batches that couldn't be written.
+	SpoolDir     null.String `json:"spoolDir,omitempty" envconfig:"K6_INFLUXDB_SPOOL_DIR"`
+	SpoolMaxSize null.String `json:"spoolMaxSize,omitempty" envconfig:"K6_INFLUXDB_SPOOL_MAX_SIZE"`
 }
 
+// v2Scheme is the suffix of the URL schemes that select the v2 API,
+// e.g. http+v2://localhost:8086/bucket.
+const v2Scheme = "+v2"
+
 // NewConfig creates a new InfluxDB output config with some default values.
 func NewConfig() Config {
 	c := Config{
@@ -50,6 +66,7 @@ func NewConfig() Config {
 		// and the user should adjust the executed script
 		// or the configuration based on the environment and rate expected.
 		ConcurrentWrites: null.NewInt(4, false),
+		APIVersion:       null.NewInt(1, false),
 	}
 	return c
 }
@@ -95,9 +112,58 @@ func (c Config) Apply(cfg Config) Config {
 	if cfg.ConcurrentWrites.Valid {
 		c.ConcurrentWrites = cfg.ConcurrentWrites
 	}
+	if cfg.APIVersion.Valid {
+		c.APIVersion = cfg.APIVersion
+	}
+	if cfg.Organization.Valid {
+		c.Organization = cfg.Organization
+	}
+	if cfg.Bucket.Valid {
+		c.Bucket = cfg.Bucket
+	}
+	if cfg.Token.Valid {
+		c.Token = cfg.Token
+	}
+	if cfg.SpoolDir.Valid {
+		c.SpoolDir = cfg.SpoolDir
+	}
+	if cfg.SpoolMaxSize.Valid {
+		c.SpoolMaxSize = cfg.SpoolMaxSize
+	}
 	return c
 }
 
+// IsV2 returns whether the points are written with the v2 API, which is
+// supported by InfluxDB 2.x and 3.x.
+func (c Config) IsV2() bool {
+	return c.APIVersion.Int64 == 2
+}
+
+// Spool returns the config of the spooling of the batches that couldn't be
+// written, which is disabled if there is no spool directory.
+func (c Config) Spool() (spool.Config, error) {
+	conf := spool.Config{Dir: c.SpoolDir.String}
+	if c.SpoolMaxSize.String != "" {
+		size, err := rotation.ParseSize(c.SpoolMaxSize.String)
+		if err != nil {
+			return conf, err
+		}
+		conf.MaxSize = size
+	}
+	return conf, conf.Validate()
+}
+
+// bucket returns the bucket the points are written to with the v2 API.
+func (c Config) bucket() string {
+	if c.Bucket.String != "" {
+		return c.Bucket.String
+	}
+	if c.DB.String != "" {
+		return c.DB.String
+	}
+	return "k6"
+}
+
 // ParseJSON parses the supplied JSON into a Config.
 func ParseJSON(data json.RawMessage) (Config, error) {
 	conf := Config{}
@@ -112,6 +178,10 @@ func ParseURL(text string) (Config, error) {
 	if err != nil {
 		return c, err
 	}
+	if strings.HasSuffix(u.Scheme, v2Scheme) {
+		u.Scheme = strings.TrimSuffix(u.Scheme, v2Scheme)
+		c.APIVersion = null.IntFrom(2)
+	}
 	if u.Host != "" {
 		c.Addr = null.StringFrom(u.Scheme + "://" + u.Host)
 	}
@@ -163,6 +233,14 @@ func ParseURL(text string) (Config, error) {
 			c.ConcurrentWrites = null.IntFrom(int64(writes))
 		case "tagsAsFields":
 			c.TagsAsFields = vs
+		case "org":
+			c.Organization = null.StringFrom(vs[0])
+		case "bucket":
+			c.Bucket = null.StringFrom(vs[0])
+		case "spoolDir":
+			c.SpoolDir = null.StringFrom(vs[0])
+		case "spoolMaxSize":
+			c.SpoolMaxSize = null.StringFrom(vs[0])
 		default:
 			return c, fmt.Errorf("unknown query parameter: %s", k)
 		}
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"gopkg.in/guregu/null.v3"
 )
 
@@ -74,3 +75,32 @@ func TestGetConsolidatedConfigHTTPProxy(t *testing.T) {
 		assert.Error(t, err)
 	})
 }
+
+func TestParseURLV2(t *testing.T) {
+	t.Parallel()
+
+	config, err := ParseURL("https+v2://influx.example.com:8086/mybucket?org=myorg")
+	require.NoError(t, err)
+	assert.Equal(t, Config{
+		Addr:         null.StringFrom("https://influx.example.com:8086"),
+		DB:           null.StringFrom("mybucket"),
+		APIVersion:   null.IntFrom(2),
+		Organization: null.StringFrom("myorg"),
+	}, config)
+	assert.True(t, config.IsV2())
+	assert.Equal(t, "mybucket", config.bucket())
+
+	config, err = GetConsolidatedConfig(nil, map[string]string{
+		"K6_INFLUXDB_API_VERSION": "2",
+		"K6_INFLUXDB_BUCKET":      "fromenv",
+		"K6_INFLUXDB_TOKEN":       "secret",
+	}, "http://localhost:8086/db")
+	require.NoError(t, err)
+	assert.True(t, config.IsV2())
+	assert.Equal(t, "fromenv", config.bucket())
+	assert.Equal(t, "secret", config.Token.String)
+
+	config, err = GetConsolidatedConfig(nil, nil, "")
+	require.NoError(t, err)
+	assert.False(t, config.IsV2())
+}
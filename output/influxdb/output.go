@@ -3,16 +3,19 @@ package influxdb
 import (
 	"errors"
 	"fmt"
+	"net/http"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/influxdata/influxdb1-client/models"
 	client "github.com/influxdata/influxdb1-client/v2"
 	"github.com/sirupsen/logrus"
 
 	"github.com/ChipArtem/k6/metrics"
 	"github.com/ChipArtem/k6/output"
+	"github.com/ChipArtem/k6/output/spool"
 )
 
 // FieldKind defines Enum for tag-to-field type conversion
@@ -43,6 +46,8 @@ type Output struct {
 	periodicFlusher *output.PeriodicFlusher
 	semaphoreCh     chan struct{}
 	wg              sync.WaitGroup
+	spoolConf       spool.Config
+	spool           *spool.Spool
 }
 
 // New returns new influxdb output
@@ -63,11 +68,18 @@ func newOutput(params output.Params) (*Output, error) {
 	if conf.ConcurrentWrites.Int64 <= 0 {
 		return nil, errors.New("influxdb's ConcurrentWrites must be a positive number")
 	}
+	spoolConf, err := conf.Spool()
+	if err != nil {
+		return nil, fmt.Errorf("invalid spool config: %w", err)
+	}
+	if spoolConf.Enabled() && params.Registry == nil {
+		return nil, errors.New("the spool requires the metrics registry of the test")
+	}
 	fldKinds, err := MakeFieldKinds(conf)
 	return &Output{
 		params: params,
 		logger: params.Logger.WithFields(logrus.Fields{
-			"output": "InfluxDBv1",
+			"output": "InfluxDBv" + strconv.FormatInt(conf.APIVersion.Int64, 10),
 		}),
 		Client:      cl,
 		Config:      conf,
@@ -75,6 +87,7 @@ func newOutput(params output.Params) (*Output, error) {
 		fieldKinds:  fldKinds,
 		semaphoreCh: make(chan struct{}, conf.ConcurrentWrites.Int64),
 		wg:          sync.WaitGroup{},
+		spoolConf:   spoolConf,
 	}, err
 }
 
@@ -131,6 +144,11 @@ func (o *Output) batchFromSamples(containers []metrics.SampleContainer) (client.
 				cache[sample.Tags] = cacheItem{tags, values}
 			}
 			values["value"] = sample.Value
+			if sample.Metric.Type == metrics.Histogram {
+				// Tag every histogram point with the upper bound of its bucket,
+				// so it can be grouped the same way as a native histogram series.
+				tags = withBucketTag(tags, sample.Metric.Buckets, sample.Value)
+			}
 			var p *client.Point
 			p, err = client.NewPoint(
 				sample.Metric.Name,
@@ -150,6 +168,9 @@ func (o *Output) batchFromSamples(containers []metrics.SampleContainer) (client.
 
 // Description returns a human-readable description of the output.
 func (o *Output) Description() string {
+	if o.Config.IsV2() {
+		return fmt.Sprintf("InfluxDBv2 (%s, bucket %s)", o.Config.Addr.String, o.Config.bucket())
+	}
 	return fmt.Sprintf("InfluxDBv1 (%s)", o.Config.Addr.String)
 }
 
@@ -159,9 +180,24 @@ func (o *Output) Start() error {
 	o.logger.Debug("Starting...")
 	// Try to create the database if it doesn't exist. Failure to do so is USUALLY harmless; it
 	// usually means we're either a non-admin user to an existing DB or connecting over UDP.
-	_, err := o.Client.Query(client.NewQuery("CREATE DATABASE "+o.BatchConf.Database, "", ""))
-	if err != nil {
-		o.logger.WithError(err).Debug("Couldn't create database; most likely harmless")
+	// The buckets of the v2 API have to be created beforehand.
+	if !o.Config.IsV2() {
+		_, err := o.Client.Query(client.NewQuery("CREATE DATABASE "+o.BatchConf.Database, "", ""))
+		if err != nil {
+			o.logger.WithError(err).Debug("Couldn't create database; most likely harmless")
+		}
+	}
+
+	if o.spoolConf.Enabled() {
+		sp, err := spool.New(o.params.FS, o.spoolConf, o.params.Registry)
+		if err != nil {
+			return err
+		}
+		if stats := sp.Stats(); stats.Records > 0 {
+			o.logger.WithField("dir", sp.Dir()).Infof("Found %d spooled batches (%d bytes) from a previous test run, "+
+				"they will be written once InfluxDB is reachable", stats.Records, stats.Size)
+		}
+		o.spool = sp
 	}
 
 	pf, err := output.NewPeriodicFlusher(o.Config.PushInterval.TimeDuration(), o.flushMetrics)
@@ -174,12 +210,24 @@ func (o *Output) Start() error {
 	return nil
 }
 
+// MetricSamples returns the samples of the spool's metrics, if the spooling is
+// enabled, which are sent to all of the outputs along with the test's samples.
+func (o *Output) MetricSamples(t time.Time) metrics.Samples {
+	if o.spool == nil {
+		return nil
+	}
+	return o.spool.Samples(t)
+}
+
 // Stop flushes any remaining metrics and stops the goroutine.
 func (o *Output) Stop() error {
 	o.logger.Debug("Stopping...")
 	defer o.logger.Debug("Stopped!")
 	o.periodicFlusher.Stop()
 	o.wg.Wait()
+	if o.spool != nil {
+		o.stopSpool()
+	}
 	return nil
 }
 
@@ -190,8 +238,18 @@ func (o *Output) flushMetrics() {
 	}
 
 	o.logger.Debug("Committing...")
+	select {
+	case o.semaphoreCh <- struct{}{}:
+	default:
+		if o.spool != nil {
+			// All of the writes are still in progress, so the batch is
+			// spooled instead of waiting for one of them.
+			o.spoolSamples(samples)
+			return
+		}
+		o.semaphoreCh <- struct{}{}
+	}
 	o.wg.Add(1)
-	o.semaphoreCh <- struct{}{}
 	go func() {
 		defer func() {
 			<-o.semaphoreCh
@@ -210,14 +268,21 @@ func (o *Output) flushMetrics() {
 		startTime := time.Now()
 		if err := o.Client.Write(batch); err != nil {
 			msg := "Couldn't write stats"
-			if strings.Contains(err.Error(), "unauthorized access") {
-				msg += ", InfluxDB v2.x isn't supported by this output, if you are using it you may consider to use the extension https://github.com/grafana/xk6-output-influxdb" //nolint:lll
+			if !o.Config.IsV2() && strings.Contains(err.Error(), "unauthorized access") {
+				msg += ", if you are using InfluxDB v2.x or v3.x, enable its API with K6_INFLUXDB_API_VERSION=2 or the http+v2:// scheme" //nolint:lll
+			}
+			if o.spool != nil && isRetryable(err) {
+				o.pushToSpool(batch)
+				msg += ", the batch was spooled"
 			}
 			o.logger.WithError(err).Error(msg)
 			return
 		}
 		t := time.Since(startTime)
 		o.logger.WithField("t", t).Debug("Batch written!")
+		if o.spool != nil {
+			o.replaySpool()
+		}
 
 		if t > o.Config.PushInterval.TimeDuration() {
 			o.logger.WithField("t", t).
@@ -225,3 +290,99 @@ func (o *Output) flushMetrics() {
 		}
 	}()
 }
+
+// isRetryable returns whether a failed write could succeed later, i.e. if
+// InfluxDB couldn't be reached, or it responded with a server error or 429.
+// Any other response rejects the batch, so it would never be written.
+func isRetryable(err error) bool {
+	var serr *statusError
+	if errors.As(err, &serr) {
+		return serr.code >= http.StatusInternalServerError || serr.code == http.StatusTooManyRequests
+	}
+	return true
+}
+
+// spoolSamples adds the samples to the spool, as a batch in the line protocol.
+func (o *Output) spoolSamples(samples []metrics.SampleContainer) {
+	batch, err := o.batchFromSamples(samples)
+	if err != nil {
+		o.logger.WithError(err).Error("Couldn't create batch from samples")
+		return
+	}
+	o.logger.WithField("points", len(batch.Points())).
+		Warn("All of the concurrent writes are in progress, the batch was spooled")
+	o.pushToSpool(batch)
+}
+
+func (o *Output) pushToSpool(batch client.BatchPoints) {
+	var b strings.Builder
+	for _, p := range batch.Points() {
+		b.WriteString(p.String())
+		b.WriteByte('\n')
+	}
+	if err := o.spool.Push([]byte(b.String())); err != nil {
+		o.logger.WithError(err).Error("Couldn't spool a batch, its points are lost")
+	}
+}
+
+// replaySpool writes the spooled batches, until one of them fails.
+func (o *Output) replaySpool() {
+	err := o.spool.Replay(func(data []byte) error {
+		points, err := models.ParsePointsWithPrecision(data, time.Now(), "n")
+		if err != nil {
+			o.logger.WithError(err).Error("Couldn't parse a spooled batch, its points are lost")
+			return spool.ErrRejected
+		}
+		batch, err := client.NewBatchPoints(o.BatchConf)
+		if err != nil {
+			return err
+		}
+		for _, p := range points {
+			batch.AddPoint(client.NewPointFrom(p))
+		}
+		if err := o.Client.Write(batch); err != nil {
+			if !isRetryable(err) {
+				o.logger.WithError(err).Error("A spooled batch was rejected, its points are lost")
+				return spool.ErrRejected
+			}
+			return err
+		}
+		o.logger.WithField("points", len(points)).Debug("Spooled batch written!")
+		return nil
+	})
+	if err != nil {
+		o.logger.WithError(err).Debug("Couldn't write the spooled batches, they will be retried")
+	}
+}
+
+// stopSpool tries to write the spooled batches one last time, and reports the
+// ones that couldn't be written or were lost.
+func (o *Output) stopSpool() {
+	if o.spool.Stats().Records > 0 {
+		o.replaySpool()
+	}
+	stats := o.spool.Stats()
+	logger := o.logger.WithField("dir", o.spool.Dir())
+	if stats.Spooled > 0 {
+		logger.Infof("%d batches were spooled and %d of them were written later", stats.Spooled, stats.Replayed)
+	}
+	if stats.Records > 0 {
+		logger.Warnf("%d batches (%d bytes) couldn't be written and are left in the spool, "+
+			"they will be written by the next test run with the same spool directory", stats.Records, stats.Size)
+	}
+	if stats.LostRecords > 0 {
+		logger.Errorf("%d batches (%d bytes) were lost, because the spool was full or couldn't be written, "+
+			"or they were rejected", stats.LostRecords, stats.LostSize)
+	}
+}
+
+// withBucketTag returns a copy of the given tags with the le tag set to the
+// upper bound of the histogram bucket that contains the value.
+func withBucketTag(tags map[string]string, buckets []float64, value float64) map[string]string {
+	result := make(map[string]string, len(tags)+1)
+	for k, v := range tags {
+		result[k] = v
+	}
+	result["le"] = metrics.HistogramBucket(buckets, value)
+	return result
+}
@@ -215,3 +215,27 @@ func TestExtractTagsToValues(t *testing.T) {
 	require.Equal(t, 3.14, values["floatField"])
 	require.Equal(t, int64(12345), values["intField"])
 }
+
+func TestBatchFromSamplesHistogramBucket(t *testing.T) {
+	t.Parallel()
+	o, err := newOutput(output.Params{
+		Logger: testutils.NewLogger(t),
+	})
+	require.NoError(t, err)
+
+	registry := metrics.NewRegistry()
+	histogram, err := registry.NewHistogram("my_histogram", []float64{100, 300})
+	require.NoError(t, err)
+	tags := registry.RootTagSet().With("tag1", "val1")
+
+	batch, err := o.batchFromSamples([]metrics.SampleContainer{metrics.Samples{
+		{TimeSeries: metrics.TimeSeries{Metric: histogram, Tags: tags}, Time: time.Now(), Value: 50},
+		{TimeSeries: metrics.TimeSeries{Metric: histogram, Tags: tags}, Time: time.Now(), Value: 1000},
+	}})
+	require.NoError(t, err)
+
+	points := batch.Points()
+	require.Len(t, points, 2)
+	assert.Equal(t, map[string]string{"tag1": "val1", "le": "100"}, points[0].Tags())
+	assert.Equal(t, map[string]string{"tag1": "val1", "le": "+Inf"}, points[1].Tags())
+}
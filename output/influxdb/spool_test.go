@@ -0,0 +1,216 @@
+package influxdb
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ChipArtem/k6/lib/fsext"
+	"github.com/ChipArtem/k6/lib/testutils"
+	"github.com/ChipArtem/k6/metrics"
+	"github.com/ChipArtem/k6/output"
+	"github.com/ChipArtem/k6/output/spool"
+)
+
+func TestOutputSpool(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu      sync.Mutex
+		lines   []string
+		failing atomic.Bool
+	)
+	failing.Store(true)
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/write" {
+			rw.WriteHeader(http.StatusOK)
+			return
+		}
+		if failing.Load() {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		mu.Lock()
+		lines = append(lines, strings.Split(strings.TrimSpace(string(body)), "\n")...)
+		mu.Unlock()
+		rw.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	fs := fsext.NewMemMapFs()
+	registry := metrics.NewRegistry()
+	o, err := newOutput(output.Params{
+		Logger:         testutils.NewLogger(t),
+		FS:             fs,
+		ConfigArgument: ts.URL + "?pushInterval=1h&spoolDir=/spool&spoolMaxSize=1MB",
+		Registry:       registry,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, spool.Config{Dir: "/spool", MaxSize: 1000 * 1000}, o.spoolConf)
+	require.NoError(t, o.Start())
+
+	metric, err := registry.NewMetric("test_gauge", metrics.Gauge)
+	require.NoError(t, err)
+	addSample := func(value float64) {
+		o.AddMetricSamples([]metrics.SampleContainer{metrics.Sample{
+			TimeSeries: metrics.TimeSeries{Metric: metric, Tags: registry.RootTagSet()},
+			Time:       time.Unix(1700000000, 0).Add(time.Duration(value) * time.Second),
+			Value:      value,
+		}})
+	}
+
+	addSample(1)
+	o.flushMetrics()
+	o.wg.Wait()
+	assert.Equal(t, 1, o.spool.Stats().Records)
+	assert.Empty(t, lines)
+
+	// the samples of the spool's metrics are emitted through the samples
+	// channel, rather than added to the output's own batches
+	spoolSamples := o.MetricSamples(time.Now())
+	require.Len(t, spoolSamples, 4)
+	for _, sample := range spoolSamples {
+		assert.Equal(t, sample.Metric, registry.Get(sample.Metric.Name))
+		if sample.Metric.Name == "output_spool_records" {
+			assert.Equal(t, 1.0, sample.Value)
+		}
+	}
+
+	failing.Store(false)
+	addSample(2)
+	o.flushMetrics()
+	o.wg.Wait()
+	assert.Equal(t, spool.Stats{Spooled: 1, Replayed: 1}, o.spool.Stats())
+	require.NoError(t, o.Stop())
+
+	var gauges []string
+	for _, line := range lines {
+		if strings.HasPrefix(line, "test_gauge ") {
+			gauges = append(gauges, line)
+		}
+	}
+	assert.Equal(t, []string{
+		"test_gauge value=2 1700000002000000000",
+		"test_gauge value=1 1700000001000000000",
+	}, gauges)
+	assert.NotContains(t, strings.Join(lines, "\n"), "output_spool_")
+}
+
+func TestOutputSpoolLeftover(t *testing.T) {
+	t.Parallel()
+
+	fs := fsext.NewMemMapFs()
+	registry := metrics.NewRegistry()
+	sp, err := spool.New(fs, spool.Config{Dir: "/spool"}, registry)
+	require.NoError(t, err)
+	require.NoError(t, sp.Push([]byte("test_gauge value=3 1700000003000000000\n")))
+
+	var written atomic.Int64
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/write" {
+			body, err := io.ReadAll(r.Body)
+			assert.NoError(t, err)
+			if strings.Contains(string(body), "test_gauge value=3 ") {
+				written.Add(1)
+			}
+		}
+		rw.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	o, err := newOutput(output.Params{
+		Logger:         testutils.NewLogger(t),
+		FS:             fs,
+		ConfigArgument: ts.URL + "?spoolDir=/spool",
+		Registry:       registry,
+	})
+	require.NoError(t, err)
+	require.NoError(t, o.Start())
+	require.NoError(t, o.Stop())
+
+	assert.Equal(t, int64(1), written.Load())
+	assert.Equal(t, spool.Stats{Replayed: 1}, o.spool.Stats())
+}
+
+func TestOutputSpoolRejected(t *testing.T) {
+	t.Parallel()
+
+	fs := fsext.NewMemMapFs()
+	registry := metrics.NewRegistry()
+	sp, err := spool.New(fs, spool.Config{Dir: "/spool"}, registry)
+	require.NoError(t, err)
+	require.NoError(t, sp.Push([]byte("test_gauge value=3 1700000003000000000\n")))
+	require.NoError(t, sp.Push([]byte("test_gauge value=4 1700000004000000000\n")))
+
+	var written atomic.Int64
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/write" {
+			body, err := io.ReadAll(r.Body)
+			assert.NoError(t, err)
+			if strings.Contains(string(body), "test_gauge value=3 ") {
+				rw.WriteHeader(http.StatusBadRequest)
+				_, _ = rw.Write([]byte(`{"error":"unable to parse"}`))
+				return
+			}
+			if strings.Contains(string(body), "test_gauge value=4 ") {
+				written.Add(1)
+			}
+		}
+		rw.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	o, err := newOutput(output.Params{
+		Logger:         testutils.NewLogger(t),
+		FS:             fs,
+		ConfigArgument: ts.URL + "?spoolDir=/spool",
+		Registry:       registry,
+	})
+	require.NoError(t, err)
+	require.NoError(t, o.Start())
+	require.NoError(t, o.Stop())
+
+	// the rejected batch is dropped, instead of blocking the next one
+	assert.Equal(t, int64(1), written.Load())
+	stats := o.spool.Stats()
+	assert.Equal(t, 0, stats.Records)
+	assert.Equal(t, 1, stats.Replayed)
+	assert.Equal(t, 1, stats.LostRecords)
+}
+
+func TestIsRetryable(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, isRetryable(errors.New("connection refused")))
+	assert.True(t, isRetryable(&statusError{code: http.StatusServiceUnavailable}))
+	assert.True(t, isRetryable(&statusError{code: http.StatusTooManyRequests}))
+	assert.False(t, isRetryable(&statusError{code: http.StatusBadRequest}))
+	assert.False(t, isRetryable(&statusError{code: http.StatusUnauthorized}))
+}
+
+func TestOutputSpoolInvalidConfig(t *testing.T) {
+	t.Parallel()
+
+	_, err := newOutput(output.Params{
+		Logger:         testutils.NewLogger(t),
+		ConfigArgument: "http://localhost:8086?spoolDir=/spool&spoolMaxSize=lots",
+	})
+	require.ErrorContains(t, err, "invalid spool config")
+
+	_, err = newOutput(output.Params{
+		Logger:         testutils.NewLogger(t),
+		ConfigArgument: "http://localhost:8086?spoolDir=/spool",
+	})
+	require.ErrorContains(t, err, "requires the metrics registry")
+}
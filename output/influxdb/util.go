@@ -11,6 +11,13 @@ import (
 )
 
 func MakeClient(conf Config) (client.Client, error) {
+	switch conf.APIVersion.Int64 {
+	case 0, 1:
+	case 2:
+		return newClientV2(conf)
+	default:
+		return nil, fmt.Errorf("unsupported InfluxDB API version %d, it should be either 1 or 2", conf.APIVersion.Int64)
+	}
 	if strings.HasPrefix(conf.Addr.String, "udp://") {
 		return client.NewUDPClient(client.UDPConfig{
 			Addr:        strings.TrimPrefix(conf.Addr.String, "udp://"),
@@ -34,7 +41,7 @@ func MakeClient(conf Config) (client.Client, error) {
 		}
 		clientHTTPConfig.Proxy = http.ProxyURL(parsedProxyURL)
 	}
-	return client.NewHTTPClient(clientHTTPConfig)
+	return newClientV1(clientHTTPConfig)
 }
 
 func MakeBatchConfig(conf Config) client.BatchPointsConfig {
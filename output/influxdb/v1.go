@@ -0,0 +1,97 @@
+package influxdb
+
+import (
+	"bytes"
+	"crypto/tls"
+	"net/http"
+	"net/url"
+	"path"
+
+	client "github.com/influxdata/influxdb1-client/v2"
+)
+
+// clientV1 is a client.Client for the HTTP API of InfluxDB 1.x. It writes the
+// points itself, so the errors keep the response status, and it uses the
+// client of the library for everything else.
+type clientV1 struct {
+	client.Client
+	httpClient *http.Client
+	writeURL   url.URL
+	username   string
+	password   string
+	userAgent  string
+}
+
+var _ client.Client = &clientV1{}
+
+func newClientV1(conf client.HTTPConfig) (*clientV1, error) {
+	cl, err := client.NewHTTPClient(conf)
+	if err != nil {
+		return nil, err
+	}
+	// the address was already validated by the client of the library
+	writeURL, err := url.Parse(conf.Addr)
+	if err != nil {
+		return nil, err
+	}
+	writeURL.Path = path.Join(writeURL.Path, "write")
+
+	transport := &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: conf.InsecureSkipVerify}, //nolint:gosec
+		Proxy:           conf.Proxy,
+	}
+	return &clientV1{
+		Client:     cl,
+		httpClient: &http.Client{Transport: transport},
+		writeURL:   *writeURL,
+		username:   conf.Username,
+		password:   conf.Password,
+		userAgent:  conf.UserAgent,
+	}, nil
+}
+
+// Write writes the points of the batch in the line protocol.
+func (c *clientV1) Write(bp client.BatchPoints) error {
+	var b bytes.Buffer
+	for _, p := range bp.Points() {
+		if p == nil {
+			continue
+		}
+		b.WriteString(p.PrecisionString(bp.Precision()))
+		b.WriteByte('\n')
+	}
+
+	u := c.writeURL
+	query := url.Values{}
+	query.Set("db", bp.Database())
+	query.Set("rp", bp.RetentionPolicy())
+	query.Set("precision", bp.Precision())
+	query.Set("consistency", bp.WriteConsistency())
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequest(http.MethodPost, u.String(), &b) //nolint:noctx
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "")
+	req.Header.Set("User-Agent", c.userAgent)
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+	return nil
+}
+
+// Close closes the idle connections of both clients.
+func (c *clientV1) Close() error {
+	c.httpClient.CloseIdleConnections()
+	return c.Client.Close()
+}
@@ -0,0 +1,180 @@
+package influxdb
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	client "github.com/influxdata/influxdb1-client/v2"
+)
+
+// errQueryNotSupported is returned by the queries of the v2 client, which
+// only writes points.
+var errQueryNotSupported = errors.New("queries aren't supported by the InfluxDB v2 client")
+
+// v2Precisions maps the configured precisions to the ones of the v2 API,
+// which doesn't support minutes and hours, and to the ones the line protocol
+// is serialized with by the v1 client.
+//
+//nolint:gochecknoglobals
+var v2Precisions = map[string][2]string{
+	"":   {"ns", "n"},
+	"n":  {"ns", "n"},
+	"ns": {"ns", "n"},
+	"u":  {"us", "u"},
+	"us": {"us", "u"},
+	"ms": {"ms", "ms"},
+	"s":  {"s", "s"},
+}
+
+// clientV2 is a client.Client that writes the points with the write API of
+// InfluxDB 2.x, using an organization, a bucket and a token. InfluxDB 3.x
+// supports the same API, where the bucket is the database.
+type clientV2 struct {
+	httpClient *http.Client
+	addr       string
+	writeURL   string
+	token      string
+	precision  string
+}
+
+var _ client.Client = &clientV2{}
+
+func newClientV2(conf Config) (*clientV2, error) {
+	if strings.HasPrefix(conf.Addr.String, "udp://") {
+		return nil, errors.New("the InfluxDB v2 API doesn't support UDP")
+	}
+	addr := strings.TrimSuffix(conf.Addr.String, "/")
+	if addr == "" {
+		addr = "http://localhost:8086"
+	}
+	if _, err := url.Parse(addr); err != nil {
+		return nil, err
+	}
+
+	precisions, ok := v2Precisions[conf.Precision.String]
+	if !ok {
+		return nil, fmt.Errorf("the precision %q isn't supported by the InfluxDB v2 API, "+
+			"it should be one of ns, us, ms or s", conf.Precision.String)
+	}
+
+	query := url.Values{}
+	query.Set("bucket", conf.bucket())
+	if conf.Organization.String != "" {
+		query.Set("org", conf.Organization.String)
+	}
+	query.Set("precision", precisions[0])
+
+	transport := http.DefaultTransport.(*http.Transport).Clone() //nolint:forcetypeassert
+	if conf.Insecure.Bool {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true} //nolint:gosec
+	}
+	if conf.Proxy.Valid {
+		proxyURL, err := url.Parse(conf.Proxy.String)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the http proxy URL: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	return &clientV2{
+		httpClient: &http.Client{Transport: transport},
+		addr:       addr,
+		writeURL:   addr + "/api/v2/write?" + query.Encode(),
+		token:      conf.Token.String,
+		precision:  precisions[1],
+	}, nil
+}
+
+// Ping checks that InfluxDB is reachable and returns its version.
+func (c *clientV2) Ping(timeout time.Duration) (time.Duration, string, error) {
+	start := time.Now()
+	req, err := http.NewRequest(http.MethodGet, c.addr+"/ping", nil) //nolint:noctx
+	if err != nil {
+		return 0, "", err
+	}
+	httpClient := *c.httpClient
+	httpClient.Timeout = timeout
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return 0, "", responseError(resp)
+	}
+	return time.Since(start), resp.Header.Get("X-Influxdb-Version"), nil
+}
+
+// Write writes the points of the batch in the line protocol.
+func (c *clientV2) Write(bp client.BatchPoints) error {
+	var b bytes.Buffer
+	for _, p := range bp.Points() {
+		if p == nil {
+			continue
+		}
+		b.WriteString(p.PrecisionString(c.precision))
+		b.WriteByte('\n')
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.writeURL, &b) //nolint:noctx
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	req.Header.Set("User-Agent", "k6")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Token "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+	return nil
+}
+
+// Query isn't supported.
+func (c *clientV2) Query(client.Query) (*client.Response, error) {
+	return nil, errQueryNotSupported
+}
+
+// QueryAsChunk isn't supported.
+func (c *clientV2) QueryAsChunk(client.Query) (*client.ChunkedResponse, error) {
+	return nil, errQueryNotSupported
+}
+
+// Close closes the idle connections.
+func (c *clientV2) Close() error {
+	c.httpClient.CloseIdleConnections()
+	return nil
+}
+
+// statusError is the error of an unexpected response status of InfluxDB.
+type statusError struct {
+	code   int
+	status string
+	msg    string
+}
+
+func (e *statusError) Error() string {
+	if e.msg != "" {
+		return fmt.Sprintf("unexpected response status %s: %s", e.status, e.msg)
+	}
+	return fmt.Sprintf("unexpected response status %s", e.status)
+}
+
+func responseError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return &statusError{code: resp.StatusCode, status: resp.Status, msg: strings.TrimSpace(string(body))}
+}
@@ -0,0 +1,106 @@
+package influxdb
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/ChipArtem/k6/lib/testutils"
+	"github.com/ChipArtem/k6/metrics"
+	"github.com/ChipArtem/k6/output"
+)
+
+func TestOutputV2(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu    sync.Mutex
+		lines []string
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v2/write", r.URL.Path)
+		assert.Equal(t, "myorg", r.URL.Query().Get("org"))
+		assert.Equal(t, "mybucket", r.URL.Query().Get("bucket"))
+		assert.Equal(t, "ms", r.URL.Query().Get("precision"))
+		assert.Equal(t, "Token secret", r.Header.Get("Authorization"))
+
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		mu.Lock()
+		lines = append(lines, strings.Split(strings.TrimSpace(string(body)), "\n")...)
+		mu.Unlock()
+		rw.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	o, err := newOutput(output.Params{
+		Logger:         testutils.NewLogger(t),
+		Environment:    map[string]string{"K6_INFLUXDB_TOKEN": "secret"},
+		ConfigArgument: strings.Replace(ts.URL, "http://", "http+v2://", 1) + "/mybucket?org=myorg&precision=ms",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "InfluxDBv2 ("+ts.URL+", bucket mybucket)", o.Description())
+
+	registry := metrics.NewRegistry()
+	metric, err := registry.NewMetric("test_gauge", metrics.Gauge)
+	require.NoError(t, err)
+
+	require.NoError(t, o.Start())
+	o.AddMetricSamples([]metrics.SampleContainer{metrics.Sample{
+		TimeSeries: metrics.TimeSeries{
+			Metric: metric,
+			Tags:   registry.RootTagSet().WithTagsFromMap(map[string]string{"url": "http://a", "name": "b"}),
+		},
+		Time:  time.Unix(1700000000, 123456789),
+		Value: 2.0,
+	}})
+	require.NoError(t, o.Stop())
+
+	require.Len(t, lines, 1)
+	assert.Equal(t, `test_gauge,name=b url="http://a",value=2 1700000000123`, lines[0])
+}
+
+func TestOutputV2Error(t *testing.T) {
+	t.Parallel()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusUnauthorized)
+		_, _ = rw.Write([]byte(`{"code":"unauthorized","message":"unauthorized access"}`))
+	}))
+	defer ts.Close()
+
+	cl, err := MakeClient(Config{Addr: null.StringFrom(ts.URL), APIVersion: null.IntFrom(2)})
+	require.NoError(t, err)
+	batch, err := (&Output{BatchConf: MakeBatchConfig(Config{})}).batchFromSamples(nil)
+	require.NoError(t, err)
+	err = cl.Write(batch)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "401 Unauthorized")
+	assert.Contains(t, err.Error(), "unauthorized access")
+}
+
+func TestMakeClientV2Errors(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]Config{
+		"udp":       {Addr: null.StringFrom("udp://localhost:8089"), APIVersion: null.IntFrom(2)},
+		"precision": {Precision: null.StringFrom("h"), APIVersion: null.IntFrom(2)},
+		"version":   {APIVersion: null.IntFrom(3)},
+	}
+	for name, conf := range testCases {
+		conf := conf
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			_, err := MakeClient(conf)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package json
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mstoykov/envconfig"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/ChipArtem/k6/lib/types"
+	"github.com/ChipArtem/k6/output/rotation"
+)
+
+// Config is the config for the json output.
+type Config struct {
+	FileName          null.String        `json:"fileName" envconfig:"K6_JSON_FILENAME"`
+	RotateMaxSize     null.String        `json:"rotateMaxSize" envconfig:"K6_JSON_ROTATE_MAX_SIZE"`
+	RotateMaxDuration types.NullDuration `json:"rotateMaxDuration" envconfig:"K6_JSON_ROTATE_MAX_DURATION"`
+	RotateMaxFiles    null.Int           `json:"rotateMaxFiles" envconfig:"K6_JSON_ROTATE_MAX_FILES"`
+	RotateNaming      null.String        `json:"rotateNaming" envconfig:"K6_JSON_ROTATE_NAMING"`
+}
+
+// NewConfig creates a new Config instance with default values for some fields.
+func NewConfig() Config {
+	return Config{
+		RotateNaming: null.NewString("sequential", false),
+	}
+}
+
+// Apply merges two configs by overwriting properties in the old config.
+func (c Config) Apply(cfg Config) Config {
+	if cfg.FileName.Valid {
+		c.FileName = cfg.FileName
+	}
+	if cfg.RotateMaxSize.Valid {
+		c.RotateMaxSize = cfg.RotateMaxSize
+	}
+	if cfg.RotateMaxDuration.Valid {
+		c.RotateMaxDuration = cfg.RotateMaxDuration
+	}
+	if cfg.RotateMaxFiles.Valid {
+		c.RotateMaxFiles = cfg.RotateMaxFiles
+	}
+	if cfg.RotateNaming.Valid {
+		c.RotateNaming = cfg.RotateNaming
+	}
+	return c
+}
+
+// Rotation returns the config of the file rotation.
+func (c Config) Rotation() (rotation.Config, error) {
+	return rotation.NewConfig(
+		c.RotateMaxSize.String, c.RotateMaxDuration.TimeDuration(), c.RotateMaxFiles.Int64, c.RotateNaming.String,
+	)
+}
+
+// ParseArg takes an arg string and converts it to a config. The arg is
+// either just the file name, or a comma-separated list of key=value pairs.
+func ParseArg(arg string) (Config, error) {
+	c := Config{}
+
+	if !strings.Contains(arg, "=") {
+		c.FileName = null.StringFrom(arg)
+		return c, nil
+	}
+
+	for _, pair := range strings.Split(arg, ",") {
+		r := strings.SplitN(pair, "=", 2)
+		if len(r) != 2 {
+			return c, fmt.Errorf("couldn't parse %q as argument for json output", arg)
+		}
+		switch r[0] {
+		case "fileName":
+			c.FileName = null.StringFrom(r[1])
+		case "rotateMaxSize":
+			c.RotateMaxSize = null.StringFrom(r[1])
+		case "rotateMaxDuration":
+			if err := c.RotateMaxDuration.UnmarshalText([]byte(r[1])); err != nil {
+				return c, err
+			}
+		case "rotateMaxFiles":
+			if err := c.RotateMaxFiles.UnmarshalText([]byte(r[1])); err != nil {
+				return c, err
+			}
+		case "rotateNaming":
+			c.RotateNaming = null.StringFrom(r[1])
+		default:
+			return c, fmt.Errorf("unknown key %q as argument for json output", r[0])
+		}
+	}
+
+	return c, nil
+}
+
+// GetConsolidatedConfig combines {default config values + JSON config +
+// environment vars + arg config values}, and returns the final result.
+func GetConsolidatedConfig(jsonRawConf json.RawMessage, env map[string]string, arg string) (Config, error) {
+	result := NewConfig()
+	if jsonRawConf != nil {
+		jsonConf := Config{}
+		if err := json.Unmarshal(jsonRawConf, &jsonConf); err != nil {
+			return result, err
+		}
+		result = result.Apply(jsonConf)
+	}
+
+	envConfig := Config{}
+	if err := envconfig.Process("", &envConfig, func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}); err != nil {
+		return result, err
+	}
+	result = result.Apply(envConfig)
+
+	if arg != "" {
+		argConf, err := ParseArg(arg)
+		if err != nil {
+			return result, err
+		}
+		result = result.Apply(argConf)
+	}
+
+	return result, nil
+}
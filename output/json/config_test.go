@@ -0,0 +1,75 @@
+package json
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/ChipArtem/k6/lib/types"
+	"github.com/ChipArtem/k6/output/rotation"
+)
+
+func TestParseArg(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		config      Config
+		expectedErr bool
+	}{
+		"results.json": {
+			config: Config{FileName: null.StringFrom("results.json")},
+		},
+		"fileName=results.json.gz,rotateMaxSize=100MB,rotateMaxFiles=3": {
+			config: Config{
+				FileName:       null.StringFrom("results.json.gz"),
+				RotateMaxSize:  null.StringFrom("100MB"),
+				RotateMaxFiles: null.IntFrom(3),
+			},
+		},
+		"fileName=results.json,rotateMaxDuration=1h,rotateNaming=timestamp": {
+			config: Config{
+				FileName:          null.StringFrom("results.json"),
+				RotateMaxDuration: types.NullDurationFrom(time.Hour),
+				RotateNaming:      null.StringFrom("timestamp"),
+			},
+		},
+		"fileName=results.json,rotateMaxFiles=many": {expectedErr: true},
+		"filename=results.json":                     {expectedErr: true},
+	}
+
+	for arg, testCase := range cases {
+		arg, testCase := arg, testCase
+		t.Run(arg, func(t *testing.T) {
+			t.Parallel()
+			config, err := ParseArg(arg)
+			if testCase.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testCase.config, config)
+		})
+	}
+}
+
+func TestGetConsolidatedConfig(t *testing.T) {
+	t.Parallel()
+
+	config, err := GetConsolidatedConfig(
+		[]byte(`{"rotateMaxSize":"1GB","rotateMaxFiles":10}`),
+		map[string]string{"K6_JSON_ROTATE_MAX_FILES": "5", "K6_JSON_ROTATE_NAMING": "timestamp"},
+		"results.json",
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "results.json", config.FileName.String)
+
+	rotationConf, err := config.Rotation()
+	require.NoError(t, err)
+	assert.Equal(t, rotation.Config{MaxSize: 1e9, MaxFiles: 5, Naming: rotation.NamingTimestamp}, rotationConf)
+
+	_, err = Config{RotateMaxFiles: null.IntFrom(5)}.Rotation()
+	assert.Error(t, err, "the maximum number of files requires a limit to rotate the files")
+}
@@ -2,17 +2,17 @@ package json
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"io"
-	"strings"
 	"time"
 
-	"github.com/klauspost/compress/gzip"
 	"github.com/mailru/easyjson/jwriter"
 	"github.com/sirupsen/logrus"
 
 	"github.com/ChipArtem/k6/metrics"
 	"github.com/ChipArtem/k6/output"
+	"github.com/ChipArtem/k6/output/rotation"
 )
 
 // TODO: add option for emitting proper JSON files (https://github.com/k6io/k6/issues/737)
@@ -27,7 +27,9 @@ type Output struct {
 
 	logger      logrus.FieldLogger
 	filename    string
+	rotation    rotation.Config
 	out         io.Writer
+	file        *rotation.File
 	closeFn     func() error
 	seenMetrics map[string]struct{}
 	thresholds  map[string]metrics.Thresholds
@@ -35,12 +37,26 @@ type Output struct {
 
 // New returns a new JSON output.
 func New(params output.Params) (output.Output, error) {
+	conf, err := GetConsolidatedConfig(params.JSONConfig, params.Environment, params.ConfigArgument)
+	if err != nil {
+		return nil, err
+	}
+	rotationConf, err := conf.Rotation()
+	if err != nil {
+		return nil, err
+	}
+	filename := conf.FileName.String
+	if rotationConf.Enabled() && (filename == "" || filename == "-") {
+		return nil, errors.New("the rotation of the json output requires a file name")
+	}
+
 	return &Output{
 		params:   params,
-		filename: params.ConfigArgument,
+		filename: filename,
+		rotation: rotationConf,
 		logger: params.Logger.WithFields(logrus.Fields{
 			"output":   "json",
-			"filename": params.ConfigArgument,
+			"filename": filename,
 		}),
 		seenMetrics: make(map[string]struct{}),
 	}, nil
@@ -55,7 +71,8 @@ func (o *Output) Description() string {
 }
 
 // Start tries to open the specified JSON file and starts the goroutine for
-// metric flushing. If gzip encoding is specified, it also handles that.
+// metric flushing. If gzip encoding is specified, it also handles that, and
+// if the rotation is enabled, the samples are written to a sequence of files.
 func (o *Output) Start() error {
 	o.logger.Debug("Starting...")
 
@@ -66,28 +83,13 @@ func (o *Output) Start() error {
 		}
 		o.out = w
 	} else {
-		logfile, err := o.params.FS.Create(o.filename)
+		file, err := rotation.Create(o.params.FS, o.filename, o.rotation)
 		if err != nil {
 			return err
 		}
-		w := bufio.NewWriter(logfile)
-
-		if strings.HasSuffix(o.filename, ".gz") {
-			outfile := gzip.NewWriter(w)
-
-			o.closeFn = func() error {
-				_ = outfile.Close()
-				_ = w.Flush()
-				return logfile.Close()
-			}
-			o.out = outfile
-		} else {
-			o.closeFn = func() error {
-				_ = w.Flush()
-				return logfile.Close()
-			}
-			o.out = logfile
-		}
+		o.file = file
+		o.closeFn = file.Close
+		o.out = file
 	}
 
 	pf, err := output.NewPeriodicFlusher(flushPeriod, o.flushMetrics)
@@ -129,19 +131,40 @@ func (o *Output) flushMetrics() {
 		count += len(samples)
 		for _, sample := range samples {
 			sample := sample
+			o.rotateIfNeeded(jw)
 			o.handleMetric(sample.Metric, jw)
 			wrapSample(sample).MarshalEasyJSON(jw)
 			jw.RawByte('\n')
 		}
 	}
 
+	o.dump(jw)
+	if count > 0 {
+		o.logger.WithField("t", time.Since(start)).WithField("count", count).Debug("Wrote metrics to JSON")
+	}
+}
+
+func (o *Output) dump(jw *jwriter.Writer) {
 	if _, err := jw.DumpTo(o.out); err != nil {
 		// Skip metric if it can't be made into JSON or envelope is null.
 		o.logger.WithError(err).Error("Sample couldn't be marshalled to JSON")
 	}
-	if count > 0 {
-		o.logger.WithField("t", time.Since(start)).WithField("count", count).Debug("Wrote metrics to JSON")
+}
+
+// rotateIfNeeded starts a new file if the current one has reached the limits
+// of the rotation. The pending samples are written to the current file first,
+// and the metrics are described again in the new file, so each of the files
+// can be processed on its own.
+func (o *Output) rotateIfNeeded(jw *jwriter.Writer) {
+	if o.file == nil || !o.file.ShouldRotate(jw.Size()) {
+		return
+	}
+	o.dump(jw)
+	if err := o.file.Rotate(); err != nil {
+		o.logger.WithError(err).Error("Couldn't rotate the JSON file")
+		return
 	}
+	o.seenMetrics = make(map[string]struct{})
 }
 
 func (o *Output) handleMetric(m *metrics.Metric, jw *jwriter.Writer) {
@@ -158,6 +181,7 @@ func (o *Output) handleMetric(m *metrics.Metric, jw *jwriter.Writer) {
 	wrapped.Data.Type = m.Type
 	wrapped.Data.Contains = m.Contains
 	wrapped.Data.Submetrics = m.Submetrics
+	wrapped.Data.Buckets = m.Buckets
 
 	if ts, ok := o.thresholds[m.Name]; ok {
 		wrapped.Data.Thresholds = ts
@@ -4,10 +4,10 @@ package json
 
 import (
 	json "encoding/json"
+	metrics "github.com/ChipArtem/k6/metrics"
 	easyjson "github.com/mailru/easyjson"
 	jlexer "github.com/mailru/easyjson/jlexer"
 	jwriter "github.com/mailru/easyjson/jwriter"
-	metrics "github.com/ChipArtem/k6/metrics"
 	time "time"
 )
 
@@ -19,7 +19,7 @@ var (
 	_ easyjson.Marshaler
 )
 
-func easyjson42239ddeDecodeGoK6IoK6OutputJson(in *jlexer.Lexer, out *sampleEnvelope) {
+func easyjson42239ddeDecodeGithubComChipArtemK6OutputJson(in *jlexer.Lexer, out *sampleEnvelope) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -54,7 +54,7 @@ func easyjson42239ddeDecodeGoK6IoK6OutputJson(in *jlexer.Lexer, out *sampleEnvel
 		in.Consumed()
 	}
 }
-func easyjson42239ddeEncodeGoK6IoK6OutputJson(out *jwriter.Writer, in sampleEnvelope) {
+func easyjson42239ddeEncodeGithubComChipArtemK6OutputJson(out *jwriter.Writer, in sampleEnvelope) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -78,18 +78,19 @@ func easyjson42239ddeEncodeGoK6IoK6OutputJson(out *jwriter.Writer, in sampleEnve
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v sampleEnvelope) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjson42239ddeEncodeGoK6IoK6OutputJson(w, v)
+	easyjson42239ddeEncodeGithubComChipArtemK6OutputJson(w, v)
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *sampleEnvelope) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjson42239ddeDecodeGoK6IoK6OutputJson(l, v)
+	easyjson42239ddeDecodeGithubComChipArtemK6OutputJson(l, v)
 }
 func easyjson42239ddeDecode(in *jlexer.Lexer, out *struct {
 	Time     time.Time         `json:"time"`
 	Value    float64           `json:"value"`
 	Tags     *metrics.TagSet   `json:"tags"`
 	Metadata map[string]string `json:"metadata,omitempty"`
+	Bucket   string            `json:"le,omitempty"`
 }) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
@@ -145,6 +146,8 @@ func easyjson42239ddeDecode(in *jlexer.Lexer, out *struct {
 				}
 				in.Delim('}')
 			}
+		case "le":
+			out.Bucket = string(in.String())
 		default:
 			in.SkipRecursive()
 		}
@@ -160,6 +163,7 @@ func easyjson42239ddeEncode(out *jwriter.Writer, in struct {
 	Value    float64           `json:"value"`
 	Tags     *metrics.TagSet   `json:"tags"`
 	Metadata map[string]string `json:"metadata,omitempty"`
+	Bucket   string            `json:"le,omitempty"`
 }) {
 	out.RawByte('{')
 	first := true
@@ -202,9 +206,14 @@ func easyjson42239ddeEncode(out *jwriter.Writer, in struct {
 			out.RawByte('}')
 		}
 	}
+	if in.Bucket != "" {
+		const prefix string = ",\"le\":"
+		out.RawString(prefix)
+		out.String(string(in.Bucket))
+	}
 	out.RawByte('}')
 }
-func easyjson42239ddeDecodeGoK6IoK6OutputJson1(in *jlexer.Lexer, out *metricEnvelope) {
+func easyjson42239ddeDecodeGithubComChipArtemK6OutputJson1(in *jlexer.Lexer, out *metricEnvelope) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -239,7 +248,7 @@ func easyjson42239ddeDecodeGoK6IoK6OutputJson1(in *jlexer.Lexer, out *metricEnve
 		in.Consumed()
 	}
 }
-func easyjson42239ddeEncodeGoK6IoK6OutputJson1(out *jwriter.Writer, in metricEnvelope) {
+func easyjson42239ddeEncodeGithubComChipArtemK6OutputJson1(out *jwriter.Writer, in metricEnvelope) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -263,12 +272,12 @@ func easyjson42239ddeEncodeGoK6IoK6OutputJson1(out *jwriter.Writer, in metricEnv
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v metricEnvelope) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjson42239ddeEncodeGoK6IoK6OutputJson1(w, v)
+	easyjson42239ddeEncodeGithubComChipArtemK6OutputJson1(w, v)
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *metricEnvelope) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjson42239ddeDecodeGoK6IoK6OutputJson1(l, v)
+	easyjson42239ddeDecodeGithubComChipArtemK6OutputJson1(l, v)
 }
 func easyjson42239ddeDecode1(in *jlexer.Lexer, out *struct {
 	Name       string               `json:"name"`
@@ -276,6 +285,7 @@ func easyjson42239ddeDecode1(in *jlexer.Lexer, out *struct {
 	Contains   metrics.ValueType    `json:"contains"`
 	Thresholds metrics.Thresholds   `json:"thresholds"`
 	Submetrics []*metrics.Submetric `json:"submetrics"`
+	Buckets    []float64            `json:"buckets,omitempty"`
 }) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
@@ -333,13 +343,36 @@ func easyjson42239ddeDecode1(in *jlexer.Lexer, out *struct {
 						if v3 == nil {
 							v3 = new(metrics.Submetric)
 						}
-						easyjson42239ddeDecodeGoK6IoK6Metrics(in, v3)
+						easyjson42239ddeDecodeGithubComChipArtemK6Metrics(in, v3)
 					}
 					out.Submetrics = append(out.Submetrics, v3)
 					in.WantComma()
 				}
 				in.Delim(']')
 			}
+		case "buckets":
+			if in.IsNull() {
+				in.Skip()
+				out.Buckets = nil
+			} else {
+				in.Delim('[')
+				if out.Buckets == nil {
+					if !in.IsDelim(']') {
+						out.Buckets = make([]float64, 0, 8)
+					} else {
+						out.Buckets = []float64{}
+					}
+				} else {
+					out.Buckets = (out.Buckets)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v4 float64
+					v4 = float64(in.Float64())
+					out.Buckets = append(out.Buckets, v4)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
 		default:
 			in.SkipRecursive()
 		}
@@ -356,6 +389,7 @@ func easyjson42239ddeEncode1(out *jwriter.Writer, in struct {
 	Contains   metrics.ValueType    `json:"contains"`
 	Thresholds metrics.Thresholds   `json:"thresholds"`
 	Submetrics []*metrics.Submetric `json:"submetrics"`
+	Buckets    []float64            `json:"buckets,omitempty"`
 }) {
 	out.RawByte('{')
 	first := true
@@ -387,22 +421,36 @@ func easyjson42239ddeEncode1(out *jwriter.Writer, in struct {
 			out.RawString("null")
 		} else {
 			out.RawByte('[')
-			for v4, v5 := range in.Submetrics {
-				if v4 > 0 {
+			for v5, v6 := range in.Submetrics {
+				if v5 > 0 {
 					out.RawByte(',')
 				}
-				if v5 == nil {
+				if v6 == nil {
 					out.RawString("null")
 				} else {
-					easyjson42239ddeEncodeGoK6IoK6Metrics(out, *v5)
+					easyjson42239ddeEncodeGithubComChipArtemK6Metrics(out, *v6)
+				}
+			}
+			out.RawByte(']')
+		}
+	}
+	if len(in.Buckets) != 0 {
+		const prefix string = ",\"buckets\":"
+		out.RawString(prefix)
+		{
+			out.RawByte('[')
+			for v7, v8 := range in.Buckets {
+				if v7 > 0 {
+					out.RawByte(',')
 				}
+				out.Float64(float64(v8))
 			}
 			out.RawByte(']')
 		}
 	}
 	out.RawByte('}')
 }
-func easyjson42239ddeDecodeGoK6IoK6Metrics(in *jlexer.Lexer, out *metrics.Submetric) {
+func easyjson42239ddeDecodeGithubComChipArtemK6Metrics(in *jlexer.Lexer, out *metrics.Submetric) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -445,7 +493,7 @@ func easyjson42239ddeDecodeGoK6IoK6Metrics(in *jlexer.Lexer, out *metrics.Submet
 		in.Consumed()
 	}
 }
-func easyjson42239ddeEncodeGoK6IoK6Metrics(out *jwriter.Writer, in metrics.Submetric) {
+func easyjson42239ddeEncodeGithubComChipArtemK6Metrics(out *jwriter.Writer, in metrics.Submetric) {
 	out.RawByte('{')
 	first := true
 	_ = first
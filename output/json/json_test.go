@@ -4,6 +4,8 @@ import (
 	"bufio"
 	"bytes"
 	"compress/gzip"
+	"encoding/json"
+	"fmt"
 	"io"
 	"testing"
 	"time"
@@ -15,6 +17,7 @@ import (
 	"github.com/ChipArtem/k6/lib/testutils"
 	"github.com/ChipArtem/k6/metrics"
 	"github.com/ChipArtem/k6/output"
+	"github.com/ChipArtem/k6/output/rotation"
 )
 
 func getValidator(t testing.TB, expected []string) func(io.Reader) {
@@ -216,6 +219,32 @@ func TestWrapSampleWithSamplePointer(t *testing.T) {
 	assert.NotEqual(t, out, (*sampleEnvelope)(nil))
 }
 
+func TestWrapSampleHistogramBucket(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	histogram, err := registry.NewHistogram("my_histogram", []float64{100, 300})
+	require.NoError(t, err)
+
+	out := wrapSample(metrics.Sample{
+		TimeSeries: metrics.TimeSeries{
+			Metric: histogram,
+			Tags:   registry.RootTagSet(),
+		},
+		Value: 150,
+	})
+	assert.Equal(t, "300", out.Data.Bucket)
+
+	out = wrapSample(metrics.Sample{
+		TimeSeries: metrics.TimeSeries{
+			Metric: histogram,
+			Tags:   registry.RootTagSet(),
+		},
+		Value: 301,
+	})
+	assert.Equal(t, "+Inf", out.Data.Bucket)
+}
+
 func setThresholds(t *testing.T, out output.Output) {
 	t.Helper()
 
@@ -225,3 +254,66 @@ func setThresholds(t *testing.T, out output.Output) {
 	ts := metrics.NewThresholds([]string{"rate<0.01", "p(99)<250"})
 	jout.SetThresholds(map[string]metrics.Thresholds{"my_metric1": ts})
 }
+
+func TestJsonOutputFileRotation(t *testing.T) {
+	t.Parallel()
+
+	fs := fsext.NewMemMapFs()
+	out, err := New(output.Params{
+		Logger:         testutils.NewLogger(t),
+		FS:             fs,
+		ConfigArgument: "fileName=/results.json.gz,rotateMaxSize=1",
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, out.Start())
+	samples, _ := generateTestMetricSamples(t)
+	out.AddMetricSamples(samples)
+	require.NoError(t, out.Stop())
+
+	manifest, err := fsext.ReadFile(fs, "/results.json.manifest.json")
+	require.NoError(t, err)
+	var m rotation.Manifest
+	require.NoError(t, json.Unmarshal(manifest, &m))
+
+	type envelope struct {
+		Type   string `json:"type"`
+		Metric string `json:"metric"`
+	}
+
+	// every sample is in its own file, along with the description of its metric
+	require.Len(t, m.Files, 5)
+	for i, entry := range m.Files {
+		assert.Equal(t, fmt.Sprintf("results.%05d.json.gz", i+1), entry.Name)
+
+		file, err := fs.Open("/" + entry.Name)
+		require.NoError(t, err)
+		reader, err := gzip.NewReader(file)
+		require.NoError(t, err)
+		lines, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		require.NoError(t, file.Close())
+
+		var envelopes []envelope
+		s := bufio.NewScanner(bytes.NewReader(lines))
+		for s.Scan() {
+			var e envelope
+			require.NoError(t, json.Unmarshal(s.Bytes(), &e))
+			envelopes = append(envelopes, e)
+		}
+		require.Len(t, envelopes, 2, entry.Name)
+		assert.Equal(t, "Metric", envelopes[0].Type)
+		assert.Equal(t, "Point", envelopes[1].Type)
+		assert.Equal(t, envelopes[0].Metric, envelopes[1].Metric)
+	}
+}
+
+func TestJsonOutputRotationStdout(t *testing.T) {
+	t.Parallel()
+
+	_, err := New(output.Params{
+		Logger:      testutils.NewLogger(t),
+		Environment: map[string]string{"K6_JSON_ROTATE_MAX_DURATION": "1h"},
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,140 @@
+package json
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ChipArtem/k6/metrics"
+)
+
+// maxLineSize is the maximum size of a line that the Reader accepts, the
+// samples with a lot of tags or metadata can be quite long.
+const maxLineSize = 16 * 1024 * 1024
+
+type envelope struct {
+	Type   string          `json:"type"`
+	Metric string          `json:"metric"`
+	Data   json.RawMessage `json:"data"`
+}
+
+type pointData struct {
+	Time     time.Time         `json:"time"`
+	Value    float64           `json:"value"`
+	Tags     map[string]string `json:"tags"`
+	Metadata map[string]string `json:"metadata"`
+}
+
+type metricData struct {
+	Name       string             `json:"name"`
+	Type       metrics.MetricType `json:"type"`
+	Contains   metrics.ValueType  `json:"contains"`
+	Thresholds metrics.Thresholds `json:"thresholds"`
+	Buckets    []float64          `json:"buckets"`
+}
+
+// Reader reads the samples written by the json output. The metrics that are
+// described in the input are registered in the registry, so the samples can
+// be sent to any other output.
+type Reader struct {
+	scanner    *bufio.Scanner
+	registry   *metrics.Registry
+	line       int
+	thresholds map[string]metrics.Thresholds
+}
+
+// NewReader returns a new Reader of the lines of r.
+func NewReader(r io.Reader, registry *metrics.Registry) *Reader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	return &Reader{
+		scanner:    scanner,
+		registry:   registry,
+		thresholds: make(map[string]metrics.Thresholds),
+	}
+}
+
+// Read returns the next sample, or io.EOF if there are no more of them.
+func (r *Reader) Read() (metrics.Sample, error) {
+	for r.scanner.Scan() {
+		r.line++
+		line := r.scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var env envelope
+		if err := json.Unmarshal(line, &env); err != nil {
+			return metrics.Sample{}, r.errorf("%w", err)
+		}
+		switch env.Type {
+		case "Metric":
+			if err := r.readMetric(env.Data); err != nil {
+				return metrics.Sample{}, err
+			}
+		case "Point":
+			return r.readPoint(env.Metric, env.Data)
+		default:
+			return metrics.Sample{}, r.errorf("unknown type %q", env.Type)
+		}
+	}
+	if err := r.scanner.Err(); err != nil {
+		return metrics.Sample{}, err
+	}
+	return metrics.Sample{}, io.EOF
+}
+
+// Thresholds returns the thresholds of the metrics that were read so far, by
+// the name of their metric. They aren't parsed yet. The thresholds of the
+// submetrics aren't recorded by the json output, so they are missing.
+func (r *Reader) Thresholds() map[string]metrics.Thresholds {
+	return r.thresholds
+}
+
+func (r *Reader) readMetric(data json.RawMessage) error {
+	var md metricData
+	if err := json.Unmarshal(data, &md); err != nil {
+		return r.errorf("invalid metric: %w", err)
+	}
+
+	var err error
+	if md.Type == metrics.Histogram {
+		_, err = r.registry.NewHistogram(md.Name, md.Buckets, md.Contains)
+	} else {
+		_, err = r.registry.NewMetric(md.Name, md.Type, md.Contains)
+	}
+	if err != nil {
+		return r.errorf("%w", err)
+	}
+	if len(md.Thresholds.Thresholds) > 0 {
+		r.thresholds[md.Name] = md.Thresholds
+	}
+	return nil
+}
+
+func (r *Reader) readPoint(name string, data json.RawMessage) (metrics.Sample, error) {
+	metric := r.registry.Get(name)
+	if metric == nil {
+		return metrics.Sample{}, r.errorf("the metric %q of the sample wasn't described before it", name)
+	}
+	var pd pointData
+	if err := json.Unmarshal(data, &pd); err != nil {
+		return metrics.Sample{}, r.errorf("invalid sample: %w", err)
+	}
+
+	return metrics.Sample{
+		TimeSeries: metrics.TimeSeries{
+			Metric: metric,
+			Tags:   r.registry.RootTagSet().WithTagsFromMap(pd.Tags),
+		},
+		Time:     pd.Time,
+		Value:    pd.Value,
+		Metadata: pd.Metadata,
+	}, nil
+}
+
+func (r *Reader) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: "+format, append([]interface{}{r.line}, args...)...)
+}
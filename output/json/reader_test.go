@@ -0,0 +1,89 @@
+package json
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ChipArtem/k6/metrics"
+)
+
+func TestReader(t *testing.T) {
+	t.Parallel()
+
+	input := strings.Join([]string{
+		`{"type":"Metric","data":{"name":"my_metric1","type":"gauge","contains":"default","thresholds":["value<5"],"submetrics":null},"metric":"my_metric1"}`,
+		`{"type":"Point","data":{"time":"2021-02-24T13:37:10Z","value":1,"tags":{"tag1":"val1"},"metadata":{"meta1":"foo"}},"metric":"my_metric1"}`,
+		``,
+		`{"type":"Metric","data":{"name":"my_metric2","type":"histogram","contains":"time","thresholds":[],"submetrics":null,"buckets":[10,100]},"metric":"my_metric2"}`,
+		`{"type":"Point","data":{"time":"2021-02-24T13:37:20Z","value":42,"tags":{},"le":"100"},"metric":"my_metric2"}`,
+		// the rotated files describe the metrics again
+		`{"type":"Metric","data":{"name":"my_metric1","type":"gauge","contains":"default","thresholds":["value<5"],"submetrics":null},"metric":"my_metric1"}`,
+		`{"type":"Point","data":{"time":"2021-02-24T13:37:30Z","value":3,"tags":{"tag1":"val1","tag2":"val2"}},"metric":"my_metric1"}`,
+	}, "\n")
+
+	registry := metrics.NewRegistry()
+	r := NewReader(strings.NewReader(input), registry)
+
+	var samples []metrics.Sample
+	for {
+		sample, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		samples = append(samples, sample)
+	}
+	require.Len(t, samples, 3)
+
+	metric1 := registry.Get("my_metric1")
+	require.NotNil(t, metric1)
+	assert.Equal(t, metrics.Gauge, metric1.Type)
+	metric2 := registry.Get("my_metric2")
+	require.NotNil(t, metric2)
+	assert.Equal(t, metrics.Histogram, metric2.Type)
+	assert.Equal(t, metrics.Time, metric2.Contains)
+	assert.Equal(t, []float64{10, 100}, metric2.Buckets)
+
+	assert.Same(t, metric1, samples[0].Metric)
+	assert.Equal(t, time.Date(2021, time.February, 24, 13, 37, 10, 0, time.UTC), samples[0].Time)
+	assert.Equal(t, 1.0, samples[0].Value)
+	assert.Equal(t, map[string]string{"tag1": "val1"}, samples[0].Tags.Map())
+	assert.Equal(t, map[string]string{"meta1": "foo"}, samples[0].Metadata)
+	assert.Same(t, metric2, samples[1].Metric)
+	assert.Equal(t, 42.0, samples[1].Value)
+	assert.Equal(t, map[string]string{"tag1": "val1", "tag2": "val2"}, samples[2].Tags.Map())
+
+	thresholds := r.Thresholds()
+	require.Len(t, thresholds, 1)
+	require.Len(t, thresholds["my_metric1"].Thresholds, 1)
+	assert.Equal(t, "value<5", thresholds["my_metric1"].Thresholds[0].Source)
+}
+
+func TestReaderErrors(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string]string{
+		"not json":           `{"type":`,
+		"unknown type":       `{"type":"Foo","data":{}}`,
+		"unknown metric":     `{"type":"Point","data":{"time":"2021-02-24T13:37:10Z","value":1},"metric":"nope"}`,
+		"invalid metric":     `{"type":"Metric","data":{"name":"m","type":"foo","contains":"default"},"metric":"m"}`,
+		"conflicting metric": `{"type":"Metric","data":{"name":"vus","type":"counter","contains":"default"},"metric":"vus"}`,
+	}
+	for name, input := range testCases {
+		input := input
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			registry := metrics.NewRegistry()
+			metrics.RegisterBuiltinMetrics(registry)
+			_, err := NewReader(strings.NewReader("\n"+input), registry).Read()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "line 2:")
+		})
+	}
+}
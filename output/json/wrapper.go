@@ -17,6 +17,7 @@ type sampleEnvelope struct {
 		Value    float64           `json:"value"`
 		Tags     *metrics.TagSet   `json:"tags"`
 		Metadata map[string]string `json:"metadata,omitempty"`
+		Bucket   string            `json:"le,omitempty"`
 	} `json:"data"`
 }
 
@@ -31,6 +32,9 @@ func wrapSample(sample metrics.Sample) sampleEnvelope {
 	s.Data.Value = sample.Value
 	s.Data.Tags = sample.Tags
 	s.Data.Metadata = sample.Metadata
+	if sample.Metric.Type == metrics.Histogram {
+		s.Data.Bucket = metrics.HistogramBucket(sample.Metric.Buckets, sample.Value)
+	}
 	return s
 }
 
@@ -43,6 +47,7 @@ type metricEnvelope struct {
 		Contains   metrics.ValueType    `json:"contains"`
 		Thresholds metrics.Thresholds   `json:"thresholds"`
 		Submetrics []*metrics.Submetric `json:"submetrics"`
+		Buckets    []float64            `json:"buckets,omitempty"`
 	} `json:"data"`
 	Metric string `json:"metric"`
 }
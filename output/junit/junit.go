@@ -0,0 +1,143 @@
+package junit
+
+import (
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ChipArtem/k6/lib"
+	"github.com/ChipArtem/k6/metrics"
+)
+
+type testSuites struct {
+	XMLName  xml.Name    `xml:"testsuites"`
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Time     string      `xml:"time,attr"`
+	Suites   []testSuite `xml:"testsuite"`
+}
+
+type testSuite struct {
+	Name      string     `xml:"name,attr"`
+	Tests     int        `xml:"tests,attr"`
+	Failures  int        `xml:"failures,attr"`
+	Timestamp string     `xml:"timestamp,attr"`
+	TestCases []testCase `xml:"testcase"`
+}
+
+type testCase struct {
+	Name      string   `xml:"name,attr"`
+	ClassName string   `xml:"classname,attr"`
+	Failure   *failure `xml:"failure,omitempty"`
+	SystemOut string   `xml:"system-out,omitempty"`
+}
+
+type failure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func (s *testSuite) add(tc testCase) {
+	s.Tests++
+	if tc.Failure != nil {
+		s.Failures++
+	}
+	s.TestCases = append(s.TestCases, tc)
+}
+
+// newTestSuites returns the JUnit report, with a test suite for the
+// thresholds and one for each group with checks.
+func newTestSuites(
+	start time.Time, duration time.Duration, thresholds map[string]metrics.Thresholds, rootGroup *lib.Group,
+) testSuites {
+	timestamp := start.UTC().Format("2006-01-02T15:04:05")
+	result := testSuites{
+		Name: "k6",
+		Time: strconv.FormatFloat(duration.Seconds(), 'f', 3, 64),
+	}
+
+	if len(thresholds) > 0 {
+		suite := thresholdsSuite(thresholds)
+		suite.Timestamp = timestamp
+		result.Suites = append(result.Suites, suite)
+	}
+
+	var walk func(g *lib.Group)
+	walk = func(g *lib.Group) {
+		if len(g.OrderedChecks) > 0 {
+			suite := checksSuite(g)
+			suite.Timestamp = timestamp
+			result.Suites = append(result.Suites, suite)
+		}
+		for _, child := range g.OrderedGroups {
+			walk(child)
+		}
+	}
+	walk(rootGroup)
+
+	for _, s := range result.Suites {
+		result.Tests += s.Tests
+		result.Failures += s.Failures
+	}
+	return result
+}
+
+// thresholdsSuite returns a test case for each threshold, which fails if the
+// threshold was breached, sorted by the metric name.
+func thresholdsSuite(thresholds map[string]metrics.Thresholds) testSuite {
+	names := make([]string, 0, len(thresholds))
+	for name := range thresholds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	suite := testSuite{Name: "thresholds"}
+	for _, name := range names {
+		for _, t := range thresholds[name].Thresholds {
+			observed := "no value"
+			if t.LastValue.Valid {
+				observed = "observed value " + strconv.FormatFloat(t.LastValue.Float64, 'g', -1, 64)
+			}
+
+			tc := testCase{
+				Name:      name + ": " + t.Source,
+				ClassName: "thresholds." + name,
+				SystemOut: observed,
+			}
+			if t.LastFailed {
+				tc.Failure = &failure{
+					Message: fmt.Sprintf("threshold '%s' on metric '%s' was breached, %s", t.Source, name, observed),
+					Type:    "threshold",
+				}
+			}
+			suite.add(tc)
+		}
+	}
+	return suite
+}
+
+// checksSuite returns a test case for each check of the group, which fails if
+// the check failed at least once.
+func checksSuite(g *lib.Group) testSuite {
+	name := "checks" + g.Path
+	suite := testSuite{Name: name}
+	for _, c := range g.OrderedChecks {
+		tc := testCase{
+			Name:      c.Name,
+			ClassName: name,
+			SystemOut: fmt.Sprintf("passes: %d, fails: %d", c.Passes, c.Fails),
+		}
+		if c.Fails > 0 {
+			tc.Failure = &failure{
+				Message: fmt.Sprintf("%d of %d checks failed", c.Fails, c.Passes+c.Fails),
+				Type:    "check",
+			}
+		}
+		suite.add(tc)
+	}
+	return suite
+}
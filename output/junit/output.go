@@ -0,0 +1,121 @@
+// Package junit implements an output that writes the results of the
+// thresholds and checks as a JUnit XML report, which CI systems understand.
+package junit
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/ChipArtem/k6/lib"
+	"github.com/ChipArtem/k6/metrics"
+	"github.com/ChipArtem/k6/output"
+)
+
+const (
+	flushPeriod     = 200 * time.Millisecond
+	defaultFilename = "junit.xml"
+)
+
+// Output collects the results of the checks from their samples and writes the
+// report, along with the results of the thresholds, when it's stopped.
+type Output struct {
+	output.SampleBuffer
+	*output.ReportFile
+
+	periodicFlusher *output.PeriodicFlusher
+
+	logger    logrus.FieldLogger
+	start     time.Time
+	rootGroup *lib.Group
+}
+
+var (
+	_ output.Output         = new(Output)
+	_ output.WithThresholds = new(Output)
+)
+
+// New returns a new JUnit output.
+func New(params output.Params) (output.Output, error) {
+	rootGroup, err := lib.NewGroup("", nil)
+	if err != nil {
+		return nil, err
+	}
+	reportFile := output.NewReportFile(params, defaultFilename)
+	return &Output{
+		ReportFile: reportFile,
+		rootGroup:  rootGroup,
+		logger: params.Logger.WithFields(logrus.Fields{
+			"output":   "junit",
+			"filename": reportFile.Filename,
+		}),
+	}, nil
+}
+
+// Description returns a human-readable description of the output.
+func (o *Output) Description() string {
+	return fmt.Sprintf("junit (%s)", o.Filename)
+}
+
+// Start creates the report file and starts the goroutine for collecting the
+// check results.
+func (o *Output) Start() error {
+	o.logger.Debug("Starting...")
+
+	if err := o.Create(); err != nil {
+		return err
+	}
+	o.start = time.Now()
+
+	pf, err := output.NewPeriodicFlusher(flushPeriod, o.flushMetrics)
+	if err != nil {
+		_ = o.Close()
+		return err
+	}
+	o.logger.Debug("Started!")
+	o.periodicFlusher = pf
+
+	return nil
+}
+
+// Stop collects any remaining check results and writes the report.
+func (o *Output) Stop() error {
+	o.logger.Debug("Stopping...")
+	defer o.logger.Debug("Stopped!")
+	o.periodicFlusher.Stop()
+
+	suites := newTestSuites(o.start, time.Since(o.start), o.Thresholds, o.rootGroup)
+
+	err := o.Write(func(w io.Writer) error {
+		if _, err := io.WriteString(w, xml.Header); err != nil {
+			return err
+		}
+		enc := xml.NewEncoder(w)
+		enc.Indent("", "  ")
+		if err := enc.Encode(suites); err != nil {
+			return err
+		}
+		_, err := io.WriteString(w, "\n")
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't write the JUnit report: %w", err)
+	}
+	return nil
+}
+
+func (o *Output) flushMetrics() {
+	for _, sc := range o.GetBufferedSamples() {
+		for _, sample := range sc.GetSamples() {
+			if sample.Metric.Name != metrics.ChecksName {
+				continue
+			}
+			if err := o.rootGroup.AddCheckSample(sample); err != nil {
+				o.logger.WithError(err).Warn("Couldn't add the check result")
+			}
+		}
+	}
+}
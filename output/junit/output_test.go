@@ -0,0 +1,135 @@
+package junit
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/ChipArtem/k6/lib/fsext"
+	"github.com/ChipArtem/k6/lib/testutils"
+	"github.com/ChipArtem/k6/metrics"
+	"github.com/ChipArtem/k6/output"
+)
+
+func TestOutput(t *testing.T) {
+	t.Parallel()
+
+	fs := fsext.NewMemMapFs()
+	o, err := New(output.Params{
+		Logger:         testutils.NewLogger(t),
+		FS:             fs,
+		ConfigArgument: "/results.xml",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "junit (/results.xml)", o.Description())
+
+	durationThresholds := metrics.NewThresholds([]string{"p(95)<500", "avg<100"})
+	durationThresholds.Thresholds[0].LastValue = null.FloatFrom(123.5)
+	durationThresholds.Thresholds[1].LastValue = null.FloatFrom(150)
+	durationThresholds.Thresholds[1].LastFailed = true
+	o.(output.WithThresholds).SetThresholds(map[string]metrics.Thresholds{
+		"http_req_failed":   metrics.NewThresholds([]string{"rate<0.01"}),
+		"http_req_duration": durationThresholds,
+	})
+
+	require.NoError(t, o.Start())
+
+	registry := metrics.NewRegistry()
+	checks := registry.MustNewMetric(metrics.ChecksName, metrics.Rate)
+	check := func(group, name string, value float64) metrics.Sample {
+		return metrics.Sample{
+			TimeSeries: metrics.TimeSeries{
+				Metric: checks,
+				Tags:   registry.RootTagSet().With("group", group).With("check", name),
+			},
+			Time:  time.Now(),
+			Value: value,
+		}
+	}
+	o.AddMetricSamples([]metrics.SampleContainer{metrics.Samples{
+		check("", "is up", 1),
+		check("::login", "status is 200", 1),
+		check("::login", "status is 200", 0),
+		check("::login", "has <token>", 1),
+		check("::login::mfa", "code accepted", 1),
+	}})
+	require.NoError(t, o.Stop())
+
+	report, err := fsext.ReadFile(fs, "/results.xml")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(report), xml.Header), string(report))
+
+	var suites testSuites
+	require.NoError(t, xml.Unmarshal(report, &suites))
+	assert.Equal(t, "k6", suites.Name)
+	assert.Equal(t, 7, suites.Tests)
+	assert.Equal(t, 2, suites.Failures)
+
+	require.Len(t, suites.Suites, 4)
+	names := make([]string, 0, len(suites.Suites))
+	for _, s := range suites.Suites {
+		names = append(names, s.Name)
+	}
+	assert.Equal(t, []string{"thresholds", "checks", "checks::login", "checks::login::mfa"}, names)
+
+	thresholds := suites.Suites[0]
+	assert.Equal(t, 3, thresholds.Tests)
+	assert.Equal(t, 1, thresholds.Failures)
+	assert.Equal(t, testCase{
+		Name:      "http_req_duration: p(95)<500",
+		ClassName: "thresholds.http_req_duration",
+		SystemOut: "observed value 123.5",
+	}, thresholds.TestCases[0])
+	assert.Equal(t, testCase{
+		Name:      "http_req_duration: avg<100",
+		ClassName: "thresholds.http_req_duration",
+		SystemOut: "observed value 150",
+		Failure: &failure{
+			Message: "threshold 'avg<100' on metric 'http_req_duration' was breached, observed value 150",
+			Type:    "threshold",
+		},
+	}, thresholds.TestCases[1])
+	assert.Equal(t, "no value", thresholds.TestCases[2].SystemOut)
+	assert.Nil(t, thresholds.TestCases[2].Failure)
+
+	login := suites.Suites[2]
+	assert.Equal(t, 2, login.Tests)
+	assert.Equal(t, 1, login.Failures)
+	assert.Equal(t, testCase{
+		Name:      "status is 200",
+		ClassName: "checks::login",
+		SystemOut: "passes: 1, fails: 1",
+		Failure:   &failure{Message: "1 of 2 checks failed", Type: "check"},
+	}, login.TestCases[0])
+	assert.Equal(t, "has <token>", login.TestCases[1].Name)
+	assert.Nil(t, login.TestCases[1].Failure)
+}
+
+func TestOutputNoThresholds(t *testing.T) {
+	t.Parallel()
+
+	fs := fsext.NewMemMapFs()
+	params := output.Params{Logger: testutils.NewLogger(t), FS: fs}
+	params.RuntimeOptions.NoThresholds = null.BoolFrom(true)
+	o, err := New(params)
+	require.NoError(t, err)
+
+	o.(output.WithThresholds).SetThresholds(map[string]metrics.Thresholds{
+		"http_req_duration": metrics.NewThresholds([]string{"p(95)<100"}),
+	})
+	require.NoError(t, o.Start())
+	require.NoError(t, o.Stop())
+
+	report, err := fsext.ReadFile(fs, defaultFilename)
+	require.NoError(t, err)
+
+	var suites testSuites
+	require.NoError(t, xml.Unmarshal(report, &suites))
+	assert.Empty(t, suites.Suites)
+	assert.Equal(t, 0, suites.Tests)
+}
@@ -11,10 +11,16 @@ import (
 // TODO: completely get rid of this, see https://github.com/grafana/k6/issues/2430
 const sendBatchToOutputsRate = 50 * time.Millisecond
 
+// metricSamplesRate is how often the samples of the outputs' own metrics are
+// collected.
+const metricSamplesRate = time.Second
+
 // Manager can be used to manage multiple outputs at the same time.
 type Manager struct {
-	outputs []Output
-	logger  logrus.FieldLogger
+	outputs     []Output
+	filters     []*Filter
+	aggregators []*Aggregator
+	logger      logrus.FieldLogger
 
 	testStopCallback func(error)
 }
@@ -28,6 +34,23 @@ func NewManager(outputs []Output, logger logrus.FieldLogger, testStopCallback fu
 	}
 }
 
+// SetFilters sets the filters of the samples that are sent to the outputs,
+// where filters[i] is the filter of the i-th output given to NewManager. The
+// outputs without a filter, or with a nil one, receive all of the samples. It
+// has to be called before Start().
+func (om *Manager) SetFilters(filters []*Filter) {
+	om.filters = filters
+}
+
+// SetAggregators sets the aggregators of the samples that are sent to the
+// outputs, which are applied after the filters, and are aligned with the
+// outputs in the same way. The aggregated samples that are left when the
+// samples channel is closed are sent to the outputs before the wait()
+// callback of Start() returns. It has to be called before Start().
+func (om *Manager) SetAggregators(aggregators []*Aggregator) {
+	om.aggregators = aggregators
+}
+
 // Start spins up all configured outputs and then starts a new goroutine that
 // pipes metrics from the given samples channel to them.
 //
@@ -47,9 +70,32 @@ func (om *Manager) Start(samplesChan chan metrics.SampleContainer) (wait func(),
 	wg := &sync.WaitGroup{}
 	wg.Add(1)
 
-	sendToOutputs := func(sampleContainers []metrics.SampleContainer) {
-		for _, out := range om.outputs {
-			out.AddMetricSamples(sampleContainers)
+	var filters []WithSampleFilter
+	for _, out := range om.outputs {
+		if filter, ok := out.(WithSampleFilter); ok {
+			filters = append(filters, filter)
+		}
+	}
+
+	sendToOutputs := func(sampleContainers []metrics.SampleContainer, last bool) {
+		for _, filter := range filters {
+			sampleContainers = filter.FilterSamples(sampleContainers)
+		}
+		for i, out := range om.outputs {
+			containers := sampleContainers
+			if i < len(om.filters) && om.filters[i] != nil {
+				containers = om.filters[i].FilterSamples(containers)
+			}
+			if i < len(om.aggregators) && om.aggregators[i] != nil {
+				containers = om.aggregators[i].AggregateSamples(containers)
+				if last {
+					containers = append(containers, om.aggregators[i].Flush()...)
+				}
+				if len(containers) == 0 {
+					continue
+				}
+			}
+			out.AddMetricSamples(containers)
 		}
 	}
 
@@ -63,12 +109,12 @@ func (om *Manager) Start(samplesChan chan metrics.SampleContainer) (wait func(),
 			select {
 			case sampleContainer, ok := <-samplesChan:
 				if !ok {
-					sendToOutputs(buffer)
+					sendToOutputs(buffer, true)
 					return
 				}
 				buffer = append(buffer, sampleContainer)
 			case <-ticker.C:
-				sendToOutputs(buffer)
+				sendToOutputs(buffer, false)
 				buffer = make([]metrics.SampleContainer, 0, cap(buffer))
 			}
 		}
@@ -82,6 +128,55 @@ func (om *Manager) Start(samplesChan chan metrics.SampleContainer) (wait func(),
 	return wait, finish, nil
 }
 
+// StartMetricSamplesEmission spins up a new goroutine that periodically
+// collects the samples of the outputs that implement WithMetricSamples and
+// sends them to the given samples channel, once more when it's stopped. It
+// returns a callback that stops the goroutine and has to be called after the
+// outputs were started and before the samples channel is closed.
+func (om *Manager) StartMetricSamplesEmission(samples chan<- metrics.SampleContainer) (stop func()) {
+	var outs []WithMetricSamples
+	for _, out := range om.outputs {
+		if metricsOut, ok := out.(WithMetricSamples); ok {
+			outs = append(outs, metricsOut)
+		}
+	}
+	if len(outs) == 0 {
+		return func() {}
+	}
+
+	emit := func() {
+		now := time.Now()
+		for _, out := range outs {
+			if outSamples := out.MetricSamples(now); len(outSamples) > 0 {
+				samples <- outSamples
+			}
+		}
+	}
+
+	stopCh := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(metricSamplesRate)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				emit()
+			case <-stopCh:
+				emit()
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(stopCh)
+		<-done
+	}
+}
+
 // startOutputs spins up all configured outputs. If some output fails to start,
 // it stops the already started ones. This may take some time, since some
 // outputs make initial network requests to set up whatever remote services are
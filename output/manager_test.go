@@ -0,0 +1,172 @@
+package output
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ChipArtem/k6/lib/testutils"
+	"github.com/ChipArtem/k6/lib/testutils/mockoutput"
+	"github.com/ChipArtem/k6/metrics"
+)
+
+type filterOutput struct {
+	*mockoutput.MockOutput
+	filter func([]metrics.SampleContainer) []metrics.SampleContainer
+}
+
+func (fo filterOutput) FilterSamples(containers []metrics.SampleContainer) []metrics.SampleContainer {
+	return fo.filter(containers)
+}
+
+func TestManagerSampleFilters(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	metric, err := registry.NewMetric("my_metric", metrics.Counter)
+	require.NoError(t, err)
+
+	first := mockoutput.New()
+	filtering := filterOutput{
+		MockOutput: mockoutput.New(),
+		filter: func(containers []metrics.SampleContainer) []metrics.SampleContainer {
+			var result []metrics.SampleContainer
+			for _, c := range containers {
+				if c.GetSamples()[0].Value > 0 {
+					result = append(result, c)
+				}
+			}
+			return result
+		},
+	}
+
+	manager := NewManager([]Output{first, filtering}, testutils.NewLogger(t), nil)
+
+	samples := make(chan metrics.SampleContainer, 3)
+	wait, finish, err := manager.Start(samples)
+	require.NoError(t, err)
+	for _, v := range []float64{1, 0, 2} {
+		samples <- metrics.Sample{TimeSeries: metrics.TimeSeries{Metric: metric, Tags: registry.RootTagSet()}, Value: v}
+	}
+	close(samples)
+	wait()
+	finish(nil)
+
+	// the samples are filtered before they are sent to any of the outputs
+	for _, out := range []*mockoutput.MockOutput{first, filtering.MockOutput} {
+		require.Len(t, out.Samples, 2)
+		assert.Equal(t, 1.0, out.Samples[0].Value)
+		assert.Equal(t, 2.0, out.Samples[1].Value)
+	}
+}
+
+func TestManagerFilters(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	vus := registry.MustNewMetric("vus", metrics.Gauge)
+	iterations := registry.MustNewMetric("iterations", metrics.Counter)
+
+	filter, err := ParseFilter("exclude=vus")
+	require.NoError(t, err)
+	filtered, unfiltered := mockoutput.New(), mockoutput.New()
+	manager := NewManager([]Output{filtered, unfiltered}, testutils.NewLogger(t), nil)
+	manager.SetFilters([]*Filter{filter})
+
+	samples := make(chan metrics.SampleContainer, 2)
+	wait, finish, err := manager.Start(samples)
+	require.NoError(t, err)
+	for _, m := range []*metrics.Metric{vus, iterations} {
+		samples <- metrics.Sample{TimeSeries: metrics.TimeSeries{Metric: m, Tags: registry.RootTagSet()}, Value: 1}
+	}
+	close(samples)
+	wait()
+	finish(nil)
+
+	require.Len(t, filtered.Samples, 1)
+	assert.Equal(t, iterations, filtered.Samples[0].Metric)
+	assert.Len(t, unfiltered.Samples, 2)
+}
+
+func TestManagerAggregators(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	iterations := registry.MustNewMetric("iterations", metrics.Counter)
+
+	_, aggregator, err := ParsePipeline("aggregate=1m", registry)
+	require.NoError(t, err)
+	aggregated, raw := mockoutput.New(), mockoutput.New()
+	manager := NewManager([]Output{aggregated, raw}, testutils.NewLogger(t), nil)
+	manager.SetAggregators([]*Aggregator{aggregator})
+
+	samples := make(chan metrics.SampleContainer, 3)
+	wait, finish, err := manager.Start(samples)
+	require.NoError(t, err)
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		samples <- metrics.Sample{
+			TimeSeries: metrics.TimeSeries{Metric: iterations, Tags: registry.RootTagSet()},
+			Time:       now,
+			Value:      1,
+		}
+	}
+	close(samples)
+	wait()
+	finish(nil)
+
+	assert.Len(t, raw.Samples, 3)
+	require.Len(t, aggregated.Samples, 2, "the buckets are flushed when the samples channel is closed")
+	for _, s := range aggregated.Samples {
+		assert.Contains(t, []string{"iterations_count", "iterations_sum"}, s.Metric.Name)
+		assert.Equal(t, 3.0, s.Value)
+	}
+}
+
+type metricSamplesOutput struct {
+	*mockoutput.MockOutput
+	samples func(time.Time) metrics.Samples
+}
+
+func (mo metricSamplesOutput) MetricSamples(t time.Time) metrics.Samples {
+	return mo.samples(t)
+}
+
+func TestManagerMetricSamples(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	gauge := registry.MustNewMetric("output_queue", metrics.Gauge)
+
+	withMetrics := metricSamplesOutput{
+		MockOutput: mockoutput.New(),
+		samples: func(t time.Time) metrics.Samples {
+			return metrics.Samples{{
+				TimeSeries: metrics.TimeSeries{Metric: gauge, Tags: registry.RootTagSet()},
+				Time:       t,
+				Value:      7,
+			}}
+		},
+	}
+	other := mockoutput.New()
+	manager := NewManager([]Output{withMetrics, other}, testutils.NewLogger(t), nil)
+
+	samples := make(chan metrics.SampleContainer, 10)
+	wait, finish, err := manager.Start(samples)
+	require.NoError(t, err)
+	stop := manager.StartMetricSamplesEmission(samples)
+	stop()
+	close(samples)
+	wait()
+	finish(nil)
+
+	// the samples are sent through the samples channel, so every output gets
+	// them, even if the emission is stopped before the first tick
+	for _, out := range []*mockoutput.MockOutput{withMetrics.MockOutput, other} {
+		require.Len(t, out.Samples, 1)
+		assert.Equal(t, gauge, out.Samples[0].Metric)
+		assert.Equal(t, 7.0, out.Samples[0].Value)
+	}
+}
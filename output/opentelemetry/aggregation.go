@@ -0,0 +1,282 @@
+package opentelemetry
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+
+	"github.com/ChipArtem/k6/lib/consts"
+	"github.com/ChipArtem/k6/metrics"
+)
+
+// series holds the cumulative state of a single time series, since the start
+// of the output.
+type series struct {
+	attributes []*commonpb.KeyValue
+	sortKey    string // the tags, used to sort the data points
+	lastTime   time.Time
+
+	value    float64 // the sum of Counters and the last value of Gauges
+	occurred uint64  // the non-zero values of Rates
+	total    uint64  // all the values of Rates
+
+	expHistogram *expHistogram // for Trends
+	buckets      []uint64      // for native Histograms
+	sum          float64
+	count        uint64
+	min, max     float64
+}
+
+func newSeries(tags *metrics.TagSet) *series {
+	m := tags.Map()
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	s := &series{attributes: make([]*commonpb.KeyValue, 0, len(keys))}
+	var sortKey strings.Builder
+	for _, k := range keys {
+		s.attributes = append(s.attributes, stringAttribute(k, m[k]))
+		sortKey.WriteString(k + "=" + m[k] + "\x00")
+	}
+	s.sortKey = sortKey.String()
+	return s
+}
+
+func (s *series) add(sample metrics.Sample) {
+	if sample.Time.After(s.lastTime) {
+		s.lastTime = sample.Time
+	}
+
+	switch sample.Metric.Type {
+	case metrics.Counter:
+		s.value += sample.Value
+	case metrics.Gauge:
+		s.value = sample.Value
+	case metrics.Rate:
+		s.total++
+		if sample.Value != 0 {
+			s.occurred++
+		}
+	case metrics.Trend:
+		if s.expHistogram == nil {
+			s.expHistogram = newExpHistogram()
+		}
+		s.expHistogram.record(sample.Value)
+	case metrics.Histogram:
+		if s.buckets == nil {
+			s.buckets = make([]uint64, len(sample.Metric.Buckets)+1)
+		}
+		s.buckets[sort.SearchFloat64s(sample.Metric.Buckets, sample.Value)]++
+		if s.count == 0 || sample.Value < s.min {
+			s.min = sample.Value
+		}
+		if s.count == 0 || sample.Value > s.max {
+			s.max = sample.Value
+		}
+		s.count++
+		s.sum += sample.Value
+	}
+}
+
+// aggregator keeps the cumulative state of all the time series and converts
+// the ones that were updated since the last successful export to OTLP.
+type aggregator struct {
+	serviceName string
+	startTime   time.Time
+
+	series  map[metrics.TimeSeries]*series
+	updated map[metrics.TimeSeries]struct{}
+}
+
+func newAggregator(serviceName string, startTime time.Time) *aggregator {
+	return &aggregator{
+		serviceName: serviceName,
+		startTime:   startTime,
+		series:      make(map[metrics.TimeSeries]*series),
+		updated:     make(map[metrics.TimeSeries]struct{}),
+	}
+}
+
+func (a *aggregator) add(containers []metrics.SampleContainer) {
+	for _, container := range containers {
+		for _, sample := range container.GetSamples() {
+			s, ok := a.series[sample.TimeSeries]
+			if !ok {
+				s = newSeries(sample.Tags)
+				a.series[sample.TimeSeries] = s
+			}
+			s.add(sample)
+			a.updated[sample.TimeSeries] = struct{}{}
+		}
+	}
+}
+
+// hasUpdates returns true if some of the time series were updated since the
+// last call to reset.
+func (a *aggregator) hasUpdates() bool {
+	return len(a.updated) > 0
+}
+
+// reset marks all of the time series as exported.
+func (a *aggregator) reset() {
+	a.updated = make(map[metrics.TimeSeries]struct{})
+}
+
+// request returns the export request with the current values of all the
+// updated time series. The metrics are sorted by name.
+func (a *aggregator) request() *colmetricspb.ExportMetricsServiceRequest {
+	byMetric := make(map[*metrics.Metric][]*series)
+	for ts := range a.updated {
+		byMetric[ts.Metric] = append(byMetric[ts.Metric], a.series[ts])
+	}
+
+	sorted := make([]*metrics.Metric, 0, len(byMetric))
+	for m := range byMetric {
+		sorted = append(sorted, m)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	start := uint64(a.startTime.UnixNano())
+	var result []*metricpb.Metric
+	for _, m := range sorted {
+		ss := byMetric[m]
+		sort.Slice(ss, func(i, j int) bool {
+			return ss[i].sortKey < ss[j].sortKey
+		})
+		result = append(result, convertMetric(m, ss, start)...)
+	}
+
+	return &colmetricspb.ExportMetricsServiceRequest{
+		ResourceMetrics: []*metricpb.ResourceMetrics{{
+			Resource: &resourcepb.Resource{
+				Attributes: []*commonpb.KeyValue{stringAttribute("service.name", a.serviceName)},
+			},
+			ScopeMetrics: []*metricpb.ScopeMetrics{{
+				Scope:   &commonpb.InstrumentationScope{Name: "k6", Version: consts.Version},
+				Metrics: result,
+			}},
+		}},
+	}
+}
+
+// convertMetric returns the OTLP metrics for the provided series of a k6
+// metric. Rates are exported as two sums, with the number of non-zero values
+// and the number of all values, since OTLP doesn't have a matching type.
+func convertMetric(m *metrics.Metric, ss []*series, start uint64) []*metricpb.Metric {
+	unit := metricUnit(m.Contains)
+
+	switch m.Type {
+	case metrics.Counter:
+		return []*metricpb.Metric{newSum(m.Name, unit, ss, start, func(s *series) float64 { return s.value })}
+	case metrics.Gauge:
+		points := make([]*metricpb.NumberDataPoint, 0, len(ss))
+		for _, s := range ss {
+			points = append(points, newNumberDataPoint(s, start, s.value))
+		}
+		return []*metricpb.Metric{{
+			Name: m.Name,
+			Unit: unit,
+			Data: &metricpb.Metric_Gauge{Gauge: &metricpb.Gauge{DataPoints: points}},
+		}}
+	case metrics.Rate:
+		return []*metricpb.Metric{
+			newSum(m.Name+".occurred", "", ss, start, func(s *series) float64 { return float64(s.occurred) }),
+			newSum(m.Name+".total", "", ss, start, func(s *series) float64 { return float64(s.total) }),
+		}
+	case metrics.Trend:
+		points := make([]*metricpb.ExponentialHistogramDataPoint, 0, len(ss))
+		for _, s := range ss {
+			point := s.expHistogram.dataPoint()
+			point.Attributes = s.attributes
+			point.StartTimeUnixNano = start
+			point.TimeUnixNano = uint64(s.lastTime.UnixNano())
+			points = append(points, point)
+		}
+		return []*metricpb.Metric{{
+			Name: m.Name,
+			Unit: unit,
+			Data: &metricpb.Metric_ExponentialHistogram{ExponentialHistogram: &metricpb.ExponentialHistogram{
+				DataPoints:             points,
+				AggregationTemporality: metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+			}},
+		}}
+	case metrics.Histogram:
+		points := make([]*metricpb.HistogramDataPoint, 0, len(ss))
+		for _, s := range ss {
+			sum, minValue, maxValue := s.sum, s.min, s.max
+			points = append(points, &metricpb.HistogramDataPoint{
+				Attributes:        s.attributes,
+				StartTimeUnixNano: start,
+				TimeUnixNano:      uint64(s.lastTime.UnixNano()),
+				Count:             s.count,
+				Sum:               &sum,
+				BucketCounts:      append([]uint64(nil), s.buckets...),
+				ExplicitBounds:    m.Buckets,
+				Min:               &minValue,
+				Max:               &maxValue,
+			})
+		}
+		return []*metricpb.Metric{{
+			Name: m.Name,
+			Unit: unit,
+			Data: &metricpb.Metric_Histogram{Histogram: &metricpb.Histogram{
+				DataPoints:             points,
+				AggregationTemporality: metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+			}},
+		}}
+	default:
+		return nil
+	}
+}
+
+func newSum(name, unit string, ss []*series, start uint64, value func(*series) float64) *metricpb.Metric {
+	points := make([]*metricpb.NumberDataPoint, 0, len(ss))
+	for _, s := range ss {
+		points = append(points, newNumberDataPoint(s, start, value(s)))
+	}
+	return &metricpb.Metric{
+		Name: name,
+		Unit: unit,
+		Data: &metricpb.Metric_Sum{Sum: &metricpb.Sum{
+			DataPoints:             points,
+			AggregationTemporality: metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+			IsMonotonic:            true,
+		}},
+	}
+}
+
+func newNumberDataPoint(s *series, start uint64, value float64) *metricpb.NumberDataPoint {
+	return &metricpb.NumberDataPoint{
+		Attributes:        s.attributes,
+		StartTimeUnixNano: start,
+		TimeUnixNano:      uint64(s.lastTime.UnixNano()),
+		Value:             &metricpb.NumberDataPoint_AsDouble{AsDouble: value},
+	}
+}
+
+// metricUnit returns the UCUM unit for the values of a metric.
+func metricUnit(vt metrics.ValueType) string {
+	switch vt {
+	case metrics.Time:
+		return "ms"
+	case metrics.Data:
+		return "By"
+	default:
+		return ""
+	}
+}
+
+func stringAttribute(key, value string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{
+		Key:   key,
+		Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}},
+	}
+}
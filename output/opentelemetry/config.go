@@ -0,0 +1,183 @@
+package opentelemetry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mstoykov/envconfig"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/ChipArtem/k6/lib/types"
+)
+
+// The supported OTLP transport protocols.
+const (
+	protocolGRPC = "grpc"
+	protocolHTTP = "http"
+)
+
+// Config is the config for the OpenTelemetry output.
+type Config struct {
+	// Protocol is either grpc or http, the latter sends protobuf payloads
+	Protocol null.String `json:"protocol" envconfig:"K6_OTEL_PROTOCOL"`
+	// Endpoint is the host and port of the collector, the default port
+	// depends on the protocol
+	Endpoint     null.String        `json:"endpoint" envconfig:"K6_OTEL_ENDPOINT"`
+	URLPath      null.String        `json:"urlPath" envconfig:"K6_OTEL_URL_PATH"`
+	Insecure     null.Bool          `json:"insecure" envconfig:"K6_OTEL_INSECURE"`
+	Headers      map[string]string  `json:"headers" envconfig:"K6_OTEL_HEADERS"`
+	PushInterval types.NullDuration `json:"pushInterval" envconfig:"K6_OTEL_PUSH_INTERVAL"`
+	ServiceName  null.String        `json:"serviceName" envconfig:"K6_OTEL_SERVICE_NAME"`
+}
+
+// NewConfig creates a new OpenTelemetry output config with some default values.
+func NewConfig() Config {
+	return Config{
+		Protocol:     null.NewString(protocolGRPC, false),
+		URLPath:      null.NewString("/v1/metrics", false),
+		Insecure:     null.NewBool(false, false),
+		PushInterval: types.NewNullDuration(time.Second, false),
+		ServiceName:  null.NewString("k6", false),
+	}
+}
+
+// Apply merges the valid values of the provided config into this one.
+func (c Config) Apply(cfg Config) Config {
+	if cfg.Protocol.Valid {
+		c.Protocol = cfg.Protocol
+	}
+	if cfg.Endpoint.Valid {
+		c.Endpoint = cfg.Endpoint
+	}
+	if cfg.URLPath.Valid {
+		c.URLPath = cfg.URLPath
+	}
+	if cfg.Insecure.Valid {
+		c.Insecure = cfg.Insecure
+	}
+	if len(cfg.Headers) > 0 {
+		c.Headers = cfg.Headers
+	}
+	if cfg.PushInterval.Valid {
+		c.PushInterval = cfg.PushInterval
+	}
+	if cfg.ServiceName.Valid {
+		c.ServiceName = cfg.ServiceName
+	}
+	return c
+}
+
+// endpoint returns the configured endpoint, or the default one for the
+// configured protocol.
+func (c Config) endpoint() string {
+	if c.Endpoint.Valid && c.Endpoint.String != "" {
+		return c.Endpoint.String
+	}
+	if c.Protocol.String == protocolHTTP {
+		return "localhost:4318"
+	}
+	return "localhost:4317"
+}
+
+// Validate checks that the config values are usable.
+func (c Config) Validate() error {
+	if c.Protocol.String != protocolGRPC && c.Protocol.String != protocolHTTP {
+		return fmt.Errorf("invalid protocol %q, use %q or %q", c.Protocol.String, protocolGRPC, protocolHTTP)
+	}
+	if c.PushInterval.Duration <= 0 {
+		return errors.New("the push interval should be positive")
+	}
+	return nil
+}
+
+// ParseJSON parses the supplied JSON into a Config.
+func ParseJSON(data json.RawMessage) (Config, error) {
+	conf := Config{}
+	err := json.Unmarshal(data, &conf)
+	return conf, err
+}
+
+// ParseURL parses the supplied URL into a Config. The scheme sets both the
+// protocol and whether TLS is used: http and https for the HTTP protocol, and
+// grpc and grpcs for the gRPC one. The path is only used by the HTTP protocol.
+func ParseURL(text string) (Config, error) {
+	c := Config{}
+	u, err := url.Parse(text)
+	if err != nil {
+		return c, err
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+		c.Protocol = null.StringFrom(protocolHTTP)
+		if u.Path != "" {
+			c.URLPath = null.StringFrom(u.Path)
+		}
+	case "grpc", "grpcs":
+		c.Protocol = null.StringFrom(protocolGRPC)
+		if u.Path != "" && u.Path != "/" {
+			return c, errors.New("the grpc protocol doesn't support an URL path")
+		}
+	default:
+		return c, fmt.Errorf("invalid URL scheme %q, use http, https, grpc or grpcs", u.Scheme)
+	}
+	c.Insecure = null.BoolFrom(u.Scheme == "http" || u.Scheme == "grpc")
+	if u.Host != "" {
+		c.Endpoint = null.StringFrom(u.Host)
+	}
+
+	for k, vs := range u.Query() {
+		switch {
+		case k == "pushInterval":
+			if err = c.PushInterval.UnmarshalText([]byte(vs[0])); err != nil {
+				return c, err
+			}
+		case k == "serviceName":
+			c.ServiceName = null.StringFrom(vs[0])
+		case strings.HasPrefix(k, "header."):
+			if c.Headers == nil {
+				c.Headers = make(map[string]string)
+			}
+			c.Headers[strings.TrimPrefix(k, "header.")] = vs[0]
+		default:
+			return c, fmt.Errorf("unknown query parameter: %s", k)
+		}
+	}
+	return c, nil
+}
+
+// GetConsolidatedConfig combines {default config values + JSON config +
+// environment vars + URL config values}, and returns the final result.
+func GetConsolidatedConfig(jsonRawConf json.RawMessage, env map[string]string, url string) (Config, error) {
+	result := NewConfig()
+	if jsonRawConf != nil {
+		jsonConf, err := ParseJSON(jsonRawConf)
+		if err != nil {
+			return result, err
+		}
+		result = result.Apply(jsonConf)
+	}
+
+	envConfig := Config{}
+	if err := envconfig.Process("", &envConfig, func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}); err != nil {
+		return result, err
+	}
+	result = result.Apply(envConfig)
+
+	if url != "" {
+		urlConf, err := ParseURL(url)
+		if err != nil {
+			return result, err
+		}
+		result = result.Apply(urlConf)
+	}
+
+	return result, result.Validate()
+}
@@ -0,0 +1,121 @@
+package opentelemetry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/ChipArtem/k6/lib/types"
+)
+
+func TestParseURL(t *testing.T) {
+	t.Parallel()
+	testCases := map[string]struct {
+		config Config
+		err    string
+	}{
+		"grpc://localhost:4317": {config: Config{
+			Protocol: null.StringFrom("grpc"),
+			Endpoint: null.StringFrom("localhost:4317"),
+			Insecure: null.BoolFrom(true),
+		}},
+		"grpcs://collector.example.com": {config: Config{
+			Protocol: null.StringFrom("grpc"),
+			Endpoint: null.StringFrom("collector.example.com"),
+			Insecure: null.BoolFrom(false),
+		}},
+		"https://collector.example.com/otlp/v1/metrics?pushInterval=5s&serviceName=load": {config: Config{
+			Protocol:     null.StringFrom("http"),
+			Endpoint:     null.StringFrom("collector.example.com"),
+			URLPath:      null.StringFrom("/otlp/v1/metrics"),
+			Insecure:     null.BoolFrom(false),
+			PushInterval: types.NullDurationFrom(5 * time.Second),
+			ServiceName:  null.StringFrom("load"),
+		}},
+		"http://localhost:4318?header.Authorization=Bearer+token": {config: Config{
+			Protocol: null.StringFrom("http"),
+			Endpoint: null.StringFrom("localhost:4318"),
+			Insecure: null.BoolFrom(true),
+			Headers:  map[string]string{"Authorization": "Bearer token"},
+		}},
+		"grpc://localhost:4317/v1/metrics": {err: "the grpc protocol doesn't support an URL path"},
+		"udp://localhost:4317":             {err: `invalid URL scheme "udp", use http, https, grpc or grpcs`},
+		"grpc://localhost:4317?foo=bar":    {err: "unknown query parameter: foo"},
+		"grpc://localhost:4317?pushInterval=a": {
+			err: `time: invalid duration "a"`,
+		},
+	}
+
+	for input, tc := range testCases {
+		input, tc := input, tc
+		t.Run(input, func(t *testing.T) {
+			t.Parallel()
+			config, err := ParseURL(input)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.config, config)
+		})
+	}
+}
+
+func TestGetConsolidatedConfig(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Defaults", func(t *testing.T) {
+		t.Parallel()
+		config, err := GetConsolidatedConfig(nil, nil, "")
+		require.NoError(t, err)
+		assert.Equal(t, NewConfig(), config)
+		assert.Equal(t, "localhost:4317", config.endpoint())
+	})
+
+	t.Run("Precedence", func(t *testing.T) {
+		t.Parallel()
+		config, err := GetConsolidatedConfig(
+			[]byte(`{"protocol":"http","endpoint":"json:4318","serviceName":"json"}`),
+			map[string]string{
+				"K6_OTEL_ENDPOINT":      "env:4318",
+				"K6_OTEL_PUSH_INTERVAL": "3s",
+				"K6_OTEL_HEADERS":       "X-Scope-OrgID:k6",
+			},
+			"",
+		)
+		require.NoError(t, err)
+		assert.Equal(t, "http", config.Protocol.String)
+		assert.Equal(t, "env:4318", config.endpoint())
+		assert.Equal(t, "json", config.ServiceName.String)
+		assert.Equal(t, types.NullDurationFrom(3*time.Second), config.PushInterval)
+		assert.Equal(t, map[string]string{"X-Scope-OrgID": "k6"}, config.Headers)
+
+		config, err = GetConsolidatedConfig(
+			[]byte(`{"protocol":"http","endpoint":"json:4318"}`),
+			map[string]string{"K6_OTEL_ENDPOINT": "env:4318"},
+			"grpc://url:4317",
+		)
+		require.NoError(t, err)
+		assert.Equal(t, "grpc", config.Protocol.String)
+		assert.Equal(t, "url:4317", config.endpoint())
+	})
+
+	t.Run("DefaultHTTPEndpoint", func(t *testing.T) {
+		t.Parallel()
+		config, err := GetConsolidatedConfig(nil, map[string]string{"K6_OTEL_PROTOCOL": "http"}, "")
+		require.NoError(t, err)
+		assert.Equal(t, "localhost:4318", config.endpoint())
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		t.Parallel()
+		_, err := GetConsolidatedConfig(nil, map[string]string{"K6_OTEL_PROTOCOL": "udp"}, "")
+		assert.EqualError(t, err, `invalid protocol "udp", use "grpc" or "http"`)
+
+		_, err = GetConsolidatedConfig(nil, map[string]string{"K6_OTEL_PUSH_INTERVAL": "0s"}, "")
+		assert.EqualError(t, err, "the push interval should be positive")
+	})
+}
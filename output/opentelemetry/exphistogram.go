@@ -0,0 +1,161 @@
+package opentelemetry
+
+import (
+	"math"
+
+	metricpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+)
+
+const (
+	// expHistogramMaxScale is the scale new exponential histograms start with,
+	// it's reduced as needed to keep the number of buckets under the limit.
+	expHistogramMaxScale = 20
+	// expHistogramMaxSize is the maximum number of buckets for each one of
+	// the positive and negative ranges.
+	expHistogramMaxSize = 160
+)
+
+// expHistogram is an OTLP exponential histogram, the buckets have a base of
+// 2^(2^-scale) and their indexes are the ones defined by the specification.
+type expHistogram struct {
+	scale     int32
+	count     uint64
+	zeroCount uint64
+	sum       float64
+	min, max  float64
+
+	positive expBuckets
+	negative expBuckets
+}
+
+func newExpHistogram() *expHistogram {
+	return &expHistogram{scale: expHistogramMaxScale}
+}
+
+func (h *expHistogram) record(v float64) {
+	if h.count == 0 || v < h.min {
+		h.min = v
+	}
+	if h.count == 0 || v > h.max {
+		h.max = v
+	}
+	h.count++
+	h.sum += v
+
+	if v == 0 {
+		h.zeroCount++
+		return
+	}
+
+	b := &h.positive
+	if v < 0 {
+		b = &h.negative
+		v = -v
+	}
+
+	index := mapToIndex(v, h.scale)
+	low, high := b.span(index)
+	var change int32
+	for (high>>change)-(low>>change)+1 > expHistogramMaxSize {
+		change++
+	}
+	if change > 0 {
+		h.scale -= change
+		h.positive.downscale(change)
+		h.negative.downscale(change)
+		index = mapToIndex(v, h.scale)
+	}
+	b.increment(index)
+}
+
+func (h *expHistogram) dataPoint() *metricpb.ExponentialHistogramDataPoint {
+	sum, minValue, maxValue := h.sum, h.min, h.max
+	return &metricpb.ExponentialHistogramDataPoint{
+		Count:     h.count,
+		Sum:       &sum,
+		Scale:     h.scale,
+		ZeroCount: h.zeroCount,
+		Positive:  h.positive.proto(),
+		Negative:  h.negative.proto(),
+		Min:       &minValue,
+		Max:       &maxValue,
+	}
+}
+
+// mapToIndex returns the index of the bucket that contains the provided
+// positive value. The buckets are lower-exclusive, so exact powers of two are
+// mapped to the bucket below them.
+func mapToIndex(v float64, scale int32) int32 {
+	frac, exp := math.Frexp(v) // v = frac * 2^exp, with frac in [0.5, 1)
+	if scale <= 0 {
+		e := int32(exp - 1)
+		if frac == 0.5 {
+			e--
+		}
+		return e >> -scale
+	}
+	if frac == 0.5 {
+		return (int32(exp-1) << scale) - 1
+	}
+	return int32(math.Ceil(math.Log(v)*math.Ldexp(math.Log2E, int(scale)))) - 1
+}
+
+// expBuckets are the consecutive buckets of one of the ranges of an
+// exponential histogram, starting with the one at offset.
+type expBuckets struct {
+	offset int32
+	counts []uint64
+}
+
+// span returns the lowest and highest indexes the buckets would have after
+// adding the provided one.
+func (b *expBuckets) span(index int32) (low, high int32) {
+	if len(b.counts) == 0 {
+		return index, index
+	}
+	low, high = b.offset, b.offset+int32(len(b.counts))-1
+	if index < low {
+		low = index
+	}
+	if index > high {
+		high = index
+	}
+	return low, high
+}
+
+func (b *expBuckets) increment(index int32) {
+	switch {
+	case len(b.counts) == 0:
+		b.offset = index
+		b.counts = []uint64{0}
+	case index < b.offset:
+		counts := make([]uint64, int(b.offset-index)+len(b.counts))
+		copy(counts[b.offset-index:], b.counts)
+		b.counts = counts
+		b.offset = index
+	case index >= b.offset+int32(len(b.counts)):
+		b.counts = append(b.counts, make([]uint64, int(index-b.offset)-len(b.counts)+1)...)
+	}
+	b.counts[index-b.offset]++
+}
+
+// downscale merges the buckets for a scale reduced by the provided change.
+func (b *expBuckets) downscale(change int32) {
+	if len(b.counts) == 0 {
+		return
+	}
+	offset := b.offset >> change
+	last := (b.offset + int32(len(b.counts)) - 1) >> change
+	counts := make([]uint64, last-offset+1)
+	for i, c := range b.counts {
+		counts[((b.offset+int32(i))>>change)-offset] += c
+	}
+	b.offset, b.counts = offset, counts
+}
+
+func (b *expBuckets) proto() *metricpb.ExponentialHistogramDataPoint_Buckets {
+	return &metricpb.ExponentialHistogramDataPoint_Buckets{
+		Offset:       b.offset,
+		BucketCounts: append([]uint64(nil), b.counts...),
+	}
+}
@@ -0,0 +1,91 @@
+package opentelemetry
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMapToIndex(t *testing.T) {
+	t.Parallel()
+	testCases := []struct {
+		value    float64
+		scale    int32
+		expected int32
+	}{
+		{value: 1, scale: 0, expected: -1},
+		{value: 1.5, scale: 0, expected: 0},
+		{value: 2, scale: 0, expected: 0},
+		{value: 3, scale: 0, expected: 1},
+		{value: 4, scale: 0, expected: 1},
+		{value: 0.5, scale: 0, expected: -2},
+		{value: 1024, scale: -2, expected: 2},
+		{value: 1025, scale: -2, expected: 2},
+		{value: 4, scale: 1, expected: 3},
+		{value: 5, scale: 1, expected: 4},
+		{value: 6, scale: 1, expected: 5},
+		{value: 1.5, scale: 1, expected: 1},
+		{value: 2, scale: 20, expected: 1<<20 - 1},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, mapToIndex(tc.value, tc.scale), "value %v, scale %d", tc.value, tc.scale)
+	}
+}
+
+func TestExpHistogram(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Values", func(t *testing.T) {
+		t.Parallel()
+		h := newExpHistogram()
+		for _, v := range []float64{0, 2, -2, 2, 7} {
+			h.record(v)
+		}
+		p := h.dataPoint()
+		assert.Equal(t, uint64(5), p.Count)
+		assert.Equal(t, uint64(1), p.ZeroCount)
+		assert.Equal(t, 9.0, p.GetSum())
+		assert.Equal(t, -2.0, p.GetMin())
+		assert.Equal(t, 7.0, p.GetMax())
+
+		var positive, negative uint64
+		for _, c := range p.Positive.BucketCounts {
+			positive += c
+		}
+		for _, c := range p.Negative.BucketCounts {
+			negative += c
+		}
+		assert.Equal(t, uint64(3), positive)
+		assert.Equal(t, uint64(1), negative)
+	})
+
+	t.Run("Downscale", func(t *testing.T) {
+		t.Parallel()
+		h := newExpHistogram()
+		for v := 1.0; v < 1e6; v *= 1.1 {
+			h.record(v)
+		}
+		h.record(-1e-3)
+
+		p := h.dataPoint()
+		assert.Less(t, p.Scale, int32(expHistogramMaxScale))
+		require.LessOrEqual(t, len(p.Positive.BucketCounts), expHistogramMaxSize)
+
+		// every value has to be in the bucket with the bounds that contain it
+		base := math.Exp2(math.Exp2(-float64(p.Scale)))
+		lower := math.Pow(base, float64(p.Positive.Offset))
+		upper := math.Pow(base, float64(p.Positive.Offset+int32(len(p.Positive.BucketCounts))))
+		assert.LessOrEqual(t, lower, 1.0)
+		assert.GreaterOrEqual(t, upper, 1e6/1.1)
+
+		var total uint64
+		for _, c := range p.Positive.BucketCounts {
+			total += c
+		}
+		assert.Equal(t, p.Count-1, total)
+		assert.Equal(t, []uint64{1}, p.Negative.BucketCounts)
+	})
+}
@@ -0,0 +1,127 @@
+package opentelemetry
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// exporter sends the metrics to an OTLP collector.
+type exporter interface {
+	Export(ctx context.Context, req *colmetricspb.ExportMetricsServiceRequest) error
+	Close() error
+}
+
+func newExporter(conf Config) (exporter, error) {
+	if conf.Protocol.String == protocolHTTP {
+		return newHTTPExporter(conf), nil
+	}
+	return newGRPCExporter(conf)
+}
+
+type grpcExporter struct {
+	conn    *grpc.ClientConn
+	client  colmetricspb.MetricsServiceClient
+	headers metadata.MD
+}
+
+func newGRPCExporter(conf Config) (*grpcExporter, error) {
+	creds := insecure.NewCredentials()
+	if !conf.Insecure.Bool {
+		creds = credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	}
+
+	// The connection is established lazily, with the first export
+	conn, err := grpc.Dial(conf.endpoint(), grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create the gRPC client for %s: %w", conf.endpoint(), err)
+	}
+
+	return &grpcExporter{
+		conn:    conn,
+		client:  colmetricspb.NewMetricsServiceClient(conn),
+		headers: metadata.New(conf.Headers),
+	}, nil
+}
+
+func (e *grpcExporter) Export(ctx context.Context, req *colmetricspb.ExportMetricsServiceRequest) error {
+	if len(e.headers) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, e.headers)
+	}
+	resp, err := e.client.Export(ctx, req)
+	if err != nil {
+		return err
+	}
+	if rejected := resp.GetPartialSuccess().GetRejectedDataPoints(); rejected > 0 {
+		return fmt.Errorf("the collector rejected %d data points: %s", rejected, resp.GetPartialSuccess().GetErrorMessage())
+	}
+	return nil
+}
+
+func (e *grpcExporter) Close() error {
+	return e.conn.Close()
+}
+
+type httpExporter struct {
+	client  *http.Client
+	url     string
+	headers map[string]string
+}
+
+func newHTTPExporter(conf Config) *httpExporter {
+	scheme := "https"
+	if conf.Insecure.Bool {
+		scheme = "http"
+	}
+	return &httpExporter{
+		client:  &http.Client{},
+		url:     scheme + "://" + conf.endpoint() + conf.URLPath.String,
+		headers: conf.Headers,
+	}
+}
+
+func (e *httpExporter) Export(ctx context.Context, req *colmetricspb.ExportMetricsServiceRequest) error {
+	body, err := proto.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("couldn't marshal the metrics: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/x-protobuf")
+	for k, v := range e.headers {
+		httpReq.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("the collector responded with status %d: %s", resp.StatusCode, msg)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+func (e *httpExporter) Close() error {
+	e.client.CloseIdleConnections()
+	return nil
+}
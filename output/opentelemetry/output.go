@@ -0,0 +1,113 @@
+// Package opentelemetry implements an output that pushes the metrics to an
+// OpenTelemetry collector, using OTLP over gRPC or HTTP.
+package opentelemetry
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/ChipArtem/k6/output"
+)
+
+// exportTimeout is the maximum time a single export request can take.
+const exportTimeout = 10 * time.Second
+
+// Output sends the metrics to an OpenTelemetry collector. All of the values
+// are cumulative since the start of the output: Counters are exported as sums,
+// Gauges as gauges, Trends as exponential histograms and Rates as two sums,
+// with the number of non-zero values and the number of all values.
+type Output struct {
+	output.SampleBuffer
+
+	config          Config
+	logger          logrus.FieldLogger
+	exporter        exporter
+	aggregator      *aggregator
+	periodicFlusher *output.PeriodicFlusher
+}
+
+var _ output.Output = new(Output)
+
+// New returns a new OpenTelemetry output.
+func New(params output.Params) (output.Output, error) {
+	return newOutput(params)
+}
+
+func newOutput(params output.Params) (*Output, error) {
+	conf, err := GetConsolidatedConfig(params.JSONConfig, params.Environment, params.ConfigArgument)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Output{
+		config: conf,
+		logger: params.Logger.WithFields(logrus.Fields{
+			"output": "OpenTelemetry",
+		}),
+	}, nil
+}
+
+// Description returns a human-readable description of the output.
+func (o *Output) Description() string {
+	return fmt.Sprintf("OpenTelemetry (%s %s)", o.config.Protocol.String, o.config.endpoint())
+}
+
+// Start creates the exporter and starts the goroutine for metric flushing.
+func (o *Output) Start() error {
+	o.logger.Debug("Starting...")
+
+	exp, err := newExporter(o.config)
+	if err != nil {
+		return err
+	}
+	o.exporter = exp
+	o.aggregator = newAggregator(o.config.ServiceName.String, time.Now())
+
+	pf, err := output.NewPeriodicFlusher(o.config.PushInterval.TimeDuration(), o.flushMetrics)
+	if err != nil {
+		_ = exp.Close()
+		return err
+	}
+	o.logger.Debug("Started!")
+	o.periodicFlusher = pf
+
+	return nil
+}
+
+// Stop flushes any remaining metrics and closes the exporter.
+func (o *Output) Stop() error {
+	o.logger.Debug("Stopping...")
+	defer o.logger.Debug("Stopped!")
+	o.periodicFlusher.Stop()
+	return o.exporter.Close()
+}
+
+// flushMetrics exports the time series updated since the last successful
+// export. Since the values are cumulative, the ones that failed to be exported
+// are just sent again with the next flush.
+func (o *Output) flushMetrics() {
+	o.aggregator.add(o.GetBufferedSamples())
+	if !o.aggregator.hasUpdates() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	defer cancel()
+
+	startTime := time.Now()
+	if err := o.exporter.Export(ctx, o.aggregator.request()); err != nil {
+		o.logger.WithError(err).Error("Couldn't export the metrics")
+		return
+	}
+	o.aggregator.reset()
+
+	t := time.Since(startTime)
+	o.logger.WithField("t", t).Debug("Metrics exported!")
+	if t > o.config.PushInterval.TimeDuration() {
+		o.logger.WithField("t", t).
+			Warn("The flush operation took higher than the expected set push interval. If you see this message multiple times then the setup or configuration need to be adjusted to achieve a sustainable rate.") //nolint:lll
+	}
+}
@@ -0,0 +1,254 @@
+package opentelemetry
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	metricpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/ChipArtem/k6/lib/testutils"
+	"github.com/ChipArtem/k6/metrics"
+	"github.com/ChipArtem/k6/output"
+)
+
+// collectorStub records all of the export requests it receives.
+type collectorStub struct {
+	colmetricspb.UnimplementedMetricsServiceServer
+
+	mu       sync.Mutex
+	requests []*colmetricspb.ExportMetricsServiceRequest
+	headers  []string
+}
+
+func (c *collectorStub) Export(
+	ctx context.Context, req *colmetricspb.ExportMetricsServiceRequest,
+) (*colmetricspb.ExportMetricsServiceResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	c.record(req, md.Get("x-test"))
+	return &colmetricspb.ExportMetricsServiceResponse{}, nil
+}
+
+func (c *collectorStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil || r.URL.Path != "/v1/metrics" || r.Header.Get("Content-Type") != "application/x-protobuf" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	req := &colmetricspb.ExportMetricsServiceRequest{}
+	if err := proto.Unmarshal(body, req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	c.record(req, r.Header.Values("X-Test"))
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	w.WriteHeader(http.StatusOK)
+}
+
+func (c *collectorStub) record(req *colmetricspb.ExportMetricsServiceRequest, headers []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests = append(c.requests, req)
+	c.headers = append(c.headers, headers...)
+}
+
+// metrics returns all the exported metrics by name, from the last request
+// they were exported in.
+func (c *collectorStub) metrics() map[string]*metricpb.Metric {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	result := make(map[string]*metricpb.Metric)
+	for _, req := range c.requests {
+		for _, rm := range req.ResourceMetrics {
+			for _, sm := range rm.ScopeMetrics {
+				for _, m := range sm.Metrics {
+					result[m.Name] = m
+				}
+			}
+		}
+	}
+	return result
+}
+
+func startGRPCCollector(t *testing.T) (*collectorStub, string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	stub := &collectorStub{}
+	srv := grpc.NewServer()
+	colmetricspb.RegisterMetricsServiceServer(srv, stub)
+	go func() {
+		_ = srv.Serve(l)
+	}()
+	t.Cleanup(srv.Stop)
+
+	return stub, "grpc://" + l.Addr().String()
+}
+
+func startHTTPCollector(t *testing.T) (*collectorStub, string) {
+	stub := &collectorStub{}
+	srv := httptest.NewServer(stub)
+	t.Cleanup(srv.Close)
+
+	return stub, srv.URL + "/v1/metrics"
+}
+
+func TestOutput(t *testing.T) {
+	t.Parallel()
+
+	collectors := map[string]func(t *testing.T) (*collectorStub, string){
+		"grpc": startGRPCCollector,
+		"http": startHTTPCollector,
+	}
+	for name, start := range collectors {
+		start := start
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			stub, url := start(t)
+			testOutput(t, stub, url)
+		})
+	}
+}
+
+func testOutput(t *testing.T, stub *collectorStub, url string) {
+	registry := metrics.NewRegistry()
+	counter := registry.MustNewMetric("reqs", metrics.Counter)
+	gauge := registry.MustNewMetric("vus", metrics.Gauge)
+	trend := registry.MustNewMetric("duration", metrics.Trend, metrics.Time)
+	rate := registry.MustNewMetric("checks", metrics.Rate)
+	histogram, err := registry.NewHistogram("size", []float64{10, 100}, metrics.Data)
+	require.NoError(t, err)
+
+	tags := registry.RootTagSet().With("status", "200").With("method", "GET")
+	now := time.Now()
+	sample := func(m *metrics.Metric, v float64) metrics.Sample {
+		return metrics.Sample{TimeSeries: metrics.TimeSeries{Metric: m, Tags: tags}, Time: now, Value: v}
+	}
+
+	o, err := New(output.Params{
+		Logger:         testutils.NewLogger(t),
+		ConfigArgument: url + "?pushInterval=10ms&header.X-Test=value",
+	})
+	require.NoError(t, err)
+	require.NoError(t, o.Start())
+
+	o.AddMetricSamples([]metrics.SampleContainer{metrics.Samples{
+		sample(counter, 1), sample(counter, 2),
+		sample(gauge, 5), sample(gauge, 3),
+		sample(trend, 1), sample(trend, 10), sample(trend, 100),
+		sample(rate, 1), sample(rate, 0), sample(rate, 1),
+		sample(histogram, 5), sample(histogram, 100), sample(histogram, 1000),
+	}})
+	require.Eventually(t, func() bool { return len(stub.metrics()) == 6 }, 5*time.Second, 10*time.Millisecond)
+
+	// the values are cumulative
+	o.AddMetricSamples([]metrics.SampleContainer{metrics.Samples{sample(counter, 4)}})
+	require.NoError(t, o.Stop())
+
+	exported := stub.metrics()
+	require.Len(t, exported, 6)
+	assert.Contains(t, stub.headers, "value")
+
+	sum := exported["reqs"].GetSum()
+	require.NotNil(t, sum)
+	assert.True(t, sum.IsMonotonic)
+	assert.Equal(t, metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE, sum.AggregationTemporality)
+	require.Len(t, sum.DataPoints, 1)
+	assert.Equal(t, 7.0, sum.DataPoints[0].GetAsDouble())
+	assert.Equal(t, uint64(now.UnixNano()), sum.DataPoints[0].TimeUnixNano)
+
+	attributes := map[string]string{}
+	for _, kv := range sum.DataPoints[0].Attributes {
+		attributes[kv.Key] = kv.Value.GetStringValue()
+	}
+	assert.Equal(t, map[string]string{"status": "200", "method": "GET"}, attributes)
+
+	require.NotNil(t, exported["vus"].GetGauge())
+	assert.Equal(t, 3.0, exported["vus"].GetGauge().DataPoints[0].GetAsDouble())
+
+	assert.Equal(t, "ms", exported["duration"].Unit)
+	expHistogram := exported["duration"].GetExponentialHistogram()
+	require.NotNil(t, expHistogram)
+	assert.Equal(t, uint64(3), expHistogram.DataPoints[0].Count)
+	assert.Equal(t, 111.0, expHistogram.DataPoints[0].GetSum())
+	assert.Equal(t, 1.0, expHistogram.DataPoints[0].GetMin())
+	assert.Equal(t, 100.0, expHistogram.DataPoints[0].GetMax())
+
+	assert.Equal(t, 2.0, exported["checks.occurred"].GetSum().DataPoints[0].GetAsDouble())
+	assert.Equal(t, 3.0, exported["checks.total"].GetSum().DataPoints[0].GetAsDouble())
+
+	assert.Equal(t, "By", exported["size"].Unit)
+	h := exported["size"].GetHistogram()
+	require.NotNil(t, h)
+	assert.Equal(t, []float64{10, 100}, h.DataPoints[0].ExplicitBounds)
+	assert.Equal(t, []uint64{1, 1, 1}, h.DataPoints[0].BucketCounts)
+	assert.Equal(t, uint64(3), h.DataPoints[0].Count)
+}
+
+func TestOutputRetriesFailedExports(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu       sync.Mutex
+		fail     = true
+		received []*colmetricspb.ExportMetricsServiceRequest
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if fail {
+			fail = false
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		req := &colmetricspb.ExportMetricsServiceRequest{}
+		require.NoError(t, proto.Unmarshal(body, req))
+		received = append(received, req)
+	}))
+	defer srv.Close()
+
+	o, err := newOutput(output.Params{
+		Logger:         testutils.NewLogger(t),
+		ConfigArgument: srv.URL + "/v1/metrics",
+	})
+	require.NoError(t, err)
+	exp, err := newExporter(o.config)
+	require.NoError(t, err)
+	o.exporter = exp
+	o.aggregator = newAggregator("k6", time.Now())
+
+	registry := metrics.NewRegistry()
+	counter := registry.MustNewMetric("reqs", metrics.Counter)
+	o.AddMetricSamples([]metrics.SampleContainer{metrics.Sample{
+		TimeSeries: metrics.TimeSeries{Metric: counter, Tags: registry.RootTagSet()},
+		Time:       time.Now(),
+		Value:      1,
+	}})
+
+	o.flushMetrics()
+	assert.True(t, o.aggregator.hasUpdates())
+	o.flushMetrics()
+	assert.False(t, o.aggregator.hasUpdates())
+	o.flushMetrics()
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, received, 1)
+	m := received[0].ResourceMetrics[0].ScopeMetrics[0].Metrics
+	require.Len(t, m, 1)
+	assert.Equal(t, 1.0, m[0].GetSum().DataPoints[0].GetAsDouble())
+	assert.Equal(t, "service.name", received[0].ResourceMetrics[0].Resource.Attributes[0].Key)
+}
@@ -0,0 +1,99 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ChipArtem/k6/lib/types"
+	"github.com/ChipArtem/k6/metrics"
+)
+
+// ParsePipeline parses the specification of how the samples are processed
+// before they are sent to an output. It's a semicolon-separated list of
+// key=value pairs, with the keys of the filter (see ParseFilter) and of the
+// aggregation, e.g.:
+//
+//	include=http_req_*;aggregate=10s;percentiles=95,99
+//
+// The aggregation keys are aggregate, which is the period of the time
+// buckets, aggregateWait and percentiles. The aggregator registers the metrics
+// of its statistics in the given registry. The filter or the aggregator are
+// nil if none of their keys are specified.
+func ParsePipeline(spec string, registry *metrics.Registry) (*Filter, *Aggregator, error) {
+	var (
+		filterPairs []string
+		aggregate   bool
+		conf        = AggregationConfig{Wait: DefaultAggregationWait}
+	)
+	for _, pair := range strings.Split(spec, ";") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			return nil, nil, fmt.Errorf("couldn't parse %q as an output option, it should be key=value", pair)
+		}
+		key, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		var err error
+		switch key {
+		case "include", "exclude", "dropTags":
+			filterPairs = append(filterPairs, pair)
+		case "aggregate":
+			aggregate = true
+			conf.Period, err = parseDuration(value)
+		case "aggregateWait":
+			conf.Wait, err = parseDuration(value)
+		case "percentiles":
+			conf.Percentiles, err = parsePercentiles(value)
+		default:
+			return nil, nil, fmt.Errorf("unknown output option %q, it should be one of "+
+				"include, exclude, dropTags, aggregate, aggregateWait or percentiles", key)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid value of the output option %q: %w", key, err)
+		}
+	}
+
+	var (
+		filter     *Filter
+		aggregator *Aggregator
+		err        error
+	)
+	if len(filterPairs) > 0 {
+		if filter, err = ParseFilter(strings.Join(filterPairs, ";")); err != nil {
+			return nil, nil, err
+		}
+	}
+	if aggregate {
+		if aggregator, err = NewAggregator(conf, registry); err != nil {
+			return nil, nil, err
+		}
+	} else if conf.Percentiles != nil {
+		return nil, nil, errors.New("the percentiles output option requires the aggregate option")
+	}
+	return filter, aggregator, nil
+}
+
+func parseDuration(s string) (time.Duration, error) {
+	var d types.Duration
+	if err := d.UnmarshalText([]byte(s)); err != nil {
+		return 0, err
+	}
+	return time.Duration(d), nil
+}
+
+func parsePercentiles(s string) ([]float64, error) {
+	values := splitOutsideBraces(s)
+	percentiles := make([]float64, 0, len(values))
+	for _, value := range values {
+		p, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, err
+		}
+		percentiles = append(percentiles, p)
+	}
+	return percentiles, nil
+}
@@ -0,0 +1,158 @@
+package prometheus
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/mstoykov/envconfig"
+	"gopkg.in/guregu/null.v3"
+)
+
+// defaultTrendBuckets are the upper bounds of the buckets of the histograms
+// for Trend metrics. They are the same as the default ones of the Prometheus
+// client libraries, since the time values are exported in seconds.
+var defaultTrendBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10} //nolint:gochecknoglobals
+
+// Config is the config for the Prometheus output.
+type Config struct {
+	// Address is where the output listens for scrape requests, unless
+	// APIServer is enabled
+	Address null.String `json:"address" envconfig:"K6_PROMETHEUS_ADDRESS"`
+	// APIServer serves the metrics on the k6 REST API server, instead of
+	// starting a new HTTP server
+	APIServer    null.Bool   `json:"apiServer" envconfig:"K6_PROMETHEUS_API_SERVER"`
+	Path         null.String `json:"path" envconfig:"K6_PROMETHEUS_PATH"`
+	Namespace    null.String `json:"namespace" envconfig:"K6_PROMETHEUS_NAMESPACE"`
+	TrendBuckets []float64   `json:"trendBuckets" envconfig:"K6_PROMETHEUS_TREND_BUCKETS"`
+}
+
+// NewConfig creates a new Prometheus output config with some default values.
+func NewConfig() Config {
+	return Config{
+		Address:      null.NewString("localhost:5656", false),
+		APIServer:    null.NewBool(false, false),
+		Path:         null.NewString("/metrics", false),
+		Namespace:    null.NewString("k6", false),
+		TrendBuckets: defaultTrendBuckets,
+	}
+}
+
+// Apply merges the valid values of the provided config into this one.
+func (c Config) Apply(cfg Config) Config {
+	if cfg.Address.Valid {
+		c.Address = cfg.Address
+	}
+	if cfg.APIServer.Valid {
+		c.APIServer = cfg.APIServer
+	}
+	if cfg.Path.Valid {
+		c.Path = cfg.Path
+	}
+	if cfg.Namespace.Valid {
+		c.Namespace = cfg.Namespace
+	}
+	if len(cfg.TrendBuckets) > 0 {
+		c.TrendBuckets = cfg.TrendBuckets
+	}
+	return c
+}
+
+// Validate checks that the config values are usable.
+func (c Config) Validate() error {
+	if !strings.HasPrefix(c.Path.String, "/") {
+		return fmt.Errorf("the path %q should start with a slash", c.Path.String)
+	}
+	if c.APIServer.Bool && isReservedAPIPath(c.Path.String) {
+		return fmt.Errorf("the path %q is reserved by the REST API server", c.Path.String)
+	}
+	if c.Namespace.String != "" && !isValidName(c.Namespace.String) {
+		return fmt.Errorf("invalid namespace %q", c.Namespace.String)
+	}
+	if !sort.Float64sAreSorted(c.TrendBuckets) {
+		return errors.New("the trend buckets should be sorted in increasing order")
+	}
+	for i := 1; i < len(c.TrendBuckets); i++ {
+		if c.TrendBuckets[i] == c.TrendBuckets[i-1] {
+			return fmt.Errorf("duplicate trend bucket %v", c.TrendBuckets[i])
+		}
+	}
+	return nil
+}
+
+// isReservedAPIPath reports whether the path is already served by the k6 REST
+// API server, so the metrics can't be exposed there.
+func isReservedAPIPath(path string) bool {
+	switch {
+	case path == "/", path == "/ping", path == "/v1":
+		return true
+	case strings.HasPrefix(path, "/v1/"), strings.HasPrefix(path, "/debug/pprof"):
+		return true
+	default:
+		return false
+	}
+}
+
+// ParseJSON parses the supplied JSON into a Config.
+func ParseJSON(data json.RawMessage) (Config, error) {
+	conf := Config{}
+	err := json.Unmarshal(data, &conf)
+	return conf, err
+}
+
+// ParseArg parses the supplied output argument into a Config. It's either
+// `api`, to serve the metrics on the REST API server, or an address with an
+// optional path, for instance `localhost:5656/metrics`.
+func ParseArg(arg string) (Config, error) {
+	c := Config{}
+	if arg == "" {
+		return c, nil
+	}
+
+	address, path, hasPath := strings.Cut(arg, "/")
+	if hasPath {
+		c.Path = null.StringFrom("/" + path)
+	}
+	if address == "api" {
+		c.APIServer = null.BoolFrom(true)
+		return c, nil
+	}
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		return c, fmt.Errorf("invalid address %q: %w", address, err)
+	}
+	c.Address = null.StringFrom(address)
+	return c, nil
+}
+
+// GetConsolidatedConfig combines {default config values + JSON config +
+// environment vars + argument config values}, and returns the final result.
+func GetConsolidatedConfig(jsonRawConf json.RawMessage, env map[string]string, arg string) (Config, error) {
+	result := NewConfig()
+	if jsonRawConf != nil {
+		jsonConf, err := ParseJSON(jsonRawConf)
+		if err != nil {
+			return result, err
+		}
+		result = result.Apply(jsonConf)
+	}
+
+	envConfig := Config{}
+	if err := envconfig.Process("", &envConfig, func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}); err != nil {
+		return result, err
+	}
+	result = result.Apply(envConfig)
+
+	argConf, err := ParseArg(arg)
+	if err != nil {
+		return result, err
+	}
+	result = result.Apply(argConf)
+
+	return result, result.Validate()
+}
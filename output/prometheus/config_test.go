@@ -0,0 +1,118 @@
+package prometheus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/guregu/null.v3"
+)
+
+func TestParseArg(t *testing.T) {
+	t.Parallel()
+	testCases := map[string]struct {
+		config Config
+		err    string
+	}{
+		"":                      {config: Config{}},
+		"api":                   {config: Config{APIServer: null.BoolFrom(true)}},
+		"api/k6/metrics":        {config: Config{APIServer: null.BoolFrom(true), Path: null.StringFrom("/k6/metrics")}},
+		"localhost:9999":        {config: Config{Address: null.StringFrom("localhost:9999")}},
+		":9999/scrape":          {config: Config{Address: null.StringFrom(":9999"), Path: null.StringFrom("/scrape")}},
+		"localhost":             {err: `invalid address "localhost": address localhost: missing port in address`},
+		"localhost:9999/path/x": {config: Config{Address: null.StringFrom("localhost:9999"), Path: null.StringFrom("/path/x")}},
+	}
+
+	for arg, tc := range testCases {
+		arg, tc := arg, tc
+		t.Run(arg, func(t *testing.T) {
+			t.Parallel()
+			config, err := ParseArg(arg)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.config, config)
+		})
+	}
+}
+
+func TestGetConsolidatedConfig(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Defaults", func(t *testing.T) {
+		t.Parallel()
+		config, err := GetConsolidatedConfig(nil, nil, "")
+		require.NoError(t, err)
+		assert.Equal(t, NewConfig(), config)
+	})
+
+	t.Run("Precedence", func(t *testing.T) {
+		t.Parallel()
+		config, err := GetConsolidatedConfig(
+			[]byte(`{"address":"json:1","namespace":"json","trendBuckets":[1,2]}`),
+			map[string]string{
+				"K6_PROMETHEUS_ADDRESS":       "env:1",
+				"K6_PROMETHEUS_TREND_BUCKETS": "0.1,1,10",
+			},
+			"arg:1",
+		)
+		require.NoError(t, err)
+		assert.Equal(t, "arg:1", config.Address.String)
+		assert.Equal(t, "json", config.Namespace.String)
+		assert.Equal(t, []float64{0.1, 1, 10}, config.TrendBuckets)
+	})
+
+	t.Run("ReservedPathWithoutAPIServer", func(t *testing.T) {
+		t.Parallel()
+		config, err := GetConsolidatedConfig(nil, map[string]string{"K6_PROMETHEUS_PATH": "/ping"}, "")
+		require.NoError(t, err)
+		assert.Equal(t, "/ping", config.Path.String)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		t.Parallel()
+		testCases := []struct {
+			env map[string]string
+			err string
+		}{
+			{
+				env: map[string]string{"K6_PROMETHEUS_PATH": "metrics"},
+				err: `the path "metrics" should start with a slash`,
+			},
+			{
+				env: map[string]string{"K6_PROMETHEUS_NAMESPACE": "k6-load"},
+				err: `invalid namespace "k6-load"`,
+			},
+			{
+				env: map[string]string{"K6_PROMETHEUS_TREND_BUCKETS": "2,1"},
+				err: "the trend buckets should be sorted in increasing order",
+			},
+			{
+				env: map[string]string{"K6_PROMETHEUS_TREND_BUCKETS": "1,1"},
+				err: "duplicate trend bucket 1",
+			},
+			{
+				env: map[string]string{"K6_PROMETHEUS_API_SERVER": "true", "K6_PROMETHEUS_PATH": "/"},
+				err: `the path "/" is reserved by the REST API server`,
+			},
+			{
+				env: map[string]string{"K6_PROMETHEUS_API_SERVER": "true", "K6_PROMETHEUS_PATH": "/ping"},
+				err: `the path "/ping" is reserved by the REST API server`,
+			},
+			{
+				env: map[string]string{"K6_PROMETHEUS_API_SERVER": "true", "K6_PROMETHEUS_PATH": "/v1/metrics"},
+				err: `the path "/v1/metrics" is reserved by the REST API server`,
+			},
+			{
+				env: map[string]string{"K6_PROMETHEUS_API_SERVER": "true", "K6_PROMETHEUS_PATH": "/debug/pprof/metrics"},
+				err: `the path "/debug/pprof/metrics" is reserved by the REST API server`,
+			},
+		}
+		for _, tc := range testCases {
+			_, err := GetConsolidatedConfig(nil, tc.env, "")
+			assert.EqualError(t, err, tc.err)
+		}
+	})
+}
@@ -0,0 +1,222 @@
+package prometheus
+
+import (
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ChipArtem/k6/metrics"
+)
+
+// family holds the aggregated values of all the time series of a k6 metric,
+// since the start of the output. Counters are exported as Prometheus
+// counters, Gauges as gauges, Rates as gauges with the ratio of non-zero
+// values, and both Trends and Histograms as histograms.
+type family struct {
+	name    string
+	typ     string
+	isRate  bool
+	divisor float64   // time values are exported in seconds
+	buckets []float64 // the upper bounds of the buckets of histograms
+
+	series map[*metrics.TagSet]*series
+}
+
+type series struct {
+	labels string
+
+	value          float64 // the sum of Counters and the last value of Gauges
+	nonZero, total uint64  // the values of Rates
+
+	counts []uint64 // the non-cumulative counts of each bucket, and +Inf
+	sum    float64
+	count  uint64
+}
+
+func newFamily(namespace string, m *metrics.Metric, trendBuckets []float64) *family {
+	f := &family{
+		name:    m.Name,
+		divisor: 1,
+		series:  make(map[*metrics.TagSet]*series),
+	}
+	if namespace != "" {
+		f.name = namespace + "_" + f.name
+	}
+
+	switch m.Contains {
+	case metrics.Time:
+		f.name += "_seconds"
+		f.divisor = 1000
+	case metrics.Data:
+		f.name += "_bytes"
+	default:
+	}
+
+	switch m.Type {
+	case metrics.Counter:
+		f.name += "_total"
+		f.typ = "counter"
+	case metrics.Gauge:
+		f.typ = "gauge"
+	case metrics.Rate:
+		f.name += "_rate"
+		f.typ = "gauge"
+		f.isRate = true
+	case metrics.Trend:
+		f.typ = "histogram"
+		f.buckets = trendBuckets
+	case metrics.Histogram:
+		f.typ = "histogram"
+		f.buckets = make([]float64, len(m.Buckets))
+		for i, b := range m.Buckets {
+			f.buckets[i] = b / f.divisor
+		}
+	}
+	return f
+}
+
+func (f *family) add(m *metrics.Metric, tags *metrics.TagSet, value float64) {
+	s, ok := f.series[tags]
+	if !ok {
+		s = &series{labels: formatLabels(tags)}
+		if f.typ == "histogram" {
+			s.counts = make([]uint64, len(f.buckets)+1)
+		}
+		f.series[tags] = s
+	}
+
+	value /= f.divisor
+	switch m.Type {
+	case metrics.Counter:
+		s.value += value
+	case metrics.Gauge:
+		s.value = value
+	case metrics.Rate:
+		s.total++
+		if value != 0 {
+			s.nonZero++
+		}
+	case metrics.Trend, metrics.Histogram:
+		s.counts[sort.SearchFloat64s(f.buckets, value)]++
+		s.sum += value
+		s.count++
+	}
+}
+
+// write writes all the time series of the family in the Prometheus text
+// exposition format, sorted by their labels.
+func (f *family) write(w io.Writer) error {
+	sorted := make([]*series, 0, len(f.series))
+	for _, s := range f.series {
+		sorted = append(sorted, s)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].labels < sorted[j].labels })
+
+	var b strings.Builder
+	b.WriteString("# TYPE " + f.name + " " + f.typ + "\n")
+	for _, s := range sorted {
+		switch f.typ {
+		case "histogram":
+			var cumulative uint64
+			for i, c := range s.counts {
+				cumulative += c
+				le := math.Inf(1)
+				if i < len(f.buckets) {
+					le = f.buckets[i]
+				}
+				writeSample(&b, f.name+"_bucket", joinLabels(s.labels, `le="`+formatFloat(le)+`"`), float64(cumulative))
+			}
+			writeSample(&b, f.name+"_sum", s.labels, s.sum)
+			writeSample(&b, f.name+"_count", s.labels, float64(s.count))
+		case "gauge":
+			if f.isRate {
+				writeSample(&b, f.name, s.labels, float64(s.nonZero)/float64(s.total))
+				continue
+			}
+			writeSample(&b, f.name, s.labels, s.value)
+		default:
+			writeSample(&b, f.name, s.labels, s.value)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeSample(b *strings.Builder, name, labels string, value float64) {
+	b.WriteString(name)
+	if labels != "" {
+		b.WriteString("{" + labels + "}")
+	}
+	b.WriteString(" " + formatFloat(value) + "\n")
+}
+
+func joinLabels(labels, label string) string {
+	if labels == "" {
+		return label
+	}
+	return labels + "," + label
+}
+
+// formatLabels returns the tags as Prometheus labels, sorted by name. The
+// tag names are sanitized and the values are escaped.
+func formatLabels(tags *metrics.TagSet) string {
+	m := tags.Map()
+	names := make([]string, 0, len(m))
+	for k := range m {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	labels := make([]string, 0, len(names))
+	for _, k := range names {
+		labels = append(labels, sanitizeName(k)+`="`+labelValueEscaper.Replace(m[k])+`"`)
+	}
+	return strings.Join(labels, ",")
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`) //nolint:gochecknoglobals
+
+// sanitizeName replaces all of the characters that aren't allowed in
+// Prometheus label names with underscores.
+func sanitizeName(name string) string {
+	if isValidName(name) {
+		return name
+	}
+	b := []byte(name)
+	for i, c := range b {
+		if !isNameChar(c, i == 0) {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+func isValidName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if !isNameChar(name[i], i == 0) {
+			return false
+		}
+	}
+	return true
+}
+
+func isNameChar(c byte, first bool) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (!first && c >= '0' && c <= '9')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
@@ -0,0 +1,102 @@
+package prometheus
+
+import (
+	"bytes"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ChipArtem/k6/metrics"
+)
+
+func TestFamily(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	tags := registry.RootTagSet().With("status", "200").With("url", `http://example.com/"quoted"`)
+	otherTags := registry.RootTagSet().With("my-tag", "a\nb")
+
+	counter := registry.MustNewMetric("reqs", metrics.Counter)
+	data := registry.MustNewMetric("data_sent", metrics.Counter, metrics.Data)
+	gauge := registry.MustNewMetric("vus", metrics.Gauge)
+	rate := registry.MustNewMetric("checks", metrics.Rate)
+	trend := registry.MustNewMetric("req_duration", metrics.Trend, metrics.Time)
+	histogram, err := registry.NewHistogram("size", []float64{10, 100})
+	require.NoError(t, err)
+
+	add := func(f *family, m *metrics.Metric, tags *metrics.TagSet, values ...float64) *family {
+		for _, v := range values {
+			f.add(m, tags, v)
+		}
+		return f
+	}
+	families := []*family{
+		add(add(newFamily("k6", counter, nil), counter, tags, 1, 2), counter, otherTags, 5),
+		add(newFamily("k6", data, nil), data, tags, 1024),
+		add(newFamily("k6", gauge, nil), gauge, tags, 5, 3),
+		add(newFamily("k6", rate, nil), rate, tags, 1, 0, 1, 1),
+		add(newFamily("k6", trend, defaultTrendBuckets), trend, tags, 3, 75, 300, 20000),
+		add(newFamily("", histogram, nil), histogram, tags, 1, 10, 50, 500),
+	}
+
+	var buf bytes.Buffer
+	for _, f := range families {
+		require.NoError(t, f.write(&buf))
+	}
+
+	parsed, err := new(expfmt.TextParser).TextToMetricFamilies(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err, buf.String())
+
+	reqs := parsed["k6_reqs_total"]
+	require.NotNil(t, reqs)
+	assert.Equal(t, dto.MetricType_COUNTER, reqs.GetType())
+	require.Len(t, reqs.Metric, 2)
+	assert.Equal(t, 5.0, reqs.Metric[0].GetCounter().GetValue())
+	assert.Equal(t, "my_tag", reqs.Metric[0].Label[0].GetName())
+	assert.Equal(t, "a\nb", reqs.Metric[0].Label[0].GetValue())
+	assert.Equal(t, 3.0, reqs.Metric[1].GetCounter().GetValue())
+	assert.Equal(t, `http://example.com/"quoted"`, reqs.Metric[1].Label[1].GetValue())
+
+	assert.Equal(t, 1024.0, parsed["k6_data_sent_bytes_total"].Metric[0].GetCounter().GetValue())
+	assert.Equal(t, 3.0, parsed["k6_vus"].Metric[0].GetGauge().GetValue())
+	assert.Equal(t, 0.75, parsed["k6_checks_rate"].Metric[0].GetGauge().GetValue())
+
+	duration := parsed["k6_req_duration_seconds"]
+	require.NotNil(t, duration)
+	assert.Equal(t, dto.MetricType_HISTOGRAM, duration.GetType())
+	h := duration.Metric[0].GetHistogram()
+	assert.Equal(t, uint64(4), h.GetSampleCount())
+	assert.InDelta(t, 20.378, h.GetSampleSum(), 1e-9)
+	cumulative := map[float64]uint64{}
+	for _, b := range h.Bucket {
+		cumulative[b.GetUpperBound()] = b.GetCumulativeCount()
+	}
+	assert.Equal(t, uint64(1), cumulative[0.005])
+	assert.Equal(t, uint64(2), cumulative[0.1])
+	assert.Equal(t, uint64(3), cumulative[0.5])
+	assert.Equal(t, uint64(3), cumulative[10])
+
+	size := parsed["size"].Metric[0].GetHistogram()
+	require.Len(t, size.Bucket, 3)
+	assert.Equal(t, uint64(2), size.Bucket[0].GetCumulativeCount())
+	assert.Equal(t, uint64(3), size.Bucket[1].GetCumulativeCount())
+	assert.Equal(t, uint64(4), size.Bucket[2].GetCumulativeCount())
+	assert.Equal(t, uint64(4), size.GetSampleCount())
+}
+
+func TestSanitizeName(t *testing.T) {
+	t.Parallel()
+	testCases := map[string]string{
+		"status":    "status",
+		"my-tag":    "my_tag",
+		"1st":       "_st",
+		"tag.name":  "tag_name",
+		"_internal": "_internal",
+	}
+	for input, expected := range testCases {
+		assert.Equal(t, expected, sanitizeName(input))
+	}
+}
@@ -0,0 +1,187 @@
+// Package prometheus implements an output that exposes the aggregated k6
+// metrics in the Prometheus text exposition format, so they can be scraped
+// while the test is running.
+package prometheus
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/ChipArtem/k6/metrics"
+	"github.com/ChipArtem/k6/output"
+)
+
+// flushInterval is how often the buffered samples are aggregated. They are
+// also aggregated on every scrape, so the exposed values are always current.
+const flushInterval = time.Second
+
+// Output serves the metrics on its own HTTP server or on the k6 REST API
+// server, depending on the config.
+type Output struct {
+	output.SampleBuffer
+
+	config          Config
+	logger          logrus.FieldLogger
+	periodicFlusher *output.PeriodicFlusher
+	server          *http.Server
+	serverDone      chan struct{}
+
+	mu       sync.Mutex
+	families map[*metrics.Metric]*family
+}
+
+var (
+	_ output.Output          = new(Output)
+	_ output.WithHTTPHandler = new(Output)
+)
+
+// New returns a new Prometheus output.
+func New(params output.Params) (output.Output, error) {
+	return newOutput(params)
+}
+
+func newOutput(params output.Params) (*Output, error) {
+	conf, err := GetConsolidatedConfig(params.JSONConfig, params.Environment, params.ConfigArgument)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Output{
+		config: conf,
+		logger: params.Logger.WithFields(logrus.Fields{
+			"output": "Prometheus",
+		}),
+		families: make(map[*metrics.Metric]*family),
+	}, nil
+}
+
+// Description returns a human-readable description of the output.
+func (o *Output) Description() string {
+	if o.config.APIServer.Bool {
+		return fmt.Sprintf("Prometheus (REST API server %s)", o.config.Path.String)
+	}
+	return fmt.Sprintf("Prometheus (http://%s%s)", o.config.Address.String, o.config.Path.String)
+}
+
+// Start starts the HTTP server, unless the metrics are served by the REST API
+// server, and the goroutine for metric aggregation.
+func (o *Output) Start() error {
+	o.logger.Debug("Starting...")
+
+	if !o.config.APIServer.Bool {
+		l, err := net.Listen("tcp", o.config.Address.String)
+		if err != nil {
+			return fmt.Errorf("couldn't listen on %s: %w", o.config.Address.String, err)
+		}
+
+		mux := http.NewServeMux()
+		mux.Handle(o.config.Path.String, o.handler())
+		o.server = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+		o.serverDone = make(chan struct{})
+		go func() {
+			defer close(o.serverDone)
+			if err := o.server.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				o.logger.WithError(err).Error("Prometheus server error")
+			}
+		}()
+	}
+
+	pf, err := output.NewPeriodicFlusher(flushInterval, o.flushMetrics)
+	if err != nil {
+		return err
+	}
+	o.logger.Debug("Started!")
+	o.periodicFlusher = pf
+
+	return nil
+}
+
+// Stop aggregates any remaining metrics and stops the HTTP server.
+func (o *Output) Stop() error {
+	o.logger.Debug("Stopping...")
+	defer o.logger.Debug("Stopped!")
+	o.periodicFlusher.Stop()
+
+	if o.server == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err := o.server.Shutdown(ctx)
+	<-o.serverDone
+	return err
+}
+
+// HTTPHandler returns the handler that serves the metrics, if they have to be
+// served by the REST API server.
+func (o *Output) HTTPHandler() (string, http.Handler) {
+	if !o.config.APIServer.Bool {
+		return "", nil
+	}
+	return o.config.Path.String, o.handler()
+}
+
+func (o *Output) handler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			rw.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		o.flushMetrics()
+		var buf bytes.Buffer
+		if err := o.write(&buf); err != nil {
+			o.logger.WithError(err).Error("Couldn't write the metrics")
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		rw.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_, _ = rw.Write(buf.Bytes())
+	})
+}
+
+func (o *Output) flushMetrics() {
+	samples := o.GetBufferedSamples()
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for _, sc := range samples {
+		for _, sample := range sc.GetSamples() {
+			f, ok := o.families[sample.Metric]
+			if !ok {
+				f = newFamily(o.config.Namespace.String, sample.Metric, o.config.TrendBuckets)
+				o.families[sample.Metric] = f
+			}
+			f.add(sample.Metric, sample.Tags, sample.Value)
+		}
+	}
+}
+
+// write writes all the metric families, sorted by name.
+func (o *Output) write(buf *bytes.Buffer) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	sorted := make([]*family, 0, len(o.families))
+	for _, f := range o.families {
+		sorted = append(sorted, f)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].name < sorted[j].name })
+
+	for _, f := range sorted {
+		if err := f.write(buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
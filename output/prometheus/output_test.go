@@ -0,0 +1,144 @@
+package prometheus
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ChipArtem/k6/lib/testutils"
+	"github.com/ChipArtem/k6/metrics"
+	"github.com/ChipArtem/k6/output"
+)
+
+func TestOutputServer(t *testing.T) {
+	t.Parallel()
+
+	o, err := newOutput(output.Params{
+		Logger:         testutils.NewLogger(t),
+		ConfigArgument: "127.0.0.1:0/scrape",
+	})
+	require.NoError(t, err)
+	path, handler := o.HTTPHandler()
+	assert.Empty(t, path)
+	assert.Nil(t, handler)
+
+	require.NoError(t, o.Start())
+	require.NotNil(t, o.server)
+
+	registry := metrics.NewRegistry()
+	counter := registry.MustNewMetric("iterations", metrics.Counter)
+	o.AddMetricSamples([]metrics.SampleContainer{metrics.Sample{
+		TimeSeries: metrics.TimeSeries{Metric: counter, Tags: registry.RootTagSet().With("scenario", "default")},
+		Time:       time.Now(),
+		Value:      3,
+	}})
+
+	// the samples are aggregated on every scrape, without waiting for a flush
+	rw := httptest.NewRecorder()
+	o.server.Handler.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/scrape", nil))
+	res := rw.Result()
+	defer func() {
+		_ = res.Body.Close()
+	}()
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", res.Header.Get("Content-Type"))
+	assert.Equal(t,
+		"# TYPE k6_iterations_total counter\n"+`k6_iterations_total{scenario="default"} 3`+"\n",
+		string(body),
+	)
+
+	rw = httptest.NewRecorder()
+	o.server.Handler.ServeHTTP(rw, httptest.NewRequest(http.MethodPost, "/scrape", strings.NewReader("")))
+	assert.Equal(t, http.StatusMethodNotAllowed, rw.Code)
+
+	require.NoError(t, o.Stop())
+}
+
+func TestOutputAPIServer(t *testing.T) {
+	t.Parallel()
+
+	o, err := newOutput(output.Params{
+		Logger:         testutils.NewLogger(t),
+		ConfigArgument: "api",
+	})
+	require.NoError(t, err)
+	require.NoError(t, o.Start())
+	assert.Nil(t, o.server)
+	assert.Equal(t, "Prometheus (REST API server /metrics)", o.Description())
+
+	path, handler := o.HTTPHandler()
+	assert.Equal(t, "/metrics", path)
+	require.NotNil(t, handler)
+
+	registry := metrics.NewRegistry()
+	gauge := registry.MustNewMetric("vus", metrics.Gauge)
+	o.AddMetricSamples([]metrics.SampleContainer{metrics.Sample{
+		TimeSeries: metrics.TimeSeries{Metric: gauge, Tags: registry.RootTagSet()},
+		Time:       time.Now(),
+		Value:      10,
+	}})
+	require.NoError(t, o.Stop())
+
+	rw := httptest.NewRecorder()
+	handler.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t, "# TYPE k6_vus gauge\nk6_vus 10\n", rw.Body.String())
+}
+
+func TestOutputAggregatedSamples(t *testing.T) {
+	t.Parallel()
+
+	o, err := newOutput(output.Params{
+		Logger:         testutils.NewLogger(t),
+		ConfigArgument: "api",
+	})
+	require.NoError(t, err)
+	require.NoError(t, o.Start())
+
+	registry := metrics.NewRegistry()
+	counter := registry.MustNewMetric("iterations", metrics.Counter)
+	trend := registry.MustNewMetric("iteration_duration", metrics.Trend, metrics.Time)
+	_, aggregator, err := output.ParsePipeline("aggregate=1m;percentiles=95", registry)
+	require.NoError(t, err)
+
+	now := time.Now()
+	var samples metrics.Samples
+	for _, value := range []float64{100, 300} {
+		samples = append(samples,
+			metrics.Sample{TimeSeries: metrics.TimeSeries{Metric: counter, Tags: registry.RootTagSet()}, Time: now, Value: 1},
+			metrics.Sample{TimeSeries: metrics.TimeSeries{Metric: trend, Tags: registry.RootTagSet()}, Time: now, Value: value},
+		)
+	}
+	assert.Empty(t, aggregator.AggregateSamples([]metrics.SampleContainer{samples}))
+	o.AddMetricSamples(aggregator.Flush())
+	require.NoError(t, o.Stop())
+
+	_, handler := o.HTTPHandler()
+	rw := httptest.NewRecorder()
+	handler.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rw.Body.String()
+
+	// every statistic is a gauge of its own, rather than an observation or an
+	// increment of the original metric
+	assert.NotContains(t, body, "counter")
+	assert.NotContains(t, body, "histogram")
+	for _, line := range []string{
+		"k6_iterations_count 2",
+		"k6_iterations_sum 2",
+		"k6_iteration_duration_count 2",
+		"k6_iteration_duration_sum_seconds 0.4",
+		"k6_iteration_duration_max_seconds 0.3",
+		"k6_iteration_duration_p95_seconds 0.29",
+	} {
+		assert.Contains(t, body, line+"\n")
+	}
+}
@@ -0,0 +1,126 @@
+package rotation
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Naming is the way the files of a rotated output are named.
+type Naming uint8
+
+const (
+	// NamingSequential numbers the files, starting from 1,
+	// e.g. results.00001.json, results.00002.json, etc.
+	NamingSequential Naming = iota
+	// NamingTimestamp names the files after the UTC time they were started at,
+	// e.g. results.20240102T150405Z.json.
+	NamingTimestamp
+)
+
+// ParseNaming returns the Naming with the given name, an empty name is
+// the sequential naming.
+func ParseNaming(name string) (Naming, error) {
+	switch name {
+	case "", "sequential":
+		return NamingSequential, nil
+	case "timestamp":
+		return NamingTimestamp, nil
+	default:
+		return 0, fmt.Errorf("invalid file naming %q, it should be either 'sequential' or 'timestamp'", name)
+	}
+}
+
+// Config determines when a file is rotated and how many of the rotated files
+// are kept. The rotation is disabled if neither MaxSize nor MaxDuration are
+// set.
+type Config struct {
+	// MaxSize is the maximum number of bytes written to a file, before they
+	// are compressed.
+	MaxSize int64
+	// MaxDuration is the maximum amount of time the samples are written to
+	// a file.
+	MaxDuration time.Duration
+	// MaxFiles is the maximum number of files that are kept, the oldest
+	// files are deleted when it's exceeded. Zero keeps all of them.
+	MaxFiles int
+	Naming   Naming
+}
+
+// NewConfig returns the validated config of the rotation, from the settings of
+// an output. The maximum size is parsed with ParseSize, unless it's empty, and
+// the naming with ParseNaming.
+func NewConfig(maxSize string, maxDuration time.Duration, maxFiles int64, naming string) (Config, error) {
+	conf := Config{MaxDuration: maxDuration, MaxFiles: int(maxFiles)}
+	if maxSize != "" {
+		size, err := ParseSize(maxSize)
+		if err != nil {
+			return conf, err
+		}
+		conf.MaxSize = size
+	}
+
+	var err error
+	if conf.Naming, err = ParseNaming(naming); err != nil {
+		return conf, err
+	}
+	return conf, conf.Validate()
+}
+
+// Enabled returns whether the files are rotated.
+func (c Config) Enabled() bool {
+	return c.MaxSize > 0 || c.MaxDuration > 0
+}
+
+// Validate returns an error if the config is invalid.
+func (c Config) Validate() error {
+	if c.MaxSize < 0 {
+		return errors.New("the maximum file size can't be negative")
+	}
+	if c.MaxDuration < 0 {
+		return errors.New("the maximum file duration can't be negative")
+	}
+	if c.MaxFiles < 0 {
+		return errors.New("the maximum number of files can't be negative")
+	}
+	if c.MaxFiles > 0 && !c.Enabled() {
+		return errors.New("the maximum number of files requires a maximum file size or duration")
+	}
+	return nil
+}
+
+//nolint:gochecknoglobals
+var sizeUnits = map[string]int64{
+	"":    1,
+	"B":   1,
+	"KB":  1000,
+	"MB":  1000 * 1000,
+	"GB":  1000 * 1000 * 1000,
+	"TB":  1000 * 1000 * 1000 * 1000,
+	"KIB": 1 << 10,
+	"MIB": 1 << 20,
+	"GIB": 1 << 30,
+	"TIB": 1 << 40,
+}
+
+// ParseSize parses a size in bytes with an optional unit, e.g. 512, 100MB or
+// 1GiB. The units without an i are powers of 1000 and the ones with it are
+// powers of 1024.
+func ParseSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if i < 0 {
+		i = len(s)
+	}
+	unit, ok := sizeUnits[strings.ToUpper(strings.TrimSpace(s[i:]))]
+	if !ok {
+		return 0, fmt.Errorf("invalid size %q, unknown unit %q", s, s[i:])
+	}
+	v, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q: %w", s, err)
+	}
+	return int64(v * float64(unit)), nil
+}
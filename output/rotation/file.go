@@ -0,0 +1,244 @@
+// Package rotation implements the writing of an output's samples to
+// a sequence of files, which are rotated when they reach a maximum size or
+// duration, along with a manifest listing them.
+package rotation
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/gzip"
+
+	"github.com/ChipArtem/k6/lib/fsext"
+)
+
+const timestampFormat = "20060102T150405Z"
+
+// ManifestEntry describes one of the files of a rotated output.
+type ManifestEntry struct {
+	Name  string     `json:"name"`
+	Start time.Time  `json:"start"`
+	End   *time.Time `json:"end,omitempty"`
+	// Size is the number of bytes written to the file, before they are
+	// compressed.
+	Size int64 `json:"size"`
+}
+
+// Manifest lists the files of a rotated output that are kept, from the oldest
+// to the newest one.
+type Manifest struct {
+	Files   []ManifestEntry `json:"files"`
+	Deleted int             `json:"deleted"`
+}
+
+// File is an io.Writer that writes to a file, which is gzipped if its name
+// ends with .gz. If the rotation is enabled, the writes go to a sequence of
+// files named after the given filename instead, each of them complete and
+// compressed on its own, and a manifest of them is written next to them.
+//
+// The rotation only happens when Rotate is called, so the users can choose
+// where the files are split, e.g. at the end of a line, and can write
+// a header at the start of every file. File isn't safe for concurrent use.
+type File struct {
+	fs       fsext.Fs
+	filename string
+	conf     Config
+	now      func() time.Time
+
+	stem, ext     string
+	gzipped       bool
+	seq           int
+	lastTimestamp string
+
+	file     io.WriteCloser
+	buf      *bufio.Writer
+	gz       *gzip.Writer
+	w        io.Writer
+	manifest Manifest
+}
+
+// Create opens the first file for writing.
+func Create(fs fsext.Fs, filename string, conf Config) (*File, error) {
+	return create(fs, filename, conf, time.Now)
+}
+
+func create(fs fsext.Fs, filename string, conf Config, now func() time.Time) (*File, error) {
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+	f := &File{
+		fs:       fs,
+		filename: filename,
+		conf:     conf,
+		now:      now,
+		gzipped:  strings.HasSuffix(filename, ".gz"),
+	}
+
+	// the sequence number or timestamp goes before the extensions,
+	// so the rotated files can still be opened by the same tools
+	f.stem, f.ext = splitExt(filename)
+
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Write writes p to the current file.
+func (f *File) Write(p []byte) (int, error) {
+	n, err := f.w.Write(p)
+	f.manifest.Files[len(f.manifest.Files)-1].Size += int64(n)
+	return n, err
+}
+
+// ShouldRotate returns whether the current file has reached the limits of the
+// rotation, counting the pending bytes that are still buffered by the caller
+// and will be written to it before it's rotated. An empty file is never
+// rotated, so a file can exceed the maximum size by a single record.
+func (f *File) ShouldRotate(pending int) bool {
+	if !f.conf.Enabled() {
+		return false
+	}
+	current := f.manifest.Files[len(f.manifest.Files)-1]
+	size := current.Size + int64(pending)
+	if size == 0 {
+		return false
+	}
+	if f.conf.MaxSize > 0 && size >= f.conf.MaxSize {
+		return true
+	}
+	return f.conf.MaxDuration > 0 && f.now().Sub(current.Start) >= f.conf.MaxDuration
+}
+
+// Rotate closes the current file and opens the next one, deleting the oldest
+// file if there are more of them than the configured maximum.
+func (f *File) Rotate() error {
+	if err := f.closeCurrent(); err != nil {
+		return err
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	if max := f.conf.MaxFiles; max > 0 && len(f.manifest.Files) > max {
+		for _, old := range f.manifest.Files[:len(f.manifest.Files)-max] {
+			if err := f.fs.Remove(f.path(old.Name)); err != nil {
+				return fmt.Errorf("couldn't delete the rotated file %q: %w", old.Name, err)
+			}
+			f.manifest.Deleted++
+		}
+		f.manifest.Files = append([]ManifestEntry(nil), f.manifest.Files[len(f.manifest.Files)-max:]...)
+	}
+	return f.writeManifest()
+}
+
+// Flush writes any buffered data to the current file.
+func (f *File) Flush() error {
+	return f.buf.Flush()
+}
+
+// Close closes the current file and writes the final manifest.
+func (f *File) Close() error {
+	if err := f.closeCurrent(); err != nil {
+		return err
+	}
+	return f.writeManifest()
+}
+
+// ManifestName returns the name of the manifest file for the given output
+// filename, e.g. results.json.manifest.json for results.json.gz. The
+// extension is kept, so the outputs writing to files with the same stem
+// don't overwrite each other's manifest.
+func ManifestName(filename string) string {
+	return strings.TrimSuffix(filename, ".gz") + ".manifest.json"
+}
+
+// splitExt splits the filename into its stem and its extension, which
+// includes the .gz suffix, e.g. results and .json.gz for results.json.gz.
+func splitExt(filename string) (stem, ext string) {
+	stem = strings.TrimSuffix(filename, ".gz")
+	if e := filepath.Ext(stem); e != filepath.Base(stem) {
+		ext = e
+	}
+	stem = strings.TrimSuffix(stem, ext)
+	if strings.HasSuffix(filename, ".gz") {
+		ext += ".gz"
+	}
+	return stem, ext
+}
+
+func (f *File) open() error {
+	start := f.now()
+	name := f.filename
+	if f.conf.Enabled() {
+		name = f.nextName(start)
+	}
+
+	file, err := f.fs.Create(name)
+	if err != nil {
+		return err
+	}
+	f.file = file
+	f.buf = bufio.NewWriter(file)
+	f.w = f.buf
+	if f.gzipped {
+		f.gz = gzip.NewWriter(f.buf)
+		f.w = f.gz
+	}
+	f.manifest.Files = append(f.manifest.Files, ManifestEntry{Name: filepath.Base(name), Start: start})
+	return nil
+}
+
+func (f *File) nextName(start time.Time) string {
+	f.seq++
+	if f.conf.Naming == NamingSequential {
+		return fmt.Sprintf("%s.%05d%s", f.stem, f.seq, f.ext)
+	}
+
+	timestamp := start.UTC().Format(timestampFormat)
+	if timestamp == f.lastTimestamp {
+		// the files rotated within the same second get the sequence number too
+		return fmt.Sprintf("%s.%s-%d%s", f.stem, timestamp, f.seq, f.ext)
+	}
+	f.lastTimestamp = timestamp
+	return fmt.Sprintf("%s.%s%s", f.stem, timestamp, f.ext)
+}
+
+func (f *File) closeCurrent() error {
+	if f.gz != nil {
+		if err := f.gz.Close(); err != nil {
+			_ = f.file.Close()
+			return err
+		}
+	}
+	if err := f.buf.Flush(); err != nil {
+		_ = f.file.Close()
+		return err
+	}
+	end := f.now()
+	f.manifest.Files[len(f.manifest.Files)-1].End = &end
+	return f.file.Close()
+}
+
+func (f *File) writeManifest() error {
+	if !f.conf.Enabled() {
+		return nil
+	}
+	data, err := json.MarshalIndent(f.manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := fsext.WriteFile(f.fs, ManifestName(f.filename), append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("couldn't write the manifest of the rotated files: %w", err)
+	}
+	return nil
+}
+
+func (f *File) path(name string) string {
+	return filepath.Join(filepath.Dir(f.filename), name)
+}
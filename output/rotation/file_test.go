@@ -0,0 +1,199 @@
+package rotation
+
+import (
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ChipArtem/k6/lib/fsext"
+)
+
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func readManifest(t *testing.T, fs fsext.Fs, name string) Manifest {
+	t.Helper()
+	data, err := fsext.ReadFile(fs, name)
+	require.NoError(t, err)
+	var m Manifest
+	require.NoError(t, json.Unmarshal(data, &m))
+	return m
+}
+
+func TestFileWithoutRotation(t *testing.T) {
+	t.Parallel()
+
+	fs := fsext.NewMemMapFs()
+	f, err := Create(fs, "/results.json", Config{})
+	require.NoError(t, err)
+	_, err = f.Write([]byte("line\n"))
+	require.NoError(t, err)
+	assert.False(t, f.ShouldRotate(1<<30))
+	require.NoError(t, f.Close())
+
+	data, err := fsext.ReadFile(fs, "/results.json")
+	require.NoError(t, err)
+	assert.Equal(t, "line\n", string(data))
+	exists, err := fsext.Exists(fs, "/results.json.manifest.json")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestFileRotationBySize(t *testing.T) {
+	t.Parallel()
+
+	fs := fsext.NewMemMapFs()
+	clock := &fakeClock{t: time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)}
+	f, err := create(fs, "/out/results.csv.gz", Config{MaxSize: 6, MaxFiles: 2}, clock.now)
+	require.NoError(t, err)
+
+	assert.False(t, f.ShouldRotate(0), "an empty file is never rotated")
+	assert.True(t, f.ShouldRotate(6), "the pending bytes are counted")
+	for _, line := range []string{"first\n", "second\n", "third\n"} {
+		if f.ShouldRotate(0) {
+			require.NoError(t, f.Rotate())
+		}
+		_, err = f.Write([]byte(line))
+		require.NoError(t, err)
+		clock.t = clock.t.Add(time.Second)
+	}
+	require.NoError(t, f.Close())
+
+	exists, err := fsext.Exists(fs, "/out/results.00001.csv.gz")
+	require.NoError(t, err)
+	assert.False(t, exists, "the oldest file should be deleted")
+
+	for name, expected := range map[string]string{
+		"/out/results.00002.csv.gz": "second\n",
+		"/out/results.00003.csv.gz": "third\n",
+	} {
+		file, err := fs.Open(name)
+		require.NoError(t, err)
+		r, err := gzip.NewReader(file)
+		require.NoError(t, err)
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, expected, string(data))
+		require.NoError(t, file.Close())
+	}
+
+	m := readManifest(t, fs, "/out/results.csv.manifest.json")
+	assert.Equal(t, 1, m.Deleted)
+	require.Len(t, m.Files, 2)
+	assert.Equal(t, "results.00002.csv.gz", m.Files[0].Name)
+	assert.Equal(t, int64(7), m.Files[0].Size)
+	assert.Equal(t, time.Date(2024, 1, 2, 15, 4, 6, 0, time.UTC), m.Files[0].Start)
+	require.NotNil(t, m.Files[1].End)
+	assert.Equal(t, time.Date(2024, 1, 2, 15, 4, 8, 0, time.UTC), *m.Files[1].End)
+}
+
+func TestFileRotationByDuration(t *testing.T) {
+	t.Parallel()
+
+	fs := fsext.NewMemMapFs()
+	clock := &fakeClock{t: time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)}
+	f, err := create(fs, "results", Config{MaxDuration: time.Minute, Naming: NamingTimestamp}, clock.now)
+	require.NoError(t, err)
+
+	_, err = f.Write([]byte("a"))
+	require.NoError(t, err)
+	clock.t = clock.t.Add(59 * time.Second)
+	assert.False(t, f.ShouldRotate(0))
+	clock.t = clock.t.Add(time.Second)
+	require.True(t, f.ShouldRotate(0))
+	require.NoError(t, f.Rotate())
+
+	// the manifest is updated with every rotation
+	m := readManifest(t, fs, "results.manifest.json")
+	require.Len(t, m.Files, 2)
+	assert.Equal(t, "results.20240102T150405Z", m.Files[0].Name)
+	assert.Equal(t, "results.20240102T150505Z", m.Files[1].Name)
+	assert.Nil(t, m.Files[1].End)
+
+	_, err = f.Write([]byte("b"))
+	require.NoError(t, err)
+	require.NoError(t, f.Rotate())
+	require.NoError(t, f.Close())
+
+	m = readManifest(t, fs, "results.manifest.json")
+	require.Len(t, m.Files, 3)
+	assert.Equal(t, "results.20240102T150505Z-3", m.Files[2].Name, "the names within the same second are unique")
+	for _, entry := range m.Files {
+		exists, err := fsext.Exists(fs, entry.Name)
+		require.NoError(t, err)
+		assert.True(t, exists, entry.Name)
+	}
+}
+
+func TestSplitExt(t *testing.T) {
+	t.Parallel()
+
+	testCases := map[string][2]string{
+		"results.json":        {"results", ".json"},
+		"results.json.gz":     {"results", ".json.gz"},
+		"results":             {"results", ""},
+		"results.gz":          {"results", ".gz"},
+		"/tmp/.hidden":        {"/tmp/.hidden", ""},
+		"./dir.d/results.csv": {"./dir.d/results", ".csv"},
+	}
+	for filename, expected := range testCases {
+		stem, ext := splitExt(filename)
+		assert.Equal(t, expected, [2]string{stem, ext}, filename)
+	}
+	assert.Equal(t, "results.json.manifest.json", ManifestName("results.json.gz"))
+}
+
+func TestConfig(t *testing.T) {
+	t.Parallel()
+
+	sizes := map[string]int64{"512": 512, "10B": 10, "100MB": 100e6, "1.5kb": 1500, "1GiB": 1 << 30}
+	for s, expected := range sizes {
+		size, err := ParseSize(s)
+		require.NoError(t, err, s)
+		assert.Equal(t, expected, size, s)
+	}
+	for _, s := range []string{"", "MB", "10 parsecs"} {
+		_, err := ParseSize(s)
+		assert.Error(t, err, s)
+	}
+
+	naming, err := ParseNaming("timestamp")
+	require.NoError(t, err)
+	assert.Equal(t, NamingTimestamp, naming)
+	_, err = ParseNaming("random")
+	assert.Error(t, err)
+
+	assert.False(t, Config{MaxFiles: 3}.Enabled())
+	assert.Error(t, Config{MaxFiles: 3}.Validate())
+	assert.Error(t, Config{MaxSize: -1}.Validate())
+	assert.NoError(t, Config{MaxSize: 1, MaxFiles: 3}.Validate())
+
+	conf, err := NewConfig("1MB", time.Minute, 3, "timestamp")
+	require.NoError(t, err)
+	assert.Equal(t, Config{MaxSize: 1e6, MaxDuration: time.Minute, MaxFiles: 3, Naming: NamingTimestamp}, conf)
+	conf, err = NewConfig("", 0, 0, "")
+	require.NoError(t, err)
+	assert.False(t, conf.Enabled())
+	_, err = NewConfig("lots", 0, 0, "")
+	assert.Error(t, err)
+	_, err = NewConfig("1MB", 0, 0, "random")
+	assert.Error(t, err)
+	_, err = NewConfig("", 0, 3, "")
+	assert.Error(t, err)
+}
+
+func TestFileCreateError(t *testing.T) {
+	t.Parallel()
+
+	_, err := Create(fsext.NewReadOnlyFs(fsext.NewMemMapFs()), "/results.json", Config{MaxSize: 1})
+	assert.Error(t, err)
+
+	_, err = Create(fsext.NewMemMapFs(), "/results.json", Config{MaxFiles: 1})
+	assert.Error(t, err)
+}
@@ -0,0 +1,319 @@
+// Package spool implements a bounded queue of records on the disk, which the
+// outputs can use to keep the data they couldn't send to their backend, and to
+// send it once the backend is reachable again.
+package spool
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ChipArtem/k6/lib/fsext"
+	"github.com/ChipArtem/k6/metrics"
+)
+
+// recordExt is the extension of the files of the records.
+const recordExt = ".spool"
+
+// DefaultMaxSize is the maximum size of a spool, if it isn't configured.
+const DefaultMaxSize = 100 * 1000 * 1000
+
+// ErrRejected is returned, possibly wrapped, by the function passed to Replay
+// when the backend rejected a record, so sending it again would fail too.
+var ErrRejected = errors.New("the record was rejected")
+
+// Config is the configuration of a Spool. The spooling is disabled if Dir is
+// empty.
+type Config struct {
+	// Dir is the directory the records are written to. The records that are
+	// left in it by a previous test run are sent by the next one.
+	Dir string
+	// MaxSize is the maximum number of bytes of the records that are kept,
+	// the oldest records are dropped when it's exceeded.
+	MaxSize int64
+}
+
+// Enabled returns whether the spooling is enabled.
+func (c Config) Enabled() bool {
+	return c.Dir != ""
+}
+
+// Validate returns an error if the config is invalid.
+func (c Config) Validate() error {
+	if c.MaxSize < 0 {
+		return errors.New("the maximum spool size can't be negative")
+	}
+	return nil
+}
+
+// Stats are the statistics of a Spool.
+type Stats struct {
+	// Records and Size are the number and the bytes of the records that are
+	// currently spooled.
+	Records int
+	Size    int64
+	// Spooled and Replayed are the number of records that were added to the
+	// spool and that were successfully replayed from it.
+	Spooled  int
+	Replayed int
+	// LostRecords and LostSize are the number and the bytes of the records
+	// that were dropped, because the spool was full or couldn't be written, or
+	// because they were rejected when replayed.
+	LostRecords int
+	LostSize    int64
+}
+
+type record struct {
+	seq  uint64
+	size int64
+}
+
+// Spool is a bounded FIFO queue of records, each of them stored in its own
+// file in the spool directory. Spool is safe for concurrent use, but only one
+// Replay runs at a time.
+type Spool struct {
+	fs   fsext.Fs
+	conf Config
+
+	mu        sync.Mutex
+	records   []record
+	nextSeq   uint64
+	inFlight  uint64 // the seq of the record that's being replayed, or 0
+	stats     Stats
+	replaying sync.Mutex
+
+	tags                          *metrics.TagSet
+	sizeMetric, recordsMetric     *metrics.Metric
+	lostSizeMetric, lostRecMetric *metrics.Metric
+}
+
+// New opens the spool in the configured directory, creating it if it doesn't
+// exist, and loads the records that were left in it. The metrics of the spool
+// are registered in the given registry.
+func New(fs fsext.Fs, conf Config, registry *metrics.Registry) (*Spool, error) {
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+	if conf.MaxSize == 0 {
+		conf.MaxSize = DefaultMaxSize
+	}
+	if err := fs.MkdirAll(conf.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("couldn't create the spool directory: %w", err)
+	}
+	infos, err := fsext.ReadDir(fs, conf.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read the spool directory: %w", err)
+	}
+
+	s := &Spool{fs: fs, conf: conf, nextSeq: 1, tags: registry.RootTagSet()}
+	if err := s.registerMetrics(registry); err != nil {
+		return nil, err
+	}
+	for _, info := range infos {
+		name := info.Name()
+		if info.IsDir() || !strings.HasSuffix(name, recordExt) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, recordExt), 10, 64)
+		if err != nil || seq == 0 {
+			continue
+		}
+		s.records = append(s.records, record{seq: seq, size: info.Size()})
+		s.stats.Size += info.Size()
+		if seq >= s.nextSeq {
+			s.nextSeq = seq + 1
+		}
+	}
+	sort.Slice(s.records, func(i, j int) bool { return s.records[i].seq < s.records[j].seq })
+	s.stats.Records = len(s.records)
+	return s, nil
+}
+
+func (s *Spool) registerMetrics(registry *metrics.Registry) error {
+	var err error
+	if s.sizeMetric, err = registry.NewMetric("output_spool_size", metrics.Gauge, metrics.Data); err != nil {
+		return err
+	}
+	if s.recordsMetric, err = registry.NewMetric("output_spool_records", metrics.Gauge); err != nil {
+		return err
+	}
+	if s.lostSizeMetric, err = registry.NewMetric("output_spool_lost_size", metrics.Gauge, metrics.Data); err != nil {
+		return err
+	}
+	s.lostRecMetric, err = registry.NewMetric("output_spool_lost_records", metrics.Gauge)
+	return err
+}
+
+// Dir returns the directory of the spool.
+func (s *Spool) Dir() string {
+	return s.conf.Dir
+}
+
+// Push adds a record to the end of the queue. If the spool would be bigger
+// than its maximum size, the oldest records are dropped to make room for it,
+// and if the record itself is bigger than that, it's dropped instead. An error
+// is returned if the record couldn't be written, in which case it's lost.
+func (s *Spool) Push(data []byte) error {
+	size := int64(len(data))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if size > s.conf.MaxSize {
+		s.lose(size)
+		return fmt.Errorf("the record of %d bytes is bigger than the maximum spool size", size)
+	}
+	for s.stats.Size+size > s.conf.MaxSize {
+		if !s.dropOldest() {
+			break
+		}
+	}
+
+	seq := s.nextSeq
+	s.nextSeq++
+	if err := s.write(seq, data); err != nil {
+		s.lose(size)
+		return fmt.Errorf("couldn't write to the spool: %w", err)
+	}
+	s.records = append(s.records, record{seq: seq, size: size})
+	s.stats.Records++
+	s.stats.Size += size
+	s.stats.Spooled++
+	return nil
+}
+
+// Replay sends the spooled records to fn, from the oldest to the newest one,
+// and removes the ones it succeeds with. It stops at the first error, which is
+// returned, and the failed record is kept at the start of the queue, unless the
+// error is ErrRejected, in which case the record is dropped and counted as
+// lost. Replay returns immediately if the records are already being replayed.
+func (s *Spool) Replay(fn func([]byte) error) error {
+	if !s.replaying.TryLock() {
+		return nil
+	}
+	defer s.replaying.Unlock()
+
+	for {
+		s.mu.Lock()
+		if len(s.records) == 0 {
+			s.mu.Unlock()
+			return nil
+		}
+		rec := s.records[0]
+		s.inFlight = rec.seq
+		s.mu.Unlock()
+
+		data, err := fsext.ReadFile(s.fs, s.path(rec.seq))
+		if err != nil {
+			// An unreadable record would block the queue forever.
+			s.mu.Lock()
+			s.inFlight = 0
+			s.remove(rec.seq)
+			s.lose(rec.size)
+			s.mu.Unlock()
+			continue
+		}
+		err = fn(data)
+
+		s.mu.Lock()
+		s.inFlight = 0
+		if errors.Is(err, ErrRejected) {
+			s.remove(rec.seq)
+			s.lose(rec.size)
+			s.mu.Unlock()
+			continue
+		}
+		if err != nil {
+			s.mu.Unlock()
+			return err
+		}
+		s.remove(rec.seq)
+		s.stats.Replayed++
+		s.mu.Unlock()
+	}
+}
+
+// Stats returns the current statistics of the spool.
+func (s *Spool) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+// Samples returns the samples of the spool's metrics, with its current
+// statistics, at the given time.
+func (s *Spool) Samples(t time.Time) metrics.Samples {
+	stats := s.Stats()
+	sample := func(m *metrics.Metric, value float64) metrics.Sample {
+		return metrics.Sample{TimeSeries: metrics.TimeSeries{Metric: m, Tags: s.tags}, Time: t, Value: value}
+	}
+	return metrics.Samples{
+		sample(s.sizeMetric, float64(stats.Size)),
+		sample(s.recordsMetric, float64(stats.Records)),
+		sample(s.lostSizeMetric, float64(stats.LostSize)),
+		sample(s.lostRecMetric, float64(stats.LostRecords)),
+	}
+}
+
+// dropOldest drops the oldest record that isn't being replayed, and returns
+// false if there isn't one.
+func (s *Spool) dropOldest() bool {
+	for _, rec := range s.records {
+		if rec.seq == s.inFlight {
+			continue
+		}
+		s.remove(rec.seq)
+		s.lose(rec.size)
+		return true
+	}
+	return false
+}
+
+// remove removes the record with the given seq from the queue and its file.
+func (s *Spool) remove(seq uint64) {
+	for i, rec := range s.records {
+		if rec.seq != seq {
+			continue
+		}
+		s.records = append(s.records[:i], s.records[i+1:]...)
+		s.stats.Records--
+		s.stats.Size -= rec.size
+		_ = s.fs.Remove(s.path(seq))
+		return
+	}
+}
+
+func (s *Spool) lose(size int64) {
+	s.stats.LostRecords++
+	s.stats.LostSize += size
+}
+
+func (s *Spool) path(seq uint64) string {
+	return filepath.Join(s.conf.Dir, fmt.Sprintf("%020d%s", seq, recordExt))
+}
+
+// write writes the record to a temporary file first, so a partially written
+// record is never loaded by New.
+func (s *Spool) write(seq uint64, data []byte) error {
+	tmp := s.path(seq) + ".tmp"
+	f, err := s.fs.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = s.fs.Rename(tmp, s.path(seq))
+	}
+	if err != nil {
+		_ = s.fs.Remove(tmp)
+	}
+	return err
+}
@@ -0,0 +1,136 @@
+package spool
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ChipArtem/k6/lib/fsext"
+	"github.com/ChipArtem/k6/metrics"
+)
+
+func replayAll(t *testing.T, s *Spool) []string {
+	var records []string
+	require.NoError(t, s.Replay(func(data []byte) error {
+		records = append(records, string(data))
+		return nil
+	}))
+	return records
+}
+
+func TestSpool(t *testing.T) {
+	t.Parallel()
+
+	fs := fsext.NewMemMapFs()
+	s, err := New(fs, Config{Dir: "/spool", MaxSize: 10}, metrics.NewRegistry())
+	require.NoError(t, err)
+
+	require.NoError(t, s.Push([]byte("aaaa")))
+	require.NoError(t, s.Push([]byte("bbbb")))
+	assert.Equal(t, Stats{Records: 2, Size: 8, Spooled: 2}, s.Stats())
+
+	// A failed replay keeps the record.
+	errFailed := errors.New("failed")
+	require.ErrorIs(t, s.Replay(func([]byte) error { return errFailed }), errFailed)
+	assert.Equal(t, 2, s.Stats().Records)
+
+	// The oldest record is dropped to make room for the new one.
+	require.NoError(t, s.Push([]byte("cccc")))
+	assert.Equal(t, Stats{Records: 2, Size: 8, Spooled: 3, LostRecords: 1, LostSize: 4}, s.Stats())
+
+	// A record that's bigger than the spool is dropped.
+	require.Error(t, s.Push([]byte("too big to spool")))
+	assert.Equal(t, 2, s.Stats().LostRecords)
+
+	assert.Equal(t, []string{"bbbb", "cccc"}, replayAll(t, s))
+	assert.Equal(t, Stats{Spooled: 3, Replayed: 2, LostRecords: 2, LostSize: 20}, s.Stats())
+
+	infos, err := fsext.ReadDir(fs, "/spool")
+	require.NoError(t, err)
+	assert.Empty(t, infos)
+}
+
+func TestSpoolReplayRejected(t *testing.T) {
+	t.Parallel()
+
+	fs := fsext.NewMemMapFs()
+	s, err := New(fs, Config{Dir: "/spool"}, metrics.NewRegistry())
+	require.NoError(t, err)
+	for _, data := range []string{"a", "bb", "ccc"} {
+		require.NoError(t, s.Push([]byte(data)))
+	}
+
+	// A rejected record is dropped, instead of blocking the ones after it.
+	var replayed []string
+	require.NoError(t, s.Replay(func(data []byte) error {
+		if string(data) == "bb" {
+			return fmt.Errorf("%w: bad request", ErrRejected)
+		}
+		replayed = append(replayed, string(data))
+		return nil
+	}))
+	assert.Equal(t, []string{"a", "ccc"}, replayed)
+	assert.Equal(t, Stats{Spooled: 3, Replayed: 2, LostRecords: 1, LostSize: 2}, s.Stats())
+}
+
+func TestSpoolReload(t *testing.T) {
+	t.Parallel()
+
+	fs := fsext.NewMemMapFs()
+	registry := metrics.NewRegistry()
+	s, err := New(fs, Config{Dir: "/spool"}, registry)
+	require.NoError(t, err)
+	for _, data := range []string{"a", "bb", "ccc"} {
+		require.NoError(t, s.Push([]byte(data)))
+	}
+	require.NoError(t, fsext.WriteFile(fs, "/spool/unrelated.txt", []byte("x"), 0o644))
+
+	s, err = New(fs, Config{Dir: "/spool"}, registry)
+	require.NoError(t, err)
+	assert.Equal(t, Stats{Records: 3, Size: 6}, s.Stats())
+	require.NoError(t, s.Push([]byte("dddd")))
+	assert.Equal(t, []string{"a", "bb", "ccc", "dddd"}, replayAll(t, s))
+}
+
+func TestSpoolSamples(t *testing.T) {
+	t.Parallel()
+
+	registry := metrics.NewRegistry()
+	s, err := New(fsext.NewMemMapFs(), Config{Dir: "/spool", MaxSize: 3}, registry)
+	require.NoError(t, err)
+	require.NoError(t, s.Push([]byte("abc")))
+	require.NoError(t, s.Push([]byte("de")))
+
+	now := time.Unix(1700000000, 0)
+	values := map[string]float64{}
+	for _, sample := range s.Samples(now) {
+		assert.Equal(t, now, sample.Time)
+		assert.Equal(t, sample.Metric, registry.Get(sample.Metric.Name), "the metric is in the test's registry")
+		values[sample.Metric.Name] = sample.Value
+	}
+	assert.Equal(t, map[string]float64{
+		"output_spool_size":         2,
+		"output_spool_records":      1,
+		"output_spool_lost_size":    3,
+		"output_spool_lost_records": 1,
+	}, values)
+}
+
+func TestConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	assert.False(t, Config{}.Enabled())
+	assert.True(t, Config{Dir: "spool"}.Enabled())
+	assert.Error(t, Config{Dir: "spool", MaxSize: -1}.Validate())
+	_, err := New(fsext.NewMemMapFs(), Config{Dir: "spool", MaxSize: -1}, metrics.NewRegistry())
+	assert.Error(t, err)
+
+	registry := metrics.NewRegistry()
+	registry.MustNewMetric("output_spool_records", metrics.Counter)
+	_, err = New(fsext.NewMemMapFs(), Config{Dir: "spool"}, registry)
+	assert.Error(t, err, "the metric already exists with another type")
+}
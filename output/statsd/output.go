@@ -54,6 +54,8 @@ func (o *Output) dispatch(entry metrics.Sample) error {
 		return o.client.Count(entry.Metric.Name, int64(entry.Value), tagList, 1)
 	case metrics.Trend:
 		return o.client.TimeInMilliseconds(entry.Metric.Name, entry.Value, tagList, 1)
+	case metrics.Histogram:
+		return o.client.Histogram(entry.Metric.Name, entry.Value, tagList, 1)
 	case metrics.Gauge:
 		return o.client.Gauge(entry.Metric.Name, entry.Value, tagList, 1)
 	case metrics.Rate:
@@ -6,7 +6,9 @@ package output
 import (
 	"encoding/json"
 	"io"
+	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
@@ -30,6 +32,7 @@ type Params struct {
 	ScriptOptions  lib.Options
 	RuntimeOptions lib.RuntimeOptions
 	ExecutionPlan  []lib.ExecutionStep
+	Registry       *metrics.Registry
 }
 
 // TODO: make v2 with buffered channels?
@@ -88,8 +91,37 @@ type WithStopWithTestError interface {
 	StopWithTestError(testRunErr error) error // nil testRunErr means error-free test run
 }
 
+// WithSampleFilter is an output that needs to process the metric samples before
+// they are sent to any of the outputs, for example to limit their cardinality.
+// FilterSamples() is always called from the same goroutine and it can modify,
+// replace or remove the given sample containers.
+type WithSampleFilter interface {
+	Output
+	FilterSamples([]metrics.SampleContainer) []metrics.SampleContainer
+}
+
+// WithMetricSamples is an output that has metrics of its own, for example
+// about the state of its backend. The Manager periodically collects their
+// samples and sends them through the samples channel, like the samples of the
+// test, so they reach all of the outputs and the end-of-test summary. Their
+// metrics should be registered in the Registry of the Params. MetricSamples()
+// is called from its own goroutine, after the output was started.
+type WithMetricSamples interface {
+	Output
+	MetricSamples(t time.Time) metrics.Samples
+}
+
 // WithBuiltinMetrics means the output can receive the builtin metrics.
 type WithBuiltinMetrics interface {
 	Output
 	SetBuiltinMetrics(builtinMetrics *metrics.BuiltinMetrics)
 }
+
+// WithHTTPHandler is an output that exposes its data over HTTP on the k6 REST
+// API server. HTTPHandler() is called after the output was started and it
+// returns the path the handler is served on, or an empty path if the output
+// doesn't need the REST API server.
+type WithHTTPHandler interface {
+	Output
+	HTTPHandler() (path string, handler http.Handler)
+}